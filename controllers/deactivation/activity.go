@@ -0,0 +1,78 @@
+package deactivation
+
+import (
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// LastActivityAnnotationKey the annotation on the UserSignup or on the MasterUserRecord which contains the date/time of the
+	// last activity of the user, in RFC3339 format (eg: `2022-12-01T10:00:00Z`).
+	// It can be set by the registration service and the proxy at login, or when reporting the last-seen timestamps from the member clusters.
+	LastActivityAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "last-activity"
+
+	// DeactivationBasisAnnotationKey the annotation on the UserTier which specifies the date/time from which the deactivation
+	// timeout is measured. Supported values are `provisioned-time` (the default), `last-activity` and `latest`
+	DeactivationBasisAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-basis"
+
+	// DeactivationBasisProvisionedTime the deactivation timeout is measured from the provisioned time of the MasterUserRecord
+	DeactivationBasisProvisionedTime = "provisioned-time"
+	// DeactivationBasisLastActivity the deactivation timeout is measured from the last activity of the user, or from the
+	// provisioned time of the MasterUserRecord if no activity was recorded yet
+	DeactivationBasisLastActivity = "last-activity"
+	// DeactivationBasisLatest the deactivation timeout is measured from the latest of the provisioned time and the last activity
+	DeactivationBasisLatest = "latest"
+)
+
+// deactivationReferenceTime returns the date/time from which the deactivation timeout is measured, based on the
+// deactivation basis of the given UserTier
+func deactivationReferenceTime(logger logr.Logger, userTier *toolchainv1alpha1.UserTier, mur *toolchainv1alpha1.MasterUserRecord, usersignup *toolchainv1alpha1.UserSignup) (time.Time, error) {
+	provisionedTime := mur.Status.ProvisionedTime.Time
+	basis, found := userTier.Annotations[DeactivationBasisAnnotationKey]
+	if !found || basis == "" {
+		basis = DeactivationBasisProvisionedTime
+	}
+	switch basis {
+	case DeactivationBasisProvisionedTime:
+		return provisionedTime, nil
+	case DeactivationBasisLastActivity:
+		if lastActivity, found := lastActivityTime(logger, mur, usersignup); found {
+			return lastActivity, nil
+		}
+		return provisionedTime, nil
+	case DeactivationBasisLatest:
+		if lastActivity, found := lastActivityTime(logger, mur, usersignup); found && lastActivity.After(provisionedTime) {
+			return lastActivity, nil
+		}
+		return provisionedTime, nil
+	default:
+		return time.Time{}, fmt.Errorf("unknown deactivation basis '%s' in UserTier '%s'", basis, userTier.Name)
+	}
+}
+
+// lastActivityTime returns the most recent activity recorded in the annotations of the given MasterUserRecord and UserSignup.
+// Invalid values are ignored.
+func lastActivityTime(logger logr.Logger, objs ...interface{ GetAnnotations() map[string]string }) (time.Time, bool) {
+	var lastActivity time.Time
+	found := false
+	for _, obj := range objs {
+		value, exists := obj.GetAnnotations()[LastActivityAnnotationKey]
+		if !exists {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			logger.Error(err, "ignoring invalid last activity annotation", "value", value)
+			continue
+		}
+		if !found || t.After(lastActivity) {
+			lastActivity = t
+			found = true
+		}
+	}
+	return lastActivity, found
+}
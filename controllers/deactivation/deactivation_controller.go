@@ -3,6 +3,7 @@ package deactivation
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -42,10 +44,19 @@ func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
 		Complete(r)
 }
 
+const (
+	// DeactivatingEventReason the reason of the event recorded when the UserSignup is set to deactivating
+	DeactivatingEventReason = "Deactivating"
+	// AutoDeactivatedEventReason the reason of the event recorded when the UserSignup is deactivated after its provisioned time expired
+	AutoDeactivatedEventReason = "AutoDeactivated"
+)
+
 // Reconciler reconciles a Deactivation object
 type Reconciler struct {
 	Client client.Client
 	Scheme *runtime.Scheme
+	// EventRecorder records the events of the deactivation of the UserSignups
+	EventRecorder *events.Recorder
 }
 
 // Reconcile reads the state of the cluster for a MUR object and determines whether to trigger deactivation or requeue based on its current status
@@ -85,8 +96,6 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	if mur.Status.ProvisionedTime == nil {
 		return reconcile.Result{}, nil
 	}
-	provisionedTimestamp := *mur.Status.ProvisionedTime
-
 	// Get the associated usersignup
 	usersignup := &toolchainv1alpha1.UserSignup{}
 	if err := r.Client.Get(context.TODO(), types.NamespacedName{
@@ -139,26 +148,49 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 
 	deactivationTimeout := time.Duration(deactivationTimeoutDays*24) * time.Hour
 
-	logger.Info("user account time values", "deactivation timeout duration", deactivationTimeout, "provisionedTimestamp", provisionedTimestamp)
+	// the deactivation timeout is measured from the provisioned time or from the last activity, depending on the UserTier
+	referenceTime, err := deactivationReferenceTime(logger, userTier, mur, usersignup)
+	if err != nil {
+		logger.Error(err, "unable to determine the deactivation reference time")
+		return reconcile.Result{}, err
+	}
 
-	timeSinceProvisioned := time.Since(provisionedTimestamp.Time)
+	logger.Info("user account time values", "deactivation timeout duration", deactivationTimeout, "provisionedTimestamp", *mur.Status.ProvisionedTime,
+		"referenceTime", referenceTime)
 
-	deactivatingNotificationDays := config.Deactivation().DeactivatingNotificationDays()
-	deactivatingNotificationTimeout := time.Duration((deactivationTimeoutDays-deactivatingNotificationDays)*24) * time.Hour
+	timeSinceReference := time.Since(referenceTime)
 
-	if timeSinceProvisioned < deactivatingNotificationTimeout {
+	// process the extension request, if any, and push back the deactivation by the extensions granted so far
+	extension, err := r.ensureDeactivationExtensions(logger, userTier, mur, usersignup)
+	if err != nil {
+		logger.Error(err, "unable to process the deactivation extensions")
+		return reconcile.Result{}, err
+	}
+
+	// the first reminder of the schedule is the deactivating notification
+	reminderDays, err := deactivationReminderDays(userTier, config.Deactivation().DeactivatingNotificationDays())
+	if err != nil {
+		logger.Error(err, "unable to determine the deactivation reminders")
+		return reconcile.Result{}, err
+	}
+	deactivatingNotificationDays := reminderDays[0]
+	deactivatingNotificationTimeout := time.Duration((deactivationTimeoutDays-deactivatingNotificationDays)*24)*time.Hour + extension
+
+	if timeSinceReference < deactivatingNotificationTimeout {
 		// It is not yet time to send the deactivating notification
 
 		// If the usersignup was already set to deactivating then reset it to false
 		// Example: promotion of a user after 28 days from a user tier with deactivationTimeoutDays = 30 to one with 90, and where deactivatingNotificationDays = 3
 		//   Usersignup would have spec.states[Deactivating] = true but there are now 62 days left before deactivation so the deactivating notification should be sent again
 		//   when it is 3 days left until deactivation
+		// Same when new activity was recorded for a user in a tier whose deactivation is based on the last activity, or when the
+		// deactivation was extended: the deactivating notification is rescheduled accordingly
 		if err := r.resetDeactivatingState(logger, usersignup); err != nil {
 			return reconcile.Result{}, err
 		}
 
 		// requeue until it will be time to send it
-		requeueAfterTimeToNotify := deactivatingNotificationTimeout - timeSinceProvisioned
+		requeueAfterTimeToNotify := deactivatingNotificationTimeout - timeSinceReference
 		logger.Info("requeueing request", "RequeueAfter", requeueAfterTimeToNotify,
 			"Expected deactivating notification date/time", time.Now().Add(requeueAfterTimeToNotify).String())
 		return reconcile.Result{RequeueAfter: requeueAfterTimeToNotify}, nil
@@ -167,12 +199,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	// If the usersignup state hasn't been set to deactivating, then set it now
 	if !states.Deactivating(usersignup) {
 		states.SetDeactivating(usersignup, true)
+		if usersignup.Annotations == nil {
+			usersignup.Annotations = map[string]string{}
+		}
+		usersignup.Annotations[DeactivationReminderDueAnnotationKey] = strconv.Itoa(deactivatingNotificationDays)
 
 		logger.Info("setting usersignup state to deactivating")
 		if err := r.Client.Update(context.TODO(), usersignup); err != nil {
 			logger.Error(err, "failed to update usersignup")
 			return reconcile.Result{}, err
 		}
+		r.EventRecorder.Normal(usersignup, DeactivatingEventReason, "the user will be deactivated in %d days", deactivatingNotificationDays)
 
 		// Upon the next reconciliation, the deactivation due time can be calculated after the notification has been sent.
 		// The sequence of events from here are:
@@ -198,9 +235,29 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	}
 
 	deactivationDueTime := deactivatingCondition.LastTransitionTime.Time.Add(time.Duration(deactivatingNotificationDays*24) * time.Hour)
+	// the deactivation may have been extended after the deactivating notification was sent
+	if extension > 0 {
+		if extendedDueTime := referenceTime.Add(deactivationTimeout + extension); extendedDueTime.After(deactivationDueTime) {
+			deactivationDueTime = extendedDueTime
+		}
+	}
 
 	if time.Now().Before(deactivationDueTime) {
-		// It is not yet time to deactivate so requeue when it will be
+		// It is not yet time to deactivate, but it may be time to send a reminder
+		nextReminderTime, err := r.ensureDeactivationReminder(logger, reminderDays, deactivationDueTime, usersignup)
+		if err != nil {
+			logger.Error(err, "failed to request the deactivation reminder")
+			return reconcile.Result{}, err
+		}
+		if !nextReminderTime.IsZero() {
+			// requeue when it will be time to send the next reminder
+			requeueAfterReminder := time.Until(nextReminderTime)
+			logger.Info("requeueing request", "RequeueAfter", requeueAfterReminder,
+				"Expected deactivation reminder date/time", nextReminderTime.String())
+			return reconcile.Result{RequeueAfter: requeueAfterReminder}, nil
+		}
+
+		// requeue when it will be time to deactivate
 		requeueAfterExpired := time.Until(deactivationDueTime)
 
 		logger.Info("requeueing request", "RequeueAfter", requeueAfterExpired,
@@ -220,14 +277,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return reconcile.Result{}, err
 	}
 
-	metrics.UserSignupAutoDeactivatedTotal.Inc()
+	metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues(string(metrics.GetEmailDomain(usersignup))).Inc()
+	r.EventRecorder.Normal(usersignup, AutoDeactivatedEventReason, "the user was deactivated after the expiration of the provisioned time")
 
 	return reconcile.Result{}, nil
 }
 
 func (r *Reconciler) resetDeactivatingState(logger logr.Logger, usersignup *toolchainv1alpha1.UserSignup) error {
-	if states.Deactivating(usersignup) {
+	_, reminderDue := usersignup.Annotations[DeactivationReminderDueAnnotationKey]
+	_, remindersSent := usersignup.Annotations[DeactivationRemindersSentAnnotationKey]
+	if states.Deactivating(usersignup) || reminderDue || remindersSent {
 		states.SetDeactivating(usersignup, false)
+		// the reminders will be sent again during the next pre-deactivation period
+		delete(usersignup.Annotations, DeactivationReminderDueAnnotationKey)
+		delete(usersignup.Annotations, DeactivationRemindersSentAnnotationKey)
 		if err := r.Client.Update(context.TODO(), usersignup); err != nil {
 			logger.Error(err, "failed to reset usersignup deactivating state")
 			return err
@@ -2,6 +2,7 @@ package deactivation
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -286,7 +287,8 @@ func TestReconcile(t *testing.T) {
 			require.False(t, res.Requeue, "requeue should not be set")
 			require.True(t, res.RequeueAfter == 0, "requeue should not be set")
 			assertThatUserSignupDeactivated(t, cl, username, true)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupAutoDeactivatedTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("external")) // `foo@bar.com`
 		})
 
 	})
@@ -436,6 +438,533 @@ func TestReconcile(t *testing.T) {
 
 }
 
+func TestReconcileWithActivityBasedDeactivation(t *testing.T) {
+	config := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Deactivation().DeactivatingNotificationDays(3))
+	username := "test-user"
+
+	// MUR was provisioned 40 days ago
+	murProvisionedTime := &metav1.Time{Time: time.Now().Add(-40 * 24 * time.Hour)}
+	newMUR := func(userSignup *toolchainv1alpha1.UserSignup) *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, username, murtest.TierName("deactivate30"), murtest.Account("cluster1"),
+			murtest.ProvisionedMur(murProvisionedTime), murtest.UserIDFromUserSignup(userSignup))
+		mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignup.Name
+		return mur
+	}
+	newUserTier := func(basis string) *toolchainv1alpha1.UserTier {
+		userTier := testusertier.NewUserTier("deactivate30", 30)
+		userTier.Annotations = map[string]string{
+			DeactivationBasisAnnotationKey: basis,
+		}
+		return userTier
+	}
+	lastActivity := func(ago time.Duration) string {
+		return time.Now().Add(-ago).Format(time.RFC3339)
+	}
+
+	for _, basis := range []string{DeactivationBasisLastActivity, DeactivationBasisLatest} {
+		t.Run(basis, func(t *testing.T) {
+
+			t.Run("user with recent activity on UserSignup should not be deactivated", func(t *testing.T) {
+				// given
+				userSignup := userSignupWithEmail(username, "foo@bar.com")
+				userSignup.Annotations[LastActivityAnnotationKey] = lastActivity(24 * time.Hour)
+				mur := newMUR(userSignup)
+				r, req, cl := prepareReconcile(t, mur.Name, newUserTier(basis), mur, userSignup, config)
+
+				// when
+				res, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				// notification is due 27 days after the last activity, ie, in 26 days
+				require.WithinDuration(t, time.Now().Add(26*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+				assertThatUserSignupDeactivated(t, cl, username, false)
+			})
+
+			t.Run("user with recent activity on MasterUserRecord should not be deactivated", func(t *testing.T) {
+				// given
+				userSignup := userSignupWithEmail(username, "foo@bar.com")
+				userSignup.Annotations[LastActivityAnnotationKey] = lastActivity(35 * 24 * time.Hour)
+				mur := newMUR(userSignup)
+				mur.Annotations = map[string]string{
+					LastActivityAnnotationKey: lastActivity(2 * 24 * time.Hour), // most recent activity
+				}
+				r, req, cl := prepareReconcile(t, mur.Name, newUserTier(basis), mur, userSignup, config)
+
+				// when
+				res, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				require.WithinDuration(t, time.Now().Add(25*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+				assertThatUserSignupDeactivated(t, cl, username, false)
+			})
+
+			t.Run("deactivating notification rescheduled after new activity", func(t *testing.T) {
+				// given
+				userSignup := userSignupWithEmail(username, "foo@bar.com")
+				states.SetDeactivating(userSignup, true)
+				userSignup.Annotations[LastActivityAnnotationKey] = lastActivity(time.Hour)
+				mur := newMUR(userSignup)
+				r, req, cl := prepareReconcile(t, mur.Name, newUserTier(basis), mur, userSignup, config)
+
+				// when
+				res, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				require.WithinDuration(t, time.Now().Add(27*24*time.Hour-time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+				require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+				require.False(t, states.Deactivating(userSignup))
+				require.False(t, states.Deactivated(userSignup))
+			})
+
+			t.Run("user without activity since provisioning should be marked as deactivating", func(t *testing.T) {
+				// given
+				userSignup := userSignupWithEmail(username, "foo@bar.com")
+				mur := newMUR(userSignup)
+				r, req, cl := prepareReconcile(t, mur.Name, newUserTier(basis), mur, userSignup, config)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+				require.True(t, states.Deactivating(userSignup))
+			})
+
+			t.Run("invalid last activity is ignored", func(t *testing.T) {
+				// given
+				userSignup := userSignupWithEmail(username, "foo@bar.com")
+				userSignup.Annotations[LastActivityAnnotationKey] = "yesterday"
+				mur := newMUR(userSignup)
+				r, req, cl := prepareReconcile(t, mur.Name, newUserTier(basis), mur, userSignup, config)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+				require.True(t, states.Deactivating(userSignup))
+			})
+		})
+	}
+
+	t.Run("last activity before provisioned time", func(t *testing.T) {
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		userSignup.Annotations[LastActivityAnnotationKey] = lastActivity(50 * 24 * time.Hour)
+		mur := newMUR(userSignup)
+
+		t.Run("last-activity uses the last activity", func(t *testing.T) {
+			// when
+			referenceTime, err := deactivationReferenceTime(logf.Log, newUserTier(DeactivationBasisLastActivity), mur, userSignup)
+
+			// then
+			require.NoError(t, err)
+			require.WithinDuration(t, time.Now().Add(-50*24*time.Hour), referenceTime, time.Second)
+		})
+
+		t.Run("latest uses the provisioned time", func(t *testing.T) {
+			// when
+			referenceTime, err := deactivationReferenceTime(logf.Log, newUserTier(DeactivationBasisLatest), mur, userSignup)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, murProvisionedTime.Time, referenceTime)
+		})
+	})
+
+	t.Run("provisioned-time ignores the last activity", func(t *testing.T) {
+		// given
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		userSignup.Annotations[LastActivityAnnotationKey] = lastActivity(time.Hour)
+		mur := newMUR(userSignup)
+		r, req, cl := prepareReconcile(t, mur.Name, newUserTier(DeactivationBasisProvisionedTime), mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.True(t, states.Deactivating(userSignup))
+	})
+
+	t.Run("unknown deactivation basis", func(t *testing.T) {
+		// given
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		mur := newMUR(userSignup)
+		r, req, _ := prepareReconcile(t, mur.Name, newUserTier("whenever"), mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, "unknown deactivation basis 'whenever' in UserTier 'deactivate30'")
+	})
+}
+
+func TestReconcileWithDeactivationExtension(t *testing.T) {
+	config := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Deactivation().DeactivatingNotificationDays(3))
+	username := "test-user"
+
+	// MUR was provisioned 29 days ago, ie, the user is in the deactivating state
+	murProvisionedTime := &metav1.Time{Time: time.Now().Add(-29 * 24 * time.Hour)}
+	newMUR := func(userSignup *toolchainv1alpha1.UserSignup) *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, username, murtest.TierName("deactivate30"), murtest.Account("cluster1"),
+			murtest.ProvisionedMur(murProvisionedTime), murtest.UserIDFromUserSignup(userSignup))
+		mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignup.Name
+		return mur
+	}
+	userTier := testusertier.NewUserTier("deactivate30", 30)
+	userTier.Annotations = map[string]string{
+		MaxDeactivationExtensionsAnnotationKey: "2",
+		DeactivationExtensionDaysAnnotationKey: "10",
+	}
+	newDeactivatingUserSignup := func() *toolchainv1alpha1.UserSignup {
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		states.SetDeactivating(userSignup, true)
+		userSignup.Status.Conditions = []toolchainv1alpha1.Condition{
+			{
+				Type:               toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * 24 * time.Hour)),
+				Reason:             toolchainv1alpha1.UserSignupDeactivatingNotificationCRCreatedReason,
+			},
+		}
+		return userSignup
+	}
+	history := func(entries ...deactivationExtension) string {
+		h, err := json.Marshal(entries)
+		require.NoError(t, err)
+		return string(h)
+	}
+
+	t.Run("deactivation extended", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Annotations[DeactivationExtensionRequestedAnnotationKey] = "true"
+		mur := newMUR(userSignup)
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		// deactivating notification rescheduled 37 days after provisioning, ie, in 8 days
+		require.WithinDuration(t, time.Now().Add(8*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.False(t, states.Deactivating(userSignup))
+		require.NotContains(t, userSignup.Annotations, DeactivationExtensionRequestedAnnotationKey)
+		extensions := []deactivationExtension{}
+		require.NoError(t, json.Unmarshal([]byte(userSignup.Annotations[DeactivationExtensionsAnnotationKey]), &extensions))
+		require.Len(t, extensions, 1)
+		require.Equal(t, "deactivate30", extensions[0].Tier)
+		require.Equal(t, 10, extensions[0].Days)
+		cond, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupDeactivationExtended)
+		require.True(t, found)
+		require.Equal(t, corev1.ConditionTrue, cond.Status)
+		require.Equal(t, fmt.Sprintf("deactivation extended by 10 days (1 of 2 extensions: 10 days on %s in tier 'deactivate30')",
+			extensions[0].Time.UTC().Format(time.RFC3339)), cond.Message)
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivationExtendedCounterVec.WithLabelValues("deactivate30"))
+	})
+
+	t.Run("deactivation due time pushed back", func(t *testing.T) {
+		// given the notification was sent 3 days ago and the deactivation was extended by 1 day afterwards
+		userTier := testusertier.NewUserTier("deactivate30", 30)
+		userTier.Annotations = map[string]string{
+			MaxDeactivationExtensionsAnnotationKey: "2",
+			DeactivationExtensionDaysAnnotationKey: "1",
+		}
+		murProvisionedTime := &metav1.Time{Time: time.Now().Add(-30 * 24 * time.Hour)}
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-3 * 24 * time.Hour))
+		userSignup.Annotations[DeactivationExtensionsAnnotationKey] = history(deactivationExtension{
+			Time: metav1.NewTime(time.Now().Add(-time.Hour)),
+			Tier: "deactivate30",
+			Days: 1,
+		})
+		mur := newMUR(userSignup)
+		mur.Status.ProvisionedTime = murProvisionedTime
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+		assertThatUserSignupDeactivated(t, cl, username, false)
+	})
+
+	t.Run("deactivation extension rejected when limit reached", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Annotations[DeactivationExtensionRequestedAnnotationKey] = "true"
+		// the user already used 2 extensions
+		first := metav1.NewTime(time.Now().Add(-25 * 24 * time.Hour).Truncate(time.Second))
+		second := metav1.NewTime(time.Now().Add(-20 * 24 * time.Hour).Truncate(time.Second))
+		userSignup.Annotations[DeactivationExtensionsAnnotationKey] = history(
+			deactivationExtension{Time: first, Tier: "deactivate30", Days: 10},
+			deactivationExtension{Time: second, Tier: "deactivate30", Days: 10},
+		)
+		mur := newMUR(userSignup)
+		mur.Status.ProvisionedTime = &metav1.Time{Time: time.Now().Add(-49 * 24 * time.Hour)}
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.True(t, states.Deactivating(userSignup))
+		require.NotContains(t, userSignup.Annotations, DeactivationExtensionRequestedAnnotationKey)
+		cond, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupDeactivationExtended)
+		require.True(t, found)
+		require.Equal(t, corev1.ConditionFalse, cond.Status)
+		require.Equal(t, UserSignupDeactivationExtensionLimitReachedReason, cond.Reason)
+		require.Equal(t, fmt.Sprintf("the maximum number of extensions (2) was reached (10 days on %s in tier 'deactivate30', 10 days on %s in tier 'deactivate30')",
+			first.UTC().Format(time.RFC3339), second.UTC().Format(time.RFC3339)), cond.Message)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivationExtendedCounterVec.WithLabelValues("deactivate30"))
+	})
+
+	t.Run("extensions granted before the last provisioning are ignored", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Annotations[DeactivationExtensionRequestedAnnotationKey] = "true"
+		userSignup.Annotations[DeactivationExtensionsAnnotationKey] = history(
+			deactivationExtension{Time: metav1.NewTime(time.Now().Add(-100 * 24 * time.Hour)), Tier: "deactivate30", Days: 10},
+			deactivationExtension{Time: metav1.NewTime(time.Now().Add(-90 * 24 * time.Hour)), Tier: "deactivate30", Days: 10},
+		)
+		mur := newMUR(userSignup)
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.False(t, states.Deactivating(userSignup))
+		extensions := []deactivationExtension{}
+		require.NoError(t, json.Unmarshal([]byte(userSignup.Annotations[DeactivationExtensionsAnnotationKey]), &extensions))
+		require.Len(t, extensions, 3) // history is kept
+	})
+
+	t.Run("deactivation extension rejected when not allowed in tier", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Annotations[DeactivationExtensionRequestedAnnotationKey] = "true"
+		mur := newMUR(userSignup)
+		r, req, cl := prepareReconcile(t, mur.Name, testusertier.NewUserTier("deactivate30", 30), mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.True(t, states.Deactivating(userSignup))
+		require.True(t, condition.IsFalseWithReason(userSignup.Status.Conditions, UserSignupDeactivationExtended, UserSignupDeactivationExtensionLimitReachedReason))
+	})
+
+	t.Run("invalid max extensions in tier", func(t *testing.T) {
+		// given
+		userTier := testusertier.NewUserTier("deactivate30", 30)
+		userTier.Annotations = map[string]string{
+			MaxDeactivationExtensionsAnnotationKey: "many",
+		}
+		userSignup := newDeactivatingUserSignup()
+		userSignup.Annotations[DeactivationExtensionRequestedAnnotationKey] = "true"
+		mur := newMUR(userSignup)
+		r, req, _ := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, `invalid value for annotation 'toolchain.dev.openshift.com/max-deactivation-extensions' in UserTier 'deactivate30': strconv.Atoi: parsing "many": invalid syntax`)
+	})
+}
+
+func TestReconcileWithDeactivationReminders(t *testing.T) {
+	config := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Deactivation().DeactivatingNotificationDays(3))
+	username := "test-user"
+	userTier := testusertier.NewUserTier("deactivate30", 30)
+	userTier.Annotations = map[string]string{
+		DeactivationReminderDaysAnnotationKey: "1, 14,7",
+	}
+	newMUR := func(userSignup *toolchainv1alpha1.UserSignup, provisionedTime time.Time) *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, username, murtest.TierName("deactivate30"), murtest.Account("cluster1"),
+			murtest.ProvisionedMur(&metav1.Time{Time: provisionedTime}), murtest.UserIDFromUserSignup(userSignup))
+		mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignup.Name
+		return mur
+	}
+	// the deactivating notification was sent at the given time, ie, the deactivation is due 14 days later
+	newDeactivatingUserSignup := func(notificationTime time.Time, sent string) *toolchainv1alpha1.UserSignup {
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		states.SetDeactivating(userSignup, true)
+		userSignup.Annotations[DeactivationRemindersSentAnnotationKey] = sent
+		userSignup.Status.Conditions = []toolchainv1alpha1.Condition{
+			{
+				Type:               toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(notificationTime),
+				Reason:             toolchainv1alpha1.UserSignupDeactivatingNotificationCRCreatedReason,
+			},
+		}
+		return userSignup
+	}
+
+	t.Run("first reminder not due yet", func(t *testing.T) {
+		// given
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		mur := newMUR(userSignup, time.Now().Add(-10*24*time.Hour))
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		// first reminder 14 days before the deactivation, ie, 16 days after provisioning
+		require.WithinDuration(t, time.Now().Add(6*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.False(t, states.Deactivating(userSignup))
+		require.NotContains(t, userSignup.Annotations, DeactivationReminderDueAnnotationKey)
+	})
+
+	t.Run("first reminder due", func(t *testing.T) {
+		// given
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		mur := newMUR(userSignup, time.Now().Add(-17*24*time.Hour))
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.True(t, states.Deactivating(userSignup))
+		require.Equal(t, "14", userSignup.Annotations[DeactivationReminderDueAnnotationKey])
+	})
+
+	t.Run("second reminder due", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatingUserSignup(time.Now().Add(-8*24*time.Hour), "14")
+		mur := newMUR(userSignup, time.Now().Add(-24*24*time.Hour))
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		// requeued for the last reminder, 1 day before the deactivation
+		require.WithinDuration(t, time.Now().Add(5*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.True(t, states.Deactivating(userSignup))
+		require.False(t, states.Deactivated(userSignup))
+		require.Equal(t, "7", userSignup.Annotations[DeactivationReminderDueAnnotationKey])
+	})
+
+	t.Run("second reminder already sent", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatingUserSignup(time.Now().Add(-8*24*time.Hour), "14,7")
+		mur := newMUR(userSignup, time.Now().Add(-24*24*time.Hour))
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+		cl.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+			return fmt.Errorf("should not update the UserSignup")
+		}
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(5*24*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+	})
+
+	t.Run("only the latest missed reminder is requested", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatingUserSignup(time.Now().Add(-13*24*time.Hour-12*time.Hour), "14")
+		mur := newMUR(userSignup, time.Now().Add(-30*24*time.Hour))
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		// requeued for the deactivation
+		require.WithinDuration(t, time.Now().Add(12*time.Hour), time.Now().Add(res.RequeueAfter), time.Minute)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.Equal(t, "1", userSignup.Annotations[DeactivationReminderDueAnnotationKey])
+	})
+
+	t.Run("reminders reset when not in pre-deactivation period anymore", func(t *testing.T) {
+		// given the user was promoted to a tier with a longer deactivation timeout
+		userTier := testusertier.NewUserTier("deactivate90", 90)
+		userTier.Annotations = map[string]string{
+			DeactivationReminderDaysAnnotationKey: "14,7,1",
+		}
+		userSignup := newDeactivatingUserSignup(time.Now().Add(-8*24*time.Hour), "14")
+		userSignup.Annotations[DeactivationReminderDueAnnotationKey] = "7"
+		mur := newMUR(userSignup, time.Now().Add(-24*24*time.Hour))
+		mur.Spec.TierName = "deactivate90"
+		r, req, cl := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: operatorNamespace}, userSignup))
+		require.False(t, states.Deactivating(userSignup))
+		require.NotContains(t, userSignup.Annotations, DeactivationReminderDueAnnotationKey)
+		require.NotContains(t, userSignup.Annotations, DeactivationRemindersSentAnnotationKey)
+	})
+
+	t.Run("invalid reminders in tier", func(t *testing.T) {
+		// given
+		userTier := testusertier.NewUserTier("deactivate30", 30)
+		userTier.Annotations = map[string]string{
+			DeactivationReminderDaysAnnotationKey: "14,-1",
+		}
+		userSignup := userSignupWithEmail(username, "foo@bar.com")
+		mur := newMUR(userSignup, time.Now().Add(-10*24*time.Hour))
+		r, req, _ := prepareReconcile(t, mur.Name, userTier, mur, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, "invalid value for annotation 'toolchain.dev.openshift.com/deactivation-reminder-days' in UserTier 'deactivate30': reminder must be a positive number of days: -1")
+	})
+}
+
+func TestMarkDeactivationReminderSent(t *testing.T) {
+	// given
+	userSignup := userSignupWithEmail("test-user", "foo@bar.com")
+	userSignup.Annotations[DeactivationReminderDueAnnotationKey] = "7"
+	userSignup.Annotations[DeactivationRemindersSentAnnotationKey] = "14"
+
+	// when
+	MarkDeactivationReminderSent(userSignup, 7)
+	MarkDeactivationReminderSent(userSignup, 7)
+
+	// then
+	require.Equal(t, "14,7", userSignup.Annotations[DeactivationRemindersSentAnnotationKey])
+	require.NotContains(t, userSignup.Annotations, DeactivationReminderDueAnnotationKey)
+}
+
 func prepareReconcile(t *testing.T, name string, initObjs ...runtime.Object) (reconcile.Reconciler, reconcile.Request, *test.FakeClient) {
 	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
 	metrics.Reset()
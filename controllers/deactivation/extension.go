@@ -0,0 +1,161 @@
+package deactivation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DeactivationExtensionRequestedAnnotationKey the annotation on the UserSignup to request an extension of the deactivation
+	// (the value is ignored). The annotation is removed once the request has been processed.
+	DeactivationExtensionRequestedAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-extension-requested"
+
+	// DeactivationExtensionsAnnotationKey the annotation on the UserSignup which contains the history of the extensions
+	// of the deactivation, as a JSON array. The extensions granted since the last provisioning of the user are also listed
+	// in the message of the `DeactivationExtended` condition.
+	DeactivationExtensionsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-extensions"
+
+	// MaxDeactivationExtensionsAnnotationKey the annotation on the UserTier which specifies the maximum number of extensions
+	// of the deactivation (defaults to `0`, ie, no extension allowed)
+	MaxDeactivationExtensionsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "max-deactivation-extensions"
+
+	// DeactivationExtensionDaysAnnotationKey the annotation on the UserTier which specifies the number of days by which the
+	// deactivation is pushed back for each extension (defaults to `7`)
+	DeactivationExtensionDaysAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-extension-days"
+
+	defaultDeactivationExtensionDays = 7
+)
+
+const (
+	// UserSignupDeactivationExtended the type of the UserSignup condition which reports the outcome of the last extension request
+	UserSignupDeactivationExtended toolchainv1alpha1.ConditionType = "DeactivationExtended"
+
+	// UserSignupDeactivationExtendedReason the reason of the `DeactivationExtended` condition when the deactivation was extended
+	UserSignupDeactivationExtendedReason = "Extended"
+	// UserSignupDeactivationExtensionLimitReachedReason the reason of the `DeactivationExtended` condition when the request
+	// was rejected because the maximum number of extensions was reached
+	UserSignupDeactivationExtensionLimitReachedReason = "ExtensionLimitReached"
+)
+
+// deactivationExtension an entry in the history of the extensions of the deactivation of a user
+type deactivationExtension struct {
+	Time metav1.Time `json:"time"`
+	Tier string      `json:"tier"`
+	Days int         `json:"days"`
+}
+
+// ensureDeactivationExtensions processes the pending extension request of the given UserSignup, if any, and returns the total
+// duration of the extensions granted since the MasterUserRecord was provisioned
+func (r *Reconciler) ensureDeactivationExtensions(logger logr.Logger, userTier *toolchainv1alpha1.UserTier, mur *toolchainv1alpha1.MasterUserRecord, usersignup *toolchainv1alpha1.UserSignup) (time.Duration, error) {
+	history := []deactivationExtension{}
+	if h, found := usersignup.Annotations[DeactivationExtensionsAnnotationKey]; found {
+		if err := json.Unmarshal([]byte(h), &history); err != nil {
+			// do not block the deactivation because of a corrupted history
+			logger.Error(err, "ignoring invalid deactivation extensions history", "value", h)
+			history = []deactivationExtension{}
+		}
+	}
+	// only the extensions granted since the user was (re)provisioned are taken into account
+	current := []deactivationExtension{}
+	for _, e := range history {
+		if !e.Time.Before(mur.Status.ProvisionedTime) {
+			current = append(current, e)
+		}
+	}
+
+	if _, requested := usersignup.Annotations[DeactivationExtensionRequestedAnnotationKey]; requested {
+		maxExtensions, err := getIntAnnotation(userTier, MaxDeactivationExtensionsAnnotationKey, 0)
+		if err != nil {
+			return 0, err
+		}
+		days, err := getIntAnnotation(userTier, DeactivationExtensionDaysAnnotationKey, defaultDeactivationExtensionDays)
+		if err != nil {
+			return 0, err
+		}
+		delete(usersignup.Annotations, DeactivationExtensionRequestedAnnotationKey)
+		var c toolchainv1alpha1.Condition
+		if len(current) >= maxExtensions {
+			logger.Info("rejecting deactivation extension request", "max_extensions", maxExtensions)
+			msg := fmt.Sprintf("the maximum number of extensions (%d) was reached", maxExtensions)
+			if len(current) > 0 {
+				msg = fmt.Sprintf("%s (%s)", msg, describeExtensions(current))
+			}
+			c = toolchainv1alpha1.Condition{
+				Type:    UserSignupDeactivationExtended,
+				Status:  corev1.ConditionFalse,
+				Reason:  UserSignupDeactivationExtensionLimitReachedReason,
+				Message: msg,
+			}
+		} else {
+			logger.Info("extending deactivation", "days", days)
+			e := deactivationExtension{
+				Time: metav1.NewTime(time.Now().Truncate(time.Second)), // the JSON encoding does not preserve fractions of seconds
+				Tier: userTier.Name,
+				Days: days,
+			}
+			history = append(history, e)
+			current = append(current, e)
+			h, err := json.Marshal(history)
+			if err != nil {
+				return 0, err
+			}
+			usersignup.Annotations[DeactivationExtensionsAnnotationKey] = string(h)
+			c = toolchainv1alpha1.Condition{
+				Type:    UserSignupDeactivationExtended,
+				Status:  corev1.ConditionTrue,
+				Reason:  UserSignupDeactivationExtendedReason,
+				Message: fmt.Sprintf("deactivation extended by %d days (%d of %d extensions: %s)", days, len(current), maxExtensions, describeExtensions(current)),
+			}
+		}
+		if err := r.Client.Update(context.TODO(), usersignup); err != nil {
+			return 0, errs.Wrap(err, "unable to process the deactivation extension request")
+		}
+		if c.Status == corev1.ConditionTrue {
+			metrics.UserSignupDeactivationExtendedCounterVec.WithLabelValues(userTier.Name).Inc()
+		}
+		usersignup.Status.Conditions, _ = condition.AddOrUpdateStatusConditions(usersignup.Status.Conditions, c)
+		if err := r.Client.Status().Update(context.TODO(), usersignup); err != nil {
+			return 0, errs.Wrap(err, "unable to update the status after processing the deactivation extension request")
+		}
+	}
+
+	var extension time.Duration
+	for _, e := range current {
+		extension += time.Duration(e.Days*24) * time.Hour
+	}
+	return extension, nil
+}
+
+// describeExtensions returns the given history of extensions, as listed in the message of the `DeactivationExtended` condition
+func describeExtensions(extensions []deactivationExtension) string {
+	entries := make([]string, len(extensions))
+	for i, e := range extensions {
+		entries[i] = fmt.Sprintf("%d days on %s in tier '%s'", e.Days, e.Time.UTC().Format(time.RFC3339), e.Tier)
+	}
+	return strings.Join(entries, ", ")
+}
+
+func getIntAnnotation(userTier *toolchainv1alpha1.UserTier, key string, defaultValue int) (int, error) {
+	v, found := userTier.Annotations[key]
+	if !found || v == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return defaultValue, errs.Wrapf(err, "invalid value for annotation '%s' in UserTier '%s'", key, userTier.Name)
+	}
+	return i, nil
+}
@@ -0,0 +1,132 @@
+package deactivation
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+)
+
+const (
+	// DeactivationReminderDaysAnnotationKey the annotation on the UserTier which specifies the schedule of the deactivation reminders,
+	// as a comma-separated list of number of days before the deactivation (eg: `14,7,1`). The first reminder (ie, the greatest number
+	// of days) is the deactivating notification. Defaults to the `deactivatingNotificationDays` of the ToolchainConfig.
+	DeactivationReminderDaysAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-reminder-days"
+
+	// DeactivationReminderDueAnnotationKey the annotation on the UserSignup which specifies the number of days before the deactivation
+	// of the reminder that should be sent to the user. The annotation is set by the deactivation controller and removed by the
+	// UserSignup controller once the reminder was sent.
+	DeactivationReminderDueAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-reminder-due"
+
+	// DeactivationRemindersSentAnnotationKey the annotation on the UserSignup which contains the comma-separated list of the
+	// reminders (in number of days before the deactivation) which were already sent to the user. The annotation is removed
+	// when the UserSignup is not in the pre-deactivation period anymore.
+	DeactivationRemindersSentAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "deactivation-reminders-sent"
+)
+
+// deactivationReminderDays returns the schedule of the deactivation reminders of the given UserTier, in number of days
+// before the deactivation, sorted in descending order
+func deactivationReminderDays(userTier *toolchainv1alpha1.UserTier, defaultDays int) ([]int, error) {
+	v, found := userTier.Annotations[DeactivationReminderDaysAnnotationKey]
+	if !found || strings.TrimSpace(v) == "" {
+		return []int{defaultDays}, nil
+	}
+	days, err := ParseDeactivationReminders(v)
+	if err != nil {
+		return nil, errs.Wrapf(err, "invalid value for annotation '%s' in UserTier '%s'", DeactivationReminderDaysAnnotationKey, userTier.Name)
+	}
+	if len(days) == 0 {
+		return []int{defaultDays}, nil
+	}
+	return days, nil
+}
+
+// ParseDeactivationReminders parses the given comma-separated list of reminders (in number of days before the deactivation)
+// and returns them without duplicates, sorted in descending order
+func ParseDeactivationReminders(value string) ([]int, error) {
+	days := []int{}
+	seen := map[int]bool{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, errs.Errorf("reminder must be a positive number of days: %d", d)
+		}
+		if !seen[d] {
+			seen[d] = true
+			days = append(days, d)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days, nil
+}
+
+// ensureDeactivationReminder requests the latest reminder which is due and which was not sent yet, if any, by setting the
+// `deactivation-reminder-due` annotation on the UserSignup. The first reminder is not handled here since it is the
+// deactivating notification.
+// Returns the time at which the next reminder will be due, or a zero time if there is no other reminder in the schedule
+func (r *Reconciler) ensureDeactivationReminder(logger logr.Logger, reminderDays []int, deactivationDueTime time.Time, usersignup *toolchainv1alpha1.UserSignup) (time.Time, error) {
+	current := 0
+	var next time.Time
+	for _, d := range reminderDays[1:] {
+		if t := deactivationDueTime.Add(-time.Duration(d*24) * time.Hour); time.Now().Before(t) {
+			next = t
+			break
+		}
+		current = d
+	}
+	if current == 0 {
+		return next, nil
+	}
+	sent, _ := ParseDeactivationReminders(usersignup.Annotations[DeactivationRemindersSentAnnotationKey])
+	for _, d := range sent {
+		if d <= current {
+			// this reminder (or a later one from a previous schedule) was already sent
+			return next, nil
+		}
+	}
+	if usersignup.Annotations[DeactivationReminderDueAnnotationKey] == strconv.Itoa(current) {
+		// already requested
+		return next, nil
+	}
+	logger.Info("requesting deactivation reminder", "days", current)
+	if usersignup.Annotations == nil {
+		usersignup.Annotations = map[string]string{}
+	}
+	usersignup.Annotations[DeactivationReminderDueAnnotationKey] = strconv.Itoa(current)
+	if err := r.Client.Update(context.TODO(), usersignup); err != nil {
+		return next, errs.Wrap(err, "unable to request the deactivation reminder")
+	}
+	return next, nil
+}
+
+// MarkDeactivationReminderSent records the given reminder (in number of days before the deactivation) in the
+// `deactivation-reminders-sent` annotation of the given UserSignup and removes its `deactivation-reminder-due` annotation.
+// The UserSignup needs to be updated by the caller.
+func MarkDeactivationReminderSent(usersignup *toolchainv1alpha1.UserSignup, days int) {
+	sent, _ := ParseDeactivationReminders(usersignup.Annotations[DeactivationRemindersSentAnnotationKey])
+	values := []string{}
+	for _, d := range sent {
+		if d != days {
+			values = append(values, strconv.Itoa(d))
+		}
+	}
+	values = append(values, strconv.Itoa(days))
+	if usersignup.Annotations == nil {
+		usersignup.Annotations = map[string]string{}
+	}
+	usersignup.Annotations[DeactivationRemindersSentAnnotationKey] = strings.Join(values, ",")
+	delete(usersignup.Annotations, DeactivationReminderDueAnnotationKey)
+}
@@ -8,8 +8,10 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/cluster"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/mapper"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -55,6 +57,8 @@ type Reconciler struct {
 	Scheme         *runtime.Scheme
 	Namespace      string
 	MemberClusters map[string]cluster.Cluster
+	// EventRecorder records the events of the provisioning and failures of the MasterUserRecords
+	EventRecorder *events.Recorder
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords,verbs=get;list;watch;create;update;patch;delete
@@ -84,6 +88,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		logger.Error(err, "unable to get MasterUserRecord")
 		return reconcile.Result{}, err
 	}
+	_, span := tracing.StartReconcileSpan(ctx, "masteruserrecord", mur)
+	defer span.End()
 
 	// If the UserAccount is not being deleted, create or synchronize UserAccounts.
 	if !coputil.IsBeingDeleted(mur) {
@@ -188,6 +194,7 @@ func (r *Reconciler) ensureUserAccount(logger logr.Logger, murAccount toolchainv
 		recordSpecUserAcc: murAccount,
 		logger:            logger,
 		scheme:            r.Scheme,
+		eventRecorder:     r.EventRecorder,
 	}
 	if err := sync.synchronizeSpec(); err != nil {
 		// note: if we got an error while sync'ing the spec, then we may not be able to update the MUR status it here neither.
@@ -214,6 +221,8 @@ func (r *Reconciler) wrapErrorWithStatusUpdate(logger logr.Logger, mur *toolchai
 	if err := updateStatus(logger, mur, err.Error()); err != nil {
 		logger.Error(err, "status update failed")
 	}
+	metrics.IncrementControllerFailures("masteruserrecord", mur.Status.Conditions, err.Error())
+	r.EventRecorder.Failure(mur, mur.Status.Conditions, err.Error())
 	if format != "" {
 		return errs.Wrapf(err, format, args...)
 	}
@@ -353,7 +362,7 @@ func updateStatusConditions(logger logr.Logger, cl client.Client, mur *toolchain
 }
 
 func newUserAccount(nsdName types.NamespacedName, mur *toolchainv1alpha1.MasterUserRecord) *toolchainv1alpha1.UserAccount {
-	return &toolchainv1alpha1.UserAccount{
+	userAccount := &toolchainv1alpha1.UserAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      nsdName.Name,
 			Namespace: nsdName.Namespace,
@@ -369,6 +378,8 @@ func newUserAccount(nsdName types.NamespacedName, mur *toolchainv1alpha1.MasterU
 			Disabled: mur.Spec.Disabled,
 		},
 	}
+	tracing.PropagateTraceContext(mur, userAccount)
+	return userAccount
 }
 
 func namespacedName(namespace, name string) types.NamespacedName {
@@ -819,6 +819,7 @@ func TestSyncMurStatusWithUserAccountStatuses(t *testing.T) {
 
 		cntrl := newController(hostClient, s, ClusterClient(test.MemberClusterName, memberClient),
 			ClusterClient(test.Member2ClusterName, memberClient2))
+		provisioned := MetricsHistogramSampleCount(t, metrics.MasterUserRecordProvisioningDurationHistogram)
 
 		// when
 		_, err := cntrl.Reconcile(context.TODO(), newMurRequest(mur))
@@ -826,6 +827,7 @@ func TestSyncMurStatusWithUserAccountStatuses(t *testing.T) {
 		// then
 		// the original error status should be cleaned
 		require.NoError(t, err)
+		AssertMetricsHistogramSampleCount(t, provisioned+1, metrics.MasterUserRecordProvisioningDurationHistogram)
 
 		murtest.AssertThatMasterUserRecord(t, "john", hostClient).
 			HasConditions(toBeProvisioned(), toBeProvisionedNotificationCreated()).
@@ -8,6 +8,8 @@ import (
 
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	notify "github.com/codeready-toolchain/toolchain-common/pkg/notification"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
@@ -30,6 +32,7 @@ type Synchronizer struct {
 	record            *toolchainv1alpha1.MasterUserRecord
 	scheme            *runtime.Scheme
 	logger            logr.Logger
+	eventRecorder     *events.Recorder
 }
 
 // synchronizeSpec synchronizes the useraccount in the MasterUserRecord with the corresponding UserAccount on the member cluster.
@@ -184,6 +187,8 @@ func (s *Synchronizer) alignReadiness() (bool, error) {
 	// the MUR status can change from provisioned to something else and back to provisioned but the time should only be set the first time.
 	if s.record.Status.ProvisionedTime == nil {
 		s.record.Status.ProvisionedTime = &v1.Time{Time: time.Now()}
+		metrics.MasterUserRecordProvisioningDurationHistogram.Observe(s.record.Status.ProvisionedTime.Sub(s.record.CreationTimestamp.Time).Seconds())
+		s.eventRecorder.Normal(s.record, toolchainv1alpha1.MasterUserRecordProvisionedReason, "the user accounts were provisioned")
 	}
 
 	if condition.IsNotTrue(s.record.Status.Conditions, toolchainv1alpha1.MasterUserRecordUserProvisionedNotificationCreated) {
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
@@ -41,6 +44,8 @@ type Reconciler struct {
 	Client          client.Client
 	Scheme          *runtime.Scheme
 	deliveryService DeliveryService
+	// EventRecorder records the events of the delivery of the Notifications
+	EventRecorder *events.Recorder
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=notifications,verbs=get;list;watch;create;update;patch;delete
@@ -64,6 +69,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	_, span := tracing.StartReconcileSpan(ctx, "notification", notification)
+	defer span.End()
 
 	config, err := toolchainconfig.GetToolchainConfig(r.Client)
 	if err != nil {
@@ -99,6 +106,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 				r.setStatusNotificationDeliveryError, err, "failed to send notification")
 		}
 		reqLogger.Info("Notification has been sent")
+		metrics.NotificationDeliveryDurationHistogram.Observe(time.Since(notification.CreationTimestamp.Time).Seconds())
+		r.EventRecorder.Normal(notification, toolchainv1alpha1.NotificationSentReason, "the notification was sent")
 	} else {
 		reqLogger.Info("Notification has been skipped")
 	}
@@ -143,6 +152,8 @@ func (r *Reconciler) wrapErrorWithStatusUpdate(logger logr.Logger, notification
 	if err := statusUpdater(notification, err.Error()); err != nil {
 		logger.Error(err, "status update failed")
 	}
+	metrics.IncrementControllerFailures("notification", notification.Status.Conditions, err.Error())
+	r.EventRecorder.Failure(notification, notification.Status.Conditions, err.Error())
 	return errs.Wrapf(err, format, args...)
 }
 
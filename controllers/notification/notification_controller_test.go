@@ -9,7 +9,9 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/notificationtemplates"
+	. "github.com/codeready-toolchain/host-operator/test"
 	ntest "github.com/codeready-toolchain/host-operator/test/notification"
 	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	notify "github.com/codeready-toolchain/toolchain-common/pkg/notification"
@@ -139,6 +141,7 @@ func TestNotificationDelivery(t *testing.T) {
 			},
 		}
 		controller, client := newController(t, ds, userSignup)
+		metrics.Reset()
 
 		notification, err := notify.NewNotificationBuilder(client, test.HostOperatorNs).
 			WithUserContext(userSignup).
@@ -152,6 +155,7 @@ func TestNotificationDelivery(t *testing.T) {
 		// then
 		require.NoError(t, err)
 		require.True(t, result.Requeue)
+		AssertMetricsHistogramSampleCount(t, 1, metrics.NotificationDeliveryDurationHistogram)
 
 		// Load the reconciled notification
 		key := types.NamespacedName{
@@ -284,6 +288,7 @@ func TestNotificationDelivery(t *testing.T) {
 		}
 		mds := &MockDeliveryService{}
 		controller, client := newController(t, mds, userSignup)
+		metrics.Reset()
 
 		notification, err := notify.NewNotificationBuilder(client, test.HostOperatorNs).
 			Create("foo@redhat.com")
@@ -308,6 +313,8 @@ func TestNotificationDelivery(t *testing.T) {
 
 		ntest.AssertThatNotification(t, instance.Name, client).
 			HasConditions(deliveryErrorCond("delivery error"))
+		AssertMetricsCounterEquals(t, 1, metrics.ControllerFailuresCounterVec.WithLabelValues("notification", toolchainv1alpha1.NotificationDeliveryErrorReason))
+		AssertMetricsHistogramSampleCount(t, 0, metrics.NotificationDeliveryDurationHistogram)
 	})
 }
 
@@ -21,7 +21,7 @@ import (
 //
 // Note: The `hash` value is computed from the TemplateRefs. See `computeTemplateRefsHash()`
 func OutdatedTierSelector(tier *toolchainv1alpha1.NSTemplateTier) (client.MatchingLabelsSelector, error) {
-	// compute the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef`
+	// compute the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef` + `.spec.spaceRoles[].templateRef`
 	hash, err := tierutil.ComputeHashForNSTemplateTier(tier)
 	if err != nil {
 		return client.MatchingLabelsSelector{}, err
@@ -4,13 +4,63 @@ import (
 	"testing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/nstemplatetier"
 	tierutil "github.com/codeready-toolchain/host-operator/controllers/nstemplatetier/util"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+func TestOutdatedTierSelector(t *testing.T) {
+	// given
+	previousBasicTier := tiertest.BasicTier(t, tiertest.PreviousBasicTemplates)
+	basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+
+	// when
+	selector, err := nstemplatetier.OutdatedTierSelector(basicTier)
+
+	// then
+	require.NoError(t, err)
+
+	t.Run("should not match up-to-date Space", func(t *testing.T) {
+		s := spacetest.NewSpace("oddity", spacetest.WithTierNameAndHashLabelFor(basicTier))
+		assert.False(t, selector.Matches(labels.Set(s.Labels)))
+	})
+
+	t.Run("should not match up-to-date Space with template overrides", func(t *testing.T) {
+		// the template overrides are not part of the tier hash label, otherwise such a Space would always be outdated
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithTierNameAndHashLabelFor(basicTier),
+			spacetest.WithAnnotation(space.ExtraNamespacesAnnotationKey, "ci"),
+			spacetest.WithAnnotation(space.TemplateParametersAnnotationKey, `{"IDLER_TIMEOUT_SECONDS":"7200"}`))
+		assert.False(t, selector.Matches(labels.Set(s.Labels)))
+	})
+
+	t.Run("should match outdated Space", func(t *testing.T) {
+		s := spacetest.NewSpace("oddity", spacetest.WithTierNameAndHashLabelFor(previousBasicTier))
+		assert.True(t, selector.Matches(labels.Set(s.Labels)))
+	})
+
+	t.Run("should match outdated Space with template overrides", func(t *testing.T) {
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithTierNameAndHashLabelFor(previousBasicTier),
+			spacetest.WithAnnotation(space.ExtraNamespacesAnnotationKey, "ci"))
+		assert.True(t, selector.Matches(labels.Set(s.Labels)))
+	})
+
+	t.Run("should not match Space of another tier", func(t *testing.T) {
+		advancedTier := tiertest.BasicTier(t, tiertest.PreviousBasicTemplates)
+		advancedTier.Name = "advanced"
+		s := spacetest.NewSpace("oddity", spacetest.WithTierNameAndHashLabelFor(advancedTier))
+		assert.False(t, selector.Matches(labels.Set(s.Labels)))
+	})
+}
+
 func TestComputeHash(t *testing.T) {
 
 	t.Run("should match without cluster resources", func(t *testing.T) {
@@ -225,4 +275,109 @@ func TestComputeHash(t *testing.T) {
 		assert.NotEqual(t, hash1, hash2)
 	})
 
+	t.Run("with space roles", func(t *testing.T) {
+		newTier := func(adminRef string) *toolchainv1alpha1.NSTemplateTier {
+			return &toolchainv1alpha1.NSTemplateTier{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      "basic",
+				},
+				Spec: toolchainv1alpha1.NSTemplateTierSpec{
+					Namespaces: []toolchainv1alpha1.NSTemplateTierNamespace{
+						{
+							TemplateRef: "basic-dev-123456old",
+						},
+					},
+					ClusterResources: &toolchainv1alpha1.NSTemplateTierClusterResources{
+						TemplateRef: "basic-clusterresources-123456a",
+					},
+					SpaceRoles: map[string]toolchainv1alpha1.NSTemplateTierSpaceRole{
+						"admin": {
+							TemplateRef: adminRef,
+						},
+						"viewer": {
+							TemplateRef: "basic-viewer-123456a",
+						},
+					},
+				},
+			}
+		}
+		newNSTemplateSetSpec := func(spaceRoleRefs ...string) toolchainv1alpha1.NSTemplateSetSpec {
+			s := toolchainv1alpha1.NSTemplateSetSpec{
+				TierName: "basic",
+				Namespaces: []toolchainv1alpha1.NSTemplateSetNamespace{
+					{
+						TemplateRef: "basic-dev-123456old",
+					},
+				},
+				ClusterResources: &toolchainv1alpha1.NSTemplateSetClusterResources{
+					TemplateRef: "basic-clusterresources-123456a",
+				},
+			}
+			for _, ref := range spaceRoleRefs {
+				s.SpaceRoles = append(s.SpaceRoles, toolchainv1alpha1.NSTemplateSetSpaceRole{
+					TemplateRef: ref,
+					Usernames:   []string{"john"},
+				})
+			}
+			return s
+		}
+
+		t.Run("should not match when space role template changed", func(t *testing.T) {
+			// when
+			hash1, err1 := tierutil.ComputeHashForNSTemplateTier(newTier("basic-admin-123456a"))
+			hash2, err2 := tierutil.ComputeHashForNSTemplateTier(newTier("basic-admin-123456b"))
+			// then
+			require.NoError(t, err1)
+			require.NoError(t, err2)
+			assert.NotEqual(t, hash1, hash2)
+		})
+
+		t.Run("should match when all space roles are used", func(t *testing.T) {
+			// when
+			hash1, err1 := tierutil.ComputeHashForNSTemplateTier(newTier("basic-admin-123456a"))
+			hash2, err2 := tierutil.ComputeHashForNSTemplateSetSpec(newNSTemplateSetSpec("basic-admin-123456a", "basic-viewer-123456a"))
+			// then
+			require.NoError(t, err1)
+			require.NoError(t, err2)
+			assert.Equal(t, hash1, hash2)
+			assert.True(t, tierutil.TierHashMatches(newTier("basic-admin-123456a"), newNSTemplateSetSpec("basic-admin-123456a", "basic-viewer-123456a")))
+		})
+
+		t.Run("should match when only some space roles are used", func(t *testing.T) {
+			assert.True(t, tierutil.TierHashMatches(newTier("basic-admin-123456a"), newNSTemplateSetSpec("basic-admin-123456a")))
+			assert.True(t, tierutil.TierHashMatches(newTier("basic-admin-123456a"), newNSTemplateSetSpec()))
+		})
+
+		t.Run("should not match when space role template is outdated", func(t *testing.T) {
+			assert.False(t, tierutil.TierHashMatches(newTier("basic-admin-123456b"), newNSTemplateSetSpec("basic-admin-123456a")))
+		})
+
+		t.Run("legacy hash", func(t *testing.T) {
+			// given
+			tier := newTier("basic-admin-123456a")
+			legacyHash, err := tierutil.ComputeLegacyHashForNSTemplateTier(tier)
+			require.NoError(t, err)
+			tierWithoutSpaceRoles := newTier("basic-admin-123456a")
+			tierWithoutSpaceRoles.Spec.SpaceRoles = nil
+
+			t.Run("same as the hash of the tier without space roles", func(t *testing.T) {
+				hash, err := tierutil.ComputeHashForNSTemplateTier(tierWithoutSpaceRoles)
+				require.NoError(t, err)
+				assert.Equal(t, legacyHash, hash)
+				assert.False(t, tierutil.IsLegacyHash(tierWithoutSpaceRoles, hash)) // not a legacy hash since it did not change
+			})
+
+			t.Run("is legacy hash", func(t *testing.T) {
+				assert.True(t, tierutil.IsLegacyHash(tier, legacyHash))
+			})
+
+			t.Run("is not legacy hash", func(t *testing.T) {
+				hash, err := tierutil.ComputeHashForNSTemplateTier(tier)
+				require.NoError(t, err)
+				assert.False(t, tierutil.IsLegacyHash(tier, hash))
+				assert.False(t, tierutil.IsLegacyHash(tier, "abc123"))
+			})
+		})
+	})
 }
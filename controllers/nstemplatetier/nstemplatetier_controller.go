@@ -6,6 +6,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	tierutil "github.com/codeready-toolchain/host-operator/controllers/nstemplatetier/util"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -34,10 +35,15 @@ func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
 		Complete(r)
 }
 
+// UpdatedEventReason the reason of the event recorded when a new entry is added in the `status.updates` of an NSTemplateTier
+const UpdatedEventReason = "Updated"
+
 // Reconciler reconciles a NSTemplateTier object (only when this latter's specs were updated)
 type Reconciler struct {
 	Client client.Client
 	Scheme *runtime.Scheme
+	// EventRecorder records the events of the updates of the NSTemplateTiers
+	EventRecorder *events.Recorder
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=nstemplatetiers,verbs=get;list;watch;create;update;patch;delete
@@ -72,6 +78,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		logger.Error(err, "unable to insert a new entry in status.updates after NSTemplateTier changed")
 		return reconcile.Result{}, errs.Wrap(err, "unable to insert a new entry in status.updates after NSTemplateTier changed")
 	} else if added {
+		r.EventRecorder.Normal(tier, UpdatedEventReason, "the NSTemplateTier was updated with the hash '%s'", tier.Status.Updates[len(tier.Status.Updates)-1].Hash)
 		logger.Info("Requeing after adding a new entry in tier.status.updates")
 		return reconcile.Result{Requeue: true}, nil
 	}
@@ -95,6 +102,13 @@ func (r *Reconciler) ensureStatusUpdateRecord(logger logr.Logger, tier *toolchai
 		logger.Info("current tier template already exists in tier.status.updates")
 		return false, nil
 	}
+	// check whether the entry was added with the legacy hash (ie, without the space roles), in which case
+	// it is migrated in place, since the templates did not change
+	if tierutil.IsLegacyHash(tier, tier.Status.Updates[len(tier.Status.Updates)-1].Hash) {
+		logger.Info("migrating the legacy hash of the current entry in tier.status.updates")
+		tier.Status.Updates[len(tier.Status.Updates)-1].Hash = hash
+		return false, r.Client.Status().Update(context.TODO(), tier)
+	}
 	logger.Info("Adding a new entry in tier.status.updates")
 	return true, r.addNewTierUpdate(tier, hash)
 }
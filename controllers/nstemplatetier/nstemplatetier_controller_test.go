@@ -8,7 +8,9 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/controllers/nstemplatetier"
+	tierutil "github.com/codeready-toolchain/host-operator/controllers/nstemplatetier/util"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 
@@ -20,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -42,6 +45,8 @@ func TestReconcile(t *testing.T) {
 			basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
 			initObjs := []runtime.Object{basicTier}
 			r, req, cl := prepareReconcile(t, basicTier.Name, initObjs...)
+			fakeRecorder := record.NewFakeRecorder(10)
+			r.(*nstemplatetier.Reconciler).EventRecorder = events.NewRecorder(fakeRecorder, nil)
 			// when
 			res, err := r.Reconcile(context.TODO(), req)
 			// then
@@ -53,6 +58,9 @@ func TestReconcile(t *testing.T) {
 				HasLatestUpdate(toolchainv1alpha1.NSTemplateTierHistory{
 					Hash: basicTier.Labels["toolchain.dev.openshift.com/basic-tier-hash"],
 				})
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, fmt.Sprintf("Normal Updated the NSTemplateTier was updated with the hash '%s'",
+				basicTier.Labels["toolchain.dev.openshift.com/basic-tier-hash"]), <-fakeRecorder.Events)
 		})
 
 		t.Run("with previous entries", func(t *testing.T) {
@@ -106,6 +114,36 @@ func TestReconcile(t *testing.T) {
 		})
 	})
 
+	t.Run("controller should migrate the legacy hash of the last entry in tier.status.updates", func(t *testing.T) {
+		// given
+		basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+		legacyHash, err := tierutil.ComputeLegacyHashForNSTemplateTier(basicTier)
+		require.NoError(t, err)
+		basicTier.Status.Updates = []toolchainv1alpha1.NSTemplateTierHistory{
+			{
+				StartTime: metav1.Now(),
+				Hash:      "abc123",
+			},
+			{
+				StartTime: metav1.Now(),
+				Hash:      legacyHash,
+			},
+		}
+		initObjs := []runtime.Object{basicTier}
+		r, req, cl := prepareReconcile(t, basicTier.Name, initObjs...)
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+		// then
+		require.NoError(t, err)
+		require.Equal(t, reconcile.Result{}, res) // no explicit requeue
+		// check that the last entry was migrated instead of adding a new entry
+		tiertest.AssertThatNSTemplateTier(t, "basic", cl).
+			HasStatusUpdatesItems(2).
+			HasLatestUpdate(toolchainv1alpha1.NSTemplateTierHistory{
+				Hash: basicTier.Labels["toolchain.dev.openshift.com/basic-tier-hash"],
+			})
+	})
+
 	t.Run("failures", func(t *testing.T) {
 
 		t.Run("unable to get NSTemplateTier", func(t *testing.T) {
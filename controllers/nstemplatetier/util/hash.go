@@ -14,7 +14,65 @@ func TemplateTierHashLabelKey(tierName string) string {
 }
 
 // ComputeHashForNSTemplateTier computes the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef`
+// + `.spec.spaceRoles[].templateRef`
 func ComputeHashForNSTemplateTier(tier *toolchainv1alpha1.NSTemplateTier) (string, error) {
+	return computeHash(tierRefs(tier), tierSpaceRoleRefs(tier))
+}
+
+// ComputeLegacyHashForNSTemplateTier computes the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef`,
+// ie, the hash that was computed before the space roles were part of the tier hash.
+// It is only used to migrate the existing `status.updates` of the NSTemplateTiers and the tier hash labels of the Spaces
+func ComputeLegacyHashForNSTemplateTier(tier *toolchainv1alpha1.NSTemplateTier) (string, error) {
+	return computeHash(tierRefs(tier), nil)
+}
+
+// IsLegacyHash returns `true` if the given hash is the legacy hash of the given tier (see `ComputeLegacyHashForNSTemplateTier`)
+// and if this legacy hash differs from the current one
+func IsLegacyHash(tier *toolchainv1alpha1.NSTemplateTier, h string) bool {
+	legacyHash, err := ComputeLegacyHashForNSTemplateTier(tier)
+	if err != nil || legacyHash != h {
+		return false
+	}
+	currentHash, err := ComputeHashForNSTemplateTier(tier)
+	return err == nil && currentHash != legacyHash
+}
+
+// ComputeHashForNSTemplateSetSpec computes the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef`
+// + `.spec.spaceRoles[].templateRef`
+// Note: the NSTemplateSet only contains the space roles with at least one user, so use `TierHashMatches` to verify that an
+// NSTemplateSet is up-to-date with its NSTemplateTier.
+func ComputeHashForNSTemplateSetSpec(s toolchainv1alpha1.NSTemplateSetSpec) (string, error) {
+	return computeHash(nsTemplateSetRefs(s), nsTemplateSetSpaceRoleRefs(s))
+}
+
+// TierHashMatches returns `true` if the given NSTemplateSet spec has the same namespaces and cluster resources template refs as
+// the given NSTemplateTier, and if all its space roles template refs belong to the NSTemplateTier
+func TierHashMatches(tmplTier *toolchainv1alpha1.NSTemplateTier, nsTmplSetSpec toolchainv1alpha1.NSTemplateSetSpec) bool {
+	tierRoleRefs := map[string]bool{}
+	for _, ref := range tierSpaceRoleRefs(tmplTier) {
+		tierRoleRefs[ref] = true
+	}
+	spaceRoleRefs := []string{}
+	for _, ref := range nsTemplateSetSpaceRoleRefs(nsTmplSetSpec) {
+		if !tierRoleRefs[ref] {
+			return false
+		}
+		spaceRoleRefs = append(spaceRoleRefs, ref)
+	}
+
+	// compare with the tier, limited to the space roles that are used in the NSTemplateSet
+	tierHash, err := computeHash(tierRefs(tmplTier), spaceRoleRefs)
+	if err != nil {
+		return false
+	}
+	nsTmplSetSpecHash, err := ComputeHashForNSTemplateSetSpec(nsTmplSetSpec)
+	if err != nil {
+		return false
+	}
+	return tierHash == nsTmplSetSpecHash
+}
+
+func tierRefs(tier *toolchainv1alpha1.NSTemplateTier) []string {
 	refs := []string{}
 	for _, ns := range tier.Spec.Namespaces {
 		refs = append(refs, ns.TemplateRef)
@@ -22,11 +80,18 @@ func ComputeHashForNSTemplateTier(tier *toolchainv1alpha1.NSTemplateTier) (strin
 	if tier.Spec.ClusterResources != nil {
 		refs = append(refs, tier.Spec.ClusterResources.TemplateRef)
 	}
-	return computeHash(refs)
+	return refs
 }
 
-// ComputeHashForNSTemplateSetSpec computes the hash of the `.spec.namespaces[].templateRef` + `.spec.clusteResource.TemplateRef`
-func ComputeHashForNSTemplateSetSpec(s toolchainv1alpha1.NSTemplateSetSpec) (string, error) {
+func tierSpaceRoleRefs(tier *toolchainv1alpha1.NSTemplateTier) []string {
+	refs := []string{}
+	for _, role := range tier.Spec.SpaceRoles {
+		refs = append(refs, role.TemplateRef)
+	}
+	return refs
+}
+
+func nsTemplateSetRefs(s toolchainv1alpha1.NSTemplateSetSpec) []string {
 	refs := []string{}
 	for _, ns := range s.Namespaces {
 		refs = append(refs, ns.TemplateRef)
@@ -34,32 +99,49 @@ func ComputeHashForNSTemplateSetSpec(s toolchainv1alpha1.NSTemplateSetSpec) (str
 	if s.ClusterResources != nil && s.ClusterResources.TemplateRef != "" { // ignore when ClusterResources only contains a custom template
 		refs = append(refs, s.ClusterResources.TemplateRef)
 	}
-	return computeHash(refs)
+	return refs
 }
 
-func TierHashMatches(tmplTier *toolchainv1alpha1.NSTemplateTier, nsTmplSetSpec toolchainv1alpha1.NSTemplateSetSpec) bool {
-	tierHash, err := ComputeHashForNSTemplateTier(tmplTier)
-	if err != nil {
-		return false
-	}
-
-	nsTmplSetSpecHash, err := ComputeHashForNSTemplateSetSpec(nsTmplSetSpec)
-	if err != nil {
-		return false
+func nsTemplateSetSpaceRoleRefs(s toolchainv1alpha1.NSTemplateSetSpec) []string {
+	refs := []string{}
+	for _, role := range s.SpaceRoles {
+		refs = append(refs, role.TemplateRef)
 	}
-	return tierHash == nsTmplSetSpecHash
+	return refs
 }
 
+// templateRefs the content of the tier hash.
+// New fields must be omitted when empty, so that the hash of the existing tiers remains unchanged when they don't use the new fields.
 type templateRefs struct {
-	Refs []string `json:"refs"`
+	Refs          []string `json:"refs"`
+	SpaceRoleRefs []string `json:"spaceRoleRefs,omitempty"`
 }
 
-func computeHash(refs []string) (string, error) {
+func computeHash(refs, spaceRoleRefs []string) (string, error) {
 	// sort the refs to make sure we have a predictive hash!
 	sort.Strings(refs)
-	m, err := json.Marshal(templateRefs{Refs: refs}) // embed in a type with JSON tags
+	spaceRoleRefs = dedup(spaceRoleRefs)
+	m, err := json.Marshal(templateRefs{ // embed in a type with JSON tags
+		Refs:          refs,
+		SpaceRoleRefs: spaceRoleRefs,
+	})
 	if err != nil {
 		return "", err
 	}
 	return hash.Encode(m), nil
 }
+
+// dedup returns the given values, sorted and without duplicates (different space roles may use the same template)
+func dedup(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Strings(values)
+	result := []string{values[0]}
+	for _, v := range values[1:] {
+		if v != result[len(result)-1] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
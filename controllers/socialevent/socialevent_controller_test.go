@@ -8,6 +8,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/controllers/socialevent"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
 	socialeventtest "github.com/codeready-toolchain/host-operator/test/socialevent"
 	"github.com/codeready-toolchain/host-operator/test/usertier"
@@ -19,6 +20,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -120,6 +122,8 @@ func TestReconcileSocialEvent(t *testing.T) {
 			event := socialeventtest.NewSocialEvent("unknown", "basic")
 			hostClient := test.NewFakeClient(t, event, baseUserTier, baseSpaceTier)
 			ctrl := newReconciler(hostClient)
+			fakeRecorder := record.NewFakeRecorder(10)
+			ctrl.StatusUpdater.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 			// when
 			_, err := ctrl.Reconcile(context.TODO(), requestFor(event))
@@ -135,6 +139,17 @@ func TestReconcileSocialEvent(t *testing.T) {
 					Message: "UserTier 'unknown' not found",
 				},
 			)
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, fmt.Sprintf("Warning %s UserTier 'unknown' not found", toolchainv1alpha1.SocialEventInvalidUserTierReason), <-fakeRecorder.Events)
+
+			t.Run("not recorded again while the tier is still unknown", func(t *testing.T) {
+				// when
+				_, err := ctrl.Reconcile(context.TODO(), requestFor(event))
+
+				// then
+				require.NoError(t, err)
+				assert.Empty(t, fakeRecorder.Events)
+			})
 		})
 
 		t.Run("unable to get space tier", func(t *testing.T) {
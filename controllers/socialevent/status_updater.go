@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	commonCondition "github.com/codeready-toolchain/toolchain-common/pkg/condition"
 
 	"github.com/go-logr/logr"
@@ -13,8 +14,18 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// socialEventEvents the type of the events recorded when the conditions of a SocialEvent are set with the following reasons
+var socialEventEvents = events.ReasonTypes{
+	toolchainv1alpha1.SocialEventInvalidUserTierReason:      corev1.EventTypeWarning,
+	toolchainv1alpha1.SocialEventUnableToGetUserTierReason:  corev1.EventTypeWarning,
+	toolchainv1alpha1.SocialEventInvalidSpaceTierReason:     corev1.EventTypeWarning,
+	toolchainv1alpha1.SocialEventUnableToGetSpaceTierReason: corev1.EventTypeWarning,
+}
+
 type StatusUpdater struct {
 	Client client.Client
+	// EventRecorder records the events of the invalid tiers of the SocialEvents
+	EventRecorder *events.Recorder
 }
 
 func (u *StatusUpdater) ready(event *toolchainv1alpha1.SocialEvent) error {
@@ -76,10 +87,15 @@ func (u *StatusUpdater) unableToGetSpaceTier(logger logr.Logger, event *toolchai
 
 func (u *StatusUpdater) updateStatusConditions(event *toolchainv1alpha1.SocialEvent, newConditions ...toolchainv1alpha1.Condition) error {
 	var updated bool
+	previous := append([]toolchainv1alpha1.Condition{}, event.Status.Conditions...)
 	event.Status.Conditions, updated = commonCondition.AddOrUpdateStatusConditions(event.Status.Conditions, newConditions...)
 	if !updated {
 		// Nothing changed
 		return nil
 	}
-	return u.Client.Status().Update(context.TODO(), event)
+	if err := u.Client.Status().Update(context.TODO(), event); err != nil {
+		return err
+	}
+	u.EventRecorder.Transitions(event, previous, socialEventEvents, newConditions...)
+	return nil
 }
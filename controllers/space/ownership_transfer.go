@@ -0,0 +1,185 @@
+package space
+
+import (
+	"context"
+	"fmt"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/spacebinding"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TransferToAnnotationKey the annotation on a Space to request the transfer of its ownership to the MasterUserRecord with the given name.
+// The annotation is removed once the transfer was processed.
+const TransferToAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "transfer-to"
+
+// transferredFromAnnotationKey the annotation on a Space which keeps the name of the UserSignup of the previous owner while
+// its SpaceBindings are deleted
+const transferredFromAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "transferred-from"
+
+const (
+	// OwnershipTransferredCondition the type of the Space condition which reports the outcome of the last ownership transfer
+	OwnershipTransferredCondition toolchainv1alpha1.ConditionType = "OwnershipTransferred"
+
+	// OwnershipTransferredReason the reason of the `OwnershipTransferred` condition when the ownership was transferred
+	OwnershipTransferredReason = "Transferred"
+	// OwnershipTransferFailedReason the reason of the `OwnershipTransferred` condition when the ownership could not be transferred
+	OwnershipTransferFailedReason = "TransferFailed"
+)
+
+// the role of the owner of a Space
+const ownerSpaceRole = "admin"
+
+// ensureOwnershipTransfer transfers the ownership of the given Space to the MasterUserRecord specified in its `transfer-to` annotation, if any.
+// The admin SpaceBinding of the new owner is created (or the role of its existing SpaceBinding is changed) *before* the SpaceBindings
+// of the previous owner are deleted, so that the Space always has an admin. The creator label of the Space is changed before the
+// SpaceBindings of the previous owner are deleted, too, so that they are not recreated by the UserSignup controller.
+func (r *Reconciler) ensureOwnershipTransfer(logger logr.Logger, space *toolchainv1alpha1.Space) error {
+	target, found := space.Annotations[TransferToAnnotationKey]
+	if !found {
+		return nil
+	}
+	logger = logger.WithValues("transfer_to", target)
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: space.Namespace, Name: target}, mur); err != nil {
+		if !errors.IsNotFound(err) {
+			return errs.Wrapf(err, "unable to get the MasterUserRecord '%s' to transfer the Space to", target)
+		}
+		return r.rejectOwnershipTransfer(logger, space, fmt.Sprintf("the MasterUserRecord '%s' does not exist", target))
+	}
+	newCreator := mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+	if newCreator == "" {
+		return r.rejectOwnershipTransfer(logger, space, fmt.Sprintf("the MasterUserRecord '%s' has no owner", target))
+	}
+	previousCreator := space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey]
+
+	// 1. make sure that the new owner is an admin of the Space
+	bindings := &toolchainv1alpha1.SpaceBindingList{}
+	if err := r.Client.List(context.TODO(), bindings, client.InNamespace(space.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceBindingSpaceLabelKey: space.Name}); err != nil {
+		return errs.Wrap(err, "unable to list the SpaceBindings to transfer the Space")
+	}
+	var newOwnerBinding *toolchainv1alpha1.SpaceBinding
+	for i := range bindings.Items {
+		if bindings.Items[i].Spec.MasterUserRecord == mur.Name {
+			newOwnerBinding = &bindings.Items[i]
+			break
+		}
+	}
+	if newOwnerBinding == nil {
+		newOwnerBinding = spacebinding.NewSpaceBinding(mur, space, newCreator)
+		newOwnerBinding.Spec.SpaceRole = ownerSpaceRole
+		if err := r.Client.Create(context.TODO(), newOwnerBinding); err != nil {
+			return errs.Wrap(err, "unable to create the SpaceBinding of the new owner")
+		}
+		logger.Info("created SpaceBinding for the new owner")
+	} else if newOwnerBinding.Spec.SpaceRole != ownerSpaceRole || newOwnerBinding.Labels[toolchainv1alpha1.SpaceCreatorLabelKey] != newCreator {
+		newOwnerBinding.Spec.SpaceRole = ownerSpaceRole
+		if newOwnerBinding.Labels == nil {
+			newOwnerBinding.Labels = map[string]string{}
+		}
+		newOwnerBinding.Labels[toolchainv1alpha1.SpaceCreatorLabelKey] = newCreator
+		if err := r.Client.Update(context.TODO(), newOwnerBinding); err != nil {
+			return errs.Wrap(err, "unable to update the SpaceBinding of the new owner")
+		}
+		logger.Info("updated SpaceBinding of the new owner")
+	}
+
+	// 2. re-point the creator label and the owner references of the Space *before* the SpaceBindings of the previous owner are deleted,
+	// so that the UserSignup controller does not recreate them in the meantime. The previous owner is kept in an annotation until
+	// its SpaceBindings are deleted, in case the deletion fails and must be retried.
+	if previousCreator != newCreator && space.Annotations[transferredFromAnnotationKey] == "" {
+		if space.Labels == nil {
+			space.Labels = map[string]string{}
+		}
+		space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey] = newCreator
+		for i, ref := range space.OwnerReferences {
+			if ref.Kind == "UserSignup" && ref.Name == previousCreator {
+				userSignup := &toolchainv1alpha1.UserSignup{}
+				if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: space.Namespace, Name: newCreator}, userSignup); err != nil {
+					return errs.Wrapf(err, "unable to get the UserSignup '%s' of the new owner", newCreator)
+				}
+				space.OwnerReferences[i].Name = userSignup.Name
+				space.OwnerReferences[i].UID = userSignup.UID
+			}
+		}
+		if previousCreator != "" {
+			space.Annotations[transferredFromAnnotationKey] = previousCreator
+		}
+		if err := r.Client.Update(context.TODO(), space); err != nil {
+			return errs.Wrap(err, "unable to update the Space to transfer its ownership")
+		}
+	}
+	if from := space.Annotations[transferredFromAnnotationKey]; from != "" {
+		previousCreator = from
+	}
+
+	// 3. remove the SpaceBindings of the previous owner
+	previousOwner := ""
+	if previousCreator != "" && previousCreator != newCreator {
+		murs := &toolchainv1alpha1.MasterUserRecordList{}
+		if err := r.Client.List(context.TODO(), murs, client.InNamespace(space.Namespace),
+			client.MatchingLabels{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: previousCreator}); err != nil {
+			return errs.Wrap(err, "unable to list the MasterUserRecords of the previous owner")
+		}
+		for _, previous := range murs.Items {
+			previousOwner = previous.Name
+			for i := range bindings.Items {
+				if bindings.Items[i].Spec.MasterUserRecord != previous.Name {
+					continue
+				}
+				if err := r.Client.Delete(context.TODO(), &bindings.Items[i]); err != nil && !errors.IsNotFound(err) {
+					return errs.Wrap(err, "unable to delete the SpaceBinding of the previous owner")
+				}
+				logger.Info("deleted SpaceBinding of the previous owner", "previous_owner", previous.Name)
+			}
+		}
+	}
+
+	// 4. complete the transfer
+	delete(space.Annotations, TransferToAnnotationKey)
+	delete(space.Annotations, transferredFromAnnotationKey)
+	if err := r.Client.Update(context.TODO(), space); err != nil {
+		return errs.Wrap(err, "unable to update the Space to complete the transfer of its ownership")
+	}
+	msg := fmt.Sprintf("ownership transferred to '%s'", mur.Name)
+	if previousOwner != "" {
+		msg = fmt.Sprintf("ownership transferred from '%s' to '%s'", previousOwner, mur.Name)
+	}
+	logger.Info("Space ownership transferred", "previous_owner", previousOwner)
+	if err := r.updateStatus(space, toolchainv1alpha1.Condition{
+		Type:    OwnershipTransferredCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  OwnershipTransferredReason,
+		Message: msg,
+	}); err != nil {
+		return err
+	}
+	r.EventRecorder.Normal(space, OwnershipTransferredReason, "%s", msg)
+	return nil
+}
+
+// rejectOwnershipTransfer removes the `transfer-to` annotation of the given Space and reports the reason in its status
+func (r *Reconciler) rejectOwnershipTransfer(logger logr.Logger, space *toolchainv1alpha1.Space, msg string) error {
+	logger.Info("rejecting Space ownership transfer", "reason", msg)
+	delete(space.Annotations, TransferToAnnotationKey)
+	if err := r.Client.Update(context.TODO(), space); err != nil {
+		return errs.Wrap(err, "unable to update the Space to reject the transfer of its ownership")
+	}
+	if err := r.updateStatus(space, toolchainv1alpha1.Condition{
+		Type:    OwnershipTransferredCondition,
+		Status:  corev1.ConditionFalse,
+		Reason:  OwnershipTransferFailedReason,
+		Message: msg,
+	}); err != nil {
+		return err
+	}
+	r.EventRecorder.Warning(space, OwnershipTransferFailedReason, "%s", msg)
+	return nil
+}
@@ -0,0 +1,290 @@
+package space_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	nstemplatetsettest "github.com/codeready-toolchain/toolchain-common/pkg/test/nstemplateset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOwnershipTransfer(t *testing.T) {
+
+	// given
+	err := apis.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+	basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	jackMUR := murtest.NewMasterUserRecord(t, "jack", murtest.WithOwnerLabel("signupJack"))
+	jeffMUR := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signupJeff"))
+	newSpaceToTransfer := func(target string) *toolchainv1alpha1.Space {
+		return spacetest.NewSpace("jack",
+			spacetest.WithTierNameAndHashLabelFor(basicTier),
+			spacetest.WithCreatorLabel("signupJack"),
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithStatusTargetCluster("member-1"),
+			spacetest.WithFinalizer(),
+			spacetest.WithCondition(spacetest.Ready()),
+			spacetest.WithAnnotation(space.TransferToAnnotationKey, target))
+	}
+	newNSTemplateSet := func() *toolchainv1alpha1.NSTemplateSet {
+		return nstemplatetsettest.NewNSTemplateSet("jack",
+			nstemplatetsettest.WithReferencesFor(basicTier,
+				nstemplatetsettest.WithSpaceRole("admin", jackMUR.Name),
+			),
+			nstemplatetsettest.WithReadyCondition())
+	}
+
+	t.Run("transfer to user without SpaceBinding", func(t *testing.T) {
+		// given
+		s := newSpaceToTransfer(jeffMUR.Name)
+		sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+		nsTmplSet := newNSTemplateSet()
+		hostClient := test.NewFakeClient(t, s, sb, jackMUR, jeffMUR, basicTier)
+		member1Client := test.NewFakeClient(t, nsTmplSet)
+		member1 := NewMemberClusterWithClient(member1Client, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+			Exists().
+			HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJeff").
+			Get()
+		assert.NotContains(t, result.Annotations, space.TransferToAnnotationKey)
+		transferred, found := condition.FindConditionByType(result.Status.Conditions, space.OwnershipTransferredCondition)
+		require.True(t, found)
+		assert.Equal(t, corev1.ConditionTrue, transferred.Status)
+		assert.Equal(t, space.OwnershipTransferredReason, transferred.Reason)
+		assert.Equal(t, "ownership transferred from 'jack' to 'jeff'", transferred.Message)
+		assertSpaceBindings(t, hostClient, s.Name, map[string]string{jeffMUR.Name: "admin"})
+		nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, nsTmplSet.Name, member1Client).
+			HasSpaceRoles(nstemplatetsettest.SpaceRole("basic-admin-123456new", jeffMUR.Name))
+	})
+
+	t.Run("transfer to user with another role", func(t *testing.T) {
+		// given
+		s := newSpaceToTransfer(jeffMUR.Name)
+		sb1 := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+		sb2 := spacebindingtest.NewSpaceBinding(jeffMUR.Name, s.Name, "viewer", "signupJack")
+		nsTmplSet := newNSTemplateSet()
+		hostClient := test.NewFakeClient(t, s, sb1, sb2, jackMUR, jeffMUR, basicTier)
+		member1Client := test.NewFakeClient(t, nsTmplSet)
+		member1 := NewMemberClusterWithClient(member1Client, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+			HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJeff")
+		assertSpaceBindings(t, hostClient, s.Name, map[string]string{jeffMUR.Name: "admin"})
+		nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, nsTmplSet.Name, member1Client).
+			HasSpaceRoles(nstemplatetsettest.SpaceRole("basic-admin-123456new", jeffMUR.Name))
+	})
+
+	t.Run("other SpaceBindings are kept", func(t *testing.T) {
+		// given
+		johnMUR := murtest.NewMasterUserRecord(t, "john", murtest.WithOwnerLabel("signupJohn"))
+		s := newSpaceToTransfer(jeffMUR.Name)
+		sb1 := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+		sb2 := spacebindingtest.NewSpaceBinding(johnMUR.Name, s.Name, "viewer", "signupJack")
+		hostClient := test.NewFakeClient(t, s, sb1, sb2, jackMUR, jeffMUR, johnMUR, basicTier)
+		member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		assertSpaceBindings(t, hostClient, s.Name, map[string]string{jeffMUR.Name: "admin", johnMUR.Name: "viewer"})
+	})
+
+	t.Run("creator label is changed before the SpaceBinding of the previous owner is deleted", func(t *testing.T) {
+		// given
+		s := newSpaceToTransfer(jeffMUR.Name)
+		sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+		hostClient := test.NewFakeClient(t, s, sb, jackMUR, jeffMUR, basicTier)
+		creatorOnDeletion := ""
+		hostClient.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*toolchainv1alpha1.SpaceBinding); ok {
+				// this is what the UserSignup controller checks before recreating the SpaceBinding of its user
+				current := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).Get()
+				creatorOnDeletion = current.Labels[toolchainv1alpha1.SpaceCreatorLabelKey]
+			}
+			return hostClient.Client.Delete(ctx, obj, opts...)
+		}
+		member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "signupJeff", creatorOnDeletion)
+		assertSpaceBindings(t, hostClient, s.Name, map[string]string{jeffMUR.Name: "admin"})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("target MasterUserRecord does not exist", func(t *testing.T) {
+			// given
+			s := newSpaceToTransfer("unknown")
+			sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+			hostClient := test.NewFakeClient(t, s, sb, jackMUR, basicTier)
+			member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+			InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+			ctrl := newReconciler(hostClient, member1)
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+			// then
+			require.NoError(t, err)
+			result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+				HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJack").
+				Get()
+			assert.NotContains(t, result.Annotations, space.TransferToAnnotationKey)
+			transferred, found := condition.FindConditionByType(result.Status.Conditions, space.OwnershipTransferredCondition)
+			require.True(t, found)
+			assert.Equal(t, corev1.ConditionFalse, transferred.Status)
+			assert.Equal(t, space.OwnershipTransferFailedReason, transferred.Reason)
+			assert.Equal(t, "the MasterUserRecord 'unknown' does not exist", transferred.Message)
+			assertSpaceBindings(t, hostClient, s.Name, map[string]string{jackMUR.Name: "admin"})
+		})
+
+		t.Run("target MasterUserRecord has no owner", func(t *testing.T) {
+			// given
+			orphanMUR := murtest.NewMasterUserRecord(t, "orphan")
+			s := newSpaceToTransfer(orphanMUR.Name)
+			sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+			hostClient := test.NewFakeClient(t, s, sb, jackMUR, orphanMUR, basicTier)
+			member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+			InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+			ctrl := newReconciler(hostClient, member1)
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+			// then
+			require.NoError(t, err)
+			result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).Get()
+			transferred, found := condition.FindConditionByType(result.Status.Conditions, space.OwnershipTransferredCondition)
+			require.True(t, found)
+			assert.Equal(t, space.OwnershipTransferFailedReason, transferred.Reason)
+			assertSpaceBindings(t, hostClient, s.Name, map[string]string{jackMUR.Name: "admin"})
+		})
+
+		t.Run("previous owner is kept when the new SpaceBinding cannot be created", func(t *testing.T) {
+			// given
+			s := newSpaceToTransfer(jeffMUR.Name)
+			sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+			hostClient := test.NewFakeClient(t, s, sb, jackMUR, jeffMUR, basicTier)
+			hostClient.MockCreate = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*toolchainv1alpha1.SpaceBinding); ok {
+					return fmt.Errorf("mock error")
+				}
+				return hostClient.Client.Create(ctx, obj, opts...)
+			}
+			member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+			InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+			ctrl := newReconciler(hostClient, member1)
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+			// then
+			require.EqualError(t, err, "unable to create the SpaceBinding of the new owner: mock error")
+			result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+				HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJack").
+				Get()
+			assert.Equal(t, jeffMUR.Name, result.Annotations[space.TransferToAnnotationKey]) // will be retried
+			assertSpaceBindings(t, hostClient, s.Name, map[string]string{jackMUR.Name: "admin"})
+		})
+
+		t.Run("deletion of the SpaceBinding of the previous owner is retried", func(t *testing.T) {
+			// given
+			s := newSpaceToTransfer(jeffMUR.Name)
+			sb := spacebindingtest.NewSpaceBinding(jackMUR.Name, s.Name, "admin", "signupJack")
+			hostClient := test.NewFakeClient(t, s, sb, jackMUR, jeffMUR, basicTier)
+			hostClient.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+				if _, ok := obj.(*toolchainv1alpha1.SpaceBinding); ok {
+					return fmt.Errorf("mock error")
+				}
+				return hostClient.Client.Delete(ctx, obj, opts...)
+			}
+			member1 := NewMemberClusterWithClient(test.NewFakeClient(t, newNSTemplateSet()), "member-1", corev1.ConditionTrue)
+			InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+			ctrl := newReconciler(hostClient, member1)
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+			// then
+			require.EqualError(t, err, "unable to delete the SpaceBinding of the previous owner: mock error")
+			result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+				HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJeff").
+				Get()
+			assert.Equal(t, jeffMUR.Name, result.Annotations[space.TransferToAnnotationKey]) // will be retried
+			assertSpaceBindings(t, hostClient, s.Name, map[string]string{jackMUR.Name: "admin", jeffMUR.Name: "admin"})
+
+			t.Run("previous owner is removed on the next reconcile", func(t *testing.T) {
+				// given
+				hostClient.MockDelete = nil
+
+				// when
+				_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+				// then
+				require.NoError(t, err)
+				result := spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
+					HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "signupJeff").
+					Get()
+				assert.NotContains(t, result.Annotations, space.TransferToAnnotationKey)
+				transferred, found := condition.FindConditionByType(result.Status.Conditions, space.OwnershipTransferredCondition)
+				require.True(t, found)
+				assert.Equal(t, "ownership transferred from 'jack' to 'jeff'", transferred.Message)
+				assertSpaceBindings(t, hostClient, s.Name, map[string]string{jeffMUR.Name: "admin"})
+			})
+		})
+	})
+}
+
+// assertSpaceBindings verifies that the SpaceBindings of the Space match the expected roles per MasterUserRecord
+func assertSpaceBindings(t *testing.T, cl client.Client, spaceName string, expected map[string]string) {
+	bindings := &toolchainv1alpha1.SpaceBindingList{}
+	err := cl.List(context.TODO(), bindings, client.InNamespace(test.HostOperatorNs),
+		client.MatchingLabels{toolchainv1alpha1.SpaceBindingSpaceLabelKey: spaceName})
+	require.NoError(t, err)
+	actual := map[string]string{}
+	for _, sb := range bindings.Items {
+		actual[sb.Spec.MasterUserRecord] = sb.Spec.SpaceRole
+	}
+	assert.Equal(t, expected, actual)
+}
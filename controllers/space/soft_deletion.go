@@ -0,0 +1,65 @@
+package space
+
+import (
+	"fmt"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// SoftDeletionDeadlineAnnotationKey the annotation on a Space which has no SpaceBinding anymore and which is scheduled
+// for deletion. The value is the deadline (RFC3339) until which the Space can be restored by adding a SpaceBinding.
+// The annotation is set and removed by the SpaceCleanup controller.
+const SoftDeletionDeadlineAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "soft-deletion-deadline"
+
+// SpaceTerminatingScheduledReason the reason of the `Ready` condition of a Space which is scheduled for deletion
+const SpaceTerminatingScheduledReason = "TerminatingScheduled"
+
+// scaleDownParameters the template parameters overridden in the NSTemplateSet of a Space which is scheduled for deletion,
+// so that all the pods in its namespaces are idled (ie, scaled to zero) by the member cluster
+var scaleDownParameters = map[string]string{
+	"IDLER_TIMEOUT_SECONDS": "1",
+}
+
+// GetSoftDeletionDeadline returns the deadline until which the given Space can be restored, and `true` if the Space is scheduled for deletion
+func GetSoftDeletionDeadline(space *toolchainv1alpha1.Space) (time.Time, bool, error) {
+	value, found := space.Annotations[SoftDeletionDeadlineAnnotationKey]
+	if !found {
+		return time.Time{}, false, nil
+	}
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, true, errs.Wrapf(err, "invalid value for annotation '%s'", SoftDeletionDeadlineAnnotationKey)
+	}
+	return deadline, true, nil
+}
+
+// withParameters returns a copy of the overrides in which the given parameters are also overridden
+func (o templateOverrides) withParameters(parameters map[string]string) templateOverrides {
+	result := templateOverrides{
+		extraNamespaceTypes: o.extraNamespaceTypes,
+		extraNamespaceRefs:  o.extraNamespaceRefs,
+		parameters:          make(map[string]string, len(o.parameters)+len(parameters)),
+	}
+	for k, v := range o.parameters {
+		result.parameters[k] = v
+	}
+	for k, v := range parameters {
+		result.parameters[k] = v
+	}
+	return result
+}
+
+func (r *Reconciler) setStatusTerminatingScheduled(space *toolchainv1alpha1.Space) error {
+	return r.updateStatus(
+		space,
+		toolchainv1alpha1.Condition{
+			Type:    toolchainv1alpha1.ConditionReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  SpaceTerminatingScheduledReason,
+			Message: fmt.Sprintf("the Space has no SpaceBinding and will be deleted after %s", space.Annotations[SoftDeletionDeadlineAnnotationKey]),
+		})
+}
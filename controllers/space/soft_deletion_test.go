@@ -0,0 +1,109 @@
+package space_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	nstemplatetsettest "github.com/codeready-toolchain/toolchain-common/pkg/test/nstemplateset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestSoftDeletedSpace(t *testing.T) {
+
+	// given
+	err := apis.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+	basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	tierTemplates := []runtime.Object{
+		newTierTemplate("basic-clusterresources-123456new", "clusterresources", "IDLER_TIMEOUT_SECONDS"),
+		newTierTemplate("basic-code-123456new", "code"),
+		newTierTemplate("basic-dev-123456new", "dev"),
+		newTierTemplate("basic-stage-123456new", "stage"),
+	}
+	deadline := time.Now().Add(72 * time.Hour).Format(time.RFC3339)
+	newScheduledSpace := func() *toolchainv1alpha1.Space {
+		return spacetest.NewSpace("oddity",
+			spacetest.WithTierNameAndHashLabelFor(basicTier),
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithStatusTargetCluster("member-1"),
+			spacetest.WithFinalizer(),
+			spacetest.WithCondition(spacetest.Ready()),
+			spacetest.WithAnnotation(space.SoftDeletionDeadlineAnnotationKey, deadline))
+	}
+
+	t.Run("Space scheduled for deletion", func(t *testing.T) {
+		// given
+		s := newScheduledSpace()
+		nsTmplSet := nstemplatetsettest.NewNSTemplateSet("oddity",
+			nstemplatetsettest.WithReferencesFor(basicTier),
+			nstemplatetsettest.WithReadyCondition())
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+		member1 := NewMemberClusterWithClient(test.NewFakeClient(t, nsTmplSet), "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(toolchainv1alpha1.Condition{
+				Type:    toolchainv1alpha1.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  space.SpaceTerminatingScheduledReason,
+				Message: "the Space has no SpaceBinding and will be deleted after " + deadline,
+			})
+		nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			HasClusterResourcesTemplateRef("basic-clusterresources-123456new")
+	})
+
+	t.Run("Space scheduled for deletion is scaled down", func(t *testing.T) {
+		// given
+		s := newScheduledSpace()
+		nsTmplSet := nstemplatetsettest.NewNSTemplateSet("oddity",
+			nstemplatetsettest.WithReferencesFor(basicTier),
+			nstemplatetsettest.WithReadyCondition())
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.SpaceSoftDeletionScaleDownAnnotationKey, "true"))
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier, config)...)
+		member1 := NewMemberClusterWithClient(test.NewFakeClient(t, nsTmplSet), "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Updating())
+		nsTmplSet = nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			HasNamespaceTemplateRefs("basic-code-123456new", "basic-dev-123456new", "basic-stage-123456new").
+			Get()
+		// the idler timeout of the cluster resources is overridden
+		assert.Regexp(t, `^basic-clusterresources-123456new-[0-9a-f]{8}$`, nsTmplSet.Spec.ClusterResources.TemplateRef)
+		tierTemplate := &toolchainv1alpha1.TierTemplate{}
+		err = hostClient.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, nsTmplSet.Spec.ClusterResources.TemplateRef), tierTemplate)
+		require.NoError(t, err)
+		assert.Equal(t, "1", tierTemplate.Spec.Template.Parameters[0].Value)
+	})
+}
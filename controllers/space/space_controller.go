@@ -10,9 +10,13 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	tierutil "github.com/codeready-toolchain/host-operator/controllers/nstemplatetier/util"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/cluster"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/mapper"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 	commoncontrollers "github.com/codeready-toolchain/toolchain-common/controllers"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
 
@@ -40,6 +44,22 @@ type Reconciler struct {
 	MemberClusters      map[string]cluster.Cluster
 	NextScheduledUpdate time.Time
 	LastExecutedUpdate  time.Time
+	// EventRecorder records the events of the state transitions of the Spaces
+	EventRecorder *events.Recorder
+}
+
+// spaceEvents the type of the events recorded when the `Ready` condition of a Space is set with the following reasons
+var spaceEvents = events.ReasonTypes{
+	toolchainv1alpha1.SpaceProvisionedReason:                 corev1.EventTypeNormal,
+	toolchainv1alpha1.SpaceUpdatingReason:                    corev1.EventTypeNormal,
+	toolchainv1alpha1.SpaceRetargetingReason:                 corev1.EventTypeNormal,
+	toolchainv1alpha1.SpaceTerminatingReason:                 corev1.EventTypeNormal,
+	SpaceTerminatingScheduledReason:                          corev1.EventTypeNormal,
+	toolchainv1alpha1.SpaceProvisioningFailedReason:          corev1.EventTypeWarning,
+	toolchainv1alpha1.SpaceRetargetingFailedReason:           corev1.EventTypeWarning,
+	toolchainv1alpha1.SpaceTerminatingFailedReason:           corev1.EventTypeWarning,
+	toolchainv1alpha1.SpaceUnableToCreateNSTemplateSetReason: corev1.EventTypeWarning,
+	toolchainv1alpha1.SpaceUnableToUpdateNSTemplateSetReason: corev1.EventTypeWarning,
 }
 
 // SetupWithManager sets up the controller reconciler with the Manager and the given member clusters.
@@ -48,7 +68,8 @@ type Reconciler struct {
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, memberClusters map[string]cluster.Cluster) error {
 	b := ctrl.NewControllerManagedBy(mgr).
 		// watch Spaces in the host cluster
-		For(&toolchainv1alpha1.Space{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		// also watch changes in the annotations, for the template overrides
+		For(&toolchainv1alpha1.Space{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Watches(&source.Kind{Type: &toolchainv1alpha1.NSTemplateTier{}},
 			handler.EnqueueRequestsFromMapFunc(MapNSTemplateTierToSpaces(r.Namespace, r.Client)),
 			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
@@ -66,6 +87,7 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, memberClusters map[strin
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces/finalizers,verbs=update
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=tiertemplates,verbs=get;list;watch;create
 
 // Reconcile ensures that there is an NSTemplateSet resource defined in the target member cluster
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
@@ -89,6 +111,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, errs.Wrap(err, "unable to get the current Space")
 	}
+	_, span := tracing.StartReconcileSpan(ctx, "space", space)
+	defer span.End()
 	if !util.IsBeingDeleted(space) {
 		// Add the finalizer if it is not present
 		if err := r.addFinalizer(logger, space); err != nil {
@@ -98,6 +122,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return reconcile.Result{}, r.ensureSpaceDeletion(logger, space)
 	}
 
+	// transfer the ownership of the Space if requested, before the NSTemplateSet is updated with the new SpaceBindings
+	if err := r.ensureOwnershipTransfer(logger, space); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// if the NSTemplateSet was created or updated, we want to make sure that the NSTemplateSet Controller was kicked before
 	// reconciling the Space again. In particular, when the NSTemplateSet.Spec is updated, if the Space Controller is triggered
 	// *before* the NSTemplateSet Controller and the NSTemplateSet's status is still `Provisioned` (as it was with the previous templates)
@@ -161,6 +190,9 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 			if err := r.Client.Status().Update(context.TODO(), space); err != nil {
 				return norequeue, err
 			}
+			if readyCond, ok := condition.FindConditionByType(space.Status.Conditions, toolchainv1alpha1.ConditionReady); ok && readyCond.Reason == toolchainv1alpha1.SpaceRetargetingReason {
+				metrics.SpaceRetargetDurationHistogram.Observe(time.Since(readyCond.LastTransitionTime.Time).Seconds())
+			}
 			// and continue with the provisioning on the new target member cluster (if specified)
 		}
 	}
@@ -207,6 +239,29 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 	); err != nil {
 		logger.Error(err, "failed to list space bindings")
 	}
+	// look-up the template overrides of the Space
+	overrides, err := getTemplateOverrides(space, tmplTier)
+	if err != nil {
+		return norequeue, r.setStatusProvisioningFailed(logger, space, errs.Wrap(err, "invalid template overrides"))
+	}
+	_, softDeleted, _ := GetSoftDeletionDeadline(space)
+	specOverrides := overrides
+	if softDeleted {
+		config, err := toolchainconfig.GetToolchainConfig(r.Client)
+		if err != nil {
+			return norequeue, errs.Wrap(err, "unable to get ToolchainConfig")
+		}
+		if config.Spaces().ScaleDownOnSoftDeletion() {
+			specOverrides = overrides.withParameters(scaleDownParameters)
+		}
+	}
+	nsTmplSetSpec, err := r.newNSTemplateSetSpec(space, spaceBindings.Items, tmplTier, specOverrides)
+	if err != nil {
+		return norequeue, r.setStatusProvisioningFailed(logger, space, err)
+	}
+	if err := r.setStatusTemplateOverrides(space, overrides); err != nil {
+		return norequeue, err
+	}
 	// create if not found on the expected target cluster
 	nsTmplSet := &toolchainv1alpha1.NSTemplateSet{}
 	if err := memberCluster.Client.Get(context.TODO(), types.NamespacedName{
@@ -218,7 +273,14 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 			if err := r.setStatusProvisioning(space); err != nil {
 				return norequeue, r.setStatusProvisioningFailed(logger, space, err)
 			}
-			nsTmplSet = NewNSTemplateSet(memberCluster.OperatorNamespace, space, spaceBindings.Items, tmplTier)
+			nsTmplSet = &toolchainv1alpha1.NSTemplateSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: memberCluster.OperatorNamespace,
+					Name:      space.Name,
+				},
+				Spec: nsTmplSetSpec,
+			}
+			tracing.PropagateTraceContext(space, nsTmplSet)
 			if err := memberCluster.Client.Create(context.TODO(), nsTmplSet); err != nil {
 				logger.Error(err, "failed to create NSTemplateSet on target member cluster")
 				return norequeue, r.setStatusNSTemplateSetCreationFailed(logger, space, err)
@@ -239,8 +301,7 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 		return requeueDelay, nil
 	}
 
-	// update the NSTemplateSet if needed (including in case of missing space roles)
-	nsTmplSetSpec := NewNSTemplateSetSpec(space, spaceBindings.Items, tmplTier)
+	// update the NSTemplateSet if needed (including in case of missing space roles or changes in the template overrides)
 	if !reflect.DeepEqual(nsTmplSet.Spec, nsTmplSetSpec) {
 		logger.Info("NSTemplateSet is not up-to-date")
 		// postpone NSTemplateSet updates if needed (but only for NSTemplateTier updates, not tier promotions or changes in spacebindings)
@@ -290,9 +351,12 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 		}
 
 		// remove any outdated tier hash labels
+		// (the Space is provisioned for the first time if it has no such label yet)
+		firstProvisioning := true
 		for key := range space.GetLabels() {
 			if strings.HasPrefix(key, "toolchain.dev.openshift.com/") && strings.HasSuffix(key, "-tier-hash") {
 				delete(space.Labels, key)
+				firstProvisioning = false
 			}
 		}
 
@@ -306,24 +370,24 @@ func (r *Reconciler) ensureNSTemplateSet(logger logr.Logger, space *toolchainv1a
 		if err := r.Client.Update(context.TODO(), space); err != nil {
 			return norequeue, r.setStatusProvisioningFailed(logger, space, err)
 		}
-		return norequeue, r.setStatusProvisioned(space)
+		if softDeleted {
+			return norequeue, r.setStatusTerminatingScheduled(space)
+		}
+		if err := r.setStatusProvisioned(space); err != nil {
+			return norequeue, err
+		}
+		switch {
+		case ok && readyCond.Reason == toolchainv1alpha1.SpaceUpdatingReason:
+			metrics.NSTemplateSetUpdateDurationHistogram.Observe(time.Since(readyCond.LastTransitionTime.Time).Seconds())
+		case firstProvisioning:
+			metrics.SpaceProvisioningDurationHistogram.Observe(time.Since(space.CreationTimestamp.Time).Seconds())
+		}
+		return norequeue, nil
 	default:
 		return norequeue, r.setStatusProvisioningFailed(logger, space, fmt.Errorf(nsTmplSetReady.Message))
 	}
 }
 
-func NewNSTemplateSet(namespace string, space *toolchainv1alpha1.Space, bindings []toolchainv1alpha1.SpaceBinding, tmplTier *toolchainv1alpha1.NSTemplateTier) *toolchainv1alpha1.NSTemplateSet {
-	// create the NSTemplateSet from the NSTemplateTier
-	nsTmplSet := &toolchainv1alpha1.NSTemplateSet{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      space.Name,
-		},
-	}
-	nsTmplSet.Spec = NewNSTemplateSetSpec(space, bindings, tmplTier)
-	return nsTmplSet
-}
-
 func NewNSTemplateSetSpec(space *toolchainv1alpha1.Space, bindings []toolchainv1alpha1.SpaceBinding, tmplTier *toolchainv1alpha1.NSTemplateTier) toolchainv1alpha1.NSTemplateSetSpec {
 	s := toolchainv1alpha1.NSTemplateSetSpec{
 		TierName: space.Spec.TierName,
@@ -526,6 +590,7 @@ func (r *Reconciler) setStatusProvisioningPending(space *toolchainv1alpha1.Space
 }
 
 func (r *Reconciler) setStatusProvisioningFailed(logger logr.Logger, space *toolchainv1alpha1.Space, cause error) error {
+	metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceProvisioningFailedReason).Inc()
 	if err := r.updateStatus(
 		space,
 		toolchainv1alpha1.Condition{
@@ -561,6 +626,7 @@ func (r *Reconciler) setStatusRetargeting(space *toolchainv1alpha1.Space) error
 }
 
 func (r *Reconciler) setStatusRetargetFailed(logger logr.Logger, space *toolchainv1alpha1.Space, cause error) error {
+	metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceRetargetingFailedReason).Inc()
 	if err := r.updateStatus(
 		space,
 		toolchainv1alpha1.Condition{
@@ -586,6 +652,7 @@ func (r *Reconciler) setStatusTerminating(space *toolchainv1alpha1.Space) error
 }
 
 func (r *Reconciler) setStatusTerminatingFailed(logger logr.Logger, space *toolchainv1alpha1.Space, cause error) error {
+	metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceTerminatingFailedReason).Inc()
 	if err := r.updateStatus(
 		space,
 		toolchainv1alpha1.Condition{
@@ -601,6 +668,7 @@ func (r *Reconciler) setStatusTerminatingFailed(logger logr.Logger, space *toolc
 }
 
 func (r *Reconciler) setStatusNSTemplateSetCreationFailed(logger logr.Logger, space *toolchainv1alpha1.Space, cause error) error {
+	metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceUnableToCreateNSTemplateSetReason).Inc()
 	if err := r.updateStatus(
 		space,
 		toolchainv1alpha1.Condition{
@@ -616,6 +684,7 @@ func (r *Reconciler) setStatusNSTemplateSetCreationFailed(logger logr.Logger, sp
 }
 
 func (r *Reconciler) setStatusNSTemplateSetUpdateFailed(logger logr.Logger, space *toolchainv1alpha1.Space, cause error) error {
+	metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceUnableToUpdateNSTemplateSetReason).Inc()
 	if err := r.updateStatus(
 		space,
 		toolchainv1alpha1.Condition{
@@ -633,10 +702,15 @@ func (r *Reconciler) setStatusNSTemplateSetUpdateFailed(logger logr.Logger, spac
 // updateStatus updates space status conditions with the new conditions
 func (r *Reconciler) updateStatus(space *toolchainv1alpha1.Space, conditions ...toolchainv1alpha1.Condition) error {
 	var updated bool
+	previous := append([]toolchainv1alpha1.Condition{}, space.Status.Conditions...)
 	space.Status.Conditions, updated = condition.AddOrUpdateStatusConditions(space.Status.Conditions, conditions...)
 	if !updated {
 		// Nothing changed
 		return nil
 	}
-	return r.Client.Status().Update(context.TODO(), space)
+	if err := r.Client.Status().Update(context.TODO(), space); err != nil {
+		return err
+	}
+	r.EventRecorder.Transitions(space, previous, spaceEvents, conditions...)
+	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
 	"github.com/codeready-toolchain/host-operator/pkg/cluster"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 	. "github.com/codeready-toolchain/host-operator/test"
 	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
 	spacetest "github.com/codeready-toolchain/host-operator/test/space"
@@ -21,6 +22,7 @@ import (
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
 	nstemplatetsettest "github.com/codeready-toolchain/toolchain-common/pkg/test/nstemplateset"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -111,6 +113,8 @@ func TestCreateSpace(t *testing.T) {
 				err := member1.Client.Update(context.TODO(), nsTmplSet)
 				require.NoError(t, err)
 				ctrl := newReconciler(hostClient, member1, member2)
+				provisioned := MetricsHistogramSampleCount(t, metrics.SpaceProvisioningDurationHistogram)
+				updated := MetricsHistogramSampleCount(t, metrics.NSTemplateSetUpdateDurationHistogram)
 
 				// when
 				res, err := ctrl.Reconcile(context.TODO(), requestFor(s))
@@ -127,6 +131,17 @@ func TestCreateSpace(t *testing.T) {
 				AssertThatCountersAndMetrics(t).
 					HaveSpacesForCluster("member-1", 1).
 					HaveSpacesForCluster("member-2", 0) // space counter unchanged
+				AssertMetricsHistogramSampleCount(t, provisioned+1, metrics.SpaceProvisioningDurationHistogram)
+				AssertMetricsHistogramSampleCount(t, updated, metrics.NSTemplateSetUpdateDurationHistogram)
+
+				t.Run("provisioning duration observed only once", func(t *testing.T) {
+					// when
+					_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+					// then
+					require.NoError(t, err)
+					AssertMetricsHistogramSampleCount(t, provisioned+1, metrics.SpaceProvisioningDurationHistogram)
+				})
 			})
 		})
 
@@ -181,6 +196,33 @@ func TestCreateSpace(t *testing.T) {
 		})
 	})
 
+	t.Run("with trace context", func(t *testing.T) {
+		// given
+		recorder := SetupSpanRecorder(t)
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithAnnotation(tracing.TraceContextAnnotationKey, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"))
+		hostClient := test.NewFakeClient(t, s, basicTier)
+		member1 := NewMemberCluster(t, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus())
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then the trace context is carried onto the NSTemplateSet
+		require.NoError(t, err)
+		nsTmplSet := nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			Get()
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", nsTmplSet.Annotations[tracing.TraceContextAnnotationKey])
+		// and the span of the reconcile is linked to the trace
+		require.Len(t, recorder.Ended(), 1)
+		assert.Equal(t, "reconcile space", recorder.Ended()[0].Name())
+		require.Len(t, recorder.Ended()[0].Links(), 1)
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", recorder.Ended()[0].Links()[0].SpanContext.TraceID().String())
+	})
+
 	t.Run("failure", func(t *testing.T) {
 
 		t.Run("space not found", func(t *testing.T) {
@@ -366,6 +408,8 @@ func TestCreateSpace(t *testing.T) {
 			ctrl := newReconciler(hostClient, member1, member2)
 			InitializeCounters(t,
 				NewToolchainStatus())
+			failures := metrics.ControllerFailuresCounterVec.WithLabelValues("space", toolchainv1alpha1.SpaceUnableToCreateNSTemplateSetReason)
+			before := promtestutil.ToFloat64(failures)
 
 			// when
 			res, err := ctrl.Reconcile(context.TODO(), requestFor(s))
@@ -376,6 +420,7 @@ func TestCreateSpace(t *testing.T) {
 			spacetest.AssertThatSpace(t, test.HostOperatorNs, s.Name, hostClient).
 				HasStatusTargetCluster("member-1").
 				HasConditions(spacetest.UnableToCreateNSTemplateSet("mock error"))
+			assert.Equal(t, before+1, promtestutil.ToFloat64(failures))
 			AssertThatCountersAndMetrics(t).
 				HaveSpacesForCluster("member-1", 0).
 				HaveSpacesForCluster("member-2", 0) // no counters increment when there is an error on NSTemplateSet
@@ -876,6 +921,8 @@ func TestUpdateSpaceTier(t *testing.T) {
 					s.Status.Conditions[0].LastTransitionTime = metav1.NewTime(s.Status.Conditions[0].LastTransitionTime.Time.Add(-1 * time.Second))
 					err := hostClient.Status().Update(context.TODO(), s)
 					require.NoError(t, err)
+					provisioned := MetricsHistogramSampleCount(t, metrics.SpaceProvisioningDurationHistogram)
+					updated := MetricsHistogramSampleCount(t, metrics.NSTemplateSetUpdateDurationHistogram)
 
 					// when
 					res, err := ctrl.Reconcile(context.TODO(), requestFor(s))
@@ -893,6 +940,8 @@ func TestUpdateSpaceTier(t *testing.T) {
 					AssertThatCountersAndMetrics(t).
 						HaveSpacesForCluster("member-1", 1).
 						HaveSpacesForCluster("member-2", 0) // space counter is unchanged
+					AssertMetricsHistogramSampleCount(t, updated+1, metrics.NSTemplateSetUpdateDurationHistogram)
+					AssertMetricsHistogramSampleCount(t, provisioned, metrics.SpaceProvisioningDurationHistogram)
 
 				})
 			})
@@ -1108,6 +1157,51 @@ func TestUpdateSpaceTier(t *testing.T) {
 			HaveSpacesForCluster("member-1", 1) // space counter is unchanged
 	})
 
+	t.Run("legacy tier hash label migrated when already up-to-date", func(t *testing.T) {
+		// given a Space with a tier hash label computed before the space roles were part of the tier hash
+		legacyHash, err := tierutil.ComputeLegacyHashForNSTemplateTier(basicTier)
+		require.NoError(t, err)
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithTierName(basicTier.Name),
+			spacetest.WithLabel(tierutil.TemplateTierHashLabelKey(basicTier.Name), legacyHash),
+			spacetest.WithCondition(spacetest.Ready()),
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithStatusTargetCluster("member-1"),
+			spacetest.WithFinalizer())
+		hostClient := test.NewFakeClient(t, s, basicTier)
+		nstmplSet := nstemplatetsettest.NewNSTemplateSet("oddity", nstemplatetsettest.WithReferencesFor(basicTier), nstemplatetsettest.WithReadyCondition())
+		member1Client := test.NewFakeClient(t, nstmplSet)
+		member1Client.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*toolchainv1alpha1.NSTemplateSet); ok {
+				return fmt.Errorf("NSTemplateSet should not be updated")
+			}
+			return member1Client.Client.Update(ctx, obj, opts...)
+		}
+		member1 := NewMemberClusterWithClient(member1Client, "member-1", corev1.ConditionTrue)
+		ctrl := newReconciler(hostClient, member1)
+		InitializeCounters(t,
+			NewToolchainStatus(
+				WithMetric(toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey, toolchainv1alpha1.Metric{
+					"1,internal": 1,
+				}),
+				WithMetric(toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey, toolchainv1alpha1.Metric{
+					string(metrics.Internal): 1,
+				}),
+				WithMember("member-1", WithSpaceCount(1)),
+			))
+
+		// when
+		res, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, res.Requeue)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Ready()).
+			HasMatchingTierLabelForTier(basicTier) // label migrated without any change in the NSTemplateSet
+	})
+
 	t.Run("update needed even when Space not ready", func(t *testing.T) {
 		notReadySpace := spacetest.NewSpace("oddity1",
 			spacetest.WithTierNameAndHashLabelFor(basicTier),
@@ -1632,10 +1726,14 @@ func TestRetargetSpace(t *testing.T) {
 			HasStatusTargetCluster("member-1") // not reset yet
 
 		t.Run("status target cluster is reset when NSTemplateSet is deleted on member-1", func(t *testing.T) {
+			// given
+			retargeted := MetricsHistogramSampleCount(t, metrics.SpaceRetargetDurationHistogram)
+
 			// when
 			res, err := ctrl.Reconcile(context.TODO(), requestFor(s))
 			// then
 			require.NoError(t, err)
+			AssertMetricsHistogramSampleCount(t, retargeted+1, metrics.SpaceRetargetDurationHistogram)
 			assert.True(t, res.Requeue) // requeue requested explicitly when NSTemplateSet is created, even though watching the resource is enough to trigger a new reconcile loop
 			spacetest.AssertThatSpace(t, s.Namespace, s.Name, hostClient).
 				HasFinalizer().
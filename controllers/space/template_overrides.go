@@ -0,0 +1,281 @@
+package space
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ExtraNamespacesAnnotationKey the annotation on a Space to request extra namespaces, as a comma-separated list of
+	// namespace types (eg: `ci,perf`). The namespace types must be allowed in the `AllowedExtraNamespacesAnnotationKey`
+	// annotation of the Space's NSTemplateTier.
+	ExtraNamespacesAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "extra-namespaces"
+
+	// TemplateParametersAnnotationKey the annotation on a Space to override template parameters, as a JSON object
+	// (eg: `{"IDLER_TIMEOUT_SECONDS":"86400"}`). The parameters must be allowed in the `ParameterBoundsAnnotationKey`
+	// annotation of the Space's NSTemplateTier.
+	TemplateParametersAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "template-parameters"
+
+	// AllowedExtraNamespacesAnnotationKey the annotation on an NSTemplateTier to list the namespace types that can be
+	// requested by its Spaces, as a JSON object of namespace types and template refs (eg: `{"ci":"${CI_TEMPL_REF}"}` in the tier.yaml)
+	AllowedExtraNamespacesAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "allowed-extra-namespaces"
+
+	// ParameterBoundsAnnotationKey the annotation on an NSTemplateTier to list the template parameters that can be
+	// overridden by its Spaces, along with their optional bounds, as a JSON object
+	// (eg: `{"IDLER_TIMEOUT_SECONDS":{"min":"3600","max":"86400"},"MEMORY_LIMIT":{"max":"4Gi"}}`).
+	// Bounds are compared as quantities, so they apply to integers as well as to values such as `4Gi` or `500m`.
+	ParameterBoundsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "parameter-bounds"
+)
+
+// TemplateOverridesCondition the type of the Space condition which reports the template overrides in use
+const TemplateOverridesCondition toolchainv1alpha1.ConditionType = "TemplateOverrides"
+
+// TemplateOverridesAppliedReason the reason of the `TemplateOverrides` condition when the overrides are applied
+const TemplateOverridesAppliedReason = "Applied"
+
+// parameterBounds the optional bounds of a template parameter which can be overridden
+type parameterBounds struct {
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+}
+
+// templateOverrides the validated overrides of a Space
+type templateOverrides struct {
+	extraNamespaceTypes []string
+	extraNamespaceRefs  []string
+	parameters          map[string]string
+}
+
+func (o templateOverrides) isEmpty() bool {
+	return len(o.extraNamespaceRefs) == 0 && len(o.parameters) == 0
+}
+
+// String returns a human-readable summary of the overrides, used in the Space status
+func (o templateOverrides) String() string {
+	msg := []string{}
+	if len(o.extraNamespaceTypes) > 0 {
+		msg = append(msg, "extra namespaces: "+strings.Join(o.extraNamespaceTypes, ","))
+	}
+	if len(o.parameters) > 0 {
+		params := make([]string, 0, len(o.parameters))
+		for _, name := range sortedKeys(o.parameters) {
+			params = append(params, name+"="+o.parameters[name])
+		}
+		msg = append(msg, "parameters: "+strings.Join(params, ","))
+	}
+	return strings.Join(msg, "; ")
+}
+
+// getTemplateOverrides returns the overrides set in the annotations of the given Space, after verifying that
+// they are allowed by the given NSTemplateTier
+func getTemplateOverrides(space *toolchainv1alpha1.Space, tmplTier *toolchainv1alpha1.NSTemplateTier) (templateOverrides, error) {
+	overrides := templateOverrides{}
+	if extraNamespaces := space.Annotations[ExtraNamespacesAnnotationKey]; extraNamespaces != "" {
+		allowed := map[string]string{}
+		if a, found := tmplTier.Annotations[AllowedExtraNamespacesAnnotationKey]; found {
+			if err := json.Unmarshal([]byte(a), &allowed); err != nil {
+				return overrides, errs.Wrapf(err, "invalid value for annotation '%s' in NSTemplateTier '%s'", AllowedExtraNamespacesAnnotationKey, tmplTier.Name)
+			}
+		}
+		for _, nsType := range strings.Split(extraNamespaces, ",") {
+			nsType = strings.TrimSpace(nsType)
+			if nsType == "" {
+				continue
+			}
+			ref, found := allowed[nsType]
+			if !found {
+				return overrides, fmt.Errorf("namespace type '%s' is not allowed as an extra namespace in the '%s' tier", nsType, tmplTier.Name)
+			}
+			overrides.extraNamespaceTypes = append(overrides.extraNamespaceTypes, nsType)
+			overrides.extraNamespaceRefs = append(overrides.extraNamespaceRefs, ref)
+		}
+	}
+	if params := space.Annotations[TemplateParametersAnnotationKey]; params != "" {
+		if err := json.Unmarshal([]byte(params), &overrides.parameters); err != nil {
+			return overrides, errs.Wrapf(err, "invalid value for annotation '%s'", TemplateParametersAnnotationKey)
+		}
+		bounds := map[string]parameterBounds{}
+		if b, found := tmplTier.Annotations[ParameterBoundsAnnotationKey]; found {
+			if err := json.Unmarshal([]byte(b), &bounds); err != nil {
+				return overrides, errs.Wrapf(err, "invalid value for annotation '%s' in NSTemplateTier '%s'", ParameterBoundsAnnotationKey, tmplTier.Name)
+			}
+		}
+		for _, name := range sortedKeys(overrides.parameters) {
+			b, found := bounds[name]
+			if !found {
+				return overrides, fmt.Errorf("parameter '%s' cannot be overridden in the '%s' tier", name, tmplTier.Name)
+			}
+			if err := b.check(name, overrides.parameters[name]); err != nil {
+				return overrides, errs.Wrapf(err, "invalid value for parameter '%s' in the '%s' tier", name, tmplTier.Name)
+			}
+		}
+	}
+	return overrides, nil
+}
+
+// check verifies that the given value is within the bounds
+func (b parameterBounds) check(name, value string) error {
+	if b.Min == "" && b.Max == "" {
+		return nil
+	}
+	v, err := resource.ParseQuantity(value)
+	if err != nil {
+		return err
+	}
+	if b.Min != "" {
+		minValue, err := resource.ParseQuantity(b.Min)
+		if err != nil {
+			return errs.Wrapf(err, "invalid min bound for parameter '%s'", name)
+		}
+		if v.Cmp(minValue) < 0 {
+			return fmt.Errorf("'%s' is lower than the min bound '%s'", value, b.Min)
+		}
+	}
+	if b.Max != "" {
+		maxValue, err := resource.ParseQuantity(b.Max)
+		if err != nil {
+			return errs.Wrapf(err, "invalid max bound for parameter '%s'", name)
+		}
+		if v.Cmp(maxValue) > 0 {
+			return fmt.Errorf("'%s' is greater than the max bound '%s'", value, b.Max)
+		}
+	}
+	return nil
+}
+
+// newNSTemplateSetSpec returns the spec of the NSTemplateSet for the given Space, including the template overrides of the Space
+func (r *Reconciler) newNSTemplateSetSpec(space *toolchainv1alpha1.Space, bindings []toolchainv1alpha1.SpaceBinding, tmplTier *toolchainv1alpha1.NSTemplateTier, overrides templateOverrides) (toolchainv1alpha1.NSTemplateSetSpec, error) {
+	s := NewNSTemplateSetSpec(space, bindings, tmplTier)
+	if overrides.isEmpty() {
+		return s, nil
+	}
+	for _, ref := range overrides.extraNamespaceRefs {
+		found := false
+		for _, ns := range s.Namespaces {
+			if ns.TemplateRef == ref {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.Namespaces = append(s.Namespaces, toolchainv1alpha1.NSTemplateSetNamespace{TemplateRef: ref})
+		}
+	}
+	if len(overrides.parameters) == 0 {
+		return s, nil
+	}
+	// replace the template refs with the refs of the templates with the overridden parameters
+	var err error
+	for i := range s.Namespaces {
+		if s.Namespaces[i].TemplateRef, err = r.ensureTierTemplateWithParameters(space.Namespace, s.Namespaces[i].TemplateRef, overrides.parameters); err != nil {
+			return s, err
+		}
+	}
+	if s.ClusterResources != nil {
+		if s.ClusterResources.TemplateRef, err = r.ensureTierTemplateWithParameters(space.Namespace, s.ClusterResources.TemplateRef, overrides.parameters); err != nil {
+			return s, err
+		}
+	}
+	for i := range s.SpaceRoles {
+		if s.SpaceRoles[i].TemplateRef, err = r.ensureTierTemplateWithParameters(space.Namespace, s.SpaceRoles[i].TemplateRef, overrides.parameters); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// ensureTierTemplateWithParameters returns the name of a copy of the given TierTemplate in which the given parameters
+// are overridden, creating this copy if needed.
+// The name of the copy is derived from the name of the source TierTemplate and from the overridden parameters, so the copy
+// can be shared among all the Spaces with the same overrides, and it is unchanged as long as the source and the parameters are unchanged.
+// Returns the name of the source TierTemplate if it has none of the given parameters.
+func (r *Reconciler) ensureTierTemplateWithParameters(namespace, templateRef string, parameters map[string]string) (string, error) {
+	source := &toolchainv1alpha1.TierTemplate{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: templateRef}, source); err != nil {
+		return "", errs.Wrapf(err, "unable to get the TierTemplate '%s'", templateRef)
+	}
+	// only keep the parameters which are declared in the template
+	params := map[string]string{}
+	for _, p := range source.Spec.Template.Parameters {
+		if v, found := parameters[p.Name]; found {
+			params[p.Name] = v
+		}
+	}
+	if len(params) == 0 {
+		return templateRef, nil
+	}
+	content := make([]string, 0, len(params))
+	for _, name := range sortedKeys(params) {
+		content = append(content, name+"="+params[name])
+	}
+	suffix := hash.EncodeString(strings.Join(content, ","))[:8]
+	name := templateRef + "-" + suffix
+
+	existing := &toolchainv1alpha1.TierTemplate{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, existing); err == nil {
+		return name, nil
+	} else if !errors.IsNotFound(err) {
+		return "", errs.Wrapf(err, "unable to get the TierTemplate '%s'", name)
+	}
+	tierTemplate := &toolchainv1alpha1.TierTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	tierTemplate.Spec.Revision = source.Spec.Revision + "-" + suffix
+	for i, p := range tierTemplate.Spec.Template.Parameters {
+		if v, found := params[p.Name]; found {
+			tierTemplate.Spec.Template.Parameters[i].Value = v
+		}
+	}
+	if err := r.Client.Create(context.TODO(), tierTemplate); err != nil && !errors.IsAlreadyExists(err) {
+		return "", errs.Wrapf(err, "unable to create the TierTemplate '%s'", name)
+	}
+	return name, nil
+}
+
+// setStatusTemplateOverrides sets the `TemplateOverrides` condition of the given Space if it has overrides,
+// or removes this condition otherwise
+func (r *Reconciler) setStatusTemplateOverrides(space *toolchainv1alpha1.Space, overrides templateOverrides) error {
+	if overrides.isEmpty() {
+		for i, c := range space.Status.Conditions {
+			if c.Type == TemplateOverridesCondition {
+				space.Status.Conditions = append(space.Status.Conditions[:i], space.Status.Conditions[i+1:]...)
+				return r.Client.Status().Update(context.TODO(), space)
+			}
+		}
+		return nil
+	}
+	return r.updateStatus(
+		space,
+		toolchainv1alpha1.Condition{
+			Type:    TemplateOverridesCondition,
+			Status:  corev1.ConditionTrue,
+			Reason:  TemplateOverridesAppliedReason,
+			Message: overrides.String(),
+		})
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,281 @@
+package space_test
+
+import (
+	"context"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	nstemplatetsettest "github.com/codeready-toolchain/toolchain-common/pkg/test/nstemplateset"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestTemplateOverrides(t *testing.T) {
+
+	// given
+	err := apis.AddToScheme(scheme.Scheme)
+	require.NoError(t, err)
+	basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	basicTier.Annotations = map[string]string{
+		space.AllowedExtraNamespacesAnnotationKey: `{"ci":"basic-ci-123456new"}`,
+		space.ParameterBoundsAnnotationKey:        `{"IDLER_TIMEOUT_SECONDS":{"min":"3600","max":"86400"},"MEMORY_LIMIT":{"max":"4Gi"}}`,
+	}
+	tierTemplates := []runtime.Object{
+		newTierTemplate("basic-clusterresources-123456new", "clusterresources", "MEMORY_LIMIT"),
+		newTierTemplate("basic-code-123456new", "code", "IDLER_TIMEOUT_SECONDS"),
+		newTierTemplate("basic-dev-123456new", "dev", "IDLER_TIMEOUT_SECONDS"),
+		newTierTemplate("basic-stage-123456new", "stage", "IDLER_TIMEOUT_SECONDS"),
+		newTierTemplate("basic-ci-123456new", "ci"),
+	}
+
+	t.Run("create NSTemplateSet with extra namespace", func(t *testing.T) {
+		// given
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithAnnotation(space.ExtraNamespacesAnnotationKey, "ci"))
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+		member1 := NewMemberCluster(t, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus())
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Provisioning(), templateOverridesApplied("extra namespaces: ci"))
+		nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			HasClusterResourcesTemplateRef("basic-clusterresources-123456new").
+			HasNamespaceTemplateRefs("basic-code-123456new", "basic-dev-123456new", "basic-stage-123456new", "basic-ci-123456new")
+	})
+
+	t.Run("create NSTemplateSet with parameter overrides", func(t *testing.T) {
+		// given
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithAnnotation(space.TemplateParametersAnnotationKey, `{"IDLER_TIMEOUT_SECONDS":"7200"}`))
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+		member1 := NewMemberCluster(t, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus())
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Provisioning(), templateOverridesApplied("parameters: IDLER_TIMEOUT_SECONDS=7200"))
+		nsTmplSet := nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			HasClusterResourcesTemplateRef("basic-clusterresources-123456new"). // no IDLER_TIMEOUT_SECONDS param in this template
+			Get()
+		require.Len(t, nsTmplSet.Spec.Namespaces, 3)
+		for _, ns := range nsTmplSet.Spec.Namespaces {
+			assert.Regexp(t, `^basic-(code|dev|stage)-123456new-[0-9a-f]{8}$`, ns.TemplateRef)
+			// the TierTemplate with the overridden parameter was created
+			tierTemplate := &toolchainv1alpha1.TierTemplate{}
+			err = hostClient.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, ns.TemplateRef), tierTemplate)
+			require.NoError(t, err)
+			assert.Equal(t, "7200", tierTemplate.Spec.Template.Parameters[0].Value)
+			assert.Equal(t, "123456new-"+ns.TemplateRef[len(ns.TemplateRef)-8:], tierTemplate.Spec.Revision)
+		}
+
+		t.Run("same TierTemplates reused by another Space with the same overrides", func(t *testing.T) {
+			// given
+			s2 := spacetest.NewSpace("oddity2",
+				spacetest.WithSpecTargetCluster("member-1"),
+				spacetest.WithAnnotation(space.TemplateParametersAnnotationKey, `{"IDLER_TIMEOUT_SECONDS":"7200"}`))
+			require.NoError(t, hostClient.Create(context.TODO(), s2))
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s2))
+
+			// then
+			require.NoError(t, err)
+			nsTmplSet2 := nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity2", member1.Client).
+				Exists().
+				Get()
+			assert.Equal(t, nsTmplSet.Spec.Namespaces, nsTmplSet2.Spec.Namespaces)
+		})
+	})
+
+	t.Run("remove condition when overrides are removed", func(t *testing.T) {
+		// given
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithTierNameAndHashLabelFor(basicTier),
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithStatusTargetCluster("member-1"),
+			spacetest.WithFinalizer(),
+			spacetest.WithCondition(spacetest.Ready()),
+			spacetest.WithCondition(templateOverridesApplied("extra namespaces: ci")))
+		nsTmplSet := nstemplatetsettest.NewNSTemplateSet("oddity",
+			nstemplatetsettest.WithReferencesFor(basicTier),
+			nstemplatetsettest.WithReadyCondition())
+		nsTmplSet.Spec.Namespaces = append(nsTmplSet.Spec.Namespaces, toolchainv1alpha1.NSTemplateSetNamespace{TemplateRef: "basic-ci-123456new"})
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+		member1Client := test.NewFakeClient(t, nsTmplSet)
+		member1 := NewMemberClusterWithClient(member1Client, "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Updating())
+		nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+			Exists().
+			HasNamespaceTemplateRefs("basic-code-123456new", "basic-dev-123456new", "basic-stage-123456new")
+	})
+
+	t.Run("tier hash label does not include the overrides", func(t *testing.T) {
+		// given
+		s := spacetest.NewSpace("oddity",
+			spacetest.WithTierNameAndHashLabelFor(basicTier), // provisioned before the extra namespace was requested
+			spacetest.WithSpecTargetCluster("member-1"),
+			spacetest.WithStatusTargetCluster("member-1"),
+			spacetest.WithFinalizer(),
+			spacetest.WithAnnotation(space.ExtraNamespacesAnnotationKey, "ci"),
+			spacetest.WithCondition(spacetest.Updating()),
+			spacetest.WithCondition(templateOverridesApplied("extra namespaces: ci")))
+		nsTmplSet := nstemplatetsettest.NewNSTemplateSet("oddity",
+			nstemplatetsettest.WithReferencesFor(basicTier),
+			nstemplatetsettest.WithReadyCondition())
+		nsTmplSet.Spec.Namespaces = append(nsTmplSet.Spec.Namespaces, toolchainv1alpha1.NSTemplateSetNamespace{TemplateRef: "basic-ci-123456new"})
+		hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+		member1 := NewMemberClusterWithClient(test.NewFakeClient(t, nsTmplSet), "member-1", corev1.ConditionTrue)
+		InitializeCounters(t, NewToolchainStatus(WithMember("member-1", WithSpaceCount(1))))
+		ctrl := newReconciler(hostClient, member1)
+
+		// when
+		_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+		// then
+		require.NoError(t, err)
+		s = spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+			Exists().
+			HasConditions(spacetest.Ready(), templateOverridesApplied("extra namespaces: ci")).
+			HasMatchingTierLabelForTier(basicTier). // not outdated, so not requeued on every NSTemplateTier event
+			Get()
+
+		t.Run("override change updates the NSTemplateSet", func(t *testing.T) {
+			// given
+			s.Annotations[space.TemplateParametersAnnotationKey] = `{"IDLER_TIMEOUT_SECONDS":"7200"}`
+			require.NoError(t, hostClient.Update(context.TODO(), s))
+
+			// when
+			_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+			// then
+			require.NoError(t, err)
+			spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+				Exists().
+				HasConditions(spacetest.Updating(), templateOverridesApplied("extra namespaces: ci; parameters: IDLER_TIMEOUT_SECONDS=7200"))
+			updated := nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+				Exists().
+				Get()
+			for _, ns := range updated.Spec.Namespaces {
+				assert.Regexp(t, `^basic-(code|dev|stage|ci)-123456new(-[0-9a-f]{8})?$`, ns.TemplateRef)
+			}
+			assert.NotEqual(t, nsTmplSet.Spec.Namespaces, updated.Spec.Namespaces)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		for name, tc := range map[string]struct {
+			annotations map[string]string
+			message     string
+		}{
+			"extra namespace not allowed": {
+				annotations: map[string]string{space.ExtraNamespacesAnnotationKey: "ci,perf"},
+				message:     "invalid template overrides: namespace type 'perf' is not allowed as an extra namespace in the 'basic' tier",
+			},
+			"parameter not allowed": {
+				annotations: map[string]string{space.TemplateParametersAnnotationKey: `{"CPU_LIMIT":"2"}`},
+				message:     "invalid template overrides: parameter 'CPU_LIMIT' cannot be overridden in the 'basic' tier",
+			},
+			"parameter below min bound": {
+				annotations: map[string]string{space.TemplateParametersAnnotationKey: `{"IDLER_TIMEOUT_SECONDS":"60"}`},
+				message:     "invalid template overrides: invalid value for parameter 'IDLER_TIMEOUT_SECONDS' in the 'basic' tier: '60' is lower than the min bound '3600'",
+			},
+			"parameter above max bound": {
+				annotations: map[string]string{space.TemplateParametersAnnotationKey: `{"MEMORY_LIMIT":"8Gi"}`},
+				message:     "invalid template overrides: invalid value for parameter 'MEMORY_LIMIT' in the 'basic' tier: '8Gi' is greater than the max bound '4Gi'",
+			},
+			"invalid parameters": {
+				annotations: map[string]string{space.TemplateParametersAnnotationKey: `IDLER_TIMEOUT_SECONDS=60`},
+				message:     "invalid template overrides: invalid value for annotation 'toolchain.dev.openshift.com/template-parameters': invalid character 'I' looking for beginning of value",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				s := spacetest.NewSpace("oddity", spacetest.WithSpecTargetCluster("member-1"))
+				s.Annotations = tc.annotations
+				hostClient := test.NewFakeClient(t, append(tierTemplates, s, basicTier)...)
+				member1 := NewMemberCluster(t, "member-1", corev1.ConditionTrue)
+				InitializeCounters(t, NewToolchainStatus())
+				ctrl := newReconciler(hostClient, member1)
+
+				// when
+				_, err := ctrl.Reconcile(context.TODO(), requestFor(s))
+
+				// then
+				require.EqualError(t, err, tc.message)
+				spacetest.AssertThatSpace(t, test.HostOperatorNs, "oddity", hostClient).
+					Exists().
+					HasConditions(spacetest.ProvisioningFailed(tc.message))
+				nstemplatetsettest.AssertThatNSTemplateSet(t, test.MemberOperatorNs, "oddity", member1.Client).
+					DoesNotExist()
+			})
+		}
+	})
+}
+
+func templateOverridesApplied(msg string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    space.TemplateOverridesCondition,
+		Status:  corev1.ConditionTrue,
+		Reason:  space.TemplateOverridesAppliedReason,
+		Message: msg,
+	}
+}
+
+func newTierTemplate(name, typeName string, params ...string) *toolchainv1alpha1.TierTemplate {
+	tmpl := templatev1.Template{}
+	for _, p := range params {
+		tmpl.Parameters = append(tmpl.Parameters, templatev1.Parameter{Name: p, Value: "default"})
+	}
+	return &toolchainv1alpha1.TierTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: test.HostOperatorNs,
+			Name:      name,
+		},
+		Spec: toolchainv1alpha1.TierTemplateSpec{
+			TierName: "basic",
+			Type:     typeName,
+			Revision: "123456new",
+			Template: tmpl,
+		},
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 	"github.com/go-logr/logr"
 	errs "github.com/pkg/errors"
 	"github.com/redhat-cop/operator-utils/pkg/util"
@@ -33,11 +35,16 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
+// DeletedEventReason the reason of the event recorded when a SpaceBinding is deleted because its Space or MasterUserRecord is gone
+const DeletedEventReason = "Deleted"
+
 // Reconciler reconciles a SpaceBinding object
 type Reconciler struct {
 	client.Client
 	Scheme    *runtime.Scheme
 	Namespace string
+	// EventRecorder records the events of the deletion of the SpaceBindings
+	EventRecorder *events.Recorder
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=get;list;watch;create;update;patch;delete
@@ -62,6 +69,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
+	_, span := tracing.StartReconcileSpan(ctx, "spacebinding", spaceBinding)
+	defer span.End()
 	if util.IsBeingDeleted(spaceBinding) {
 		logger.Info("the SpaceBinding is already being deleted")
 		return reconcile.Result{}, nil
@@ -72,7 +81,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	if err := r.Client.Get(context.TODO(), spaceName, space); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("the Space was not found", "Space", spaceBinding.Spec.Space)
-			return ctrl.Result{}, r.deleteSpaceBinding(logger, spaceBinding)
+			return ctrl.Result{}, r.deleteSpaceBinding(logger, spaceBinding, "the Space '%s' was deleted", spaceBinding.Spec.Space)
 
 		}
 		return ctrl.Result{}, errs.Wrapf(err, "unable to get the bound Space")
@@ -83,7 +92,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	if err := r.Client.Get(context.TODO(), murName, mur); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("the MUR was not found", "MasterUserRecord", spaceBinding.Spec.MasterUserRecord)
-			return ctrl.Result{}, r.deleteSpaceBinding(logger, spaceBinding)
+			return ctrl.Result{}, r.deleteSpaceBinding(logger, spaceBinding, "the MasterUserRecord '%s' was deleted", spaceBinding.Spec.MasterUserRecord)
 		}
 		return ctrl.Result{}, errs.Wrapf(err, "unable to get the bound MUR")
 	}
@@ -91,10 +100,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	return ctrl.Result{}, nil
 }
 
-func (r *Reconciler) deleteSpaceBinding(logger logr.Logger, spaceBinding *toolchainv1alpha1.SpaceBinding) error {
+func (r *Reconciler) deleteSpaceBinding(logger logr.Logger, spaceBinding *toolchainv1alpha1.SpaceBinding, reasonFmt string, args ...interface{}) error {
 	logger.Info("deleting the SpaceBinding")
 	if err := r.Delete(context.TODO(), spaceBinding); err != nil {
 		return errs.Wrapf(err, "unable to delete the SpaceBinding")
 	}
+	r.EventRecorder.Normal(spaceBinding, DeletedEventReason, "the SpaceBinding was deleted because "+reasonFmt, args...)
 	return nil
 }
@@ -7,14 +7,17 @@ import (
 
 	"github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/test/space"
 	sb "github.com/codeready-toolchain/host-operator/test/spacebinding"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -34,6 +37,8 @@ func TestDeleteSpaceBinding(t *testing.T) {
 	t.Run("lara-redhat SpaceBinding removed when redhat space is missing", func(t *testing.T) {
 
 		reconciler, request, fakeClient := prepareReconciler(t, sbLaraRedhatAdmin, sbJoeRedhatView, sbLaraIbmEdit, laraMur, joeMur, ibmSpace)
+		fakeRecorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		// when
 		_, err := reconciler.Reconcile(context.TODO(), request)
@@ -43,11 +48,15 @@ func TestDeleteSpaceBinding(t *testing.T) {
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "lara", "redhat", fakeClient).DoesNotExist()
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "joe", "redhat", fakeClient).Exists()
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "lara", "ibm", fakeClient).Exists()
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal Deleted the SpaceBinding was deleted because the Space 'redhat' was deleted", <-fakeRecorder.Events)
 	})
 
 	t.Run("joe-redhat SpaceBinding removed when joe MUR is missing", func(t *testing.T) {
 
 		reconciler, request, fakeClient := prepareReconciler(t, sbJoeRedhatView, sbLaraRedhatAdmin, sbLaraIbmEdit, laraMur, ibmSpace, redhatSpace)
+		fakeRecorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		// when
 		_, err := reconciler.Reconcile(context.TODO(), request)
@@ -57,6 +66,8 @@ func TestDeleteSpaceBinding(t *testing.T) {
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "lara", "redhat", fakeClient).Exists()
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "joe", "redhat", fakeClient).DoesNotExist()
 		sb.AssertThatSpaceBinding(t, test.HostOperatorNs, "lara", "ibm", fakeClient).Exists()
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal Deleted the SpaceBinding was deleted because the MasterUserRecord 'joe' was deleted", <-fakeRecorder.Events)
 	})
 
 	t.Run("lara-redhat SpaceBinding is being deleted, so no action needed", func(t *testing.T) {
@@ -5,6 +5,9 @@ import (
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	spacectrl "github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	commoncontrollers "github.com/codeready-toolchain/toolchain-common/controllers"
 	"github.com/go-logr/logr"
 	"github.com/redhat-cop/operator-utils/pkg/util"
@@ -20,10 +23,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	// RestoredEventReason the reason of the event recorded when a Space scheduled for deletion is restored
+	RestoredEventReason = "Restored"
+	// DeletionScheduledEventReason the reason of the event recorded when a Space without SpaceBindings is scheduled for deletion
+	DeletionScheduledEventReason = "DeletionScheduled"
+	// DeletedEventReason the reason of the event recorded when a Space without SpaceBindings is deleted
+	DeletedEventReason = "Deleted"
+)
+
 // Reconciler reconciles a Space object
 type Reconciler struct {
 	Client    client.Client
 	Namespace string
+	// EventRecorder records the events of the deletion and restoration of the Spaces
+	EventRecorder *events.Recorder
 }
 
 // SetupWithManager sets up the controller reconciler with the Manager
@@ -40,10 +54,10 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 const deletionTimeThreshold = 30 * time.Second
 
-//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch;update;delete
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=get;list;watch
 
-// Reconcile ensures that Space which doesn't have any SpaceBinding is deleted
+// Reconcile ensures that Space which doesn't have any SpaceBinding is deleted, after the soft deletion period if configured
 func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Info("reconciling Space")
@@ -87,18 +101,49 @@ func (r *Reconciler) ensureDeletionIfNeeded(logger logr.Logger, space *toolchain
 		return false, 0, errs.Wrap(err, "unable to list SpaceBindings")
 	}
 
+	deadline, softDeleted, err := spacectrl.GetSoftDeletionDeadline(space)
+	if err != nil {
+		logger.Error(err, "rescheduling the deletion of the Space")
+	}
+
 	if len(bindings.Items) > 0 {
+		if softDeleted {
+			// a SpaceBinding was added before the deadline: restore the Space
+			delete(space.Annotations, spacectrl.SoftDeletionDeadlineAnnotationKey)
+			if err := r.Client.Update(context.TODO(), space); err != nil {
+				return false, 0, errs.Wrap(err, "unable to restore Space")
+			}
+			logger.Info("Space has been restored")
+			r.EventRecorder.Normal(space, RestoredEventReason, "the Space was restored by the addition of a SpaceBinding")
+			return false, 0, nil
+		}
 		logger.Info("Space has SpaceBindings - skipping...", "number-of-spacebindings", len(bindings.Items))
 		return false, 0, nil
 	}
 
 	timeSinceCreation := time.Since(space.GetCreationTimestamp().Time)
 	if timeSinceCreation > deletionTimeThreshold {
+		config, err := toolchainconfig.GetToolchainConfig(r.Client)
+		if err != nil {
+			return false, 0, errs.Wrap(err, "unable to get ToolchainConfig")
+		}
+		// a Space without SpaceBindings is only scheduled for deletion during the soft deletion period, if configured,
+		// so that it can be restored by adding a SpaceBinding
+		if softDeletionPeriod := config.Spaces().SoftDeletionPeriod(); softDeletionPeriod > 0 {
+			if !softDeleted || deadline.IsZero() {
+				return r.scheduleDeletion(logger, space, softDeletionPeriod)
+			}
+			if remaining := time.Until(deadline); remaining > 0 {
+				logger.Info("Space is scheduled for deletion", "deadline", deadline)
+				return true, remaining, nil
+			}
+		}
 		if err := r.Client.Delete(context.TODO(), space); err != nil {
 			return false, 0, errs.Wrap(err, "unable to delete Space")
 		}
 
 		logger.Info("Space has been deleted")
+		r.EventRecorder.Normal(space, DeletedEventReason, "the Space was deleted because it has no SpaceBinding")
 		return false, 0, nil
 	}
 
@@ -107,3 +152,19 @@ func (r *Reconciler) ensureDeletionIfNeeded(logger logr.Logger, space *toolchain
 
 	return true, requeueAfter, nil
 }
+
+// scheduleDeletion sets the deadline until which the Space can be restored, after which it will be deleted
+func (r *Reconciler) scheduleDeletion(logger logr.Logger, space *toolchainv1alpha1.Space, softDeletionPeriod time.Duration) (bool, time.Duration, error) {
+	deadline := time.Now().Add(softDeletionPeriod)
+	if space.Annotations == nil {
+		space.Annotations = map[string]string{}
+	}
+	space.Annotations[spacectrl.SoftDeletionDeadlineAnnotationKey] = deadline.Format(time.RFC3339)
+	if err := r.Client.Update(context.TODO(), space); err != nil {
+		return false, 0, errs.Wrap(err, "unable to schedule the deletion of the Space")
+	}
+	logger.Info("Space has been scheduled for deletion", "deadline", deadline)
+	r.EventRecorder.Normal(space, DeletionScheduledEventReason, "the Space has no SpaceBinding and will be deleted after %s unless a SpaceBinding is added",
+		deadline.Format(time.RFC3339))
+	return true, softDeletionPeriod, nil
+}
@@ -8,16 +8,22 @@ import (
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	spacectrl "github.com/codeready-toolchain/host-operator/controllers/space"
 	"github.com/codeready-toolchain/host-operator/controllers/spacecleanup"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	. "github.com/codeready-toolchain/host-operator/test"
 	spacetest "github.com/codeready-toolchain/host-operator/test/space"
 	"github.com/codeready-toolchain/host-operator/test/spacebinding"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -28,6 +34,8 @@ func TestCleanupSpace(t *testing.T) {
 		// given
 		space := spacetest.NewSpace("without-spacebinding", spacetest.WithCreationTimestamp(time.Now().Add(-31*time.Second)))
 		r, req, cl := prepareReconcile(t, space)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		// when
 		res, err := r.Reconcile(context.TODO(), req)
@@ -37,6 +45,8 @@ func TestCleanupSpace(t *testing.T) {
 		assert.False(t, res.Requeue)
 		spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
 			DoesNotExist()
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal Deleted the Space was deleted because it has no SpaceBinding", <-fakeRecorder.Events)
 	})
 
 	t.Run("without any SpaceBinding and created less than 30 seconds ago- Space shouldn't be deleted, just requeued", func(t *testing.T) {
@@ -163,6 +173,123 @@ func TestCleanupSpace(t *testing.T) {
 	})
 }
 
+func TestSoftDeleteSpace(t *testing.T) {
+
+	t.Run("without any SpaceBinding - Space should be scheduled for deletion", func(t *testing.T) {
+		// given
+		space := spacetest.NewSpace("without-spacebinding", spacetest.WithCreationTimestamp(time.Now().Add(-time.Minute)))
+		r, req, cl := prepareReconcile(t, space, softDeletionConfig(t))
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, res.Requeue)
+		assert.Equal(t, 72*time.Hour, res.RequeueAfter)
+		space = spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
+			Exists().
+			Get()
+		deadline, scheduled, err := spacectrl.GetSoftDeletionDeadline(space)
+		require.NoError(t, err)
+		assert.True(t, scheduled)
+		assert.WithinDuration(t, time.Now().Add(72*time.Hour), deadline, time.Minute)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, fmt.Sprintf("Normal DeletionScheduled the Space has no SpaceBinding and will be deleted after %s unless a SpaceBinding is added",
+			space.Annotations[spacectrl.SoftDeletionDeadlineAnnotationKey]), <-fakeRecorder.Events)
+
+		t.Run("Space is not deleted before the deadline", func(t *testing.T) {
+			// when
+			res, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			assert.True(t, res.Requeue)
+			assert.Greater(t, res.RequeueAfter, 71*time.Hour)
+			actual := spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
+				Exists().
+				Get()
+			assert.Equal(t, space.Annotations[spacectrl.SoftDeletionDeadlineAnnotationKey], actual.Annotations[spacectrl.SoftDeletionDeadlineAnnotationKey])
+		})
+
+		t.Run("Space is restored when a SpaceBinding is added", func(t *testing.T) {
+			// given
+			require.NoError(t, cl.Create(context.TODO(), spacebinding.NewSpaceBinding("johny", space.Name, "admin", "a-creator")))
+
+			// when
+			res, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			assert.False(t, res.Requeue)
+			space := spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
+				Exists().
+				Get()
+			assert.NotContains(t, space.Annotations, spacectrl.SoftDeletionDeadlineAnnotationKey)
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, "Normal Restored the Space was restored by the addition of a SpaceBinding", <-fakeRecorder.Events)
+		})
+	})
+
+	t.Run("without any SpaceBinding and after the deadline - Space should be deleted", func(t *testing.T) {
+		// given
+		space := spacetest.NewSpace("without-spacebinding",
+			spacetest.WithCreationTimestamp(time.Now().Add(-100*time.Hour)),
+			spacetest.WithAnnotation(spacectrl.SoftDeletionDeadlineAnnotationKey, time.Now().Add(-time.Minute).Format(time.RFC3339)))
+		r, req, cl := prepareReconcile(t, space, softDeletionConfig(t))
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, res.Requeue)
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
+			DoesNotExist()
+	})
+
+	t.Run("with invalid deadline - Space should be rescheduled for deletion", func(t *testing.T) {
+		// given
+		space := spacetest.NewSpace("without-spacebinding",
+			spacetest.WithCreationTimestamp(time.Now().Add(-100*time.Hour)),
+			spacetest.WithAnnotation(spacectrl.SoftDeletionDeadlineAnnotationKey, "tomorrow"))
+		r, req, cl := prepareReconcile(t, space, softDeletionConfig(t))
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, res.Requeue)
+		space = spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
+			Exists().
+			Get()
+		_, _, err = spacectrl.GetSoftDeletionDeadline(space)
+		require.NoError(t, err)
+	})
+
+	t.Run("when scheduling the deletion fails", func(t *testing.T) {
+		// given
+		space := spacetest.NewSpace("update-fails", spacetest.WithCreationTimestamp(time.Now().Add(-time.Minute)))
+		r, req, cl := prepareReconcile(t, space, softDeletionConfig(t))
+		cl.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+			return fmt.Errorf("some error")
+		}
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, "unable to schedule the deletion of the Space: some error")
+	})
+}
+
+func softDeletionConfig(t *testing.T) *toolchainv1alpha1.ToolchainConfig {
+	return commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.SpaceSoftDeletionPeriodAnnotationKey, "72h"))
+}
+
 func prepareReconcile(t *testing.T, space *toolchainv1alpha1.Space, initObjs ...runtime.Object) (*spacecleanup.Reconciler, reconcile.Request, *test.FakeClient) {
 	require.NoError(t, os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs))
 	s := scheme.Scheme
@@ -6,6 +6,7 @@ import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/capacity"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/pending"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/go-logr/logr"
@@ -24,11 +25,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+const (
+	// TierNameSetEventReason the reason of the event recorded when the default tier is set on a Space
+	TierNameSetEventReason = "TierNameSet"
+	// TargetClusterSetEventReason the reason of the event recorded when a target cluster is set on a Space
+	TargetClusterSetEventReason = "TargetClusterSet"
+	// NoClusterAvailableEventReason the reason of the event recorded when no member cluster can host a Space
+	NoClusterAvailableEventReason = "NoClusterAvailable"
+)
+
 // Reconciler reconciles a Space object
 type Reconciler struct {
 	Client            client.Client
 	Namespace         string
 	GetMemberClusters cluster.GetMemberClustersFunc
+	// EventRecorder records the events of the completion of the Spaces
+	EventRecorder *events.Recorder
 }
 
 // SetupWithManager sets up the controller reconciler with the Manager
@@ -75,11 +87,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return reconcile.Result{}, nil
 	}
 
+	tierName := space.Spec.TierName
 	if changed, err := r.ensureFields(logger, space); err != nil || !changed {
 		return reconcile.Result{}, err
 	}
 
-	return ctrl.Result{}, r.Client.Update(context.TODO(), space)
+	if err := r.Client.Update(context.TODO(), space); err != nil {
+		return reconcile.Result{}, err
+	}
+	if space.Spec.TierName != tierName {
+		r.EventRecorder.Normal(space, TierNameSetEventReason, "the tier '%s' was set", space.Spec.TierName)
+	} else {
+		r.EventRecorder.Normal(space, TargetClusterSetEventReason, "the target cluster '%s' was set", space.Spec.TargetCluster)
+	}
+	return ctrl.Result{}, nil
 }
 
 func (r *Reconciler) ensureFields(logger logr.Logger, space *toolchainv1alpha1.Space) (bool, error) {
@@ -100,6 +121,7 @@ func (r *Reconciler) ensureFields(logger logr.Logger, space *toolchainv1alpha1.S
 		}
 		if targetCluster == "" {
 			logger.Info("no cluster available")
+			r.EventRecorder.Warning(space, NoClusterAvailableEventReason, "no member cluster is available to host the Space")
 			return false, nil
 		}
 		space.Spec.TargetCluster = targetCluster
@@ -10,15 +10,18 @@ import (
 	"github.com/codeready-toolchain/host-operator/controllers/spacecompletion"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	. "github.com/codeready-toolchain/host-operator/test"
 	spacetest "github.com/codeready-toolchain/host-operator/test/space"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -32,6 +35,8 @@ func TestCreateSpace(t *testing.T) {
 		space := spacetest.NewSpace("without-fields",
 			spacetest.WithTierName(""))
 		r, req, cl := prepareReconcile(t, space, getMemberClusters)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		// when
 		_, err := r.Reconcile(context.TODO(), req)
@@ -41,6 +46,8 @@ func TestCreateSpace(t *testing.T) {
 		spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
 			HasTier("base").
 			HasSpecTargetCluster("")
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal TierNameSet the tier 'base' was set", <-fakeRecorder.Events)
 	})
 
 	t.Run("with tierName but without targetCluster - only targetCluster should be set", func(t *testing.T) {
@@ -48,6 +55,8 @@ func TestCreateSpace(t *testing.T) {
 		space := spacetest.NewSpace("without-targetCluster",
 			spacetest.WithTierName("advanced"))
 		r, req, cl := prepareReconcile(t, space, getMemberClusters)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		// when
 		_, err := r.Reconcile(context.TODO(), req)
@@ -57,6 +66,8 @@ func TestCreateSpace(t *testing.T) {
 		spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
 			HasTier("advanced").
 			HasSpecTargetCluster("member1")
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal TargetClusterSet the target cluster 'member1' was set", <-fakeRecorder.Events)
 	})
 
 	t.Run("with targetCluster but without tierName - only tierName should be set", func(t *testing.T) {
@@ -116,6 +127,8 @@ func TestCreateSpace(t *testing.T) {
 			space := spacetest.NewSpace("without-members",
 				spacetest.WithTierName("advanced"))
 			r, req, cl := prepareReconcile(t, space, NewGetMemberClusters())
+			fakeRecorder := record.NewFakeRecorder(10)
+			r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 			// when
 			_, err := r.Reconcile(context.TODO(), req)
@@ -125,6 +138,8 @@ func TestCreateSpace(t *testing.T) {
 			spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, cl).
 				HasTier("advanced").
 				HasSpecTargetCluster("")
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, "Warning NoClusterAvailable no member cluster is available to host the Space", <-fakeRecorder.Events)
 		})
 
 		t.Run("when the space is not there, then just skip it", func(t *testing.T) {
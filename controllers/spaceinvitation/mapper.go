@@ -0,0 +1,40 @@
+package spaceinvitation
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// MapMasterUserRecordToPendingInvitations maps a MasterUserRecord to all the pending invitations, since the invitee
+// of an invitation can be specified by email address
+func MapMasterUserRecordToPendingInvitations(cl client.Client, namespace string) func(object client.Object) []reconcile.Request {
+	var logger = ctrl.Log.WithName("MasterUserRecordToInvitationsMapper")
+	return func(obj client.Object) []reconcile.Request {
+		if _, ok := obj.(*toolchainv1alpha1.MasterUserRecord); !ok {
+			return []reconcile.Request{}
+		}
+		invitations := &corev1.ConfigMapList{}
+		if err := cl.List(context.TODO(), invitations, client.InNamespace(namespace), client.HasLabels{InvitationLabelKey}); err != nil {
+			logger.Error(err, "Could not list the invitations")
+			return nil
+		}
+		req := []reconcile.Request{}
+		for i := range invitations.Items {
+			status, err := GetStatus(&invitations.Items[i])
+			if err != nil || (status.Phase != "" && status.Phase != PhasePending) {
+				continue
+			}
+			req = append(req, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: namespace, Name: invitations.Items[i].Name},
+			})
+		}
+		return req
+	}
+}
@@ -0,0 +1,373 @@
+package spaceinvitation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	"github.com/codeready-toolchain/toolchain-common/pkg/spacebinding"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// InvitationLabelKey the label of the ConfigMaps which are invitations to join a Space. The value of the label is ignored.
+	InvitationLabelKey = toolchainv1alpha1.LabelKeyPrefix + "space-invitation"
+
+	// InvitationStatusAnnotationKey the annotation on the invitation which contains the status of the invitation, as JSON
+	InvitationStatusAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "space-invitation-status"
+)
+
+// The keys of the data of the invitation
+const (
+	// SpaceKey the name of the Space to join
+	SpaceKey = "space"
+	// InviteeKey the username (ie, the name of the MasterUserRecord) or the email address of the invited user
+	InviteeKey = "invitee"
+	// RoleKey the role of the invited user in the Space, which must be one of the `spaceRoles` of the Space's NSTemplateTier
+	RoleKey = "role"
+	// ExpirationTimeKey the time (RFC3339) after which the invitation expires if it was not accepted yet.
+	// Defaults to the creation time of the invitation plus the default expiration of the controller.
+	ExpirationTimeKey = "expirationTime"
+)
+
+const (
+	// MaxMembersPerSpaceAnnotationKey the annotation on an NSTemplateTier which specifies the maximum number of members
+	// (ie, SpaceBindings) of each of its Spaces, including the owner. No limit when not set.
+	MaxMembersPerSpaceAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "max-members-per-space"
+
+	// MaxSpacesPerUserAnnotationKey the annotation on an NSTemplateTier which specifies the maximum number of Spaces
+	// (including their own) a user can be a member of to be invited in a Space of this tier. No limit when not set.
+	MaxSpacesPerUserAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "max-spaces-per-user"
+)
+
+// The phases of the invitation
+const (
+	// PhasePending the invitee has not been provisioned yet
+	PhasePending = "Pending"
+	// PhaseAccepted the SpaceBinding of the invitee was created (or its role was changed)
+	PhaseAccepted = "Accepted"
+	// PhaseExpired the invitee was not provisioned before the expiration of the invitation
+	PhaseExpired = "Expired"
+	// PhaseRejected the invitation is invalid or exceeds the limits of the Space's NSTemplateTier
+	PhaseRejected = "Rejected"
+)
+
+// Status the status of an invitation
+type Status struct {
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+	// SpaceBinding the name of the SpaceBinding of the invitee, once the invitation was accepted
+	SpaceBinding   string       `json:"spaceBinding,omitempty"`
+	ExpirationTime *metav1.Time `json:"expirationTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// Reconciler turns the invitations to join a Space into SpaceBindings once the invitees have a MasterUserRecord
+type Reconciler struct {
+	Client    client.Client
+	Namespace string
+	// EventRecorder records the events of the completion of the invitations
+	EventRecorder *events.Recorder
+}
+
+// SetupWithManager sets up the controller reconciler with the Manager
+// Watches the ConfigMaps with the `toolchain.dev.openshift.com/space-invitation` label, and the MasterUserRecords
+// to accept the pending invitations as soon as the invitees are provisioned
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("spaceinvitation").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(isInvitation))).
+		Watches(&source.Kind{Type: &toolchainv1alpha1.MasterUserRecord{}},
+			handler.EnqueueRequestsFromMapFunc(MapMasterUserRecordToPendingInvitations(mgr.GetClient(), r.Namespace))).
+		Complete(r)
+}
+
+func isInvitation(obj client.Object) bool {
+	_, found := obj.GetLabels()[InvitationLabelKey]
+	return found
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords,verbs=get;list;watch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=usersignups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=nstemplatetiers,verbs=get;list;watch
+
+// Reconcile validates the invitation against the Space's NSTemplateTier and creates the SpaceBinding of the invitee
+// once the invitee has a MasterUserRecord, unless the invitation expired.
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	invitation := &corev1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: request.Name}, invitation); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("invitation not found")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errs.Wrap(err, "unable to get the invitation")
+	}
+	if !isInvitation(invitation) || invitation.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	status, err := GetStatus(invitation)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if status.Phase != "" && status.Phase != PhasePending {
+		return reconcile.Result{}, nil
+	}
+
+	config, err := toolchainconfig.GetToolchainConfig(r.Client)
+	if err != nil {
+		return reconcile.Result{}, errs.Wrap(err, "unable to get ToolchainConfig")
+	}
+	expirationTime, err := expirationTime(config, invitation)
+	if err != nil {
+		return reconcile.Result{}, r.complete(logger, invitation, status, PhaseRejected, err.Error())
+	}
+	status.ExpirationTime = &metav1.Time{Time: expirationTime}
+	if !time.Now().Before(expirationTime) {
+		return reconcile.Result{}, r.complete(logger, invitation, status, PhaseExpired, "the invitee was not provisioned before the expiration of the invitation")
+	}
+
+	space, tier, msg, err := r.validate(invitation)
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if msg != "" {
+		return reconcile.Result{}, r.complete(logger, invitation, status, PhaseRejected, msg)
+	}
+
+	mur, err := r.lookupInvitee(invitation.Data[InviteeKey])
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if mur == nil {
+		// another reconcile will occur when the MasterUserRecord is created, or when the invitation expires
+		logger.Info("invitee not provisioned yet", "invitee", invitation.Data[InviteeKey])
+		status.Phase = PhasePending
+		status.Message = "waiting for the invitee to be provisioned"
+		return reconcile.Result{RequeueAfter: time.Until(expirationTime)}, r.updateStatus(invitation, status)
+	}
+
+	binding, msg, err := r.ensureSpaceBinding(logger, space, tier, mur, invitation.Data[RoleKey])
+	if err != nil {
+		return reconcile.Result{}, err
+	} else if msg != "" {
+		return reconcile.Result{}, r.complete(logger, invitation, status, PhaseRejected, msg)
+	}
+	status.SpaceBinding = binding.Name
+	return reconcile.Result{}, r.complete(logger, invitation, status, PhaseAccepted,
+		fmt.Sprintf("'%s' joined the Space '%s' with the role '%s'", mur.Name, space.Name, binding.Spec.SpaceRole))
+}
+
+// expirationTime returns the time after which the given invitation expires (by default, after the expiration configured in the ToolchainConfig)
+func expirationTime(config toolchainconfig.ToolchainConfig, invitation *corev1.ConfigMap) (time.Time, error) {
+	value, found := invitation.Data[ExpirationTimeKey]
+	if !found || value == "" {
+		return invitation.CreationTimestamp.Add(config.Spaces().InvitationExpiration()), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid value for '%s': %s", ExpirationTimeKey, value)
+	}
+	return t, nil
+}
+
+// validate verifies that the Space exists and that the role is one of the space roles of its NSTemplateTier.
+// Returns a non-empty message if the invitation is invalid.
+func (r *Reconciler) validate(invitation *corev1.ConfigMap) (*toolchainv1alpha1.Space, *toolchainv1alpha1.NSTemplateTier, string, error) {
+	for _, key := range []string{SpaceKey, InviteeKey, RoleKey} {
+		if invitation.Data[key] == "" {
+			return nil, nil, fmt.Sprintf("'%s' must be specified", key), nil
+		}
+	}
+	space := &toolchainv1alpha1.Space{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: invitation.Data[SpaceKey]}, space); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil, fmt.Sprintf("the Space '%s' does not exist", invitation.Data[SpaceKey]), nil
+		}
+		return nil, nil, "", errs.Wrapf(err, "unable to get the Space '%s'", invitation.Data[SpaceKey])
+	}
+	if space.DeletionTimestamp != nil {
+		return nil, nil, fmt.Sprintf("the Space '%s' is being deleted", space.Name), nil
+	}
+	tier := &toolchainv1alpha1.NSTemplateTier{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: space.Spec.TierName}, tier); err != nil {
+		return nil, nil, "", errs.Wrapf(err, "unable to get the NSTemplateTier '%s' of the Space", space.Spec.TierName)
+	}
+	if _, found := tier.Spec.SpaceRoles[invitation.Data[RoleKey]]; !found {
+		return nil, nil, fmt.Sprintf("the role '%s' is not available in the NSTemplateTier '%s'", invitation.Data[RoleKey], tier.Name), nil
+	}
+	return space, tier, "", nil
+}
+
+// lookupInvitee returns the MasterUserRecord of the invitee, or `nil` if the invitee was not provisioned yet.
+// The invitee is either the name of a MasterUserRecord, or the email address of a UserSignup.
+func (r *Reconciler) lookupInvitee(invitee string) (*toolchainv1alpha1.MasterUserRecord, error) {
+	murName := invitee
+	if strings.Contains(invitee, "@") {
+		userSignups := &toolchainv1alpha1.UserSignupList{}
+		if err := r.Client.List(context.TODO(), userSignups, client.InNamespace(r.Namespace),
+			client.MatchingLabels{toolchainv1alpha1.UserSignupUserEmailHashLabelKey: hash.EncodeString(invitee)}); err != nil {
+			return nil, errs.Wrap(err, "unable to list the UserSignups of the invitee")
+		}
+		murName = ""
+		for _, us := range userSignups.Items {
+			if us.Status.CompliantUsername != "" {
+				murName = us.Status.CompliantUsername
+				break
+			}
+		}
+		if murName == "" {
+			return nil, nil
+		}
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: murName}, mur); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errs.Wrapf(err, "unable to get the MasterUserRecord '%s'", murName)
+	}
+	if mur.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return mur, nil
+}
+
+// ensureSpaceBinding creates the SpaceBinding of the invitee with the given role, or changes the role of its existing SpaceBinding,
+// within the limits of the NSTemplateTier. Returns a non-empty message if the SpaceBinding cannot be created or updated.
+func (r *Reconciler) ensureSpaceBinding(logger logr.Logger, space *toolchainv1alpha1.Space, tier *toolchainv1alpha1.NSTemplateTier,
+	mur *toolchainv1alpha1.MasterUserRecord, role string) (*toolchainv1alpha1.SpaceBinding, string, error) {
+	members := &toolchainv1alpha1.SpaceBindingList{}
+	if err := r.Client.List(context.TODO(), members, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceBindingSpaceLabelKey: space.Name}); err != nil {
+		return nil, "", errs.Wrap(err, "unable to list the SpaceBindings of the Space")
+	}
+	for i := range members.Items {
+		binding := &members.Items[i]
+		if binding.Spec.MasterUserRecord != mur.Name {
+			continue
+		}
+		if binding.Spec.SpaceRole == role {
+			return binding, "", nil
+		}
+		if owner := space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey]; owner != "" && owner == mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] {
+			return nil, fmt.Sprintf("the role of the owner of the Space '%s' cannot be changed", space.Name), nil
+		}
+		binding.Spec.SpaceRole = role
+		if err := r.Client.Update(context.TODO(), binding); err != nil {
+			return nil, "", errs.Wrap(err, "unable to update the role of the SpaceBinding")
+		}
+		logger.Info("updated the role of the SpaceBinding", "spacebinding", binding.Name, "role", role)
+		return binding, "", nil
+	}
+
+	// new member: check the limits of the tier
+	if maxMembers, err := Limit(tier, MaxMembersPerSpaceAnnotationKey); err != nil {
+		return nil, "", err
+	} else if maxMembers >= 0 && len(members.Items) >= maxMembers {
+		return nil, fmt.Sprintf("the Space '%s' has reached the maximum number of members (%d)", space.Name, maxMembers), nil
+	}
+	if maxSpaces, err := Limit(tier, MaxSpacesPerUserAnnotationKey); err != nil {
+		return nil, "", err
+	} else if maxSpaces >= 0 {
+		spaces := &toolchainv1alpha1.SpaceBindingList{}
+		if err := r.Client.List(context.TODO(), spaces, client.InNamespace(r.Namespace),
+			client.MatchingLabels{toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey: mur.Name}); err != nil {
+			return nil, "", errs.Wrap(err, "unable to list the SpaceBindings of the invitee")
+		}
+		if len(spaces.Items) >= maxSpaces {
+			return nil, fmt.Sprintf("'%s' has reached the maximum number of Spaces (%d)", mur.Name, maxSpaces), nil
+		}
+	}
+
+	binding := spacebinding.NewSpaceBinding(mur, space, space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey])
+	binding.Spec.SpaceRole = role
+	if err := r.Client.Create(context.TODO(), binding); err != nil {
+		return nil, "", errs.Wrap(err, "unable to create the SpaceBinding of the invitee")
+	}
+	logger.Info("created the SpaceBinding of the invitee", "spacebinding", binding.Name, "role", role)
+	return binding, "", nil
+}
+
+// Limit returns the value of the given limit annotation of the NSTemplateTier, or -1 if it is not set
+func Limit(tier *toolchainv1alpha1.NSTemplateTier, key string) (int, error) {
+	value, found := tier.Annotations[key]
+	if !found || value == "" {
+		return -1, nil
+	}
+	l, err := strconv.Atoi(value)
+	if err != nil || l < 0 {
+		return -1, fmt.Errorf("invalid value for annotation '%s' in NSTemplateTier '%s': %s", key, tier.Name, value)
+	}
+	return l, nil
+}
+
+// complete sets the final phase of the invitation
+func (r *Reconciler) complete(logger logr.Logger, invitation *corev1.ConfigMap, status *Status, phase, msg string) error {
+	logger.Info("invitation completed", "phase", phase, "message", msg)
+	now := metav1.Now()
+	status.Phase = phase
+	status.Message = msg
+	status.CompletionTime = &now
+	if err := r.updateStatus(invitation, status); err != nil {
+		return err
+	}
+	if phase == PhaseAccepted {
+		r.EventRecorder.Normal(invitation, phase, msg)
+	} else {
+		r.EventRecorder.Warning(invitation, phase, msg)
+	}
+	return nil
+}
+
+// GetStatus returns the status of the given invitation
+func GetStatus(invitation *corev1.ConfigMap) (*Status, error) {
+	status := &Status{}
+	value, found := invitation.Annotations[InvitationStatusAnnotationKey]
+	if !found {
+		return status, nil
+	}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		return nil, errs.Wrapf(err, "invalid status of the invitation '%s'", invitation.Name)
+	}
+	return status, nil
+}
+
+func (r *Reconciler) updateStatus(invitation *corev1.ConfigMap, status *Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if invitation.Annotations == nil {
+		invitation.Annotations = map[string]string{}
+	}
+	invitation.Annotations[InvitationStatusAnnotationKey] = string(value)
+	if err := r.Client.Update(context.TODO(), invitation); err != nil {
+		return errs.Wrap(err, "unable to update the status of the invitation")
+	}
+	return nil
+}
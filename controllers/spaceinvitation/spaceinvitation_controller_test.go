@@ -0,0 +1,380 @@
+package spaceinvitation_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/spaceinvitation"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSpaceInvitation(t *testing.T) {
+	newObjects := func(tierOptions ...tiertest.TierOption) []runtime.Object {
+		return []runtime.Object{
+			tiertest.BasicTier(t, tiertest.CurrentBasicTemplates, tierOptions...),
+			spacetest.NewSpace("jack", spacetest.WithTierName("basic"), spacetest.WithCreatorLabel("signup-jack")),
+			murtest.NewMasterUserRecord(t, "jack", murtest.WithOwnerLabel("signup-jack")),
+			spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "signup-jack"),
+		}
+	}
+
+	t.Run("invitee provisioned", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff",
+			spaceinvitation.RoleKey:    "viewer",
+		})
+		jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+		r, req, cl := prepareReconcile(t, append(newObjects(), invitation, jeff)...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		status := getStatus(t, cl)
+		assert.Equal(t, spaceinvitation.PhaseAccepted, status.Phase)
+		assert.Equal(t, "'jeff' joined the Space 'jack' with the role 'viewer'", status.Message)
+		assert.NotNil(t, status.CompletionTime)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal Accepted 'jeff' joined the Space 'jack' with the role 'viewer'", <-fakeRecorder.Events)
+		binding := &toolchainv1alpha1.SpaceBinding{}
+		require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, status.SpaceBinding), binding))
+		assert.Equal(t, "jeff", binding.Spec.MasterUserRecord)
+		assert.Equal(t, "jack", binding.Spec.Space)
+		assert.Equal(t, "viewer", binding.Spec.SpaceRole)
+		assert.Equal(t, "signup-jack", binding.Labels[toolchainv1alpha1.SpaceCreatorLabelKey])
+
+		t.Run("accepted invitation is not processed again", func(t *testing.T) {
+			// given
+			require.NoError(t, cl.Delete(context.TODO(), binding))
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(1)
+			assert.Empty(t, fakeRecorder.Events)
+		})
+	})
+
+	t.Run("invitee not provisioned yet", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff@redhat.com",
+			spaceinvitation.RoleKey:    "admin",
+		})
+		r, req, cl := prepareReconcile(t, append(newObjects(), invitation)...)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 167*time.Hour)
+		status := getStatus(t, cl)
+		assert.Equal(t, spaceinvitation.PhasePending, status.Phase)
+		require.NotNil(t, status.ExpirationTime)
+		spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(1)
+
+		t.Run("accepted once the invitee is provisioned", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.WithName("signup-jeff"), commonsignup.WithEmail("jeff@redhat.com"))
+			userSignup.Status.CompliantUsername = "jeff"
+			require.NoError(t, cl.Create(context.TODO(), userSignup))
+			require.NoError(t, cl.Create(context.TODO(), murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))))
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			status := getStatus(t, cl)
+			assert.Equal(t, spaceinvitation.PhaseAccepted, status.Phase)
+			spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(2)
+		})
+	})
+
+	t.Run("default expiration configured in the ToolchainConfig", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff@redhat.com",
+			spaceinvitation.RoleKey:    "admin",
+		})
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.SpaceInvitationExpirationAnnotationKey, "48h"))
+		r, req, cl := prepareReconcile(t, append(newObjects(), invitation, config)...)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 47*time.Hour)
+		assert.True(t, res.RequeueAfter <= 48*time.Hour)
+		status := getStatus(t, cl)
+		assert.Equal(t, spaceinvitation.PhasePending, status.Phase)
+		require.NotNil(t, status.ExpirationTime)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), status.ExpirationTime.Time, time.Minute)
+	})
+
+	t.Run("invitation expired", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:          "jack",
+			spaceinvitation.InviteeKey:        "jeff",
+			spaceinvitation.RoleKey:           "admin",
+			spaceinvitation.ExpirationTimeKey: time.Now().Add(-time.Minute).Format(time.RFC3339),
+		})
+		jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+		r, req, cl := prepareReconcile(t, append(newObjects(), invitation, jeff)...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, spaceinvitation.PhaseExpired, getStatus(t, cl).Phase)
+		spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(1)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Warning Expired the invitee was not provisioned before the expiration of the invitation", <-fakeRecorder.Events)
+	})
+
+	t.Run("role of existing member is changed", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff",
+			spaceinvitation.RoleKey:    "admin",
+		})
+		jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+		sb := spacebindingtest.NewSpaceBinding("jeff", "jack", "viewer", "signup-jack")
+		r, req, cl := prepareReconcile(t, append(newObjects(), invitation, jeff, sb)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		status := getStatus(t, cl)
+		assert.Equal(t, spaceinvitation.PhaseAccepted, status.Phase)
+		assert.Equal(t, sb.Name, status.SpaceBinding)
+		spacebindingtest.AssertThatSpaceBinding(t, test.HostOperatorNs, "jeff", "jack", cl).HasSpec("jeff", "jack", "admin")
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+
+		for name, tc := range map[string]struct {
+			data        map[string]string
+			tierOptions []tiertest.TierOption
+			expectedMsg string
+		}{
+			"missing role": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "jack",
+					spaceinvitation.InviteeKey: "jeff",
+				},
+				expectedMsg: "'role' must be specified",
+			},
+			"unknown Space": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "unknown",
+					spaceinvitation.InviteeKey: "jeff",
+					spaceinvitation.RoleKey:    "admin",
+				},
+				expectedMsg: "the Space 'unknown' does not exist",
+			},
+			"unknown role": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "jack",
+					spaceinvitation.InviteeKey: "jeff",
+					spaceinvitation.RoleKey:    "owner",
+				},
+				expectedMsg: "the role 'owner' is not available in the NSTemplateTier 'basic'",
+			},
+			"invalid expiration time": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:          "jack",
+					spaceinvitation.InviteeKey:        "jeff",
+					spaceinvitation.RoleKey:           "admin",
+					spaceinvitation.ExpirationTimeKey: "tomorrow",
+				},
+				expectedMsg: "invalid value for 'expirationTime': tomorrow",
+			},
+			"role of the owner": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "jack",
+					spaceinvitation.InviteeKey: "jack",
+					spaceinvitation.RoleKey:    "viewer",
+				},
+				expectedMsg: "the role of the owner of the Space 'jack' cannot be changed",
+			},
+			"too many members": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "jack",
+					spaceinvitation.InviteeKey: "jeff",
+					spaceinvitation.RoleKey:    "viewer",
+				},
+				tierOptions: []tiertest.TierOption{withAnnotation(spaceinvitation.MaxMembersPerSpaceAnnotationKey, "1")},
+				expectedMsg: "the Space 'jack' has reached the maximum number of members (1)",
+			},
+			"too many spaces": {
+				data: map[string]string{
+					spaceinvitation.SpaceKey:   "jack",
+					spaceinvitation.InviteeKey: "jeff",
+					spaceinvitation.RoleKey:    "viewer",
+				},
+				tierOptions: []tiertest.TierOption{withAnnotation(spaceinvitation.MaxSpacesPerUserAnnotationKey, "1")},
+				expectedMsg: "'jeff' has reached the maximum number of Spaces (1)",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				invitation := newInvitation(tc.data)
+				jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+				jeffSpace := spacebindingtest.NewSpaceBinding("jeff", "jeff", "admin", "signup-jeff")
+				r, req, cl := prepareReconcile(t, append(newObjects(tc.tierOptions...), invitation, jeff, jeffSpace)...)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				status := getStatus(t, cl)
+				assert.Equal(t, spaceinvitation.PhaseRejected, status.Phase)
+				assert.Equal(t, tc.expectedMsg, status.Message)
+				spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(2)
+			})
+		}
+	})
+
+	t.Run("within limits", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff",
+			spaceinvitation.RoleKey:    "viewer",
+		})
+		jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+		r, req, cl := prepareReconcile(t, append(newObjects(
+			withAnnotation(spaceinvitation.MaxMembersPerSpaceAnnotationKey, "2"),
+			withAnnotation(spaceinvitation.MaxSpacesPerUserAnnotationKey, "1")), invitation, jeff)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, spaceinvitation.PhaseAccepted, getStatus(t, cl).Phase)
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		// given
+		invitation := newInvitation(map[string]string{
+			spaceinvitation.SpaceKey:   "jack",
+			spaceinvitation.InviteeKey: "jeff",
+			spaceinvitation.RoleKey:    "viewer",
+		})
+		jeff := murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signup-jeff"))
+		r, req, cl := prepareReconcile(t, append(newObjects(
+			withAnnotation(spaceinvitation.MaxMembersPerSpaceAnnotationKey, "many")), invitation, jeff)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, "invalid value for annotation 'toolchain.dev.openshift.com/max-members-per-space' in NSTemplateTier 'basic': many")
+		spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(1)
+	})
+}
+
+func TestMapMasterUserRecordToPendingInvitations(t *testing.T) {
+	// given
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	pending := newInvitation(map[string]string{})
+	pending.Name = "pending"
+	accepted := newInvitation(map[string]string{})
+	accepted.Name = "accepted"
+	accepted.Annotations = map[string]string{spaceinvitation.InvitationStatusAnnotationKey: `{"phase":"Accepted"}`}
+	other := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: test.HostOperatorNs}}
+	cl := test.NewFakeClient(t, pending, accepted, other)
+	mapper := spaceinvitation.MapMasterUserRecordToPendingInvitations(cl, test.HostOperatorNs)
+
+	// when
+	requests := mapper(murtest.NewMasterUserRecord(t, "jeff"))
+
+	// then
+	assert.Equal(t, []reconcile.Request{{NamespacedName: test.NamespacedName(test.HostOperatorNs, "pending")}}, requests)
+}
+
+func newInvitation(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "invitation",
+			Namespace:         test.HostOperatorNs,
+			CreationTimestamp: metav1.Now(),
+			Labels: map[string]string{
+				spaceinvitation.InvitationLabelKey: "",
+			},
+		},
+		Data: data,
+	}
+}
+
+func withAnnotation(key, value string) tiertest.TierOption {
+	return func(tier *toolchainv1alpha1.NSTemplateTier) {
+		if tier.Annotations == nil {
+			tier.Annotations = map[string]string{}
+		}
+		tier.Annotations[key] = value
+	}
+}
+
+func getStatus(t *testing.T, cl client.Client) *spaceinvitation.Status {
+	invitation := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "invitation"), invitation))
+	status, err := spaceinvitation.GetStatus(invitation)
+	require.NoError(t, err)
+	return status
+}
+
+func prepareReconcile(t *testing.T, initObjs ...runtime.Object) (*spaceinvitation.Reconciler, reconcile.Request, *test.FakeClient) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	cl := test.NewFakeClient(t, initObjs...)
+	r := &spaceinvitation.Reconciler{
+		Client:    cl,
+		Namespace: test.HostOperatorNs,
+	}
+	return r, reconcile.Request{NamespacedName: test.NamespacedName(test.HostOperatorNs, "invitation")}, cl
+}
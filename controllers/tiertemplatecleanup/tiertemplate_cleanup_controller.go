@@ -0,0 +1,209 @@
+package tiertemplatecleanup
+
+import (
+	"context"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// DeletedEventReason the reason of the event recorded when a TierTemplate which is not referenced anymore is deleted
+	DeletedEventReason = "Deleted"
+	// UnreferencedEventReason the reason of the event recorded when a TierTemplate which is not referenced anymore is not
+	// deleted because of the dry-run mode
+	UnreferencedEventReason = "Unreferenced"
+)
+
+// Reconciler deletes the TierTemplates of an NSTemplateTier which are not referenced anymore
+type Reconciler struct {
+	Client         client.Client
+	Namespace      string
+	MemberClusters map[string]cluster.Cluster
+	// EventRecorder records the events of the deletion of the TierTemplates
+	EventRecorder *events.Recorder
+}
+
+// SetupWithManager sets up the controller reconciler with the Manager
+// Watches the NSTemplateTier resources
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("tiertemplatecleanup").
+		For(&toolchainv1alpha1.NSTemplateTier{}).
+		Complete(r)
+}
+
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=nstemplatetiers,verbs=get;list;watch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=tiertemplates,verbs=get;list;watch;delete
+
+// Reconcile deletes the TierTemplates of the NSTemplateTier which are not referenced by:
+// - the NSTemplateTier itself,
+// - a previous revision of the NSTemplateTier which was in use during the retention window,
+// - any NSTemplateSet in the member clusters,
+// and which were created before the grace period.
+// The grace period, the retention window and the dry-run mode are configured in the ToolchainConfig.
+// Note: the TierTemplates of an NSTemplateTier which is deleted are also deleted (once unreferenced).
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	config, err := toolchainconfig.GetToolchainConfig(r.Client)
+	if err != nil {
+		return reconcile.Result{}, errs.Wrap(err, "unable to get ToolchainConfig")
+	}
+	gc := config.TierTemplateGC()
+
+	tier := &toolchainv1alpha1.NSTemplateTier{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: request.Name}, tier); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, errs.Wrap(err, "unable to get the current NSTemplateTier")
+		}
+		logger.Info("NSTemplateTier not found, looking for its remaining TierTemplates")
+		tier = nil
+	}
+
+	tierTemplates := &toolchainv1alpha1.TierTemplateList{}
+	if err := r.Client.List(context.TODO(), tierTemplates, client.InNamespace(r.Namespace)); err != nil {
+		return reconcile.Result{}, errs.Wrap(err, "unable to list the TierTemplates")
+	}
+	candidates := make([]toolchainv1alpha1.TierTemplate, 0, len(tierTemplates.Items))
+	for _, tierTemplate := range tierTemplates.Items {
+		if tierTemplate.Spec.TierName == request.Name && tierTemplate.DeletionTimestamp == nil {
+			candidates = append(candidates, tierTemplate)
+		}
+	}
+	if len(candidates) == 0 {
+		metrics.TierTemplatesUnreferencedGaugeVec.DeleteLabelValues(request.Name)
+		return requeue(gc, tier), nil
+	}
+
+	referenced, err := r.referencedTemplates(tier)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	retentionStart := retainedSince(gc, tier)
+
+	unreferenced := 0
+	for i := range candidates {
+		tierTemplate := &candidates[i]
+		if referenced[tierTemplate.Name] {
+			continue
+		}
+		if time.Since(tierTemplate.CreationTimestamp.Time) < gc.GracePeriod() {
+			logger.Info("TierTemplate is not referenced but was created within the grace period", "tiertemplate", tierTemplate.Name)
+			continue
+		}
+		if retentionStart != nil && !tierTemplate.CreationTimestamp.Time.Before(*retentionStart) {
+			logger.Info("TierTemplate is not referenced but may be in use by a revision of the NSTemplateTier within the retention window", "tiertemplate", tierTemplate.Name)
+			continue
+		}
+		unreferenced++
+		if err := r.deleteTierTemplate(logger, gc, tierTemplate); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	metrics.TierTemplatesUnreferencedGaugeVec.WithLabelValues(request.Name).Set(float64(unreferenced))
+	return requeue(gc, tier), nil
+}
+
+func requeue(gc toolchainconfig.TierTemplateGCConfig, tier *toolchainv1alpha1.NSTemplateTier) reconcile.Result {
+	if tier == nil || gc.Interval() <= 0 {
+		return reconcile.Result{}
+	}
+	return reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: gc.Interval(),
+	}
+}
+
+func (r *Reconciler) deleteTierTemplate(logger logr.Logger, gc toolchainconfig.TierTemplateGCConfig, tierTemplate *toolchainv1alpha1.TierTemplate) error {
+	if gc.DryRun() {
+		logger.Info("TierTemplate is not referenced anymore and would be deleted (dry-run mode)", "tiertemplate", tierTemplate.Name)
+		r.EventRecorder.Normal(tierTemplate, UnreferencedEventReason, "the TierTemplate is not referenced anymore and would be deleted (dry-run mode)")
+		return nil
+	}
+	logger.Info("deleting TierTemplate which is not referenced anymore", "tiertemplate", tierTemplate.Name)
+	if err := r.Client.Delete(context.TODO(), tierTemplate); err != nil {
+		if errors.IsNotFound(err) {
+			// already deleted
+			return nil
+		}
+		return errs.Wrapf(err, "unable to delete the TierTemplate '%s'", tierTemplate.Name)
+	}
+	metrics.TierTemplatesDeletedTotal.Inc()
+	r.EventRecorder.Normal(tierTemplate, DeletedEventReason, "the TierTemplate was deleted because it is not referenced anymore")
+	return nil
+}
+
+// referencedTemplates returns the names of the TierTemplates referenced by the given NSTemplateTier (if not nil)
+// and by all the NSTemplateSets in the member clusters.
+// Returns an error if the NSTemplateSets could not be listed in one of the member clusters, in which case no TierTemplate should be deleted.
+func (r *Reconciler) referencedTemplates(tier *toolchainv1alpha1.NSTemplateTier) (map[string]bool, error) {
+	referenced := map[string]bool{}
+	if tier != nil {
+		for _, ns := range tier.Spec.Namespaces {
+			referenced[ns.TemplateRef] = true
+		}
+		if tier.Spec.ClusterResources != nil {
+			referenced[tier.Spec.ClusterResources.TemplateRef] = true
+		}
+		for _, role := range tier.Spec.SpaceRoles {
+			referenced[role.TemplateRef] = true
+		}
+	}
+	for name, memberCluster := range r.MemberClusters {
+		nsTemplateSets := &toolchainv1alpha1.NSTemplateSetList{}
+		if err := memberCluster.Client.List(context.TODO(), nsTemplateSets, client.InNamespace(memberCluster.OperatorNamespace)); err != nil {
+			return nil, errs.Wrapf(err, "unable to list the NSTemplateSets in the member cluster '%s'", name)
+		}
+		for _, nsTemplateSet := range nsTemplateSets.Items {
+			for _, ns := range nsTemplateSet.Spec.Namespaces {
+				referenced[ns.TemplateRef] = true
+			}
+			if nsTemplateSet.Spec.ClusterResources != nil {
+				referenced[nsTemplateSet.Spec.ClusterResources.TemplateRef] = true
+			}
+			for _, role := range nsTemplateSet.Spec.SpaceRoles {
+				referenced[role.TemplateRef] = true
+			}
+		}
+	}
+	return referenced, nil
+}
+
+// retainedSince returns the creation time from which the TierTemplates of the given NSTemplateTier must be retained, because they
+// belong to a revision of the NSTemplateTier which was in use during the retention window (nil if there is no such TierTemplate).
+//
+// The TierTemplates are created right before the NSTemplateTier is updated, hence the TierTemplates of a given entry of the
+// `status.updates` history were created between the start of the previous entry and its own start.
+// As a consequence, the TierTemplates to retain are those created after the start of the entry which precedes the entry
+// that was active when the retention window began.
+func retainedSince(gc toolchainconfig.TierTemplateGCConfig, tier *toolchainv1alpha1.NSTemplateTier) *time.Time {
+	if tier == nil {
+		return nil
+	}
+	windowStart := time.Now().Add(-gc.RetentionWindow())
+	active := -1
+	for i, update := range tier.Status.Updates {
+		if !update.StartTime.Time.After(windowStart) {
+			active = i
+		}
+	}
+	if active <= 0 {
+		// all revisions of the tier were in use during the retention window (or the tier has no history yet)
+		return &time.Time{}
+	}
+	return &tier.Status.Updates[active-1].StartTime.Time
+}
@@ -0,0 +1,345 @@
+package tiertemplatecleanup_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/tiertemplatecleanup"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	commoncluster "github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	nstemplatesettest "github.com/codeready-toolchain/toolchain-common/pkg/test/nstemplateset"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCleanupTierTemplates(t *testing.T) {
+
+	// the `basic` tier was updated 30 days ago (old -> previous) and 1 hour ago (previous -> current)
+	newTier := func() *toolchainv1alpha1.NSTemplateTier {
+		tier := tiertest.NewNSTemplateTier("basic", "dev")
+		tier.Spec.Namespaces = []toolchainv1alpha1.NSTemplateTierNamespace{{TemplateRef: "basic-dev-current"}}
+		tier.Spec.ClusterResources.TemplateRef = "basic-clusterresources-current"
+		tier.Spec.SpaceRoles = map[string]toolchainv1alpha1.NSTemplateTierSpaceRole{"admin": {TemplateRef: "basic-admin-current"}}
+		tier.Status.Updates = []toolchainv1alpha1.NSTemplateTierHistory{
+			{StartTime: metav1.NewTime(time.Now().Add(-60 * 24 * time.Hour)), Hash: "old"},
+			{StartTime: metav1.NewTime(time.Now().Add(-30 * 24 * time.Hour)), Hash: "previous"},
+			{StartTime: metav1.NewTime(time.Now().Add(-1 * time.Hour)), Hash: "current"},
+		}
+		return tier
+	}
+	oldTemplates := []runtime.Object{
+		newTierTemplate("basic", "dev", "old", -61*24*time.Hour),
+		newTierTemplate("basic", "clusterresources", "old", -61*24*time.Hour),
+		newTierTemplate("basic", "admin", "old", -61*24*time.Hour),
+	}
+	previousTemplates := []runtime.Object{
+		newTierTemplate("basic", "dev", "previous", -31*24*time.Hour),
+		newTierTemplate("basic", "clusterresources", "previous", -31*24*time.Hour),
+		newTierTemplate("basic", "admin", "previous", -31*24*time.Hour),
+	}
+	currentTemplates := []runtime.Object{
+		newTierTemplate("basic", "dev", "current", -2*time.Hour),
+		newTierTemplate("basic", "clusterresources", "current", -2*time.Hour),
+		newTierTemplate("basic", "admin", "current", -2*time.Hour),
+	}
+	all := func(objs ...[]runtime.Object) []runtime.Object {
+		result := []runtime.Object{}
+		for _, o := range objs {
+			result = append(result, o...)
+		}
+		return result
+	}
+
+	t.Run("delete templates of revisions which are not in use since the retention window", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		r, req, cl := prepareReconcile(t, newTier(), nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{Requeue: true, RequeueAfter: time.Hour}, res)
+		assertTierTemplates(t, cl, all(previousTemplates, currentTemplates)...)
+		AssertMetricsCounterEquals(t, 3, metrics.TierTemplatesDeletedTotal)
+		AssertMetricsGaugeEquals(t, 3, metrics.TierTemplatesUnreferencedGaugeVec.WithLabelValues("basic"))
+		require.Len(t, fakeRecorder.Events, 3)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "Normal Deleted the TierTemplate was deleted because it is not referenced anymore", <-fakeRecorder.Events)
+		}
+	})
+
+	t.Run("delete templates of previous revisions when last update is outside of the retention window", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		tier := newTier()
+		tier.Status.Updates[2].StartTime = metav1.NewTime(time.Now().Add(-10 * 24 * time.Hour))
+		r, req, cl := prepareReconcile(t, tier, nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, currentTemplates...)
+		AssertMetricsCounterEquals(t, 6, metrics.TierTemplatesDeletedTotal)
+	})
+
+	t.Run("keep templates referenced by NSTemplateSets", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		tier := newTier()
+		tier.Status.Updates[2].StartTime = metav1.NewTime(time.Now().Add(-10 * 24 * time.Hour))
+		nsTemplateSet := nstemplatesettest.NewNSTemplateSet("johny")
+		nsTemplateSet.Spec.TierName = "basic"
+		nsTemplateSet.Spec.Namespaces = []toolchainv1alpha1.NSTemplateSetNamespace{{TemplateRef: "basic-dev-old"}}
+		nsTemplateSet.Spec.ClusterResources = &toolchainv1alpha1.NSTemplateSetClusterResources{TemplateRef: "basic-clusterresources-previous"}
+		nsTemplateSet.Spec.SpaceRoles = []toolchainv1alpha1.NSTemplateSetSpaceRole{{TemplateRef: "basic-admin-previous", Usernames: []string{"johny"}}}
+		r, req, cl := prepareReconcile(t, tier, []runtime.Object{nsTemplateSet}, all(oldTemplates, previousTemplates, currentTemplates)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, append(currentTemplates, oldTemplates[0], previousTemplates[1], previousTemplates[2])...)
+		AssertMetricsCounterEquals(t, 3, metrics.TierTemplatesDeletedTotal)
+	})
+
+	t.Run("keep templates created within the grace period", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		unused := newTierTemplate("basic", "dev", "unused", -time.Minute)
+		r, req, cl := prepareReconcile(t, newTier(), nil, append(currentTemplates, unused)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, append(currentTemplates, unused)...)
+		AssertMetricsCounterEquals(t, 0, metrics.TierTemplatesDeletedTotal)
+	})
+
+	t.Run("keep all templates when the tier has no history", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		tier := newTier()
+		tier.Status.Updates = nil
+		r, req, cl := prepareReconcile(t, tier, nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, all(oldTemplates, previousTemplates, currentTemplates)...)
+	})
+
+	t.Run("ignore templates of other tiers", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		other := newTierTemplate("advanced", "dev", "old", -61*24*time.Hour)
+		r, req, cl := prepareReconcile(t, newTier(), nil, append(currentTemplates, other)...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, append(currentTemplates, other)...)
+	})
+
+	t.Run("delete all unreferenced templates when the tier was deleted", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		nsTemplateSet := nstemplatesettest.NewNSTemplateSet("johny")
+		nsTemplateSet.Spec.Namespaces = []toolchainv1alpha1.NSTemplateSetNamespace{{TemplateRef: "basic-dev-current"}}
+		r, req, cl := prepareReconcile(t, nil, []runtime.Object{nsTemplateSet}, all(oldTemplates, previousTemplates, currentTemplates)...)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, reconcile.Result{}, res)
+		// the current templates are still within the grace period
+		assertTierTemplates(t, cl, currentTemplates...)
+		AssertMetricsCounterEquals(t, 6, metrics.TierTemplatesDeletedTotal)
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.TierTemplateGCDryRunAnnotationKey, "true"))
+		r, req, cl := prepareReconcile(t, newTier(), nil, append(all(oldTemplates, previousTemplates, currentTemplates), config)...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertTierTemplates(t, cl, all(oldTemplates, previousTemplates, currentTemplates)...)
+		AssertMetricsCounterEquals(t, 0, metrics.TierTemplatesDeletedTotal)
+		AssertMetricsGaugeEquals(t, 3, metrics.TierTemplatesUnreferencedGaugeVec.WithLabelValues("basic"))
+		require.Len(t, fakeRecorder.Events, 3)
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "Normal Unreferenced the TierTemplate is not referenced anymore and would be deleted (dry-run mode)", <-fakeRecorder.Events)
+		}
+	})
+
+	t.Run("settings configured in the ToolchainConfig", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		config := commonconfig.NewToolchainConfigObjWithReset(t,
+			ConfigAnnotation(toolchainconfig.TierTemplateGCGracePeriodAnnotationKey, "2000h"),
+			ConfigAnnotation(toolchainconfig.TierTemplateGCIntervalAnnotationKey, "30m"))
+		r, req, cl := prepareReconcile(t, newTier(), nil, append(all(oldTemplates, previousTemplates, currentTemplates), config)...)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, res.RequeueAfter)
+		// the old templates were created within the (extended) grace period
+		assertTierTemplates(t, cl, all(oldTemplates, previousTemplates, currentTemplates)...)
+		AssertMetricsCounterEquals(t, 0, metrics.TierTemplatesDeletedTotal)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("unable to list NSTemplateSets", func(t *testing.T) {
+			// given
+			metrics.Reset()
+			r, req, cl := prepareReconcile(t, newTier(), nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+			memberClient := r.MemberClusters["member-1"].Client.(*test.FakeClient)
+			memberClient.MockList = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.EqualError(t, err, "unable to list the NSTemplateSets in the member cluster 'member-1': mock error")
+			assertTierTemplates(t, cl, all(oldTemplates, previousTemplates, currentTemplates)...)
+		})
+
+		t.Run("unable to delete TierTemplate", func(t *testing.T) {
+			// given
+			metrics.Reset()
+			r, req, cl := prepareReconcile(t, newTier(), nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+			cl.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "unable to delete the TierTemplate")
+			AssertMetricsCounterEquals(t, 0, metrics.TierTemplatesDeletedTotal)
+		})
+
+		t.Run("TierTemplate already deleted", func(t *testing.T) {
+			// given
+			metrics.Reset()
+			r, req, cl := prepareReconcile(t, newTier(), nil, all(oldTemplates, previousTemplates, currentTemplates)...)
+			cl.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+				return errors.NewNotFound(toolchainv1alpha1.GroupVersion.WithResource("tiertemplates").GroupResource(), obj.GetName())
+			}
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			AssertMetricsCounterEquals(t, 0, metrics.TierTemplatesDeletedTotal)
+		})
+	})
+}
+
+func newTierTemplate(tier, kind, revision string, age time.Duration) *toolchainv1alpha1.TierTemplate {
+	return &toolchainv1alpha1.TierTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         test.HostOperatorNs,
+			Name:              fmt.Sprintf("%s-%s-%s", tier, kind, revision),
+			CreationTimestamp: metav1.NewTime(time.Now().Add(age)),
+		},
+		Spec: toolchainv1alpha1.TierTemplateSpec{
+			TierName: tier,
+			Type:     kind,
+			Revision: revision,
+		},
+	}
+}
+
+func assertTierTemplates(t *testing.T, cl client.Client, expected ...runtime.Object) {
+	tierTemplates := &toolchainv1alpha1.TierTemplateList{}
+	require.NoError(t, cl.List(context.TODO(), tierTemplates, client.InNamespace(test.HostOperatorNs)))
+	expectedNames := make([]string, 0, len(expected))
+	for _, obj := range expected {
+		expectedNames = append(expectedNames, obj.(client.Object).GetName())
+	}
+	actualNames := make([]string, 0, len(tierTemplates.Items))
+	for _, tierTemplate := range tierTemplates.Items {
+		actualNames = append(actualNames, tierTemplate.Name)
+	}
+	assert.ElementsMatch(t, expectedNames, actualNames)
+}
+
+func prepareReconcile(t *testing.T, tier *toolchainv1alpha1.NSTemplateTier, memberObjs []runtime.Object, initObjs ...runtime.Object) (*tiertemplatecleanup.Reconciler, reconcile.Request, *test.FakeClient) {
+	require.NoError(t, os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs))
+	s := scheme.Scheme
+	require.NoError(t, apis.AddToScheme(s))
+	objs := []runtime.Object{}
+	for _, obj := range initObjs {
+		objs = append(objs, obj.DeepCopyObject())
+	}
+	if tier != nil {
+		objs = append(objs, tier)
+	}
+	cl := test.NewFakeClient(t, objs...)
+	memberClient := test.NewFakeClient(t, memberObjs...)
+	r := &tiertemplatecleanup.Reconciler{
+		Client:    cl,
+		Namespace: test.HostOperatorNs,
+		MemberClusters: map[string]cluster.Cluster{
+			"member-1": {
+				Config: &commoncluster.Config{
+					Type:              commoncluster.Member,
+					OperatorNamespace: test.MemberOperatorNs,
+				},
+				Client: memberClient,
+			},
+		},
+	}
+	return r, reconcile.Request{NamespacedName: test.NamespacedName(test.HostOperatorNs, "basic")}, cl
+}
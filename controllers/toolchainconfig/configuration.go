@@ -2,10 +2,12 @@ package toolchainconfig
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,13 +22,114 @@ const (
 	NotificationDeliveryServiceMailgun = "mailgun"
 
 	NotificationContextRegistrationURLKey = "RegistrationURL"
+	NotificationContextDaysRemainingKey   = "DaysRemaining"
+
+	// EmailDomainClassesAnnotationKey the annotation on the ToolchainConfig resource which defines the classes of email address domains
+	// used to label the metrics, as a JSON array. Eg: `[{"name":"internal","domains":["redhat.com","*.ibm.com"]}]`
+	EmailDomainClassesAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "email-domain-classes"
+	// CounterReconcileIntervalAnnotationKey the minimum interval between two recomputations of the cached counts from the UserSignups,
+	// MasterUserRecords and Spaces (defaults to `10s`, `0` to disable the recomputation)
+	CounterReconcileIntervalAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "counter-reconcile-interval"
+
+	// TierTemplateGCDryRunAnnotationKey when set to `true`, the unreferenced TierTemplates are reported but not deleted (defaults to `false`)
+	TierTemplateGCDryRunAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tiertemplate-gc-dry-run"
+	// TierTemplateGCGracePeriodAnnotationKey the minimum age of an unreferenced TierTemplate before it can be deleted (defaults to `24h`)
+	TierTemplateGCGracePeriodAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tiertemplate-gc-grace-period"
+	// TierTemplateGCRetentionWindowAnnotationKey the duration during which the TierTemplates of the previous revisions of an NSTemplateTier
+	// are retained after the NSTemplateTier was updated (defaults to `168h`)
+	TierTemplateGCRetentionWindowAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tiertemplate-gc-retention-window"
+	// TierTemplateGCIntervalAnnotationKey the interval between two checks of the TierTemplates of a given NSTemplateTier (defaults to `1h`)
+	TierTemplateGCIntervalAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tiertemplate-gc-interval"
+
+	// ReactivationPolicyAnnotationKey the policy applied to the tiers and the space settings of a returning user: `restore` to restore those
+	// that were recorded when the user was deactivated, or `reset` to use the defaults (defaults to `reset`)
+	ReactivationPolicyAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "reactivation-policy"
+	// ReactivationManualApprovalThresholdAnnotationKey the number of reactivations after which a returning user needs to be approved manually,
+	// even if the automatic approval is enabled (defaults to `0`, ie, no manual approval required)
+	ReactivationManualApprovalThresholdAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "reactivation-manual-approval-threshold"
+
+	// RiskScoreManualApprovalThresholdAnnotationKey the risk score (between 1 and 100) from which the users need to be approved manually,
+	// even if the automatic approval is enabled (defaults to `0`, ie, no manual approval required)
+	RiskScoreManualApprovalThresholdAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "risk-score-manual-approval-threshold"
+	// RiskScoreDomainVelocityWindowAnnotationKey the window during which the signups with the same email domain are counted
+	// (eg: `30m`, defaults to `1h`)
+	RiskScoreDomainVelocityWindowAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "risk-score-domain-velocity-window"
+	// RiskScoreDomainVelocityThresholdAnnotationKey the number of signups with the same email domain during the window above which
+	// the risk score of the users of this domain is increased (defaults to `10`, `0` to disable)
+	RiskScoreDomainVelocityThresholdAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "risk-score-domain-velocity-threshold"
+
+	// SpaceSoftDeletionPeriodAnnotationKey the period during which a Space without SpaceBindings can be restored by adding a SpaceBinding,
+	// before it is deleted (eg: `72h`, defaults to `0`, ie, the Space is deleted immediately)
+	SpaceSoftDeletionPeriodAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "space-soft-deletion-period"
+	// SpaceSoftDeletionScaleDownAnnotationKey when set to `true`, the namespaces of a Space which is scheduled for deletion are scaled
+	// to zero until the Space is restored or deleted (defaults to `false`)
+	SpaceSoftDeletionScaleDownAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "space-soft-deletion-scale-down"
+	// SpaceInvitationExpirationAnnotationKey the expiration of the invitations to join a Space which do not specify an expiration time
+	// (eg: `48h`, defaults to `168h`)
+	SpaceInvitationExpirationAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "space-invitation-expiration"
+
+	// UsernameForbiddenWordsAnnotationKey the comma-separated words which must not appear in the compliant usernames, besides the forbidden
+	// prefixes and suffixes (defaults to none)
+	UsernameForbiddenWordsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "username-forbidden-words"
+	// UsernameReuseCoolDownAnnotationKey the period during which the compliant username of a deleted user cannot be allocated to
+	// another user (eg: `720h`, defaults to `0`, ie, the username can be reused immediately)
+	UsernameReuseCoolDownAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "username-reuse-cool-down"
+	// RenameProvisioningTimeoutAnnotationKey the maximum duration of the provisioning of the MasterUserRecord and the Space of a renamed user,
+	// after which the rename is rolled back (eg: `30m`, defaults to `15m`, `0` for no timeout)
+	RenameProvisioningTimeoutAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "rename-provisioning-timeout"
+
+	// UserSignupArchiveSinkAnnotationKey the sink to which a record of the UserSignups is written before they are deleted: `file`, `configmap`
+	// or `http` (defaults to none, ie, the UserSignups are not archived)
+	UserSignupArchiveSinkAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-sink"
+	// UserSignupArchiveFilePathAnnotationKey the path to the file in which the records are appended as JSON lines (`file` sink)
+	UserSignupArchiveFilePathAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-file-path"
+	// UserSignupArchiveConfigMapNameAnnotationKey the name of the ConfigMap in which the records are written (`configmap` sink,
+	// defaults to `usersignup-archive`)
+	UserSignupArchiveConfigMapNameAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-configmap-name"
+	// UserSignupArchiveConfigMapMaxRecordsAnnotationKey the maximum number of records retained in the ConfigMap (`configmap` sink,
+	// defaults to `1000`)
+	UserSignupArchiveConfigMapMaxRecordsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-configmap-max-records"
+	// UserSignupArchiveURLAnnotationKey the URL of the endpoint to which the records are sent (`http` sink)
+	UserSignupArchiveURLAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-url"
+	// UserSignupArchiveTimeoutAnnotationKey the timeout of the requests to the endpoint (`http` sink, defaults to `10s`)
+	UserSignupArchiveTimeoutAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "usersignup-archive-timeout"
+
+	// WebhooksEnabledAnnotationKey when set to `true`, the manager serves the validating and mutating webhooks of the host resources
+	// (defaults to `false`). The webhook configurations and the serving certificate must then be deployed as in `config/webhook`,
+	// and must not be deployed while the webhooks are disabled, since their failure policy rejects the requests which cannot be
+	// sent to the webhooks. Since the webhooks are registered when the manager starts, the operator must be restarted to apply a change.
+	WebhooksEnabledAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "webhooks-enabled"
+
+	// EventRateLimitIntervalAnnotationKey the interval during which the same Kubernetes Event (same object, type, reason and message)
+	// is recorded only once by a controller (eg: `10m`, defaults to `5m`, `0` to record all events)
+	EventRateLimitIntervalAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "event-rate-limit-interval"
+
+	// TierSourceAnnotationKey the location of the NSTemplateTier and UserTier templates (a directory, a tarball or an OCI image layout
+	// directory). When not set, the templates embedded in the operator binary are used. Since the tier source is configured when the
+	// operator starts, the operator must be restarted to apply a change of the tier source settings.
+	TierSourceAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tier-source"
+	// TierSourceSyncIntervalAnnotationKey the interval between two checks of the tier source location (eg: `5m`, defaults to `1m`)
+	TierSourceSyncIntervalAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tier-source-sync-interval"
+
+	// TracingExporterAnnotationKey the exporter to which the spans of the reconciliations are sent: `none`, `stdout`, `file` or `otlp`
+	// (defaults to `none`, ie, the spans are not recorded). Since the tracer provider is configured when the operator starts,
+	// the operator must be restarted to apply a change of the tracing settings.
+	TracingExporterAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tracing-exporter"
+	// TracingFilePathAnnotationKey the path to the file in which the spans are appended as JSON (`file` exporter)
+	TracingFilePathAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tracing-file-path"
+	// TracingOTLPEndpointAnnotationKey the host and port of the OpenTelemetry collector (`otlp` exporter, eg: `otel-collector:4318`)
+	TracingOTLPEndpointAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tracing-otlp-endpoint"
+	// TracingOTLPInsecureAnnotationKey when set to `true`, the spans are sent to the collector over HTTP instead of HTTPS
+	// (`otlp` exporter, defaults to `false`)
+	TracingOTLPInsecureAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "tracing-otlp-insecure"
 )
 
 var logger = logf.Log.WithName("toolchainconfig")
 
 type ToolchainConfig struct {
-	cfg     *toolchainv1alpha1.ToolchainConfigSpec
-	secrets map[string]map[string]string
+	cfg         *toolchainv1alpha1.ToolchainConfigSpec
+	annotations map[string]string
+	secrets     map[string]map[string]string
 }
 
 // GetToolchainConfig returns a ToolchainConfig using the cache, or if the cache was not initialized
@@ -70,7 +173,7 @@ func newToolchainConfig(config runtime.Object, secrets map[string]map[string]str
 		logger.Error(fmt.Errorf("cache does not contain toolchainconfig resource type"), "failed to get ToolchainConfig from resource, using default configuration")
 		return ToolchainConfig{cfg: &toolchainv1alpha1.ToolchainConfigSpec{}}
 	}
-	return ToolchainConfig{cfg: &toolchaincfg.Spec, secrets: secrets}
+	return ToolchainConfig{cfg: &toolchaincfg.Spec, annotations: toolchaincfg.Annotations, secrets: secrets}
 }
 
 func (c *ToolchainConfig) Print() {
@@ -94,7 +197,11 @@ func (c *ToolchainConfig) Deactivation() DeactivationConfig {
 }
 
 func (c *ToolchainConfig) Metrics() MetricsConfig {
-	return MetricsConfig{c.cfg.Host.Metrics}
+	return MetricsConfig{metrics: c.cfg.Host.Metrics, annotations: c.annotations}
+}
+
+func (c *ToolchainConfig) Events() EventsConfig {
+	return EventsConfig{c.annotations}
 }
 
 func (c *ToolchainConfig) Notifications() NotificationsConfig {
@@ -104,12 +211,28 @@ func (c *ToolchainConfig) Notifications() NotificationsConfig {
 	}
 }
 
+func (c *ToolchainConfig) Reactivation() ReactivationConfig {
+	return ReactivationConfig{c.annotations}
+}
+
 func (c *ToolchainConfig) RegistrationService() RegistrationServiceConfig {
 	return RegistrationServiceConfig{c.cfg.Host.RegistrationService}
 }
 
+func (c *ToolchainConfig) RiskScore() RiskScoreConfig {
+	return RiskScoreConfig{c.annotations}
+}
+
+func (c *ToolchainConfig) Spaces() SpacesConfig {
+	return SpacesConfig{c.annotations}
+}
+
+func (c *ToolchainConfig) TierTemplateGC() TierTemplateGCConfig {
+	return TierTemplateGCConfig{c.annotations}
+}
+
 func (c *ToolchainConfig) Tiers() TiersConfig {
-	return TiersConfig{c.cfg.Host.Tiers}
+	return TiersConfig{tiers: c.cfg.Host.Tiers, annotations: c.annotations}
 }
 
 func (c *ToolchainConfig) ToolchainStatus() ToolchainStatusConfig {
@@ -117,7 +240,19 @@ func (c *ToolchainConfig) ToolchainStatus() ToolchainStatusConfig {
 }
 
 func (c *ToolchainConfig) Users() UsersConfig {
-	return UsersConfig{c.cfg.Host.Users}
+	return UsersConfig{c: c.cfg.Host.Users, annotations: c.annotations}
+}
+
+func (c *ToolchainConfig) Tracing() TracingConfig {
+	return TracingConfig{c.annotations}
+}
+
+func (c *ToolchainConfig) UserSignupArchive() UserSignupArchiveConfig {
+	return UserSignupArchiveConfig{c.annotations}
+}
+
+func (c *ToolchainConfig) Webhooks() WebhooksConfig {
+	return WebhooksConfig{c.annotations}
 }
 
 type AutoApprovalConfig struct {
@@ -185,13 +320,42 @@ func (d DeactivationConfig) UserSignupUnverifiedRetentionDays() int {
 }
 
 type MetricsConfig struct {
-	metrics toolchainv1alpha1.MetricsConfig
+	metrics     toolchainv1alpha1.MetricsConfig
+	annotations map[string]string
 }
 
 func (d MetricsConfig) ForceSynchronization() bool {
 	return commonconfig.GetBool(d.metrics.ForceSynchronization, false)
 }
 
+// DomainClasses returns the classes of email address domains, or the default ones if the annotation is missing or invalid
+func (d MetricsConfig) DomainClasses() []metrics.DomainClass {
+	value, found := d.annotations[EmailDomainClassesAnnotationKey]
+	if !found {
+		return metrics.DefaultDomainClasses
+	}
+	classes, err := metrics.ParseDomainClasses(value)
+	if err != nil {
+		logger.Error(err, "invalid email domain classes, using the default ones", "value", value)
+		return metrics.DefaultDomainClasses
+	}
+	return classes
+}
+
+// CounterReconcileInterval returns the minimum interval between two recomputations of the cached counts (`0` if disabled)
+func (d MetricsConfig) CounterReconcileInterval() time.Duration {
+	return getDuration(d.annotations, CounterReconcileIntervalAnnotationKey, 10*time.Second)
+}
+
+type EventsConfig struct {
+	annotations map[string]string
+}
+
+// RateLimitInterval returns the interval during which the same event is recorded only once (`0` to record all events)
+func (e EventsConfig) RateLimitInterval() time.Duration {
+	return getDuration(e.annotations, EventRateLimitIntervalAnnotationKey, 5*time.Minute)
+}
+
 type NotificationsConfig struct {
 	c       toolchainv1alpha1.NotificationsConfig
 	secrets map[string]map[string]string
@@ -267,8 +431,98 @@ func (r RegistrationServiceConfig) RegistrationServiceURL() string {
 	return commonconfig.GetString(r.c.RegistrationServiceURL, "https://registration.crt-placeholder.com")
 }
 
+const (
+	// ReactivationPolicyReset the returning users are provisioned with the default tiers (or those of their SocialEvent)
+	ReactivationPolicyReset = "reset"
+	// ReactivationPolicyRestore the returning users are provisioned with the tiers and space settings they had when they were deactivated
+	ReactivationPolicyRestore = "restore"
+)
+
+type ReactivationConfig struct {
+	annotations map[string]string
+}
+
+// Policy returns the policy applied to the tiers and space settings of the returning users: `reset` (the default) or `restore`
+func (r ReactivationConfig) Policy() string {
+	value := getString(r.annotations, ReactivationPolicyAnnotationKey, ReactivationPolicyReset)
+	if value != ReactivationPolicyReset && value != ReactivationPolicyRestore {
+		logger.Info("unknown reactivation policy, using the default one", "value", value)
+		return ReactivationPolicyReset
+	}
+	return value
+}
+
+// ManualApprovalThreshold returns the number of reactivations after which a returning user needs to be approved manually (`0` if disabled)
+func (r ReactivationConfig) ManualApprovalThreshold() int {
+	return getInt(r.annotations, ReactivationManualApprovalThresholdAnnotationKey, 0)
+}
+
+type RiskScoreConfig struct {
+	annotations map[string]string
+}
+
+// ManualApprovalThreshold returns the risk score from which the users need to be approved manually (`0` if disabled)
+func (r RiskScoreConfig) ManualApprovalThreshold() int {
+	return getInt(r.annotations, RiskScoreManualApprovalThresholdAnnotationKey, 0)
+}
+
+// DomainVelocityWindow returns the window during which the signups with the same email domain are counted
+func (r RiskScoreConfig) DomainVelocityWindow() time.Duration {
+	return getDuration(r.annotations, RiskScoreDomainVelocityWindowAnnotationKey, time.Hour)
+}
+
+// DomainVelocityThreshold returns the number of signups with the same email domain during the window above which the risk score
+// of the users of this domain is increased (`0` if disabled)
+func (r RiskScoreConfig) DomainVelocityThreshold() int {
+	return getInt(r.annotations, RiskScoreDomainVelocityThresholdAnnotationKey, 10)
+}
+
+type SpacesConfig struct {
+	annotations map[string]string
+}
+
+// SoftDeletionPeriod returns the period during which a Space without SpaceBindings can be restored (`0` if the Space is deleted immediately)
+func (s SpacesConfig) SoftDeletionPeriod() time.Duration {
+	return getDuration(s.annotations, SpaceSoftDeletionPeriodAnnotationKey, 0)
+}
+
+// ScaleDownOnSoftDeletion returns `true` if the namespaces of the Spaces which are scheduled for deletion are scaled to zero
+func (s SpacesConfig) ScaleDownOnSoftDeletion() bool {
+	return getBool(s.annotations, SpaceSoftDeletionScaleDownAnnotationKey, false)
+}
+
+// InvitationExpiration returns the expiration of the invitations which do not specify an expiration time
+func (s SpacesConfig) InvitationExpiration() time.Duration {
+	return getDuration(s.annotations, SpaceInvitationExpirationAnnotationKey, 7*24*time.Hour)
+}
+
+type TierTemplateGCConfig struct {
+	annotations map[string]string
+}
+
+// DryRun returns `true` if the unreferenced TierTemplates are reported but not deleted
+func (t TierTemplateGCConfig) DryRun() bool {
+	return getBool(t.annotations, TierTemplateGCDryRunAnnotationKey, false)
+}
+
+// GracePeriod returns the minimum age of an unreferenced TierTemplate before it can be deleted
+func (t TierTemplateGCConfig) GracePeriod() time.Duration {
+	return getDuration(t.annotations, TierTemplateGCGracePeriodAnnotationKey, 24*time.Hour)
+}
+
+// RetentionWindow returns the duration during which the TierTemplates of the previous revisions of an NSTemplateTier are retained
+func (t TierTemplateGCConfig) RetentionWindow() time.Duration {
+	return getDuration(t.annotations, TierTemplateGCRetentionWindowAnnotationKey, 7*24*time.Hour)
+}
+
+// Interval returns the interval between two checks of the TierTemplates of a given NSTemplateTier (`0` for no periodic check)
+func (t TierTemplateGCConfig) Interval() time.Duration {
+	return getDuration(t.annotations, TierTemplateGCIntervalAnnotationKey, time.Hour)
+}
+
 type TiersConfig struct {
-	tiers toolchainv1alpha1.TiersConfig
+	tiers       toolchainv1alpha1.TiersConfig
+	annotations map[string]string
 }
 
 func (d TiersConfig) DefaultUserTier() string {
@@ -288,6 +542,15 @@ func (d TiersConfig) DurationBeforeChangeTierRequestDeletion() time.Duration {
 	return duration
 }
 
+// Source returns the location of the tier templates, or an empty string if the templates embedded in the operator binary are used
+func (d TiersConfig) Source() string {
+	return getString(d.annotations, TierSourceAnnotationKey, "")
+}
+
+func (d TiersConfig) SourceSyncInterval() time.Duration {
+	return getDuration(d.annotations, TierSourceSyncIntervalAnnotationKey, time.Minute)
+}
+
 type ToolchainStatusConfig struct {
 	t toolchainv1alpha1.ToolchainStatusConfig
 }
@@ -302,7 +565,8 @@ func (d ToolchainStatusConfig) ToolchainStatusRefreshTime() time.Duration {
 }
 
 type UsersConfig struct {
-	c toolchainv1alpha1.UsersConfig
+	c           toolchainv1alpha1.UsersConfig
+	annotations map[string]string
 }
 
 func (d UsersConfig) MasterUserRecordUpdateFailureThreshold() int {
@@ -324,3 +588,138 @@ func (d UsersConfig) ForbiddenUsernameSuffixes() []string {
 	})
 	return v
 }
+
+// ForbiddenUsernameWords returns the words which must not appear in the compliant usernames
+func (d UsersConfig) ForbiddenUsernameWords() []string {
+	return getList(d.annotations, UsernameForbiddenWordsAnnotationKey)
+}
+
+// UsernameReuseCoolDown returns the period during which the username of a deleted user cannot be allocated to another user
+func (d UsersConfig) UsernameReuseCoolDown() time.Duration {
+	return getDuration(d.annotations, UsernameReuseCoolDownAnnotationKey, 0)
+}
+
+// RenameProvisioningTimeout returns the maximum duration of the provisioning of a renamed user (`0` for no timeout)
+func (d UsersConfig) RenameProvisioningTimeout() time.Duration {
+	return getDuration(d.annotations, RenameProvisioningTimeoutAnnotationKey, 15*time.Minute)
+}
+
+type UserSignupArchiveConfig struct {
+	annotations map[string]string
+}
+
+// Sink returns the sink to which the records of the UserSignups are written (empty if the UserSignups are not archived)
+func (u UserSignupArchiveConfig) Sink() string {
+	return getString(u.annotations, UserSignupArchiveSinkAnnotationKey, "")
+}
+
+func (u UserSignupArchiveConfig) FilePath() string {
+	return getString(u.annotations, UserSignupArchiveFilePathAnnotationKey, "")
+}
+
+func (u UserSignupArchiveConfig) ConfigMapName() string {
+	return getString(u.annotations, UserSignupArchiveConfigMapNameAnnotationKey, "usersignup-archive")
+}
+
+func (u UserSignupArchiveConfig) ConfigMapMaxRecords() int {
+	return getInt(u.annotations, UserSignupArchiveConfigMapMaxRecordsAnnotationKey, 1000)
+}
+
+func (u UserSignupArchiveConfig) URL() string {
+	return getString(u.annotations, UserSignupArchiveURLAnnotationKey, "")
+}
+
+func (u UserSignupArchiveConfig) Timeout() time.Duration {
+	return getDuration(u.annotations, UserSignupArchiveTimeoutAnnotationKey, 10*time.Second)
+}
+
+type TracingConfig struct {
+	annotations map[string]string
+}
+
+// Exporter returns the kind of exporter to which the spans are sent
+func (t TracingConfig) Exporter() string {
+	return getString(t.annotations, TracingExporterAnnotationKey, "none")
+}
+
+func (t TracingConfig) FilePath() string {
+	return getString(t.annotations, TracingFilePathAnnotationKey, "")
+}
+
+func (t TracingConfig) OTLPEndpoint() string {
+	return getString(t.annotations, TracingOTLPEndpointAnnotationKey, "")
+}
+
+func (t TracingConfig) OTLPInsecure() bool {
+	return getBool(t.annotations, TracingOTLPInsecureAnnotationKey, false)
+}
+
+type WebhooksConfig struct {
+	annotations map[string]string
+}
+
+// IsEnabled returns `true` if the validating and mutating webhooks of the host resources are served
+func (w WebhooksConfig) IsEnabled() bool {
+	return getBool(w.annotations, WebhooksEnabledAnnotationKey, false)
+}
+
+// getString returns the value of the given annotation, or the default value if the annotation is missing or empty
+func getString(annotations map[string]string, key, defaultValue string) string {
+	if value := strings.TrimSpace(annotations[key]); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getDuration returns the duration set in the given annotation, or the default value if the annotation is missing or invalid
+func getDuration(annotations map[string]string, key string, defaultValue time.Duration) time.Duration {
+	value := getString(annotations, key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logger.Error(err, "invalid duration in the ToolchainConfig, using the default value", "annotation", key, "value", value)
+		return defaultValue
+	}
+	return d
+}
+
+// getBool returns the boolean set in the given annotation, or the default value if the annotation is missing or invalid
+func getBool(annotations map[string]string, key string, defaultValue bool) bool {
+	value := getString(annotations, key, "")
+	if value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.Error(err, "invalid boolean in the ToolchainConfig, using the default value", "annotation", key, "value", value)
+		return defaultValue
+	}
+	return b
+}
+
+// getInt returns the integer set in the given annotation, or the default value if the annotation is missing or invalid
+func getInt(annotations map[string]string, key string, defaultValue int) int {
+	value := getString(annotations, key, "")
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		logger.Error(err, "invalid integer in the ToolchainConfig, using the default value", "annotation", key, "value", value)
+		return defaultValue
+	}
+	return i
+}
+
+// getList returns the comma-separated values set in the given annotation, or nil if the annotation is missing
+func getList(annotations map[string]string, key string) []string {
+	var values []string
+	for _, v := range strings.Split(annotations[key], ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
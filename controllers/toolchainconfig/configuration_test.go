@@ -7,6 +7,7 @@ import (
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
@@ -315,6 +316,33 @@ func TestEnvironment(t *testing.T) {
 	})
 }
 
+func TestEvents(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 5*time.Minute, toolchainCfg.Events().RateLimitInterval())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			EventRateLimitIntervalAnnotationKey: "10m",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 10*time.Minute, toolchainCfg.Events().RateLimitInterval())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			EventRateLimitIntervalAnnotationKey: "-",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 5*time.Minute, toolchainCfg.Events().RateLimitInterval())
+	})
+}
+
 func TestMetrics(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
@@ -328,6 +356,61 @@ func TestMetrics(t *testing.T) {
 
 		assert.True(t, toolchainCfg.Metrics().ForceSynchronization())
 	})
+	t.Run("counter reconcile interval", func(t *testing.T) {
+		t.Run("default", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, 10*time.Second, toolchainCfg.Metrics().CounterReconcileInterval())
+		})
+		t.Run("non-default", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			cfg.Annotations = map[string]string{
+				CounterReconcileIntervalAnnotationKey: "0",
+			}
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, time.Duration(0), toolchainCfg.Metrics().CounterReconcileInterval())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			cfg.Annotations = map[string]string{
+				CounterReconcileIntervalAnnotationKey: "often",
+			}
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, 10*time.Second, toolchainCfg.Metrics().CounterReconcileInterval())
+		})
+	})
+	t.Run("domain classes", func(t *testing.T) {
+		t.Run("default", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, metrics.DefaultDomainClasses, toolchainCfg.Metrics().DomainClasses())
+		})
+		t.Run("non-default", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			cfg.Annotations = map[string]string{
+				EmailDomainClassesAnnotationKey: `[{"name":"internal","domains":["redhat.com"]},{"name":"partner","domains":["*.partner.com"]}]`,
+			}
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, []metrics.DomainClass{
+				{Name: "internal", Domains: []string{"redhat.com"}},
+				{Name: "partner", Domains: []string{"*.partner.com"}},
+			}, toolchainCfg.Metrics().DomainClasses())
+		})
+		t.Run("invalid", func(t *testing.T) {
+			cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+			cfg.Annotations = map[string]string{
+				EmailDomainClassesAnnotationKey: `[{"name":"external","domains":["redhat.com"]}]`,
+			}
+			toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+			assert.Equal(t, metrics.DefaultDomainClasses, toolchainCfg.Metrics().DomainClasses())
+		})
+	})
 }
 
 func TestNotifications(t *testing.T) {
@@ -385,6 +468,38 @@ func TestNotifications(t *testing.T) {
 	})
 }
 
+func TestReactivation(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, ReactivationPolicyReset, toolchainCfg.Reactivation().Policy())
+		assert.Equal(t, 0, toolchainCfg.Reactivation().ManualApprovalThreshold())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			ReactivationPolicyAnnotationKey:                  "restore",
+			ReactivationManualApprovalThresholdAnnotationKey: "3",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, ReactivationPolicyRestore, toolchainCfg.Reactivation().Policy())
+		assert.Equal(t, 3, toolchainCfg.Reactivation().ManualApprovalThreshold())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			ReactivationPolicyAnnotationKey:                  "keep",
+			ReactivationManualApprovalThresholdAnnotationKey: "three",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, ReactivationPolicyReset, toolchainCfg.Reactivation().Policy())
+		assert.Equal(t, 0, toolchainCfg.Reactivation().ManualApprovalThreshold())
+	})
+}
+
 func TestRegistrationService(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
@@ -409,6 +524,80 @@ func TestRegistrationService(t *testing.T) {
 	})
 }
 
+func TestRiskScore(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 0, toolchainCfg.RiskScore().ManualApprovalThreshold())
+		assert.Equal(t, time.Hour, toolchainCfg.RiskScore().DomainVelocityWindow())
+		assert.Equal(t, 10, toolchainCfg.RiskScore().DomainVelocityThreshold())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			RiskScoreManualApprovalThresholdAnnotationKey: "60",
+			RiskScoreDomainVelocityWindowAnnotationKey:    "30m",
+			RiskScoreDomainVelocityThresholdAnnotationKey: "5",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 60, toolchainCfg.RiskScore().ManualApprovalThreshold())
+		assert.Equal(t, 30*time.Minute, toolchainCfg.RiskScore().DomainVelocityWindow())
+		assert.Equal(t, 5, toolchainCfg.RiskScore().DomainVelocityThreshold())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			RiskScoreManualApprovalThresholdAnnotationKey: "high",
+			RiskScoreDomainVelocityWindowAnnotationKey:    "long",
+			RiskScoreDomainVelocityThresholdAnnotationKey: "many",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 0, toolchainCfg.RiskScore().ManualApprovalThreshold())
+		assert.Equal(t, time.Hour, toolchainCfg.RiskScore().DomainVelocityWindow())
+		assert.Equal(t, 10, toolchainCfg.RiskScore().DomainVelocityThreshold())
+	})
+}
+
+func TestSpaces(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, time.Duration(0), toolchainCfg.Spaces().SoftDeletionPeriod())
+		assert.False(t, toolchainCfg.Spaces().ScaleDownOnSoftDeletion())
+		assert.Equal(t, 7*24*time.Hour, toolchainCfg.Spaces().InvitationExpiration())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			SpaceSoftDeletionPeriodAnnotationKey:    "72h",
+			SpaceSoftDeletionScaleDownAnnotationKey: "true",
+			SpaceInvitationExpirationAnnotationKey:  "48h",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 72*time.Hour, toolchainCfg.Spaces().SoftDeletionPeriod())
+		assert.True(t, toolchainCfg.Spaces().ScaleDownOnSoftDeletion())
+		assert.Equal(t, 48*time.Hour, toolchainCfg.Spaces().InvitationExpiration())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			SpaceSoftDeletionPeriodAnnotationKey:    "3 days",
+			SpaceSoftDeletionScaleDownAnnotationKey: "maybe",
+			SpaceInvitationExpirationAnnotationKey:  "2 days",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, time.Duration(0), toolchainCfg.Spaces().SoftDeletionPeriod())
+		assert.False(t, toolchainCfg.Spaces().ScaleDownOnSoftDeletion())
+		assert.Equal(t, 7*24*time.Hour, toolchainCfg.Spaces().InvitationExpiration())
+	})
+}
+
 func TestTiers(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
@@ -417,23 +606,77 @@ func TestTiers(t *testing.T) {
 		assert.Equal(t, "deactivate30", toolchainCfg.Tiers().DefaultUserTier())
 		assert.Equal(t, "base", toolchainCfg.Tiers().DefaultSpaceTier())
 		assert.Equal(t, 24*time.Hour, toolchainCfg.Tiers().DurationBeforeChangeTierRequestDeletion())
+		assert.Empty(t, toolchainCfg.Tiers().Source())
+		assert.Equal(t, time.Minute, toolchainCfg.Tiers().SourceSyncInterval())
 	})
 	t.Run("invalid", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Tiers().DurationBeforeChangeTierRequestDeletion("rapid"))
+		cfg.Annotations = map[string]string{
+			TierSourceSyncIntervalAnnotationKey: "often",
+		}
 		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
 
 		assert.Equal(t, 24*time.Hour, toolchainCfg.Tiers().DurationBeforeChangeTierRequestDeletion())
+		assert.Equal(t, time.Minute, toolchainCfg.Tiers().SourceSyncInterval())
 	})
 	t.Run("non-default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Tiers().
 			DefaultUserTier("deactivate90").
 			DefaultSpaceTier("advanced").
 			DurationBeforeChangeTierRequestDeletion("48h"))
+		cfg.Annotations = map[string]string{
+			TierSourceAnnotationKey:             "/var/run/tiers/tiers.tar.gz",
+			TierSourceSyncIntervalAnnotationKey: "5m",
+		}
 		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
 
 		assert.Equal(t, "deactivate90", toolchainCfg.Tiers().DefaultUserTier())
 		assert.Equal(t, "advanced", toolchainCfg.Tiers().DefaultSpaceTier())
 		assert.Equal(t, 48*time.Hour, toolchainCfg.Tiers().DurationBeforeChangeTierRequestDeletion())
+		assert.Equal(t, "/var/run/tiers/tiers.tar.gz", toolchainCfg.Tiers().Source())
+		assert.Equal(t, 5*time.Minute, toolchainCfg.Tiers().SourceSyncInterval())
+	})
+}
+
+func TestTierTemplateGC(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.False(t, toolchainCfg.TierTemplateGC().DryRun())
+		assert.Equal(t, 24*time.Hour, toolchainCfg.TierTemplateGC().GracePeriod())
+		assert.Equal(t, 7*24*time.Hour, toolchainCfg.TierTemplateGC().RetentionWindow())
+		assert.Equal(t, time.Hour, toolchainCfg.TierTemplateGC().Interval())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			TierTemplateGCDryRunAnnotationKey:          "true",
+			TierTemplateGCGracePeriodAnnotationKey:     "48h",
+			TierTemplateGCRetentionWindowAnnotationKey: "24h",
+			TierTemplateGCIntervalAnnotationKey:        "30m",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.True(t, toolchainCfg.TierTemplateGC().DryRun())
+		assert.Equal(t, 48*time.Hour, toolchainCfg.TierTemplateGC().GracePeriod())
+		assert.Equal(t, 24*time.Hour, toolchainCfg.TierTemplateGC().RetentionWindow())
+		assert.Equal(t, 30*time.Minute, toolchainCfg.TierTemplateGC().Interval())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			TierTemplateGCDryRunAnnotationKey:          "yes please",
+			TierTemplateGCGracePeriodAnnotationKey:     "2 days",
+			TierTemplateGCRetentionWindowAnnotationKey: "1 day",
+			TierTemplateGCIntervalAnnotationKey:        "hourly",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.False(t, toolchainCfg.TierTemplateGC().DryRun())
+		assert.Equal(t, 24*time.Hour, toolchainCfg.TierTemplateGC().GracePeriod())
+		assert.Equal(t, 7*24*time.Hour, toolchainCfg.TierTemplateGC().RetentionWindow())
+		assert.Equal(t, time.Hour, toolchainCfg.TierTemplateGC().Interval())
 	})
 }
 
@@ -459,6 +702,50 @@ func TestToolchainStatus(t *testing.T) {
 	})
 }
 
+func TestUserSignupArchive(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Empty(t, toolchainCfg.UserSignupArchive().Sink())
+		assert.Empty(t, toolchainCfg.UserSignupArchive().FilePath())
+		assert.Equal(t, "usersignup-archive", toolchainCfg.UserSignupArchive().ConfigMapName())
+		assert.Equal(t, 1000, toolchainCfg.UserSignupArchive().ConfigMapMaxRecords())
+		assert.Empty(t, toolchainCfg.UserSignupArchive().URL())
+		assert.Equal(t, 10*time.Second, toolchainCfg.UserSignupArchive().Timeout())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			UserSignupArchiveSinkAnnotationKey:                "http",
+			UserSignupArchiveFilePathAnnotationKey:            "/var/archive/usersignups.jsonl",
+			UserSignupArchiveConfigMapNameAnnotationKey:       "archive",
+			UserSignupArchiveConfigMapMaxRecordsAnnotationKey: "50",
+			UserSignupArchiveURLAnnotationKey:                 "https://archive.example.com",
+			UserSignupArchiveTimeoutAnnotationKey:             "30s",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, "http", toolchainCfg.UserSignupArchive().Sink())
+		assert.Equal(t, "/var/archive/usersignups.jsonl", toolchainCfg.UserSignupArchive().FilePath())
+		assert.Equal(t, "archive", toolchainCfg.UserSignupArchive().ConfigMapName())
+		assert.Equal(t, 50, toolchainCfg.UserSignupArchive().ConfigMapMaxRecords())
+		assert.Equal(t, "https://archive.example.com", toolchainCfg.UserSignupArchive().URL())
+		assert.Equal(t, 30*time.Second, toolchainCfg.UserSignupArchive().Timeout())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			UserSignupArchiveConfigMapMaxRecordsAnnotationKey: "many",
+			UserSignupArchiveTimeoutAnnotationKey:             "soon",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, 1000, toolchainCfg.UserSignupArchive().ConfigMapMaxRecords())
+		assert.Equal(t, 10*time.Second, toolchainCfg.UserSignupArchive().Timeout())
+	})
+}
+
 func TestUsers(t *testing.T) {
 	t.Run("default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
@@ -467,6 +754,9 @@ func TestUsers(t *testing.T) {
 		assert.Equal(t, 2, toolchainCfg.Users().MasterUserRecordUpdateFailureThreshold())
 		assert.Equal(t, []string{"openshift", "kube", "default", "redhat", "sandbox"}, toolchainCfg.Users().ForbiddenUsernamePrefixes())
 		assert.Equal(t, []string{"admin"}, toolchainCfg.Users().ForbiddenUsernameSuffixes())
+		assert.Empty(t, toolchainCfg.Users().ForbiddenUsernameWords())
+		assert.Equal(t, time.Duration(0), toolchainCfg.Users().UsernameReuseCoolDown())
+		assert.Equal(t, 15*time.Minute, toolchainCfg.Users().RenameProvisioningTimeout())
 	})
 	t.Run("non-default", func(t *testing.T) {
 		cfg := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.Users().MasterUserRecordUpdateFailureThreshold(10).ForbiddenUsernamePrefixes("bread,butter").ForbiddenUsernameSuffixes("sugar,cream"))
@@ -476,4 +766,91 @@ func TestUsers(t *testing.T) {
 		assert.Equal(t, []string{"bread", "butter"}, toolchainCfg.Users().ForbiddenUsernamePrefixes())
 		assert.Equal(t, []string{"sugar", "cream"}, toolchainCfg.Users().ForbiddenUsernameSuffixes())
 	})
+	t.Run("non-default annotations", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			UsernameForbiddenWordsAnnotationKey:    "foo, bar",
+			UsernameReuseCoolDownAnnotationKey:     "720h",
+			RenameProvisioningTimeoutAnnotationKey: "30m",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, []string{"foo", "bar"}, toolchainCfg.Users().ForbiddenUsernameWords())
+		assert.Equal(t, 720*time.Hour, toolchainCfg.Users().UsernameReuseCoolDown())
+		assert.Equal(t, 30*time.Minute, toolchainCfg.Users().RenameProvisioningTimeout())
+	})
+	t.Run("invalid annotations", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			UsernameReuseCoolDownAnnotationKey:     "a month",
+			RenameProvisioningTimeoutAnnotationKey: "half an hour",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, time.Duration(0), toolchainCfg.Users().UsernameReuseCoolDown())
+		assert.Equal(t, 15*time.Minute, toolchainCfg.Users().RenameProvisioningTimeout())
+	})
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.False(t, toolchainCfg.Webhooks().IsEnabled())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			WebhooksEnabledAnnotationKey: "true",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.True(t, toolchainCfg.Webhooks().IsEnabled())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			WebhooksEnabledAnnotationKey: "on",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.False(t, toolchainCfg.Webhooks().IsEnabled())
+	})
+}
+
+func TestTracing(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, "none", toolchainCfg.Tracing().Exporter())
+		assert.Empty(t, toolchainCfg.Tracing().FilePath())
+		assert.Empty(t, toolchainCfg.Tracing().OTLPEndpoint())
+		assert.False(t, toolchainCfg.Tracing().OTLPInsecure())
+	})
+	t.Run("non-default", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			TracingExporterAnnotationKey:     "otlp",
+			TracingFilePathAnnotationKey:     "/tmp/spans.json",
+			TracingOTLPEndpointAnnotationKey: "otel-collector:4318",
+			TracingOTLPInsecureAnnotationKey: "true",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.Equal(t, "otlp", toolchainCfg.Tracing().Exporter())
+		assert.Equal(t, "/tmp/spans.json", toolchainCfg.Tracing().FilePath())
+		assert.Equal(t, "otel-collector:4318", toolchainCfg.Tracing().OTLPEndpoint())
+		assert.True(t, toolchainCfg.Tracing().OTLPInsecure())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		cfg := commonconfig.NewToolchainConfigObjWithReset(t)
+		cfg.Annotations = map[string]string{
+			TracingOTLPInsecureAnnotationKey: "maybe",
+		}
+		toolchainCfg := newToolchainConfig(cfg, map[string]map[string]string{})
+
+		assert.False(t, toolchainCfg.Tracing().OTLPInsecure())
+	})
 }
@@ -20,6 +20,8 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/registrationservice"
 	"github.com/codeready-toolchain/host-operator/version"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
@@ -118,6 +120,14 @@ type Reconciler struct {
 	GetMembersFunc cluster.GetMemberClustersFunc
 	HTTPClientImpl HTTPClient
 	Namespace      string
+	// EventRecorder records the events of the changes of readiness of the toolchain
+	EventRecorder *events.Recorder
+}
+
+// toolchainStatusEvents the type of the events recorded when the `Ready` condition of the ToolchainStatus is set with the following reasons
+var toolchainStatusEvents = events.ReasonTypes{
+	toolchainv1alpha1.ToolchainStatusAllComponentsReadyReason: corev1.EventTypeNormal,
+	toolchainv1alpha1.ToolchainStatusComponentsNotReadyReason: corev1.EventTypeWarning,
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=toolchainstatuses,verbs=get;list;watch;create;update;patch;delete
@@ -651,10 +661,14 @@ func compareAndAssignMemberStatuses(logger logr.Logger, toolchainStatus *toolcha
 // the last updated timestamp was not updated.
 func (r *Reconciler) updateStatusConditions(logger logr.Logger, status *toolchainv1alpha1.ToolchainStatus,
 	newConditions ...toolchainv1alpha1.Condition) error {
+	previous := append([]toolchainv1alpha1.Condition{}, status.Status.Conditions...)
 	status.Status.Conditions = condition.AddOrUpdateStatusConditionsWithLastUpdatedTimestamp(status.Status.Conditions, newConditions...)
 	logger.Info("updating ToolchainStatus status conditions", "resource_version", status.ResourceVersion)
 	err := r.Client.Status().Update(context.TODO(), status)
 	logger.Info("updated ToolchainStatus status conditions", "resource_version", status.ResourceVersion)
+	if err == nil {
+		r.EventRecorder.Transitions(status, previous, toolchainStatusEvents, newConditions...)
+	}
 	return err
 }
 
@@ -668,6 +682,8 @@ func (r *Reconciler) wrapErrorWithStatusUpdate(logger logr.Logger, toolchainStat
 	if err := statusUpdater(logger, toolchainStatus, err.Error()); err != nil {
 		logger.Error(err, "Error updating ToolchainStatus status")
 	}
+	metrics.IncrementControllerFailures("toolchainstatus", toolchainStatus.Status.Conditions, err.Error())
+	r.EventRecorder.Failure(toolchainStatus, toolchainStatus.Status.Conditions, err.Error())
 	return errs.Wrapf(err, format, args...)
 }
 
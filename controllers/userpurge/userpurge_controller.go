@@ -0,0 +1,450 @@
+package userpurge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// PurgeRequestLabelKey the label of the ConfigMaps which are requests to purge all the data of a user
+	// (aka "right to be forgotten"). The value of the label is ignored.
+	PurgeRequestLabelKey = toolchainv1alpha1.LabelKeyPrefix + "purge-request"
+
+	// PurgeStatusAnnotationKey the annotation on the purge request which contains the status of the purge, as JSON
+	PurgeStatusAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "purge-status"
+)
+
+// The keys of the data of the purge request. Exactly one of `usersignup`, `username` or `email` must be set.
+const (
+	// UserSignupNameKey the name of the UserSignup of the user to purge
+	UserSignupNameKey = "usersignup"
+	// UsernameKey the username (or compliant username) of the user to purge
+	UsernameKey = "username"
+	// EmailKey the email address of the user to purge
+	EmailKey = "email"
+	// KeepBannedUserKey when `true`, the BannedUsers of the user are kept, with their email address replaced by its hash,
+	// so that the user remains banned. Otherwise, they are deleted.
+	KeepBannedUserKey = "keepBannedUser"
+)
+
+// The phases of the purge
+const (
+	PhaseInProgress = "InProgress"
+	PhaseCompleted  = "Completed"
+	PhaseFailed     = "Failed"
+)
+
+// The status of each step of the purge
+const (
+	StepPending    = "Pending"
+	StepInProgress = "InProgress"
+	StepCompleted  = "Completed"
+)
+
+// The steps of the purge, in the order in which they are executed
+const (
+	StepDeleteUserSignup       = "DeleteUserSignup"
+	StepDeleteMasterUserRecord = "DeleteMasterUserRecord"
+	StepDeleteSpaces           = "DeleteSpaces"
+	StepDeleteSpaceBindings    = "DeleteSpaceBindings"
+	StepDeleteNotifications    = "DeleteNotifications"
+	StepWaitForMemberClusters  = "WaitForMemberClusters"
+	StepPurgeBannedUsers       = "PurgeBannedUsers"
+)
+
+// the interval between two checks while a step is in progress (eg, waiting for the cleanup in the member clusters)
+const stepInProgressRequeueInterval = 5 * time.Second
+
+// Status the status of a purge request
+type Status struct {
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+	// UserSignup the name of the UserSignup of the purged user
+	UserSignup string `json:"userSignup,omitempty"`
+	// CompliantUsername the compliant username of the purged user (removed once the purge is completed)
+	CompliantUsername string `json:"compliantUsername,omitempty"`
+	// EmailHash the hash of the email address of the purged user
+	EmailHash      string       `json:"emailHash,omitempty"`
+	Steps          []Step       `json:"steps,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// Step the status of a step of the purge
+type Step struct {
+	Name    string       `json:"name"`
+	Status  string       `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Time    *metav1.Time `json:"time,omitempty"`
+}
+
+// stepFunc executes a step of the purge and returns `true` once the step is completed, along with a message
+type stepFunc func(logger logr.Logger, request *corev1.ConfigMap, status *Status) (bool, string, error)
+
+// Reconciler purges all the data of a user: UserSignup, MasterUserRecord, Spaces, SpaceBindings, Notifications
+// and (optionally) BannedUsers
+type Reconciler struct {
+	Client         client.Client
+	Namespace      string
+	MemberClusters map[string]cluster.Cluster
+	// EventRecorder records the events of the start, completion and failure of the purges. The events do not contain
+	// any identifier of the user other than the name of the UserSignup.
+	EventRecorder *events.Recorder
+}
+
+// SetupWithManager sets up the controller reconciler with the Manager
+// Watches the ConfigMaps with the `toolchain.dev.openshift.com/purge-request` label
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("userpurge").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(isPurgeRequest))).
+		Complete(r)
+}
+
+func isPurgeRequest(obj client.Object) bool {
+	_, found := obj.GetLabels()[PurgeRequestLabelKey]
+	return found
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=usersignups,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=notifications,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=bannedusers,verbs=get;list;watch;update;delete
+
+// Reconcile executes the steps of the purge request in order, and records their outcome in the status of the request.
+// Once all the steps are completed, the identifiers of the user are removed from the request, so that only the name
+// of the UserSignup and the hash of the email address remain as a proof of completion.
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	purgeRequest := &corev1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: request.Name}, purgeRequest); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("purge request not found")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errs.Wrap(err, "unable to get the purge request")
+	}
+	if !isPurgeRequest(purgeRequest) || purgeRequest.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	status, err := GetStatus(purgeRequest)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if status.Phase == PhaseCompleted || status.Phase == PhaseFailed {
+		return reconcile.Result{}, nil
+	}
+
+	if status.UserSignup == "" {
+		if err := r.resolveUser(purgeRequest, status); err != nil {
+			logger.Error(err, "unable to resolve the user to purge")
+			status.Phase = PhaseFailed
+			status.Message = err.Error()
+			if err := r.updateStatus(purgeRequest, status); err != nil {
+				return reconcile.Result{}, err
+			}
+			r.EventRecorder.Warning(purgeRequest, PhaseFailed, status.Message)
+			return reconcile.Result{}, nil
+		}
+		logger.Info("purging user", "usersignup", status.UserSignup)
+		status.Phase = PhaseInProgress
+		for _, name := range []string{StepDeleteUserSignup, StepDeleteMasterUserRecord, StepDeleteSpaces, StepDeleteSpaceBindings,
+			StepDeleteNotifications, StepWaitForMemberClusters, StepPurgeBannedUsers} {
+			status.Steps = append(status.Steps, Step{Name: name, Status: StepPending})
+		}
+		if err := r.updateStatus(purgeRequest, status); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.EventRecorder.Normal(purgeRequest, PhaseInProgress, "purging the user of the UserSignup '%s'", status.UserSignup)
+	}
+
+	steps := map[string]stepFunc{
+		StepDeleteUserSignup:       r.deleteUserSignup,
+		StepDeleteMasterUserRecord: r.deleteMasterUserRecord,
+		StepDeleteSpaces:           r.deleteSpaces,
+		StepDeleteSpaceBindings:    r.deleteSpaceBindings,
+		StepDeleteNotifications:    r.deleteNotifications,
+		StepWaitForMemberClusters:  r.waitForMemberClusters,
+		StepPurgeBannedUsers:       r.purgeBannedUsers,
+	}
+	for i := range status.Steps {
+		step := &status.Steps[i]
+		if step.Status == StepCompleted {
+			continue
+		}
+		done, msg, err := steps[step.Name](logger, purgeRequest, status)
+		if err != nil {
+			step.Status = StepInProgress
+			step.Message = err.Error()
+			if updateErr := r.updateStatus(purgeRequest, status); updateErr != nil {
+				logger.Error(updateErr, "unable to update the status of the purge request")
+			}
+			return reconcile.Result{}, errs.Wrapf(err, "unable to execute step '%s' of the purge", step.Name)
+		}
+		now := metav1.Now()
+		step.Message = msg
+		step.Time = &now
+		if !done {
+			step.Status = StepInProgress
+			logger.Info("purge step in progress", "step", step.Name, "message", msg)
+			return reconcile.Result{RequeueAfter: stepInProgressRequeueInterval}, r.updateStatus(purgeRequest, status)
+		}
+		logger.Info("purge step completed", "step", step.Name, "message", msg)
+		step.Status = StepCompleted
+		if err := r.updateStatus(purgeRequest, status); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// all steps are completed: remove the identifiers of the user from the request
+	now := metav1.Now()
+	status.Phase = PhaseCompleted
+	status.CompletionTime = &now
+	status.CompliantUsername = ""
+	delete(purgeRequest.Data, UsernameKey)
+	delete(purgeRequest.Data, EmailKey)
+	logger.Info("user purged", "usersignup", status.UserSignup)
+	if err := r.updateStatus(purgeRequest, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.EventRecorder.Normal(purgeRequest, PhaseCompleted, "the user of the UserSignup '%s' was purged", status.UserSignup)
+	return reconcile.Result{}, nil
+}
+
+// resolveUser looks-up the UserSignup matching the identifier in the purge request and records it in the status
+func (r *Reconciler) resolveUser(purgeRequest *corev1.ConfigMap, status *Status) error {
+	listOpts := []client.ListOption{client.InNamespace(r.Namespace)}
+	var match func(toolchainv1alpha1.UserSignup) bool
+	switch {
+	case purgeRequest.Data[UserSignupNameKey] != "":
+		match = func(us toolchainv1alpha1.UserSignup) bool {
+			return us.Name == purgeRequest.Data[UserSignupNameKey]
+		}
+	case purgeRequest.Data[UsernameKey] != "":
+		match = func(us toolchainv1alpha1.UserSignup) bool {
+			return us.Spec.Username == purgeRequest.Data[UsernameKey] || us.Status.CompliantUsername == purgeRequest.Data[UsernameKey]
+		}
+	case purgeRequest.Data[EmailKey] != "":
+		match = func(us toolchainv1alpha1.UserSignup) bool {
+			return us.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey] == purgeRequest.Data[EmailKey]
+		}
+		listOpts = append(listOpts, client.MatchingLabels{toolchainv1alpha1.UserSignupUserEmailHashLabelKey: hash.EncodeString(purgeRequest.Data[EmailKey])})
+	default:
+		return fmt.Errorf("one of '%s', '%s' or '%s' must be specified", UserSignupNameKey, UsernameKey, EmailKey)
+	}
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := r.Client.List(context.TODO(), userSignups, listOpts...); err != nil {
+		return errs.Wrap(err, "unable to list the UserSignups")
+	}
+	for _, us := range userSignups.Items {
+		if match(us) {
+			status.UserSignup = us.Name
+			status.CompliantUsername = us.Status.CompliantUsername
+			status.EmailHash = us.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey]
+			if email, found := us.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]; found && status.EmailHash == "" {
+				status.EmailHash = hash.EncodeString(email)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("no UserSignup matches the purge request")
+}
+
+func (r *Reconciler) deleteUserSignup(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.UserSignup}, userSignup); err != nil {
+		if errors.IsNotFound(err) {
+			return true, "UserSignup deleted", nil
+		}
+		return false, "", err
+	}
+	if err := r.Client.Delete(context.TODO(), userSignup); err != nil && !errors.IsNotFound(err) {
+		return false, "", err
+	}
+	return true, "UserSignup deleted", nil
+}
+
+func (r *Reconciler) deleteMasterUserRecord(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	if status.CompliantUsername == "" {
+		return true, "no MasterUserRecord was provisioned", nil
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.CompliantUsername}, mur); err != nil {
+		if errors.IsNotFound(err) {
+			return true, "MasterUserRecord deleted", nil
+		}
+		return false, "", err
+	}
+	if err := r.Client.Delete(context.TODO(), mur); err != nil && !errors.IsNotFound(err) {
+		return false, "", err
+	}
+	return true, "MasterUserRecord deleted", nil
+}
+
+func (r *Reconciler) deleteSpaces(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	spaces := &toolchainv1alpha1.SpaceList{}
+	if err := r.Client.List(context.TODO(), spaces, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceCreatorLabelKey: status.UserSignup}); err != nil {
+		return false, "", err
+	}
+	for i := range spaces.Items {
+		if err := r.Client.Delete(context.TODO(), &spaces.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return false, "", err
+		}
+	}
+	return true, fmt.Sprintf("%d Space(s) deleted", len(spaces.Items)), nil
+}
+
+func (r *Reconciler) deleteSpaceBindings(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	if status.CompliantUsername == "" {
+		return true, "no SpaceBinding was provisioned", nil
+	}
+	spaceBindings := &toolchainv1alpha1.SpaceBindingList{}
+	if err := r.Client.List(context.TODO(), spaceBindings, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey: status.CompliantUsername}); err != nil {
+		return false, "", err
+	}
+	for i := range spaceBindings.Items {
+		if err := r.Client.Delete(context.TODO(), &spaceBindings.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return false, "", err
+		}
+	}
+	return true, fmt.Sprintf("%d SpaceBinding(s) deleted", len(spaceBindings.Items)), nil
+}
+
+func (r *Reconciler) deleteNotifications(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	if status.CompliantUsername == "" {
+		return true, "no Notification was sent", nil
+	}
+	notifications := &toolchainv1alpha1.NotificationList{}
+	if err := r.Client.List(context.TODO(), notifications, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.NotificationUserNameLabelKey: status.CompliantUsername}); err != nil {
+		return false, "", err
+	}
+	for i := range notifications.Items {
+		if err := r.Client.Delete(context.TODO(), &notifications.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return false, "", err
+		}
+	}
+	return true, fmt.Sprintf("%d Notification(s) deleted", len(notifications.Items)), nil
+}
+
+// waitForMemberClusters waits until the MasterUserRecord and the Spaces are gone, ie, until their finalizers were removed
+// once the UserAccounts and the NSTemplateSets were deleted in the member clusters
+func (r *Reconciler) waitForMemberClusters(_ logr.Logger, _ *corev1.ConfigMap, status *Status) (bool, string, error) {
+	spaces := &toolchainv1alpha1.SpaceList{}
+	if err := r.Client.List(context.TODO(), spaces, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.SpaceCreatorLabelKey: status.UserSignup}); err != nil {
+		return false, "", err
+	}
+	if len(spaces.Items) > 0 {
+		return false, fmt.Sprintf("waiting for the deletion of %d Space(s)", len(spaces.Items)), nil
+	}
+	if status.CompliantUsername == "" {
+		return true, "member clusters cleaned up", nil
+	}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.CompliantUsername}, &toolchainv1alpha1.MasterUserRecord{}); err == nil {
+		return false, "waiting for the deletion of the MasterUserRecord", nil
+	} else if !errors.IsNotFound(err) {
+		return false, "", err
+	}
+	for name, memberCluster := range r.MemberClusters {
+		key := types.NamespacedName{Namespace: memberCluster.OperatorNamespace, Name: status.CompliantUsername}
+		if err := memberCluster.Client.Get(context.TODO(), key, &toolchainv1alpha1.UserAccount{}); err == nil {
+			return false, fmt.Sprintf("waiting for the deletion of the UserAccount in the member cluster '%s'", name), nil
+		} else if !errors.IsNotFound(err) {
+			return false, "", errs.Wrapf(err, "unable to get the UserAccount in the member cluster '%s'", name)
+		}
+		if err := memberCluster.Client.Get(context.TODO(), key, &toolchainv1alpha1.NSTemplateSet{}); err == nil {
+			return false, fmt.Sprintf("waiting for the deletion of the NSTemplateSet in the member cluster '%s'", name), nil
+		} else if !errors.IsNotFound(err) {
+			return false, "", errs.Wrapf(err, "unable to get the NSTemplateSet in the member cluster '%s'", name)
+		}
+	}
+	return true, "member clusters cleaned up", nil
+}
+
+// purgeBannedUsers deletes the BannedUsers of the user, or only replaces their email address with its hash if they should be kept
+func (r *Reconciler) purgeBannedUsers(_ logr.Logger, purgeRequest *corev1.ConfigMap, status *Status) (bool, string, error) {
+	if status.EmailHash == "" {
+		return true, "no BannedUser found", nil
+	}
+	keep, _ := strconv.ParseBool(purgeRequest.Data[KeepBannedUserKey])
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	if err := r.Client.List(context.TODO(), bannedUsers, client.InNamespace(r.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.BannedUserEmailHashLabelKey: status.EmailHash}); err != nil {
+		return false, "", err
+	}
+	for i := range bannedUsers.Items {
+		bannedUser := &bannedUsers.Items[i]
+		if !keep {
+			if err := r.Client.Delete(context.TODO(), bannedUser); err != nil && !errors.IsNotFound(err) {
+				return false, "", err
+			}
+			continue
+		}
+		if bannedUser.Spec.Email == status.EmailHash {
+			continue
+		}
+		bannedUser.Spec.Email = status.EmailHash
+		if err := r.Client.Update(context.TODO(), bannedUser); err != nil {
+			return false, "", err
+		}
+	}
+	if keep {
+		return true, fmt.Sprintf("%d BannedUser(s) anonymised", len(bannedUsers.Items)), nil
+	}
+	return true, fmt.Sprintf("%d BannedUser(s) deleted", len(bannedUsers.Items)), nil
+}
+
+// GetStatus returns the status of the given purge request
+func GetStatus(purgeRequest *corev1.ConfigMap) (*Status, error) {
+	status := &Status{}
+	value, found := purgeRequest.Annotations[PurgeStatusAnnotationKey]
+	if !found {
+		return status, nil
+	}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		return nil, errs.Wrapf(err, "invalid status of the purge request '%s'", purgeRequest.Name)
+	}
+	return status, nil
+}
+
+func (r *Reconciler) updateStatus(purgeRequest *corev1.ConfigMap, status *Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if purgeRequest.Annotations == nil {
+		purgeRequest.Annotations = map[string]string{}
+	}
+	purgeRequest.Annotations[PurgeStatusAnnotationKey] = string(value)
+	if err := r.Client.Update(context.TODO(), purgeRequest); err != nil {
+		return errs.Wrap(err, "unable to update the status of the purge request")
+	}
+	return nil
+}
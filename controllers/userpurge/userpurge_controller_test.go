@@ -0,0 +1,310 @@
+package userpurge_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/userpurge"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	commoncluster "github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestPurgeUser(t *testing.T) {
+	newUserSignup := func() *toolchainv1alpha1.UserSignup {
+		userSignup := commonsignup.NewUserSignup(commonsignup.WithName("johnsmith"))
+		userSignup.Status.CompliantUsername = "johnsmith"
+		return userSignup
+	}
+	newBannedUser := func(userSignup *toolchainv1alpha1.UserSignup) *toolchainv1alpha1.BannedUser {
+		return &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned-johnsmith",
+				Namespace: test.HostOperatorNs,
+				Labels: map[string]string{
+					toolchainv1alpha1.BannedUserEmailHashLabelKey: userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey],
+				},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{
+				Email: userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey],
+			},
+		}
+	}
+	newUserObjects := func(userSignup *toolchainv1alpha1.UserSignup) []runtime.Object {
+		return []runtime.Object{
+			userSignup,
+			murtest.NewMasterUserRecord(t, "johnsmith", murtest.MetaNamespace(test.HostOperatorNs), murtest.WithOwnerLabel(userSignup.Name),
+				murtest.Finalizer("finalizer.toolchain.dev.openshift.com")),
+			spacetest.NewSpace("johnsmith", spacetest.WithCreatorLabel(userSignup.Name)),
+			spacetest.NewSpace("johnsmith-2", spacetest.WithCreatorLabel(userSignup.Name)),
+			spacetest.NewSpace("other", spacetest.WithCreatorLabel("other")),
+			spacebindingtest.NewSpaceBinding("johnsmith", "johnsmith", "admin", userSignup.Name),
+			spacebindingtest.NewSpaceBinding("johnsmith", "other", "viewer", "other"),
+			spacebindingtest.NewSpaceBinding("other", "other", "admin", "other"),
+			newNotification("johnsmith-deactivated", "johnsmith"),
+			newNotification("other-deactivated", "other"),
+			newBannedUser(userSignup),
+		}
+	}
+
+	t.Run("purge by email and keep the BannedUser", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup()
+		purgeRequest := newPurgeRequest(map[string]string{
+			userpurge.EmailKey:          "foo@redhat.com",
+			userpurge.KeepBannedUserKey: "true",
+		})
+		memberClient := test.NewFakeClient(t, &toolchainv1alpha1.UserAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "johnsmith", Namespace: test.MemberOperatorNs},
+		})
+		r, req, cl := prepareReconcile(t, memberClient, append(newUserObjects(userSignup), purgeRequest)...)
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then the resources are deleted, but the MasterUserRecord and the UserAccount still exist
+		require.NoError(t, err)
+		assert.NotZero(t, res.RequeueAfter)
+		status := getStatus(t, cl)
+		assert.Equal(t, userpurge.PhaseInProgress, status.Phase)
+		assert.Equal(t, userSignup.Name, status.UserSignup)
+		assert.Equal(t, "johnsmith", status.CompliantUsername)
+		assert.Equal(t, userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey], status.EmailHash)
+		assertSteps(t, status, map[string]string{
+			userpurge.StepDeleteUserSignup:       userpurge.StepCompleted,
+			userpurge.StepDeleteMasterUserRecord: userpurge.StepCompleted,
+			userpurge.StepDeleteSpaces:           userpurge.StepCompleted,
+			userpurge.StepDeleteSpaceBindings:    userpurge.StepCompleted,
+			userpurge.StepDeleteNotifications:    userpurge.StepCompleted,
+			userpurge.StepWaitForMemberClusters:  userpurge.StepInProgress,
+			userpurge.StepPurgeBannedUsers:       userpurge.StepPending,
+		})
+		assert.Equal(t, "waiting for the deletion of the MasterUserRecord", status.Steps[5].Message)
+		assertNotFound(t, cl, userSignup.Name, &toolchainv1alpha1.UserSignup{})
+		spacetest.AssertThatSpaces(t, cl).HaveCount(1)
+		spacebindingtest.AssertThatSpaceBindings(t, cl).HaveCount(1)
+		assertNotFound(t, cl, "johnsmith-deactivated", &toolchainv1alpha1.Notification{})
+		require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "other-deactivated"), &toolchainv1alpha1.Notification{}))
+
+		t.Run("waiting for the UserAccount in the member cluster", func(t *testing.T) {
+			// given the MasterUserRecord finalizer was removed
+			mur := &toolchainv1alpha1.MasterUserRecord{}
+			require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "johnsmith"), mur))
+			mur.Finalizers = nil
+			require.NoError(t, cl.Update(context.TODO(), mur))
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			status := getStatus(t, cl)
+			assert.Equal(t, userpurge.PhaseInProgress, status.Phase)
+			assert.Equal(t, "waiting for the deletion of the UserAccount in the member cluster 'member-1'", status.Steps[5].Message)
+
+			t.Run("completed", func(t *testing.T) {
+				// given
+				require.NoError(t, memberClient.Delete(context.TODO(), &toolchainv1alpha1.UserAccount{
+					ObjectMeta: metav1.ObjectMeta{Name: "johnsmith", Namespace: test.MemberOperatorNs},
+				}))
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				status := getStatus(t, cl)
+				assert.Equal(t, userpurge.PhaseCompleted, status.Phase)
+				assert.NotNil(t, status.CompletionTime)
+				assert.Empty(t, status.CompliantUsername)
+				assert.Equal(t, userSignup.Name, status.UserSignup)
+				assertSteps(t, status, map[string]string{
+					userpurge.StepWaitForMemberClusters: userpurge.StepCompleted,
+					userpurge.StepPurgeBannedUsers:      userpurge.StepCompleted,
+				})
+				// the BannedUser only retains the hash of the email address
+				bannedUser := &toolchainv1alpha1.BannedUser{}
+				require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "banned-johnsmith"), bannedUser))
+				assert.Equal(t, status.EmailHash, bannedUser.Spec.Email)
+				// the identifiers of the user were removed from the request
+				purgeRequest := &corev1.ConfigMap{}
+				require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "purge-request"), purgeRequest))
+				assert.NotContains(t, purgeRequest.Data, userpurge.EmailKey)
+			})
+		})
+	})
+
+	t.Run("purge by username and delete the BannedUser", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup()
+		purgeRequest := newPurgeRequest(map[string]string{
+			userpurge.UsernameKey: "johnsmith",
+		})
+		objs := append(newUserObjects(userSignup)[2:], userSignup, purgeRequest) // without the MasterUserRecord
+		r, req, cl := prepareReconcile(t, test.NewFakeClient(t), objs...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		status := getStatus(t, cl)
+		assert.Equal(t, userpurge.PhaseCompleted, status.Phase)
+		assert.Equal(t, "1 BannedUser(s) deleted", status.Steps[6].Message)
+		assertNotFound(t, cl, "banned-johnsmith", &toolchainv1alpha1.BannedUser{})
+		assertNotFound(t, cl, userSignup.Name, &toolchainv1alpha1.UserSignup{})
+		require.Len(t, fakeRecorder.Events, 2)
+		assert.Equal(t, fmt.Sprintf("Normal InProgress purging the user of the UserSignup '%s'", userSignup.Name), <-fakeRecorder.Events)
+		assert.Equal(t, fmt.Sprintf("Normal Completed the user of the UserSignup '%s' was purged", userSignup.Name), <-fakeRecorder.Events)
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("no identifier", func(t *testing.T) {
+			// given
+			r, req, cl := prepareReconcile(t, test.NewFakeClient(t), newPurgeRequest(map[string]string{}))
+			fakeRecorder := record.NewFakeRecorder(10)
+			r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			status := getStatus(t, cl)
+			assert.Equal(t, userpurge.PhaseFailed, status.Phase)
+			assert.Equal(t, "one of 'usersignup', 'username' or 'email' must be specified", status.Message)
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, "Warning Failed one of 'usersignup', 'username' or 'email' must be specified", <-fakeRecorder.Events)
+		})
+
+		t.Run("no matching UserSignup", func(t *testing.T) {
+			// given
+			r, req, cl := prepareReconcile(t, test.NewFakeClient(t), newUserSignup(), newPurgeRequest(map[string]string{
+				userpurge.UserSignupNameKey: "unknown",
+			}))
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			status := getStatus(t, cl)
+			assert.Equal(t, userpurge.PhaseFailed, status.Phase)
+			assert.Equal(t, "no UserSignup matches the purge request", status.Message)
+		})
+
+		t.Run("step fails", func(t *testing.T) {
+			// given
+			userSignup := newUserSignup()
+			r, req, cl := prepareReconcile(t, test.NewFakeClient(t), append(newUserObjects(userSignup), newPurgeRequest(map[string]string{
+				userpurge.UserSignupNameKey: userSignup.Name,
+			}))...)
+			cl.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+				if _, ok := obj.(*toolchainv1alpha1.Space); ok {
+					return fmt.Errorf("mock error")
+				}
+				return cl.Client.Delete(ctx, obj, opts...)
+			}
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.EqualError(t, err, "unable to execute step 'DeleteSpaces' of the purge: mock error")
+			status := getStatus(t, cl)
+			assert.Equal(t, userpurge.PhaseInProgress, status.Phase)
+			assert.Equal(t, userpurge.StepInProgress, status.Steps[2].Status)
+			assert.Equal(t, "mock error", status.Steps[2].Message)
+		})
+	})
+}
+
+func newPurgeRequest(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "purge-request",
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				userpurge.PurgeRequestLabelKey: "",
+			},
+		},
+		Data: data,
+	}
+}
+
+func newNotification(name, username string) *toolchainv1alpha1.Notification {
+	return &toolchainv1alpha1.Notification{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				toolchainv1alpha1.NotificationUserNameLabelKey: username,
+			},
+		},
+	}
+}
+
+func getStatus(t *testing.T, cl client.Client) *userpurge.Status {
+	purgeRequest := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "purge-request"), purgeRequest))
+	status, err := userpurge.GetStatus(purgeRequest)
+	require.NoError(t, err)
+	return status
+}
+
+func assertSteps(t *testing.T, status *userpurge.Status, expected map[string]string) {
+	for _, step := range status.Steps {
+		if s, found := expected[step.Name]; found {
+			assert.Equal(t, s, step.Status, "unexpected status of step '%s'", step.Name)
+		}
+	}
+}
+
+func assertNotFound(t *testing.T, cl client.Client, name string, obj client.Object) {
+	err := cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, name), obj)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func prepareReconcile(t *testing.T, memberClient client.Client, initObjs ...runtime.Object) (*userpurge.Reconciler, reconcile.Request, *test.FakeClient) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	cl := test.NewFakeClient(t, initObjs...)
+	r := &userpurge.Reconciler{
+		Client:    cl,
+		Namespace: test.HostOperatorNs,
+		MemberClusters: map[string]cluster.Cluster{
+			"member-1": {
+				Config: &commoncluster.Config{
+					Type:              commoncluster.Member,
+					OperatorNamespace: test.MemberOperatorNs,
+				},
+				Client: memberClient,
+			},
+		},
+	}
+	return r, reconcile.Request{NamespacedName: test.NamespacedName(test.HostOperatorNs, "purge-request")}, cl
+}
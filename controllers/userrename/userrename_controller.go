@@ -0,0 +1,783 @@
+package userrename
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/codeready-toolchain/toolchain-common/pkg/spacebinding"
+	"github.com/codeready-toolchain/toolchain-common/pkg/states"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// RenameRequestLabelKey the label of the ConfigMaps which are requests to change the compliant username of a provisioned user.
+	// The value of the label is ignored.
+	RenameRequestLabelKey = toolchainv1alpha1.LabelKeyPrefix + "rename-request"
+
+	// RenameStatusAnnotationKey the annotation on the rename request which contains the status of the rename, as JSON
+	RenameStatusAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "rename-status"
+
+	// RenamedFromAnnotationKey the annotation on the MasterUserRecord, Space and SpaceBindings created by a rename, with the
+	// previous username of the user
+	RenamedFromAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "renamed-from"
+)
+
+// The keys of the data of the rename request
+const (
+	// UserSignupNameKey the name of the UserSignup of the user to rename (required)
+	UserSignupNameKey = "usersignup"
+	// UsernameKey the new compliant username of the user. When not set, the username is derived from the (new) username
+	// of the UserSignup, as for a new user.
+	UsernameKey = "username"
+	// RollbackKey when `true`, the rename is rolled back. A rename can be rolled back until the MasterUserRecord, Space
+	// and SpaceBindings with the previous username start to be deleted.
+	RollbackKey = "rollback"
+	// AcknowledgeDataLossKey must be set to `true` for the resources with the previous username to be deleted, when the user
+	// has a Space: deleting the previous Space deletes its namespaces in the member cluster, along with all their content.
+	// Until then, the user is switched to the new Space but keeps access to the previous namespaces, so that their content
+	// can be migrated, and the rename can still be rolled back.
+	AcknowledgeDataLossKey = "acknowledgeDataLoss"
+)
+
+// The phases of the rename
+const (
+	PhaseInProgress  = "InProgress"
+	PhaseCompleted   = "Completed"
+	PhaseFailed      = "Failed"
+	PhaseRollingBack = "RollingBack"
+	PhaseRolledBack  = "RolledBack"
+)
+
+// The status of each step of the rename
+const (
+	StepPending    = "Pending"
+	StepInProgress = "InProgress"
+	StepCompleted  = "Completed"
+	StepRolledBack = "RolledBack"
+)
+
+// The steps of the rename, in the order in which they are executed. The resources with the new username are created
+// and provisioned before the user is switched to them, and the resources with the previous username are deleted last.
+const (
+	StepReserveUsername                = "ReserveUsername"
+	StepCreateMasterUserRecord         = "CreateMasterUserRecord"
+	StepCreateSpace                    = "CreateSpace"
+	StepCreateSpaceBindings            = "CreateSpaceBindings"
+	StepWaitForProvisioning            = "WaitForProvisioning"
+	StepSwitchUserSignup               = "SwitchUserSignup"
+	StepDeletePreviousSpaceBindings    = "DeletePreviousSpaceBindings"
+	StepDeletePreviousSpace            = "DeletePreviousSpace"
+	StepDeletePreviousMasterUserRecord = "DeletePreviousMasterUserRecord"
+)
+
+// the first step which cannot be rolled back
+const pointOfNoReturn = StepDeletePreviousSpaceBindings
+
+// the interval between two checks while a step is in progress (eg, waiting for the provisioning in the member cluster)
+const stepInProgressRequeueInterval = 5 * time.Second
+
+// Status the status of a rename request
+type Status struct {
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
+	// UserSignup the name of the UserSignup of the renamed user
+	UserSignup string `json:"userSignup,omitempty"`
+	// PreviousUsername the compliant username of the user before the rename
+	PreviousUsername string `json:"previousUsername,omitempty"`
+	// Username the new compliant username of the user
+	Username string `json:"username,omitempty"`
+	// UsernameWasReserved whether the new username was already reserved for the user before the rename (eg, when the user
+	// is renamed back to one of their previous usernames), in which case it remains reserved if the rename is rolled back
+	UsernameWasReserved bool         `json:"usernameWasReserved,omitempty"`
+	Steps               []Step       `json:"steps,omitempty"`
+	StartTime           *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime      *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// Step the status of a step of the rename
+type Step struct {
+	Name    string       `json:"name"`
+	Status  string       `json:"status"`
+	Message string       `json:"message,omitempty"`
+	Time    *metav1.Time `json:"time,omitempty"`
+}
+
+// stepFunc executes (or rolls back) a step of the rename and returns `true` once the step is completed, along with a message
+type stepFunc func(logger logr.Logger, status *Status) (bool, string, error)
+
+// Reconciler renames provisioned users: a MasterUserRecord, a Space and SpaceBindings are created with the new username,
+// the user is switched to them once they are provisioned, and the resources with the previous username are deleted.
+// Since the namespaces of a Space are named after it, the NSTemplateSet is recreated in the member cluster, with new
+// namespaces: the content of the previous namespaces is not migrated, and it is deleted along with the previous Space
+// only once the data loss is acknowledged in the rename request (see `AcknowledgeDataLossKey`).
+// The previous username remains reserved for the user, so that it is not allocated to another user.
+type Reconciler struct {
+	Client            client.Client
+	Namespace         string
+	UsernameAllocator *username.Allocator
+	// EventRecorder records the events of the start, completion, failure and rollback of the renames
+	EventRecorder *events.Recorder
+}
+
+// SetupWithManager sets up the controller reconciler with the Manager
+// Watches the ConfigMaps with the `toolchain.dev.openshift.com/rename-request` label
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("userrename").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(predicate.NewPredicateFuncs(isRenameRequest))).
+		Complete(r)
+}
+
+func isRenameRequest(obj client.Object) bool {
+	_, found := obj.GetLabels()[RenameRequestLabelKey]
+	return found
+}
+
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=usersignups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=usersignups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spaces,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=get;list;watch;create;delete
+
+// Reconcile executes the steps of the rename request in order, and records their outcome in the status of the request.
+// When a rollback is requested (or when the provisioning times out), the steps which were executed are rolled back
+// in the reverse order.
+func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	renameRequest := &corev1.ConfigMap{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: request.Name}, renameRequest); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("rename request not found")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errs.Wrap(err, "unable to get the rename request")
+	}
+	if !isRenameRequest(renameRequest) || renameRequest.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	status, err := GetStatus(renameRequest)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	switch status.Phase {
+	case PhaseCompleted, PhaseFailed, PhaseRolledBack:
+		return reconcile.Result{}, nil
+	case PhaseRollingBack:
+		return r.rollback(logger, renameRequest, status)
+	}
+
+	if status.UserSignup == "" {
+		if err := r.resolveRename(renameRequest, status); err != nil {
+			logger.Error(err, "unable to resolve the rename")
+			status.Phase = PhaseFailed
+			status.Message = err.Error()
+			if err := r.updateStatus(renameRequest, status); err != nil {
+				return reconcile.Result{}, err
+			}
+			r.EventRecorder.Warning(renameRequest, PhaseFailed, status.Message)
+			return reconcile.Result{}, nil
+		}
+		logger.Info("renaming user", "usersignup", status.UserSignup, "previous_username", status.PreviousUsername, "username", status.Username)
+		now := metav1.Now()
+		status.Phase = PhaseInProgress
+		status.StartTime = &now
+		for _, name := range []string{StepReserveUsername, StepCreateMasterUserRecord, StepCreateSpace, StepCreateSpaceBindings,
+			StepWaitForProvisioning, StepSwitchUserSignup, StepDeletePreviousSpaceBindings, StepDeletePreviousSpace,
+			StepDeletePreviousMasterUserRecord} {
+			status.Steps = append(status.Steps, Step{Name: name, Status: StepPending})
+		}
+		// the username was reserved while resolving the rename
+		status.Steps[0].Status = StepCompleted
+		status.Steps[0].Message = fmt.Sprintf("username '%s' reserved", status.Username)
+		status.Steps[0].Time = &now
+		if err := r.updateStatus(renameRequest, status); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.EventRecorder.Normal(renameRequest, PhaseInProgress, "renaming '%s' to '%s'", status.PreviousUsername, status.Username)
+	}
+
+	if rollback, _ := strconv.ParseBool(renameRequest.Data[RollbackKey]); rollback {
+		return r.startRollback(logger, renameRequest, status, "rollback requested")
+	}
+
+	// the username is reserved while resolving the rename
+	steps := map[string]stepFunc{
+		StepCreateMasterUserRecord:         r.createMasterUserRecord,
+		StepCreateSpace:                    r.createSpace,
+		StepCreateSpaceBindings:            r.createSpaceBindings,
+		StepWaitForProvisioning:            r.waitForProvisioning,
+		StepSwitchUserSignup:               r.switchUserSignup,
+		StepDeletePreviousSpaceBindings:    r.deletePreviousSpaceBindings,
+		StepDeletePreviousSpace:            r.deletePreviousSpace,
+		StepDeletePreviousMasterUserRecord: r.deletePreviousMasterUserRecord,
+	}
+	for i := range status.Steps {
+		step := &status.Steps[i]
+		if step.Status == StepCompleted {
+			continue
+		}
+		if step.Name == pointOfNoReturn {
+			acknowledged, msg, err := r.dataLossAcknowledged(renameRequest, status)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !acknowledged {
+				// no need to requeue: the rename request is reconciled again once it is updated with the acknowledgement
+				if step.Message == msg {
+					return reconcile.Result{}, nil
+				}
+				now := metav1.Now()
+				step.Message = msg
+				step.Time = &now
+				logger.Info("waiting for the acknowledgement of the data loss", "step", step.Name)
+				return reconcile.Result{}, r.updateStatus(renameRequest, status)
+			}
+		}
+		done, msg, err := steps[step.Name](logger, status)
+		if err != nil {
+			step.Status = StepInProgress
+			step.Message = err.Error()
+			if updateErr := r.updateStatus(renameRequest, status); updateErr != nil {
+				logger.Error(updateErr, "unable to update the status of the rename request")
+			}
+			return reconcile.Result{}, errs.Wrapf(err, "unable to execute step '%s' of the rename", step.Name)
+		}
+		now := metav1.Now()
+		step.Message = msg
+		step.Time = &now
+		if !done {
+			if step.Name == StepWaitForProvisioning {
+				timedOut, timeout, err := r.provisioningTimedOut(status)
+				if err != nil {
+					return reconcile.Result{}, err
+				}
+				if timedOut {
+					step.Status = StepInProgress
+					return r.startRollback(logger, renameRequest, status, fmt.Sprintf("provisioning timed out after %s: %s", timeout, msg))
+				}
+			}
+			step.Status = StepInProgress
+			logger.Info("rename step in progress", "step", step.Name, "message", msg)
+			return reconcile.Result{RequeueAfter: stepInProgressRequeueInterval}, r.updateStatus(renameRequest, status)
+		}
+		logger.Info("rename step completed", "step", step.Name, "message", msg)
+		step.Status = StepCompleted
+		if err := r.updateStatus(renameRequest, status); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	now := metav1.Now()
+	status.Phase = PhaseCompleted
+	status.CompletionTime = &now
+	logger.Info("user renamed", "usersignup", status.UserSignup, "previous_username", status.PreviousUsername, "username", status.Username)
+	if err := r.updateStatus(renameRequest, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.EventRecorder.Normal(renameRequest, PhaseCompleted, "'%s' renamed to '%s'", status.PreviousUsername, status.Username)
+	return reconcile.Result{}, nil
+}
+
+// provisioningTimedOut returns `true` if the provisioning of the new MasterUserRecord and Space of the rename took longer
+// than the timeout configured in the ToolchainConfig (if any), along with this timeout
+func (r *Reconciler) provisioningTimedOut(status *Status) (bool, time.Duration, error) {
+	config, err := toolchainconfig.GetToolchainConfig(r.Client)
+	if err != nil {
+		return false, 0, errs.Wrap(err, "unable to get the ToolchainConfig")
+	}
+	timeout := config.Users().RenameProvisioningTimeout()
+	return timeout > 0 && status.StartTime != nil && time.Since(status.StartTime.Time) > timeout, timeout, nil
+}
+
+// dataLossAcknowledged returns `true` if the resources with the previous username can be deleted, ie, if the user has no Space
+// or if the deletion of the namespaces of the previous Space and of their content was acknowledged in the rename request.
+// Otherwise, it returns a message which explains how to acknowledge it.
+func (r *Reconciler) dataLossAcknowledged(renameRequest *corev1.ConfigMap, status *Status) (bool, string, error) {
+	if acknowledged, _ := strconv.ParseBool(renameRequest.Data[AcknowledgeDataLossKey]); acknowledged {
+		return true, "", nil
+	}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.PreviousUsername},
+		&toolchainv1alpha1.Space{}); err != nil {
+		if errors.IsNotFound(err) {
+			return true, "", nil
+		}
+		return false, "", errs.Wrapf(err, "unable to get the Space '%s'", status.PreviousUsername)
+	}
+	return false, fmt.Sprintf("waiting for the acknowledgement of the deletion of the namespaces of the Space '%s' and of their content: "+
+		"set '%s' to 'true' in the rename request once their content was migrated, or roll back the rename",
+		status.PreviousUsername, AcknowledgeDataLossKey), nil
+}
+
+// resolveRename looks-up the UserSignup of the rename request, and reserves the new username of the user
+func (r *Reconciler) resolveRename(renameRequest *corev1.ConfigMap, status *Status) error {
+	name := renameRequest.Data[UserSignupNameKey]
+	if name == "" {
+		return fmt.Errorf("'%s' must be specified", UserSignupNameKey)
+	}
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: name}, userSignup); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("the UserSignup '%s' does not exist", name)
+		}
+		return errs.Wrapf(err, "unable to get the UserSignup '%s'", name)
+	}
+	if userSignup.Status.CompliantUsername == "" || states.Deactivated(userSignup) {
+		return fmt.Errorf("the user '%s' is not provisioned", name)
+	}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: userSignup.Status.CompliantUsername},
+		&toolchainv1alpha1.MasterUserRecord{}); err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("the MasterUserRecord '%s' of the user does not exist", userSignup.Status.CompliantUsername)
+		}
+		return errs.Wrapf(err, "unable to get the MasterUserRecord '%s'", userSignup.Status.CompliantUsername)
+	}
+
+	newUsername := renameRequest.Data[UsernameKey]
+	if newUsername == userSignup.Status.CompliantUsername {
+		return fmt.Errorf("the username of the user is already '%s'", newUsername)
+	}
+	if newUsername != "" {
+		if validationErrors := validation.IsDNS1123Label(newUsername); len(validationErrors) > 0 {
+			return fmt.Errorf("the username '%s' is invalid: %v", newUsername, validationErrors)
+		}
+		owner, err := r.UsernameAllocator.ReservedBy(newUsername)
+		if err != nil {
+			return err
+		}
+		status.UsernameWasReserved = owner == userSignup.Name
+		if _, err := r.UsernameAllocator.Reserve(userSignup, newUsername); err != nil {
+			return err
+		}
+	} else {
+		config, err := toolchainconfig.GetToolchainConfig(r.Client)
+		if err != nil {
+			return errs.Wrap(err, "unable to get the ToolchainConfig")
+		}
+		base, err := r.UsernameAllocator.Base(userSignup, config.Users().ForbiddenUsernamePrefixes(), config.Users().ForbiddenUsernameSuffixes(),
+			config.Users().ForbiddenUsernameWords())
+		if err != nil {
+			return err
+		}
+		if newUsername, err = r.UsernameAllocator.Allocate(userSignup, base); err != nil {
+			return err
+		}
+		if newUsername == userSignup.Status.CompliantUsername {
+			return fmt.Errorf("the username derived from '%s' is the current username of the user", userSignup.Spec.Username)
+		}
+	}
+	status.UserSignup = userSignup.Name
+	status.PreviousUsername = userSignup.Status.CompliantUsername
+	status.Username = newUsername
+	return nil
+}
+
+// createMasterUserRecord creates a copy of the MasterUserRecord of the user with the new username. The provisioning time is
+// copied as well, so that the deactivation of the user is not postponed by the rename.
+func (r *Reconciler) createMasterUserRecord(logger logr.Logger, status *Status) (bool, string, error) {
+	previous := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.PreviousUsername}, previous); err != nil {
+		return false, "", errs.Wrapf(err, "unable to get the MasterUserRecord '%s'", status.PreviousUsername)
+	}
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.Username}, mur); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, "", err
+		}
+		mur = &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: renamedObjectMeta(previous.ObjectMeta, status),
+			Spec:       *previous.Spec.DeepCopy(),
+		}
+		if err := r.Client.Create(context.TODO(), mur); err != nil {
+			return false, "", errs.Wrapf(err, "unable to create the MasterUserRecord '%s'", status.Username)
+		}
+		// the counter is decremented by the MasterUserRecord controller once the MasterUserRecord with the previous username
+		// (or with the new username, in case of rollback) is deleted
+		counter.IncrementMasterUserRecordCount(logger, metrics.GetEmailDomain(mur))
+	} else if mur.Annotations[RenamedFromAnnotationKey] != status.PreviousUsername {
+		return false, "", fmt.Errorf("the MasterUserRecord '%s' already exists", status.Username)
+	}
+	if previous.Status.ProvisionedTime != nil && !previous.Status.ProvisionedTime.Equal(mur.Status.ProvisionedTime) {
+		mur.Status.ProvisionedTime = previous.Status.ProvisionedTime
+		if err := r.Client.Status().Update(context.TODO(), mur); err != nil {
+			return false, "", errs.Wrapf(err, "unable to set the provisioned time of the MasterUserRecord '%s'", status.Username)
+		}
+	}
+	return true, fmt.Sprintf("MasterUserRecord '%s' created", status.Username), nil
+}
+
+// createSpace creates a copy of the Space of the user with the new username, if the user has a Space
+func (r *Reconciler) createSpace(_ logr.Logger, status *Status) (bool, string, error) {
+	previous := &toolchainv1alpha1.Space{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.PreviousUsername}, previous); err != nil {
+		if errors.IsNotFound(err) {
+			return true, fmt.Sprintf("no Space '%s' to rename", status.PreviousUsername), nil
+		}
+		return false, "", errs.Wrapf(err, "unable to get the Space '%s'", status.PreviousUsername)
+	}
+	space := &toolchainv1alpha1.Space{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.Username}, space); err == nil {
+		if space.Annotations[RenamedFromAnnotationKey] != status.PreviousUsername {
+			return false, "", fmt.Errorf("the Space '%s' already exists", status.Username)
+		}
+		return true, fmt.Sprintf("Space '%s' created", status.Username), nil
+	} else if !errors.IsNotFound(err) {
+		return false, "", err
+	}
+	space = &toolchainv1alpha1.Space{
+		ObjectMeta: renamedObjectMeta(previous.ObjectMeta, status),
+		Spec:       *previous.Spec.DeepCopy(),
+	}
+	if err := r.Client.Create(context.TODO(), space); err != nil {
+		return false, "", errs.Wrapf(err, "unable to create the Space '%s'", status.Username)
+	}
+	return true, fmt.Sprintf("Space '%s' created", status.Username), nil
+}
+
+// createSpaceBindings creates a copy of the SpaceBindings of the user, and of the SpaceBindings of the other users to
+// the Space of the user, with the new username
+func (r *Reconciler) createSpaceBindings(_ logr.Logger, status *Status) (bool, string, error) {
+	bindings, err := r.listSpaceBindings(status.PreviousUsername)
+	if err != nil {
+		return false, "", err
+	}
+	rename := func(name string) string {
+		if name == status.PreviousUsername {
+			return status.Username
+		}
+		return name
+	}
+	count := 0
+	for _, previous := range bindings {
+		if previous.DeletionTimestamp != nil {
+			continue
+		}
+		mur := &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.Namespace, Name: rename(previous.Spec.MasterUserRecord)},
+		}
+		space := &toolchainv1alpha1.Space{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.Namespace, Name: rename(previous.Spec.Space)},
+		}
+		binding := spacebinding.NewSpaceBinding(mur, space, previous.Labels[toolchainv1alpha1.SpaceCreatorLabelKey])
+		binding.Annotations = map[string]string{RenamedFromAnnotationKey: status.PreviousUsername}
+		binding.Spec.SpaceRole = previous.Spec.SpaceRole
+		if err := r.Client.Create(context.TODO(), binding); err != nil && !errors.IsAlreadyExists(err) {
+			return false, "", errs.Wrapf(err, "unable to create the SpaceBinding of '%s' to '%s'", mur.Name, space.Name)
+		}
+		count++
+	}
+	return true, fmt.Sprintf("%d SpaceBinding(s) created", count), nil
+}
+
+// waitForProvisioning waits until the new MasterUserRecord and the new Space are ready
+func (r *Reconciler) waitForProvisioning(_ logr.Logger, status *Status) (bool, string, error) {
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.Username}, mur); err != nil {
+		return false, "", errs.Wrapf(err, "unable to get the MasterUserRecord '%s'", status.Username)
+	}
+	if !condition.IsTrue(mur.Status.Conditions, toolchainv1alpha1.ConditionReady) {
+		return false, fmt.Sprintf("waiting for the provisioning of the MasterUserRecord '%s'", status.Username), nil
+	}
+	space := &toolchainv1alpha1.Space{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: status.Username}, space); err != nil {
+		if errors.IsNotFound(err) {
+			return true, "MasterUserRecord provisioned", nil
+		}
+		return false, "", errs.Wrapf(err, "unable to get the Space '%s'", status.Username)
+	}
+	if !condition.IsTrueWithReason(space.Status.Conditions, toolchainv1alpha1.ConditionReady, toolchainv1alpha1.SpaceProvisionedReason) {
+		return false, fmt.Sprintf("waiting for the provisioning of the Space '%s'", status.Username), nil
+	}
+	return true, "MasterUserRecord and Space provisioned", nil
+}
+
+// switchUserSignup sets the new username in the status of the UserSignup, so that the UserSignup controller uses the new
+// MasterUserRecord from now on, and records the rename in the reservations of the usernames
+func (r *Reconciler) switchUserSignup(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.setCompliantUsername(status.UserSignup, status.Username); err != nil {
+		return false, "", err
+	}
+	if err := r.UsernameAllocator.SetRenamedTo(status.UserSignup, status.PreviousUsername, status.Username); err != nil {
+		return false, "", err
+	}
+	// the new username may be a previous username of the user
+	if err := r.UsernameAllocator.SetRenamedTo(status.UserSignup, status.Username, ""); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("UserSignup switched to '%s'", status.Username), nil
+}
+
+func (r *Reconciler) deletePreviousSpaceBindings(_ logr.Logger, status *Status) (bool, string, error) {
+	count, err := r.deleteSpaceBindings(status.PreviousUsername)
+	if err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("%d SpaceBinding(s) deleted", count), nil
+}
+
+func (r *Reconciler) deletePreviousSpace(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.delete(&toolchainv1alpha1.Space{}, status.PreviousUsername, ""); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Space '%s' deleted", status.PreviousUsername), nil
+}
+
+func (r *Reconciler) deletePreviousMasterUserRecord(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.delete(&toolchainv1alpha1.MasterUserRecord{}, status.PreviousUsername, ""); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("MasterUserRecord '%s' deleted", status.PreviousUsername), nil
+}
+
+// startRollback starts rolling back the rename, unless the resources with the previous username already started to be deleted
+func (r *Reconciler) startRollback(logger logr.Logger, renameRequest *corev1.ConfigMap, status *Status, reason string) (ctrl.Result, error) {
+	for _, step := range status.Steps {
+		if step.Name == pointOfNoReturn && step.Status != StepPending {
+			status.Message = fmt.Sprintf("%s, but the rename cannot be rolled back once the resources with the previous username "+
+				"started to be deleted: request a rename to '%s' instead", reason, status.PreviousUsername)
+			delete(renameRequest.Data, RollbackKey)
+			return reconcile.Result{}, r.updateStatus(renameRequest, status)
+		}
+	}
+	logger.Info("rolling back the rename", "reason", reason)
+	status.Phase = PhaseRollingBack
+	status.Message = reason
+	if err := r.updateStatus(renameRequest, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.EventRecorder.Warning(renameRequest, PhaseRollingBack, reason)
+	return r.rollback(logger, renameRequest, status)
+}
+
+// rollback rolls back the steps which were executed, in the reverse order
+func (r *Reconciler) rollback(logger logr.Logger, renameRequest *corev1.ConfigMap, status *Status) (ctrl.Result, error) {
+	rollbacks := map[string]stepFunc{
+		StepReserveUsername:        r.unreserveUsername,
+		StepCreateMasterUserRecord: r.deleteMasterUserRecord,
+		StepCreateSpace:            r.deleteSpace,
+		StepCreateSpaceBindings:    r.deleteNewSpaceBindings,
+		StepWaitForProvisioning:    func(logr.Logger, *Status) (bool, string, error) { return true, "", nil },
+		StepSwitchUserSignup:       r.switchUserSignupBack,
+	}
+	for i := len(status.Steps) - 1; i >= 0; i-- {
+		step := &status.Steps[i]
+		if step.Status == StepPending || step.Status == StepRolledBack {
+			continue
+		}
+		done, msg, err := rollbacks[step.Name](logger, status)
+		if err != nil {
+			step.Message = err.Error()
+			if updateErr := r.updateStatus(renameRequest, status); updateErr != nil {
+				logger.Error(updateErr, "unable to update the status of the rename request")
+			}
+			return reconcile.Result{}, errs.Wrapf(err, "unable to roll back step '%s' of the rename", step.Name)
+		}
+		now := metav1.Now()
+		step.Message = msg
+		step.Time = &now
+		if !done {
+			logger.Info("rename step rollback in progress", "step", step.Name, "message", msg)
+			return reconcile.Result{RequeueAfter: stepInProgressRequeueInterval}, r.updateStatus(renameRequest, status)
+		}
+		logger.Info("rename step rolled back", "step", step.Name, "message", msg)
+		step.Status = StepRolledBack
+		if err := r.updateStatus(renameRequest, status); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	now := metav1.Now()
+	status.Phase = PhaseRolledBack
+	status.CompletionTime = &now
+	logger.Info("user rename rolled back", "usersignup", status.UserSignup, "username", status.PreviousUsername)
+	if err := r.updateStatus(renameRequest, status); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.EventRecorder.Normal(renameRequest, PhaseRolledBack, "rename of '%s' to '%s' rolled back", status.PreviousUsername, status.Username)
+	return reconcile.Result{}, nil
+}
+
+func (r *Reconciler) unreserveUsername(_ logr.Logger, status *Status) (bool, string, error) {
+	if status.UsernameWasReserved {
+		return true, fmt.Sprintf("username '%s' still reserved", status.Username), nil
+	}
+	if err := r.UsernameAllocator.Unreserve(status.UserSignup, status.Username); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("username '%s' no longer reserved", status.Username), nil
+}
+
+func (r *Reconciler) deleteMasterUserRecord(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.delete(&toolchainv1alpha1.MasterUserRecord{}, status.Username, status.PreviousUsername); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("MasterUserRecord '%s' deleted", status.Username), nil
+}
+
+func (r *Reconciler) deleteSpace(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.delete(&toolchainv1alpha1.Space{}, status.Username, status.PreviousUsername); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Space '%s' deleted", status.Username), nil
+}
+
+func (r *Reconciler) deleteNewSpaceBindings(_ logr.Logger, status *Status) (bool, string, error) {
+	count, err := r.deleteSpaceBindings(status.Username)
+	if err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("%d SpaceBinding(s) deleted", count), nil
+}
+
+func (r *Reconciler) switchUserSignupBack(_ logr.Logger, status *Status) (bool, string, error) {
+	if err := r.UsernameAllocator.SetRenamedTo(status.UserSignup, status.PreviousUsername, ""); err != nil {
+		return false, "", err
+	}
+	if status.UsernameWasReserved {
+		if err := r.UsernameAllocator.SetRenamedTo(status.UserSignup, status.Username, status.PreviousUsername); err != nil {
+			return false, "", err
+		}
+	}
+	if err := r.setCompliantUsername(status.UserSignup, status.PreviousUsername); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("UserSignup switched back to '%s'", status.PreviousUsername), nil
+}
+
+func (r *Reconciler) setCompliantUsername(userSignupName, compliantUsername string) error {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: userSignupName}, userSignup); err != nil {
+		return errs.Wrapf(err, "unable to get the UserSignup '%s'", userSignupName)
+	}
+	if userSignup.Status.CompliantUsername == compliantUsername {
+		return nil
+	}
+	userSignup.Status.CompliantUsername = compliantUsername
+	if err := r.Client.Status().Update(context.TODO(), userSignup); err != nil {
+		return errs.Wrapf(err, "unable to update the status of the UserSignup '%s'", userSignupName)
+	}
+	return nil
+}
+
+// listSpaceBindings returns the SpaceBindings of the MasterUserRecord with the given name, and the SpaceBindings to the Space
+// with the given name
+func (r *Reconciler) listSpaceBindings(name string) ([]toolchainv1alpha1.SpaceBinding, error) {
+	var bindings []toolchainv1alpha1.SpaceBinding
+	found := map[string]bool{}
+	for _, label := range []string{toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey, toolchainv1alpha1.SpaceBindingSpaceLabelKey} {
+		list := &toolchainv1alpha1.SpaceBindingList{}
+		if err := r.Client.List(context.TODO(), list, client.InNamespace(r.Namespace), client.MatchingLabels{label: name}); err != nil {
+			return nil, errs.Wrapf(err, "unable to list the SpaceBindings of '%s'", name)
+		}
+		for _, binding := range list.Items {
+			if !found[binding.Name] {
+				found[binding.Name] = true
+				bindings = append(bindings, binding)
+			}
+		}
+	}
+	return bindings, nil
+}
+
+func (r *Reconciler) deleteSpaceBindings(name string) (int, error) {
+	bindings, err := r.listSpaceBindings(name)
+	if err != nil {
+		return 0, err
+	}
+	for i := range bindings {
+		if err := r.Client.Delete(context.TODO(), &bindings[i]); err != nil && !errors.IsNotFound(err) {
+			return 0, errs.Wrapf(err, "unable to delete the SpaceBinding '%s'", bindings[i].Name)
+		}
+	}
+	return len(bindings), nil
+}
+
+// delete deletes the resource with the given name, if it exists. When `renamedFrom` is not empty, the resource is deleted
+// only if it was created by the rename from this username.
+func (r *Reconciler) delete(obj client.Object, name, renamedFrom string) error {
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: r.Namespace, Name: name}, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if renamedFrom != "" && obj.GetAnnotations()[RenamedFromAnnotationKey] != renamedFrom {
+		return nil
+	}
+	if err := r.Client.Delete(context.TODO(), obj); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// renamedObjectMeta returns the metadata of the copy of a resource with the new username
+func renamedObjectMeta(previous metav1.ObjectMeta, status *Status) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Namespace:       previous.Namespace,
+		Name:            status.Username,
+		Labels:          map[string]string{},
+		Annotations:     map[string]string{},
+		OwnerReferences: previous.OwnerReferences,
+	}
+	for key, value := range previous.Labels {
+		meta.Labels[key] = value
+	}
+	for key, value := range previous.Annotations {
+		meta.Annotations[key] = value
+	}
+	meta.Annotations[RenamedFromAnnotationKey] = status.PreviousUsername
+	return meta
+}
+
+// GetStatus returns the status of the given rename request
+func GetStatus(renameRequest *corev1.ConfigMap) (*Status, error) {
+	status := &Status{}
+	value, found := renameRequest.Annotations[RenameStatusAnnotationKey]
+	if !found {
+		return status, nil
+	}
+	if err := json.Unmarshal([]byte(value), status); err != nil {
+		return nil, errs.Wrapf(err, "invalid status of the rename request '%s'", renameRequest.Name)
+	}
+	return status, nil
+}
+
+func (r *Reconciler) updateStatus(renameRequest *corev1.ConfigMap, status *Status) error {
+	value, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	if renameRequest.Annotations == nil {
+		renameRequest.Annotations = map[string]string{}
+	}
+	renameRequest.Annotations[RenameStatusAnnotationKey] = string(value)
+	if err := r.Client.Update(context.TODO(), renameRequest); err != nil {
+		return errs.Wrap(err, "unable to update the status of the rename request")
+	}
+	return nil
+}
@@ -0,0 +1,622 @@
+package userrename_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/controllers/userrename"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
+	. "github.com/codeready-toolchain/host-operator/test"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestRenameUser(t *testing.T) {
+	provisionedTime := metav1.NewTime(time.Now().Add(-24 * time.Hour).Truncate(time.Second))
+	newUserObjects := func() []runtime.Object {
+		userSignup := commonsignup.NewUserSignup(commonsignup.WithName("johnsmith"), commonsignup.WithUsername("jsmith"))
+		userSignup.Status.CompliantUsername = "johnsmith"
+		return []runtime.Object{
+			userSignup,
+			murtest.NewMasterUserRecord(t, "johnsmith", murtest.MetaNamespace(test.HostOperatorNs), murtest.WithOwnerLabel(userSignup.Name),
+				murtest.ProvisionedMur(&provisionedTime)),
+			spacetest.NewSpace("johnsmith", spacetest.WithCreatorLabel(userSignup.Name), spacetest.WithTierName("base")),
+			spacetest.NewSpace("other", spacetest.WithCreatorLabel("other")),
+			spacebindingtest.NewSpaceBinding("johnsmith", "johnsmith", "admin", userSignup.Name),
+			spacebindingtest.NewSpaceBinding("johnsmith", "other", "viewer", "other"),
+			spacebindingtest.NewSpaceBinding("other", "johnsmith", "contributor", userSignup.Name),
+			spacebindingtest.NewSpaceBinding("other", "other", "admin", "other"),
+		}
+	}
+
+	t.Run("rename to the given username", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+			userrename.UsernameKey:       "john",
+		}))...)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+		InitializeCounters(t, NewToolchainStatus(
+			WithMetric(toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey, toolchainv1alpha1.Metric{
+				"1,internal": 1,
+			}),
+			WithMetric(toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey, toolchainv1alpha1.Metric{
+				string(metrics.Internal): 1,
+			})))
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then the new resources are created, and the provisioning is in progress
+		require.NoError(t, err)
+		assert.NotZero(t, res.RequeueAfter)
+		status := getStatus(t, cl)
+		assert.Equal(t, userrename.PhaseInProgress, status.Phase)
+		assert.Equal(t, "johnsmith", status.UserSignup)
+		assert.Equal(t, "johnsmith", status.PreviousUsername)
+		assert.Equal(t, "john", status.Username)
+		assertSteps(t, status, map[string]string{
+			userrename.StepReserveUsername:                userrename.StepCompleted,
+			userrename.StepCreateMasterUserRecord:         userrename.StepCompleted,
+			userrename.StepCreateSpace:                    userrename.StepCompleted,
+			userrename.StepCreateSpaceBindings:            userrename.StepCompleted,
+			userrename.StepWaitForProvisioning:            userrename.StepInProgress,
+			userrename.StepSwitchUserSignup:               userrename.StepPending,
+			userrename.StepDeletePreviousMasterUserRecord: userrename.StepPending,
+		})
+		assert.Equal(t, "3 SpaceBinding(s) created", status.Steps[3].Message)
+		assert.Equal(t, "waiting for the provisioning of the MasterUserRecord 'john'", status.Steps[4].Message)
+		mur := murtest.AssertThatMasterUserRecord(t, "john", cl).
+			HasLabelWithValue(toolchainv1alpha1.MasterUserRecordOwnerLabelKey, "johnsmith").
+			Get()
+		assert.Equal(t, "johnsmith", mur.Annotations[userrename.RenamedFromAnnotationKey])
+		require.NotNil(t, mur.Status.ProvisionedTime)
+		assert.True(t, provisionedTime.Equal(mur.Status.ProvisionedTime))
+		spacetest.AssertThatSpace(t, test.HostOperatorNs, "john", cl).
+			Exists().
+			HasTier("base").
+			HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "johnsmith")
+		assert.Equal(t, []string{
+			"john/john/admin", "john/other/viewer", "johnsmith/johnsmith/admin", "johnsmith/other/viewer",
+			"other/john/contributor", "other/johnsmith/contributor", "other/other/admin",
+		}, spaceBindings(t, cl))
+		assertReservation(t, cl, "john", "johnsmith", "")
+		AssertThatCountersAndMetrics(t).
+			HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+				string(metrics.Internal): 2,
+			})
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal InProgress renaming 'johnsmith' to 'john'", <-fakeRecorder.Events)
+
+		t.Run("switched once provisioned", func(t *testing.T) {
+			// given
+			provision(t, cl, "john")
+
+			// when
+			res, err := r.Reconcile(context.TODO(), req)
+
+			// then the previous resources are not deleted until the data loss is acknowledged
+			require.NoError(t, err)
+			assert.Zero(t, res.RequeueAfter)
+			status := getStatus(t, cl)
+			assert.Equal(t, userrename.PhaseInProgress, status.Phase)
+			assertSteps(t, status, map[string]string{
+				userrename.StepWaitForProvisioning:            userrename.StepCompleted,
+				userrename.StepSwitchUserSignup:               userrename.StepCompleted,
+				userrename.StepDeletePreviousSpaceBindings:    userrename.StepPending,
+				userrename.StepDeletePreviousSpace:            userrename.StepPending,
+				userrename.StepDeletePreviousMasterUserRecord: userrename.StepPending,
+			})
+			assert.Equal(t, "waiting for the acknowledgement of the deletion of the namespaces of the Space 'johnsmith' and of their content: "+
+				"set 'acknowledgeDataLoss' to 'true' in the rename request once their content was migrated, or roll back the rename", status.Steps[6].Message)
+			userSignup := &toolchainv1alpha1.UserSignup{}
+			require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "johnsmith"), userSignup))
+			assert.Equal(t, "john", userSignup.Status.CompliantUsername)
+			murtest.AssertThatMasterUserRecord(t, "johnsmith", cl).Exists()
+			spacetest.AssertThatSpace(t, test.HostOperatorNs, "johnsmith", cl).Exists()
+			assert.Equal(t, []string{
+				"john/john/admin", "john/other/viewer", "johnsmith/johnsmith/admin", "johnsmith/other/viewer",
+				"other/john/contributor", "other/johnsmith/contributor", "other/other/admin",
+			}, spaceBindings(t, cl))
+			assert.Empty(t, fakeRecorder.Events)
+
+			t.Run("still waiting", func(t *testing.T) {
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, userrename.PhaseInProgress, getStatus(t, cl).Phase)
+				spacetest.AssertThatSpace(t, test.HostOperatorNs, "johnsmith", cl).Exists()
+			})
+		})
+
+		t.Run("completed once the data loss is acknowledged", func(t *testing.T) {
+			// given
+			acknowledgeDataLoss(t, cl)
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			status := getStatus(t, cl)
+			assert.Equal(t, userrename.PhaseCompleted, status.Phase)
+			assert.NotNil(t, status.CompletionTime)
+			for _, step := range status.Steps {
+				assert.Equal(t, userrename.StepCompleted, step.Status, "unexpected status of step '%s'", step.Name)
+			}
+			userSignup := &toolchainv1alpha1.UserSignup{}
+			require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "johnsmith"), userSignup))
+			assert.Equal(t, "john", userSignup.Status.CompliantUsername)
+			assertNotFound(t, cl, "johnsmith", &toolchainv1alpha1.MasterUserRecord{})
+			assertNotFound(t, cl, "johnsmith", &toolchainv1alpha1.Space{})
+			assert.Equal(t, []string{"john/john/admin", "john/other/viewer", "other/john/contributor", "other/other/admin"}, spaceBindings(t, cl))
+			// the previous username remains reserved for the user
+			assertReservation(t, cl, "johnsmith", "johnsmith", "john")
+			// the new MasterUserRecord is counted only once, and the previous one is uncounted by the MasterUserRecord
+			// controller once it is actually deleted
+			AssertThatCountersAndMetrics(t).
+				HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+					string(metrics.Internal): 2,
+				})
+			require.Len(t, fakeRecorder.Events, 1)
+			assert.Equal(t, "Normal Completed 'johnsmith' renamed to 'john'", <-fakeRecorder.Events)
+
+			t.Run("rollback ignored once completed", func(t *testing.T) {
+				// given
+				requestRollback(t, cl)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, userrename.PhaseCompleted, getStatus(t, cl).Phase)
+				murtest.AssertThatMasterUserRecord(t, "john", cl).Exists()
+			})
+		})
+
+		t.Run("deletion of the previous resources fails", func(t *testing.T) {
+			// given
+			r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+				userrename.UserSignupNameKey:      "johnsmith",
+				userrename.UsernameKey:            "john",
+				userrename.AcknowledgeDataLossKey: "true",
+			}))...)
+			_, err := r.Reconcile(context.TODO(), req)
+			require.NoError(t, err)
+			provision(t, cl, "john")
+			// the user is switched, but the previous resources are not deleted yet
+			cl.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+				if _, ok := obj.(*toolchainv1alpha1.SpaceBinding); ok {
+					return fmt.Errorf("mock error")
+				}
+				return cl.Client.Delete(ctx, obj, opts...)
+			}
+
+			// when
+			_, err = r.Reconcile(context.TODO(), req)
+
+			// then
+			require.EqualError(t, err, "unable to execute step 'DeletePreviousSpaceBindings' of the rename: unable to delete the SpaceBinding 'johnsmith-johnsmith': mock error")
+
+			t.Run("rollback rejected after the point of no return", func(t *testing.T) {
+				// given
+				requestRollback(t, cl)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				status := getStatus(t, cl)
+				assert.Equal(t, userrename.PhaseInProgress, status.Phase)
+				assert.Equal(t, "rollback requested, but the rename cannot be rolled back once the resources with the previous username "+
+					"started to be deleted: request a rename to 'johnsmith' instead", status.Message)
+				renameRequest := &corev1.ConfigMap{}
+				require.NoError(t, cl.Get(context.TODO(), req.NamespacedName, renameRequest))
+				assert.NotContains(t, renameRequest.Data, userrename.RollbackKey)
+			})
+		})
+	})
+
+	t.Run("rollback before the switch", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+			userrename.UsernameKey:       "john",
+		}))...)
+		InitializeCounters(t, NewToolchainStatus(
+			WithMetric(toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey, toolchainv1alpha1.Metric{
+				"1,internal": 1,
+			}),
+			WithMetric(toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey, toolchainv1alpha1.Metric{
+				string(metrics.Internal): 1,
+			})))
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		requestRollback(t, cl)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertRolledBack(t, cl, "rollback requested")
+		require.Len(t, fakeRecorder.Events, 2)
+		assert.Equal(t, "Warning RollingBack rollback requested", <-fakeRecorder.Events)
+		assert.Equal(t, "Normal RolledBack rename of 'johnsmith' to 'john' rolled back", <-fakeRecorder.Events)
+		// the new MasterUserRecord is uncounted by the MasterUserRecord controller once it is actually deleted
+		AssertThatCountersAndMetrics(t).
+			HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+				string(metrics.Internal): 2,
+			})
+	})
+
+	t.Run("rollback while waiting for the acknowledgement of the data loss", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+			userrename.UsernameKey:       "john",
+		}))...)
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		provision(t, cl, "john")
+		_, err = r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		requestRollback(t, cl)
+
+		// when
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then the user is switched back to the previous resources, which were kept
+		require.NoError(t, err)
+		assertRolledBack(t, cl, "rollback requested")
+	})
+
+	t.Run("rename of a user without a Space does not wait for the acknowledgement of the data loss", func(t *testing.T) {
+		// given
+		var objs []runtime.Object
+		for _, obj := range newUserObjects() {
+			if s, ok := obj.(*toolchainv1alpha1.Space); ok && s.Name == "johnsmith" {
+				continue
+			}
+			if b, ok := obj.(*toolchainv1alpha1.SpaceBinding); ok && b.Spec.Space == "johnsmith" {
+				continue
+			}
+			objs = append(objs, obj)
+		}
+		r, req, cl := prepareReconcile(t, append(objs, newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+			userrename.UsernameKey:       "john",
+		}))...)
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		mur := &toolchainv1alpha1.MasterUserRecord{}
+		require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "john"), mur))
+		mur.Status.Conditions = []toolchainv1alpha1.Condition{{
+			Type:   toolchainv1alpha1.ConditionReady,
+			Status: corev1.ConditionTrue,
+			Reason: toolchainv1alpha1.MasterUserRecordProvisionedReason,
+		}}
+		require.NoError(t, cl.Status().Update(context.TODO(), mur))
+
+		// when
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, userrename.PhaseCompleted, getStatus(t, cl).Phase)
+		assertNotFound(t, cl, "johnsmith", &toolchainv1alpha1.MasterUserRecord{})
+		assert.Equal(t, []string{"john/other/viewer", "other/other/admin"}, spaceBindings(t, cl))
+	})
+
+	t.Run("rolled back when the provisioning times out", func(t *testing.T) {
+		// given
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.RenameProvisioningTimeoutAnnotationKey, "1ns"))
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), config, newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+			userrename.UsernameKey:       "john",
+		}))...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assertRolledBack(t, cl, "provisioning timed out after 1ns: waiting for the provisioning of the MasterUserRecord 'john'")
+	})
+
+	t.Run("rename to a username derived from the UserSignup", func(t *testing.T) {
+		// given
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey: "johnsmith",
+		}))...)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		status := getStatus(t, cl)
+		assert.Equal(t, userrename.PhaseInProgress, status.Phase)
+		assert.Equal(t, "jsmith", status.Username)
+		murtest.AssertThatMasterUserRecord(t, "jsmith", cl).Exists()
+	})
+
+	t.Run("rename back to a previous username", func(t *testing.T) {
+		// given
+		previous := newReservation("john", "johnsmith")
+		previous.Data[username.RenamedToKey] = "johnsmith"
+		r, req, cl := prepareReconcile(t, append(newUserObjects(), previous, newRenameRequest(map[string]string{
+			userrename.UserSignupNameKey:      "johnsmith",
+			userrename.UsernameKey:            "john",
+			userrename.AcknowledgeDataLossKey: "true",
+		}))...)
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		assert.True(t, getStatus(t, cl).UsernameWasReserved)
+		provision(t, cl, "john")
+
+		// when
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, userrename.PhaseCompleted, getStatus(t, cl).Phase)
+		assertReservation(t, cl, "john", "johnsmith", "")
+		assertReservation(t, cl, "johnsmith", "johnsmith", "john")
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		for name, data := range map[string]struct {
+			request  map[string]string
+			objs     []runtime.Object
+			expected string
+		}{
+			"no UserSignup": {
+				request:  map[string]string{},
+				expected: "'usersignup' must be specified",
+			},
+			"unknown UserSignup": {
+				request:  map[string]string{userrename.UserSignupNameKey: "unknown"},
+				expected: "the UserSignup 'unknown' does not exist",
+			},
+			"user not provisioned": {
+				request:  map[string]string{userrename.UserSignupNameKey: "other"},
+				objs:     []runtime.Object{commonsignup.NewUserSignup(commonsignup.WithName("other"))},
+				expected: "the user 'other' is not provisioned",
+			},
+			"same username": {
+				request:  map[string]string{userrename.UserSignupNameKey: "johnsmith", userrename.UsernameKey: "johnsmith"},
+				expected: "the username of the user is already 'johnsmith'",
+			},
+			"invalid username": {
+				request:  map[string]string{userrename.UserSignupNameKey: "johnsmith", userrename.UsernameKey: "John.Smith"},
+				expected: "the username 'John.Smith' is invalid: [a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')]",
+			},
+			"username taken": {
+				request:  map[string]string{userrename.UserSignupNameKey: "johnsmith", userrename.UsernameKey: "john"},
+				objs:     []runtime.Object{newReservation("john", "someone-else")},
+				expected: "the username [john] is already taken",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				objs := append(newUserObjects(), data.objs...)
+				r, req, cl := prepareReconcile(t, append(objs, newRenameRequest(data.request))...)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				status := getStatus(t, cl)
+				assert.Equal(t, userrename.PhaseFailed, status.Phase)
+				assert.Equal(t, data.expected, status.Message)
+				murtest.AssertThatMasterUserRecords(t, cl).HaveCount(1)
+			})
+		}
+
+		t.Run("step fails", func(t *testing.T) {
+			// given
+			r, req, cl := prepareReconcile(t, append(newUserObjects(), newRenameRequest(map[string]string{
+				userrename.UserSignupNameKey: "johnsmith",
+				userrename.UsernameKey:       "john",
+			}))...)
+			cl.MockCreate = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*toolchainv1alpha1.Space); ok {
+					return fmt.Errorf("mock error")
+				}
+				return cl.Client.Create(ctx, obj, opts...)
+			}
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.EqualError(t, err, "unable to execute step 'CreateSpace' of the rename: unable to create the Space 'john': mock error")
+			status := getStatus(t, cl)
+			assert.Equal(t, userrename.PhaseInProgress, status.Phase)
+			assert.Equal(t, userrename.StepInProgress, status.Steps[2].Status)
+			assert.Equal(t, "unable to create the Space 'john': mock error", status.Steps[2].Message)
+
+			t.Run("rolled back", func(t *testing.T) {
+				// given
+				cl.MockCreate = nil
+				requestRollback(t, cl)
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				assertRolledBack(t, cl, "rollback requested")
+			})
+		})
+	})
+}
+
+func newRenameRequest(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rename-request",
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				userrename.RenameRequestLabelKey: "",
+			},
+		},
+		Data: data,
+	}
+}
+
+func newReservation(name, owner string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      username.ReservationNamePrefix + name,
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				username.ReservationLabelKey:      name,
+				username.ReservationOwnerLabelKey: owner,
+			},
+		},
+		Data: map[string]string{
+			username.UsernameKey: name,
+			username.OwnerKey:    owner,
+		},
+	}
+}
+
+// provision sets the Ready condition of the MasterUserRecord and the Space with the given name
+func provision(t *testing.T, cl client.Client, name string) {
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, name), mur))
+	mur.Status.Conditions = []toolchainv1alpha1.Condition{{
+		Type:   toolchainv1alpha1.ConditionReady,
+		Status: corev1.ConditionTrue,
+		Reason: toolchainv1alpha1.MasterUserRecordProvisionedReason,
+	}}
+	require.NoError(t, cl.Status().Update(context.TODO(), mur))
+	space := &toolchainv1alpha1.Space{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, name), space))
+	space.Status.Conditions = []toolchainv1alpha1.Condition{spacetest.Ready()}
+	require.NoError(t, cl.Status().Update(context.TODO(), space))
+}
+
+func requestRollback(t *testing.T, cl client.Client) {
+	renameRequest := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "rename-request"), renameRequest))
+	renameRequest.Data[userrename.RollbackKey] = "true"
+	require.NoError(t, cl.Update(context.TODO(), renameRequest))
+}
+
+func acknowledgeDataLoss(t *testing.T, cl client.Client) {
+	renameRequest := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "rename-request"), renameRequest))
+	renameRequest.Data[userrename.AcknowledgeDataLossKey] = "true"
+	require.NoError(t, cl.Update(context.TODO(), renameRequest))
+}
+
+// assertRolledBack verifies that the user was not renamed to `john`, and that the username `john` is no longer reserved
+func assertRolledBack(t *testing.T, cl client.Client, message string) {
+	status := getStatus(t, cl)
+	assert.Equal(t, userrename.PhaseRolledBack, status.Phase)
+	assert.Equal(t, message, status.Message)
+	// the steps which were not executed remain pending
+	for _, step := range status.Steps {
+		assert.Contains(t, []string{userrename.StepRolledBack, userrename.StepPending}, step.Status, "unexpected status of step '%s'", step.Name)
+	}
+	assert.Equal(t, userrename.StepRolledBack, status.Steps[0].Status)
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "johnsmith"), userSignup))
+	assert.Equal(t, "johnsmith", userSignup.Status.CompliantUsername)
+	assertNotFound(t, cl, "john", &toolchainv1alpha1.MasterUserRecord{})
+	assertNotFound(t, cl, "john", &toolchainv1alpha1.Space{})
+	murtest.AssertThatMasterUserRecord(t, "johnsmith", cl).Exists()
+	spacetest.AssertThatSpace(t, test.HostOperatorNs, "johnsmith", cl).Exists()
+	assert.Equal(t, []string{"johnsmith/johnsmith/admin", "johnsmith/other/viewer", "other/johnsmith/contributor", "other/other/admin"},
+		spaceBindings(t, cl))
+	assertNotFound(t, cl, username.ReservationNamePrefix+"john", &corev1.ConfigMap{})
+}
+
+func assertReservation(t *testing.T, cl client.Client, name, owner, renamedTo string) {
+	reservation := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, username.ReservationNamePrefix+name), reservation))
+	assert.Equal(t, owner, reservation.Data[username.OwnerKey])
+	assert.Equal(t, renamedTo, reservation.Data[username.RenamedToKey])
+}
+
+// spaceBindings returns the `<mur>/<space>/<role>` of all the SpaceBindings
+func spaceBindings(t *testing.T, cl client.Client) []string {
+	bindings := &toolchainv1alpha1.SpaceBindingList{}
+	require.NoError(t, cl.List(context.TODO(), bindings, client.InNamespace(test.HostOperatorNs)))
+	var result []string
+	for _, binding := range bindings.Items {
+		result = append(result, fmt.Sprintf("%s/%s/%s", binding.Spec.MasterUserRecord, binding.Spec.Space, binding.Spec.SpaceRole))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func getStatus(t *testing.T, cl client.Client) *userrename.Status {
+	renameRequest := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "rename-request"), renameRequest))
+	status, err := userrename.GetStatus(renameRequest)
+	require.NoError(t, err)
+	return status
+}
+
+func assertSteps(t *testing.T, status *userrename.Status, expected map[string]string) {
+	for _, step := range status.Steps {
+		if s, found := expected[step.Name]; found {
+			assert.Equal(t, s, step.Status, "unexpected status of step '%s'", step.Name)
+		}
+	}
+}
+
+func assertNotFound(t *testing.T, cl client.Client, name string, obj client.Object) {
+	err := cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, name), obj)
+	require.Error(t, err)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func prepareReconcile(t *testing.T, initObjs ...runtime.Object) (*userrename.Reconciler, reconcile.Request, *test.FakeClient) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	cl := test.NewFakeClient(t, initObjs...)
+	r := &userrename.Reconciler{
+		Client:            cl,
+		Namespace:         test.HostOperatorNs,
+		UsernameAllocator: username.NewAllocator(cl, test.HostOperatorNs),
+	}
+	return r, reconcile.Request{NamespacedName: test.NamespacedName(test.HostOperatorNs, "rename-request")}, cl
+}
@@ -0,0 +1,228 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// BanReasonAnnotationKey the annotation on the BannedUser which explains why the user was banned
+	BanReasonAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-reason"
+	// BanExpirationTimeAnnotationKey the annotation on the BannedUser with the time (RFC3339) after which the ban no longer applies.
+	// Without this annotation, the ban is permanent.
+	BanExpirationTimeAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-expiration-time"
+	// BanScopeAnnotationKey the annotation on the BannedUser with the scope of the ban (see BanScope)
+	BanScopeAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-scope"
+	// BanTiersAnnotationKey the annotation on the BannedUser with the comma-separated names of the UserTiers and NSTemplateTiers
+	// which the user is banned from, when the scope of the ban is `tiers`
+	BanTiersAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-tiers"
+	// BanSocialEventsAnnotationKey the annotation on the BannedUser with the comma-separated names of the SocialEvents
+	// which the user is banned from, when the scope of the ban is `tiers`
+	BanSocialEventsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-social-events"
+	// BanAppealAnnotationKey the annotation on the BannedUser with the state of the appeal of the user against the ban (see BanAppeal)
+	BanAppealAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-appeal"
+)
+
+// BanScope the users affected by a ban
+type BanScope string
+
+const (
+	// BanScopeAll the new signups are blocked and the existing users are deactivated (default)
+	BanScopeAll BanScope = "all"
+	// BanScopeSignup only the users who are not provisioned yet are blocked, the existing users are kept
+	BanScopeSignup BanScope = "signup"
+	// BanScopeTiers only the users of the tiers or SocialEvents listed in the BannedUser are blocked (or deactivated)
+	BanScopeTiers BanScope = "tiers"
+)
+
+// BanAppeal the state of the appeal of the user against a ban
+type BanAppeal string
+
+const (
+	// BanAppealPending the appeal is under review: the user is not provisioned, and waits for a manual review
+	BanAppealPending BanAppeal = "pending"
+	// BanAppealGranted the appeal was granted: the ban no longer applies
+	BanAppealGranted BanAppeal = "granted"
+	// BanAppealRejected the appeal was rejected: the ban applies
+	BanAppealRejected BanAppeal = "rejected"
+)
+
+// Ban the settings of a BannedUser
+type Ban struct {
+	Reason         string
+	ExpirationTime *time.Time
+	Scope          BanScope
+	Tiers          []string
+	SocialEvents   []string
+	Appeal         BanAppeal
+}
+
+// ParseBan returns the settings of the given BannedUser, taken from its annotations
+func ParseBan(bannedUser *toolchainv1alpha1.BannedUser) (*Ban, error) {
+	ban := &Ban{
+		Reason:       bannedUser.Annotations[BanReasonAnnotationKey],
+		Scope:        BanScopeAll,
+		Tiers:        splitNames(bannedUser.Annotations[BanTiersAnnotationKey]),
+		SocialEvents: splitNames(bannedUser.Annotations[BanSocialEventsAnnotationKey]),
+	}
+	if value, found := bannedUser.Annotations[BanExpirationTimeAnnotationKey]; found {
+		expirationTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration time '%s': %w", value, err)
+		}
+		ban.ExpirationTime = &expirationTime
+	}
+	switch scope := BanScope(bannedUser.Annotations[BanScopeAnnotationKey]); scope {
+	case "", BanScopeAll:
+	case BanScopeSignup:
+		ban.Scope = scope
+	case BanScopeTiers:
+		if len(ban.Tiers) == 0 && len(ban.SocialEvents) == 0 {
+			return nil, fmt.Errorf("the scope '%s' requires the '%s' or '%s' annotation", scope, BanTiersAnnotationKey, BanSocialEventsAnnotationKey)
+		}
+		ban.Scope = scope
+	default:
+		return nil, fmt.Errorf("unknown scope '%s'", scope)
+	}
+	switch appeal := BanAppeal(bannedUser.Annotations[BanAppealAnnotationKey]); appeal {
+	case "", BanAppealPending, BanAppealGranted, BanAppealRejected:
+		ban.Appeal = appeal
+	default:
+		return nil, fmt.Errorf("unknown appeal state '%s'", appeal)
+	}
+	return ban, nil
+}
+
+func splitNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Expired returns `true` if the ban has an expiration time which is before the given time
+func (b *Ban) Expired(now time.Time) bool {
+	return b.ExpirationTime != nil && !now.Before(*b.ExpirationTime)
+}
+
+// Message returns the reason and the expiration time of the ban, as displayed in the status of the UserSignup
+func (b *Ban) Message() string {
+	var msg []string
+	if b.Reason != "" {
+		msg = append(msg, b.Reason)
+	}
+	if b.ExpirationTime != nil {
+		msg = append(msg, fmt.Sprintf("banned until %s", b.ExpirationTime.UTC().Format(time.RFC3339)))
+	}
+	return strings.Join(msg, ", ")
+}
+
+// requeueAfter returns the duration until the expiration of the ban, or 0 if the ban is permanent
+func (b *Ban) requeueAfter(now time.Time) time.Duration {
+	if b.ExpirationTime == nil {
+		return 0
+	}
+	return b.ExpirationTime.Sub(now)
+}
+
+// appliesTo returns `true` if the ban applies to a user with the given tiers and SocialEvent, and who is provisioned or not
+func (b *Ban) appliesTo(provisioned bool, tiers []string, socialEvent string) bool {
+	switch b.Scope {
+	case BanScopeSignup:
+		return !provisioned
+	case BanScopeTiers:
+		if socialEvent != "" && contains(b.SocialEvents, socialEvent) {
+			return true
+		}
+		for _, tier := range tiers {
+			if contains(b.Tiers, tier) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// applicableBan returns the first of the given BannedUsers whose ban applies to the given UserSignup, or nil if the user is not banned.
+// Invalid, expired bans and bans whose appeal was granted are ignored.
+func (r *Reconciler) applicableBan(logger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup,
+	bannedUsers []toolchainv1alpha1.BannedUser) (*Ban, error) {
+	now := time.Now()
+	for i := range bannedUsers {
+		ban, err := ParseBan(&bannedUsers[i])
+		if err != nil {
+			// the ban is applied with its default settings rather than not at all
+			logger.Error(err, "invalid settings of the BannedUser, applying a permanent ban", "name", bannedUsers[i].Name)
+			ban = &Ban{Scope: BanScopeAll, Reason: bannedUsers[i].Annotations[BanReasonAnnotationKey]}
+		}
+		if ban.Expired(now) || ban.Appeal == BanAppealGranted {
+			logger.Info("ignoring BannedUser", "name", bannedUsers[i].Name, "expired", ban.Expired(now), "appeal", ban.Appeal)
+			continue
+		}
+		if ban.Scope == BanScopeAll {
+			return ban, nil
+		}
+		provisioned, tiers, err := r.provisionedTiers(config, userSignup)
+		if err != nil {
+			return nil, err
+		}
+		if ban.appliesTo(provisioned, tiers, userSignup.Labels[toolchainv1alpha1.SocialEventUserSignupLabelKey]) {
+			return ban, nil
+		}
+	}
+	return nil, nil
+}
+
+// provisionedTiers returns `true` if the user has a MasterUserRecord, along with the names of the UserTier and NSTemplateTier
+// of the user: those of their MasterUserRecord and Space if they exist, or those they would be provisioned with otherwise
+func (r *Reconciler) provisionedTiers(config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) (bool, []string, error) {
+	murs := &toolchainv1alpha1.MasterUserRecordList{}
+	if err := r.Client.List(context.TODO(), murs, client.InNamespace(userSignup.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name}); err != nil {
+		return false, nil, err
+	}
+	if len(murs.Items) > 0 {
+		tiers := []string{murs.Items[0].Spec.TierName}
+		space := &toolchainv1alpha1.Space{}
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: userSignup.Namespace, Name: murs.Items[0].Name}, space); err != nil {
+			if !errors.IsNotFound(err) {
+				return false, nil, err
+			}
+		} else {
+			tiers = append(tiers, space.Spec.TierName)
+		}
+		return true, tiers, nil
+	}
+	event, err := r.getSocialEvent(userSignup)
+	if err != nil {
+		return false, nil, err
+	}
+	if event != nil {
+		return false, []string{event.Spec.UserTier, event.Spec.SpaceTier}, nil
+	}
+	return false, []string{config.Tiers().DefaultUserTier(), config.Tiers().DefaultSpaceTier()}, nil
+}
@@ -0,0 +1,405 @@
+package usersignup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseBan(t *testing.T) {
+	newBannedUser := func(annotations map[string]string) *toolchainv1alpha1.BannedUser {
+		return &toolchainv1alpha1.BannedUser{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+	}
+
+	t.Run("default", func(t *testing.T) {
+		// when
+		ban, err := ParseBan(newBannedUser(nil))
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, &Ban{Scope: BanScopeAll}, ban)
+		assert.False(t, ban.Expired(time.Now()))
+		assert.Empty(t, ban.Message())
+	})
+
+	t.Run("all settings", func(t *testing.T) {
+		// when
+		ban, err := ParseBan(newBannedUser(map[string]string{
+			BanReasonAnnotationKey:         "crypto mining",
+			BanExpirationTimeAnnotationKey: "2022-12-01T10:00:00Z",
+			BanScopeAnnotationKey:          "tiers",
+			BanTiersAnnotationKey:          "base, advanced",
+			BanSocialEventsAnnotationKey:   "summit",
+			BanAppealAnnotationKey:         "pending",
+		}))
+
+		// then
+		require.NoError(t, err)
+		expirationTime := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+		assert.Equal(t, "crypto mining", ban.Reason)
+		assert.True(t, expirationTime.Equal(*ban.ExpirationTime))
+		assert.Equal(t, BanScopeTiers, ban.Scope)
+		assert.Equal(t, []string{"base", "advanced"}, ban.Tiers)
+		assert.Equal(t, []string{"summit"}, ban.SocialEvents)
+		assert.Equal(t, BanAppealPending, ban.Appeal)
+		assert.False(t, ban.Expired(expirationTime.Add(-time.Second)))
+		assert.True(t, ban.Expired(expirationTime))
+		assert.Equal(t, "crypto mining, banned until 2022-12-01T10:00:00Z", ban.Message())
+	})
+
+	for msg, annotations := range map[string]map[string]string{
+		"invalid expiration time": {BanExpirationTimeAnnotationKey: "tomorrow"},
+		"unknown scope 'forever'": {BanScopeAnnotationKey: "forever"},
+		"requires the 'toolchain.dev.openshift.com/ban-tiers' or 'toolchain.dev.openshift.com/ban-social-events' annotation": {BanScopeAnnotationKey: "tiers"},
+		"unknown appeal state 'maybe'": {BanAppealAnnotationKey: "maybe"},
+	} {
+		t.Run(msg, func(t *testing.T) {
+			// when
+			_, err := ParseBan(newBannedUser(annotations))
+
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), msg)
+		})
+	}
+}
+
+func TestUserSignupWithBan(t *testing.T) {
+	newBannedUser := func(annotations map[string]string) *toolchainv1alpha1.BannedUser {
+		return &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "banned-foo",
+				Namespace:   test.HostOperatorNs,
+				Annotations: annotations,
+				Labels: map[string]string{
+					toolchainv1alpha1.BannedUserEmailHashLabelKey: "fd2addbd8d82f0d2dc088fa122377eaa",
+				},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{
+				Email: "foo@redhat.com",
+			},
+		}
+	}
+	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
+	inOneHour := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	oneHourAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	t.Run("time-bound ban", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually())
+		bannedUser := newBannedUser(map[string]string{
+			BanReasonAnnotationKey:         "crypto mining",
+			BanExpirationTimeAnnotationKey: inOneHour,
+		})
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+			commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, res.RequeueAfter > 59*time.Minute && res.RequeueAfter <= time.Hour, "requeue at the expiration of the ban: %s", res.RequeueAfter)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+		complete, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+		require.True(t, found)
+		assert.Equal(t, toolchainv1alpha1.UserSignupUserBannedReason, complete.Reason)
+		assert.Equal(t, "crypto mining, banned until "+inOneHour, complete.Message)
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+	})
+
+	t.Run("user is restored when the ban expired", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+			commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueBanned))
+		bannedUser := newBannedUser(map[string]string{
+			BanExpirationTimeAnnotationKey: oneHourAgo,
+		})
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+			commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		res, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Zero(t, res.RequeueAfter)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+	})
+
+	t.Run("signup scope", func(t *testing.T) {
+		bannedUser := newBannedUser(map[string]string{
+			BanScopeAnnotationKey: string(BanScopeSignup),
+		})
+
+		t.Run("new user is banned", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually())
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		})
+
+		t.Run("existing user is kept", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved))
+			userSignup.Status.CompliantUsername = "foo"
+			mur := murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs), murtest.WithOwnerLabel(userSignup.Name))
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, mur, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+	})
+
+	t.Run("tiers scope", func(t *testing.T) {
+		newProvisionedUserSignup := func() (*toolchainv1alpha1.UserSignup, *toolchainv1alpha1.MasterUserRecord) {
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved))
+			userSignup.Status.CompliantUsername = "foo"
+			mur := murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs),
+				murtest.TierName("deactivate80"), murtest.WithOwnerLabel(userSignup.Name))
+			return userSignup, mur
+		}
+
+		t.Run("user of the tier is deactivated", func(t *testing.T) {
+			// given
+			userSignup, mur := newProvisionedUserSignup()
+			bannedUser := newBannedUser(map[string]string{
+				BanScopeAnnotationKey: string(BanScopeTiers),
+				BanTiersAnnotationKey: "deactivate80",
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, mur, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier, deactivate80Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		})
+
+		t.Run("user of another tier is kept", func(t *testing.T) {
+			// given
+			userSignup, mur := newProvisionedUserSignup()
+			bannedUser := newBannedUser(map[string]string{
+				BanScopeAnnotationKey:        string(BanScopeTiers),
+				BanTiersAnnotationKey:        "deactivate30",
+				BanSocialEventsAnnotationKey: "summit",
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, mur, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier, deactivate80Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+
+		t.Run("new user of the social event is banned", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "summit"))
+			bannedUser := newBannedUser(map[string]string{
+				BanScopeAnnotationKey:        string(BanScopeTiers),
+				BanSocialEventsAnnotationKey: "summit",
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+		})
+	})
+
+	t.Run("appeal", func(t *testing.T) {
+		t.Run("pending", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueBanned))
+			bannedUser := newBannedUser(map[string]string{
+				BanReasonAnnotationKey: "crypto mining",
+				BanAppealAnnotationKey: string(BanAppealPending),
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValuePending, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
+				toolchainv1alpha1.Condition{
+					Type:    toolchainv1alpha1.UserSignupApproved,
+					Status:  v1.ConditionFalse,
+					Reason:  toolchainv1alpha1.UserSignupPendingApprovalReason,
+					Message: "the appeal against the ban is under review (crypto mining)",
+				},
+				toolchainv1alpha1.Condition{
+					Type:    toolchainv1alpha1.UserSignupComplete,
+					Status:  v1.ConditionFalse,
+					Reason:  toolchainv1alpha1.UserSignupPendingApprovalReason,
+					Message: "the appeal against the ban is under review (crypto mining)",
+				})
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		})
+
+		t.Run("granted", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValuePending))
+			bannedUser := newBannedUser(map[string]string{
+				BanAppealAnnotationKey: string(BanAppealGranted),
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+
+		t.Run("rejected", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+				commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValuePending))
+			bannedUser := newBannedUser(map[string]string{
+				BanAppealAnnotationKey: string(BanAppealRejected),
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		})
+	})
+
+	t.Run("rule", func(t *testing.T) {
+		newRule := func(annotations map[string]string) *toolchainv1alpha1.BannedUser {
+			return &toolchainv1alpha1.BannedUser{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "banned-domain",
+					Namespace:   test.HostOperatorNs,
+					Annotations: annotations,
+				},
+				Spec: toolchainv1alpha1.BannedUserSpec{
+					Email: "redhat.com",
+				},
+			}
+		}
+
+		t.Run("user of the domain is banned", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually())
+			bannedUser := newRule(map[string]string{
+				banneduser.EmailDomainAnnotationKey: "redhat.com",
+				BanReasonAnnotationKey:              "spam",
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			complete, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete)
+			require.True(t, found)
+			assert.Equal(t, "spam", complete.Message)
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		})
+
+		t.Run("user who does not match all criteria is provisioned", func(t *testing.T) {
+			// given
+			userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually())
+			bannedUser := newRule(map[string]string{
+				banneduser.EmailDomainAnnotationKey: "redhat.com",
+				banneduser.CompanyAnnotationKey:     "acme",
+			})
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+	})
+}
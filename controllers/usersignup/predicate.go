@@ -2,6 +2,7 @@ package usersignup
 
 import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 
 	"github.com/go-logr/logr"
@@ -26,13 +27,15 @@ var predicateLog = ctrl.Log.WithName("UserSignupChangedPredicate")
 // * annotation toolchain.dev.openshift.com/user-email has changed
 // * annotation toolchain.dev.openshift.com/migration-in-progress was removed
 // * label toolchain.dev.openshift.com/email-hash has changed
+// * annotation toolchain.dev.openshift.com/deactivation-reminder-due has changed
 func (p UserSignupChangedPredicate) Update(e runtimeevent.UpdateEvent) bool {
 	if !checkMetaObjects(changedLog, e) {
 		return false
 	}
 	return e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration() ||
 		p.annotationChanged(e, toolchainv1alpha1.UserSignupUserEmailAnnotationKey) ||
-		p.labelChanged(e, toolchainv1alpha1.UserSignupUserEmailHashLabelKey)
+		p.labelChanged(e, toolchainv1alpha1.UserSignupUserEmailHashLabelKey) ||
+		p.annotationChanged(e, deactivation.DeactivationReminderDueAnnotationKey)
 }
 
 func (p UserSignupChangedPredicate) annotationChanged(e runtimeevent.UpdateEvent, annotationName string) bool {
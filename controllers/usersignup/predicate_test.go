@@ -6,6 +6,7 @@ import (
 	"github.com/gofrs/uuid"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
 	. "github.com/codeready-toolchain/host-operator/test"
 
 	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
@@ -180,6 +181,16 @@ func TestUserSignupChangedPredicate(t *testing.T) {
 		}
 		require.True(t, pred.Update(e))
 	})
+
+	t.Run("test UserSignupChangedPredicate returns true when deactivation-reminder-due annotation changed", func(t *testing.T) {
+		userSignupWithDeactivationReminderDue := userSignupUnchanged.DeepCopy()
+		userSignupWithDeactivationReminderDue.Annotations[deactivation.DeactivationReminderDueAnnotationKey] = "7"
+		e := runtimeevent.UpdateEvent{
+			ObjectOld: userSignupOld,
+			ObjectNew: userSignupWithDeactivationReminderDue,
+		}
+		require.True(t, pred.Update(e))
+	})
 }
 
 func TestAutomaticApprovalPredicateWhenApprovalIsEnabled(t *testing.T) {
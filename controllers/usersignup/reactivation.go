@@ -0,0 +1,170 @@
+package usersignup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	"github.com/go-logr/logr"
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// LastProvisioningAnnotationKey the annotation on the UserSignup which records, as JSON, the tiers and the space settings of the
+// user at the time of their deactivation, so that they can be restored when the user comes back
+const LastProvisioningAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "last-provisioning"
+
+const (
+	// UserSignupReactivated the type of the UserSignup condition which reports how the last reactivation of the user was handled
+	UserSignupReactivated toolchainv1alpha1.ConditionType = "Reactivated"
+
+	// UserSignupReactivationRestoredReason the reason of the `Reactivated` condition when the tiers and space settings were restored
+	UserSignupReactivationRestoredReason = "Restored"
+	// UserSignupReactivationResetToDefaultsReason the reason of the `Reactivated` condition when the default tiers were used
+	UserSignupReactivationResetToDefaultsReason = "ResetToDefaults"
+	// UserSignupReactivationManualApprovalRequiredReason the reason of the `Reactivated` condition when the user needs to be
+	// approved manually because of the number of reactivations
+	UserSignupReactivationManualApprovalRequiredReason = "ManualApprovalRequired"
+)
+
+// the annotations of the Space which are recorded when the user is deactivated
+var spaceSettingsAnnotationKeys = []string{
+	space.ExtraNamespacesAnnotationKey,
+	space.TemplateParametersAnnotationKey,
+}
+
+// lastProvisioning the tiers and space settings of a user at the time of their deactivation
+type lastProvisioning struct {
+	DeactivationTime metav1.Time       `json:"deactivationTime"`
+	UserTier         string            `json:"userTier,omitempty"`
+	SpaceTier        string            `json:"spaceTier,omitempty"`
+	TargetCluster    string            `json:"targetCluster,omitempty"`
+	SocialEvent      string            `json:"socialEvent,omitempty"`
+	SpaceAnnotations map[string]string `json:"spaceAnnotations,omitempty"`
+}
+
+// recordLastProvisioning records the tiers and space settings of the given MasterUserRecord and its Space in the
+// `last-provisioning` annotation of the UserSignup, before the MasterUserRecord is deleted because of the deactivation
+func (r *Reconciler) recordLastProvisioning(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, mur *toolchainv1alpha1.MasterUserRecord) error {
+	record := lastProvisioning{
+		DeactivationTime: metav1.NewTime(time.Now().Truncate(time.Second)), // the JSON encoding does not preserve fractions of seconds
+		UserTier:         mur.Spec.TierName,
+		SocialEvent:      userSignup.Labels[toolchainv1alpha1.SocialEventUserSignupLabelKey],
+	}
+	if len(mur.Spec.UserAccounts) > 0 {
+		record.TargetCluster = mur.Spec.UserAccounts[0].TargetCluster
+	}
+	s := &toolchainv1alpha1.Space{}
+	if err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: userSignup.Namespace, Name: mur.Name}, s); err != nil {
+		if !errors.IsNotFound(err) {
+			return errs.Wrapf(err, "unable to get the Space '%s' to record its settings", mur.Name)
+		}
+	} else {
+		record.SpaceTier = s.Spec.TierName
+		for _, key := range spaceSettingsAnnotationKeys {
+			if v, found := s.Annotations[key]; found {
+				if record.SpaceAnnotations == nil {
+					record.SpaceAnnotations = map[string]string{}
+				}
+				record.SpaceAnnotations[key] = v
+			}
+		}
+	}
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	logger.Info("recording the tiers and space settings before deactivation", "user_tier", record.UserTier, "space_tier", record.SpaceTier)
+	userSignup.Annotations[LastProvisioningAnnotationKey] = string(value)
+	if err := r.Client.Update(context.TODO(), userSignup); err != nil {
+		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToUpdateAnnotation, err,
+			"unable to record the tiers and space settings on UserSignup resource")
+	}
+	return nil
+}
+
+// getLastProvisioning returns the tiers and space settings recorded when the user was deactivated, or nil if the user was never
+// deactivated (or if the record is invalid)
+func getLastProvisioning(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) *lastProvisioning {
+	value, found := userSignup.Annotations[LastProvisioningAnnotationKey]
+	if !found {
+		return nil
+	}
+	record := &lastProvisioning{}
+	if err := json.Unmarshal([]byte(value), record); err != nil {
+		logger.Error(err, "ignoring invalid record of the last provisioning", "value", value)
+		return nil
+	}
+	return record
+}
+
+// LastProvisionedTiers returns the UserTier and NSTemplateTier recorded when the given UserSignup was last deactivated, if any
+func LastProvisionedTiers(userSignup *toolchainv1alpha1.UserSignup) (string, string) {
+	record := &lastProvisioning{}
+	if err := json.Unmarshal([]byte(userSignup.Annotations[LastProvisioningAnnotationKey]), record); err != nil {
+		return "", ""
+	}
+	return record.UserTier, record.SpaceTier
+}
+
+// lastProvisioningToRestore returns the tiers and space settings to restore for the returning user, or nil if the defaults should be used
+func (r *Reconciler) lastProvisioningToRestore(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) *lastProvisioning {
+	if !condition.IsTrueWithReason(userSignup.Status.Conditions, UserSignupReactivated, UserSignupReactivationRestoredReason) {
+		return nil
+	}
+	return getLastProvisioning(logger, userSignup)
+}
+
+// requiresManualApprovalForReactivation returns true if the returning user has already been reactivated more than the configured
+// threshold, in which case they need to be approved manually
+func (r *Reconciler) requiresManualApprovalForReactivation(logger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) bool {
+	threshold := config.Reactivation().ManualApprovalThreshold()
+	if threshold <= 0 {
+		return false
+	}
+	activations, err := strconv.Atoi(userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
+	if err != nil {
+		logger.Info("unable to determine the number of activations of the returning user", "value", userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
+		return false
+	}
+	// the first activation is not a reactivation
+	return activations-1 >= threshold
+}
+
+// reactivationPath returns the reason of the `Reactivated` condition for a returning user who was approved, along with a message
+func (r *Reconciler) reactivationPath(config toolchainconfig.ToolchainConfig, record *lastProvisioning) (string, string) {
+	if config.Reactivation().Policy() == toolchainconfig.ReactivationPolicyRestore {
+		return UserSignupReactivationRestoredReason, fmt.Sprintf("user tier '%s' and space tier '%s' restored", record.UserTier, record.SpaceTier)
+	}
+	return UserSignupReactivationResetToDefaultsReason, "default tiers used"
+}
+
+func statusReactivated(reason, message string) func(string) toolchainv1alpha1.Condition {
+	return func(_ string) toolchainv1alpha1.Condition {
+		return toolchainv1alpha1.Condition{
+			Type:    UserSignupReactivated,
+			Status:  corev1.ConditionTrue,
+			Reason:  reason,
+			Message: message,
+		}
+	}
+}
+
+var statusReactivationManualApprovalRequired = func(_ string) toolchainv1alpha1.Condition {
+	return toolchainv1alpha1.Condition{
+		Type:    UserSignupReactivated,
+		Status:  corev1.ConditionFalse,
+		Reason:  UserSignupReactivationManualApprovalRequiredReason,
+		Message: "the maximum number of automatic reactivations was reached",
+	}
+}
@@ -0,0 +1,212 @@
+package usersignup
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/space"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	. "github.com/codeready-toolchain/host-operator/test"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestRecordLastProvisioningOnDeactivation(t *testing.T) {
+	// given
+	userSignup := commonsignup.NewUserSignup(
+		commonsignup.Deactivated(),
+		commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved),
+		commonsignup.WithLabel(toolchainv1alpha1.SocialEventUserSignupLabelKey, "my-event"))
+	userSignup.Status.CompliantUsername = "foo"
+	mur := murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs), murtest.TierName("deactivate80"),
+		murtest.WithOwnerLabel(userSignup.Name))
+	s := spacetest.NewSpace("foo",
+		spacetest.WithTierName("base2"),
+		spacetest.WithSpecTargetCluster("member1"),
+		spacetest.WithAnnotation(space.ExtraNamespacesAnnotationKey, "extra"),
+		spacetest.WithAnnotation("other", "value"))
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, mur, s,
+		commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then
+	require.NoError(t, err)
+	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+	require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+	record := &lastProvisioning{}
+	require.NoError(t, json.Unmarshal([]byte(userSignup.Annotations[LastProvisioningAnnotationKey]), record))
+	assert.Equal(t, "deactivate80", record.UserTier)
+	assert.Equal(t, "base2", record.SpaceTier)
+	assert.Equal(t, "member-cluster", record.TargetCluster)
+	assert.Equal(t, "my-event", record.SocialEvent)
+	assert.Equal(t, map[string]string{space.ExtraNamespacesAnnotationKey: "extra"}, record.SpaceAnnotations)
+	assert.False(t, record.DeactivationTime.IsZero())
+}
+
+func TestReactivation(t *testing.T) {
+	record := func(userTier, spaceTier string) string {
+		r, err := json.Marshal(lastProvisioning{
+			UserTier:  userTier,
+			SpaceTier: spaceTier,
+			SpaceAnnotations: map[string]string{
+				space.ExtraNamespacesAnnotationKey: "extra",
+			},
+		})
+		require.NoError(t, err)
+		return string(r)
+	}
+	newReturningUserSignup := func(activations, lastProvisioning string, modifiers ...commonsignup.Modifier) *toolchainv1alpha1.UserSignup {
+		modifiers = append(modifiers,
+			commonsignup.WithTargetCluster("member1"),
+			commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueDeactivated),
+			commonsignup.WithActivations(activations),
+			commonsignup.WithAnnotation(LastProvisioningAnnotationKey, lastProvisioning))
+		return commonsignup.NewUserSignup(modifiers...)
+	}
+	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
+
+	t.Run("restore", func(t *testing.T) {
+		// given
+		userSignup := newReturningUserSignup("1", record("deactivate80", "base2"), commonsignup.ApprovedManually())
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup,
+			commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.ReactivationPolicyAnnotationKey, toolchainconfig.ReactivationPolicyRestore)),
+			baseNSTemplateTier, base2NSTemplateTier, deactivate30Tier, deactivate80Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, userSignup.Name, r.Client).HasTier(*deactivate80Tier)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.True(t, condition.IsTrueWithReason(userSignup.Status.Conditions, UserSignupReactivated, UserSignupReactivationRestoredReason))
+		assert.Equal(t, "2", userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
+
+		t.Run("space tier and settings restored", func(t *testing.T) {
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			s := spacetest.AssertThatSpace(t, test.HostOperatorNs, userSignup.Name, r.Client).
+				Exists().
+				HasTier("base2").
+				Get()
+			assert.Equal(t, "extra", s.Annotations[space.ExtraNamespacesAnnotationKey])
+		})
+	})
+
+	t.Run("restore with tiers that do not exist anymore", func(t *testing.T) {
+		// given
+		userSignup := newReturningUserSignup("1", record("unknown", "unknown"), commonsignup.ApprovedManually())
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup,
+			commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.ReactivationPolicyAnnotationKey, toolchainconfig.ReactivationPolicyRestore)),
+			baseNSTemplateTier, deactivate30Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, userSignup.Name, r.Client).HasTier(*deactivate30Tier)
+		s := spacetest.AssertThatSpace(t, test.HostOperatorNs, userSignup.Name, r.Client).
+			Exists().
+			HasTier("base").
+			Get()
+		assert.NotContains(t, s.Annotations, space.ExtraNamespacesAnnotationKey)
+	})
+
+	t.Run("reset to defaults", func(t *testing.T) {
+		// given
+		userSignup := newReturningUserSignup("1", record("deactivate80", "base2"), commonsignup.ApprovedManually())
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup,
+			commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, base2NSTemplateTier, deactivate30Tier, deactivate80Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, userSignup.Name, r.Client).HasTier(*deactivate30Tier)
+		s := spacetest.AssertThatSpace(t, test.HostOperatorNs, userSignup.Name, r.Client).
+			Exists().
+			HasTier("base").
+			Get()
+		assert.NotContains(t, s.Annotations, space.ExtraNamespacesAnnotationKey)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.True(t, condition.IsTrueWithReason(userSignup.Status.Conditions, UserSignupReactivated, UserSignupReactivationResetToDefaultsReason))
+	})
+
+	t.Run("manual approval threshold", func(t *testing.T) {
+		config := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.AutomaticApproval().Enabled(true),
+			ConfigAnnotation(toolchainconfig.ReactivationManualApprovalThresholdAnnotationKey, "1"))
+
+		t.Run("approved automatically below the threshold", func(t *testing.T) {
+			// given the user was activated once
+			userSignup := newReturningUserSignup("1", record("deactivate30", "base"))
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, config, baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+
+		t.Run("manual approval required above the threshold", func(t *testing.T) {
+			// given the user was already reactivated once
+			userSignup := newReturningUserSignup("2", record("deactivate30", "base"))
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, config, baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValuePending, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+			assert.True(t, condition.IsFalseWithReason(userSignup.Status.Conditions, UserSignupReactivated, UserSignupReactivationManualApprovalRequiredReason))
+			assert.True(t, condition.IsFalseWithReason(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved, toolchainv1alpha1.UserSignupPendingApprovalReason))
+
+			t.Run("approved manually", func(t *testing.T) {
+				// given
+				userSignup.Spec.States = append(userSignup.Spec.States, toolchainv1alpha1.UserSignupStateApproved)
+				require.NoError(t, r.Client.Update(context.TODO(), userSignup))
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+				require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+				assert.True(t, condition.IsTrueWithReason(userSignup.Status.Conditions, UserSignupReactivated, UserSignupReactivationResetToDefaultsReason))
+			})
+		})
+	})
+}
@@ -0,0 +1,93 @@
+package usersignup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/abuse"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// UserSignupRiskAssessed the type of the UserSignup condition which reports whether the user was held for a manual approval
+	// because of their risk score
+	UserSignupRiskAssessed toolchainv1alpha1.ConditionType = "RiskAssessed"
+
+	// UserSignupRiskManualApprovalRequiredReason the reason of the `RiskAssessed` condition when the user needs to be approved manually
+	// because of their risk score
+	UserSignupRiskManualApprovalRequiredReason = "ManualApprovalRequired"
+
+	// UserSignupRiskAssessmentFailedReason the reason of the `Complete` condition when the risk score of the user could not be computed
+	UserSignupRiskAssessmentFailedReason = "RiskAssessmentFailed"
+)
+
+// assessRisk computes the risk score of the user, and records it in the annotations and the label of the UserSignup.
+// Returns nil if the risk scoring is not configured.
+func (r *Reconciler) assessRisk(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) (*abuse.Assessment, error) {
+	if r.RiskScorer == nil {
+		return nil, nil
+	}
+	assessment, err := r.RiskScorer.Assess(userSignup)
+	if err != nil {
+		return nil, err
+	}
+	score := strconv.Itoa(assessment.Score)
+	if userSignup.Annotations[abuse.RiskScoreAnnotationKey] == score &&
+		userSignup.Annotations[abuse.RiskSignalsAnnotationKey] == assessment.SignalsValue() &&
+		userSignup.Labels[abuse.RiskLevelLabelKey] == string(assessment.Level()) {
+		return &assessment, nil
+	}
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	if userSignup.Labels == nil {
+		userSignup.Labels = map[string]string{}
+	}
+	scoreChanged := userSignup.Annotations[abuse.RiskScoreAnnotationKey] != score
+	userSignup.Annotations[abuse.RiskScoreAnnotationKey] = score
+	userSignup.Annotations[abuse.RiskSignalsAnnotationKey] = assessment.SignalsValue()
+	userSignup.Labels[abuse.RiskLevelLabelKey] = string(assessment.Level())
+	if err := r.Client.Update(context.TODO(), userSignup); err != nil {
+		return nil, err
+	}
+	if scoreChanged {
+		metrics.UserSignupRiskScoreHistogram.Observe(float64(assessment.Score))
+	}
+	logger.Info("risk score of the user updated", "score", assessment.Score, "signals", assessment.SignalsValue())
+	return &assessment, nil
+}
+
+// requiresManualApprovalForRisk returns true if the risk score of the user reached the configured threshold,
+// in which case they need to be approved manually
+func (r *Reconciler) requiresManualApprovalForRisk(config toolchainconfig.ToolchainConfig, assessment *abuse.Assessment) bool {
+	threshold := config.RiskScore().ManualApprovalThreshold()
+	return assessment != nil && threshold > 0 && assessment.Score >= threshold
+}
+
+func statusRiskManualApprovalRequired(assessment *abuse.Assessment) func(string) toolchainv1alpha1.Condition {
+	return func(_ string) toolchainv1alpha1.Condition {
+		return toolchainv1alpha1.Condition{
+			Type:    UserSignupRiskAssessed,
+			Status:  corev1.ConditionFalse,
+			Reason:  UserSignupRiskManualApprovalRequiredReason,
+			Message: fmt.Sprintf("the risk score of the user is %d (%s)", assessment.Score, assessment.SignalsValue()),
+		}
+	}
+}
+
+func (u *StatusUpdater) setStatusFailedToAssessRisk(userSignup *toolchainv1alpha1.UserSignup, message string) error {
+	return u.updateStatusConditions(
+		userSignup,
+		toolchainv1alpha1.Condition{
+			Type:    toolchainv1alpha1.UserSignupComplete,
+			Status:  corev1.ConditionFalse,
+			Reason:  UserSignupRiskAssessmentFailedReason,
+			Message: message,
+		})
+}
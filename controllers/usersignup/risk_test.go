@@ -0,0 +1,149 @@
+package usersignup
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/abuse"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestUserSignupWithRiskScore(t *testing.T) {
+	// given
+	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
+	// a user banned with a similar email address (but not the same)
+	bannedUser := &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "banned-foo",
+			Namespace: test.HostOperatorNs,
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email: "foo@gmail.com",
+		},
+	}
+	prepare := func(t *testing.T, userSignup *toolchainv1alpha1.UserSignup, threshold int) (*Reconciler, reconcile.Request) {
+		config := commonconfig.NewToolchainConfigObjWithReset(t, testconfig.AutomaticApproval().Enabled(true),
+			ConfigAnnotation(toolchainconfig.RiskScoreManualApprovalThresholdAnnotationKey, strconv.Itoa(threshold)))
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, bannedUser, config, baseNSTemplateTier, deactivate30Tier)
+		r.RiskScorer = abuse.NewScorer(r.Client, test.HostOperatorNs, r.BannedUsers)
+		InitializeCounters(t, NewToolchainStatus())
+		return r, req
+	}
+
+	t.Run("held for a manual approval", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "5"))
+		r, req := prepare(t, userSignup, 60)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, "70", userSignup.Annotations[abuse.RiskScoreAnnotationKey])
+		assert.Equal(t, "banned-username-similarity=50,verification-retries=20", userSignup.Annotations[abuse.RiskSignalsAnnotationKey])
+		assert.Equal(t, string(abuse.RiskLevelHigh), userSignup.Labels[abuse.RiskLevelLabelKey])
+		assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValuePending, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+		risk, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupRiskAssessed)
+		require.True(t, found)
+		assert.Equal(t, v1.ConditionFalse, risk.Status)
+		assert.Equal(t, UserSignupRiskManualApprovalRequiredReason, risk.Reason)
+		assert.Equal(t, "the risk score of the user is 70 (banned-username-similarity=50,verification-retries=20)", risk.Message)
+		assert.True(t, condition.IsFalseWithReason(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupApproved, toolchainv1alpha1.UserSignupPendingApprovalReason))
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupHeldForReviewCounterVec.WithLabelValues("internal"))
+
+		t.Run("held only once", func(t *testing.T) {
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupHeldForReviewCounterVec.WithLabelValues("internal"))
+		})
+
+		t.Run("provisioned once approved manually", func(t *testing.T) {
+			// given
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			commonsignup.ApprovedManually()(userSignup)
+			require.NoError(t, r.Client.Update(context.TODO(), userSignup))
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		})
+	})
+
+	t.Run("approved automatically below the threshold", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "5"))
+		r, req := prepare(t, userSignup, 80)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, "70", userSignup.Annotations[abuse.RiskScoreAnnotationKey])
+		assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueApproved, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+		_, found := condition.FindConditionByType(userSignup.Status.Conditions, UserSignupRiskAssessed)
+		assert.False(t, found)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupHeldForReviewCounterVec.WithLabelValues("internal"))
+	})
+
+	t.Run("score recorded when manual approval is disabled", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup()
+		r, req := prepare(t, userSignup, 0)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, "50", userSignup.Annotations[abuse.RiskScoreAnnotationKey])
+		assert.Equal(t, string(abuse.RiskLevelMedium), userSignup.Labels[abuse.RiskLevelLabelKey])
+	})
+
+	t.Run("approved manually despite the score", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(),
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "5"))
+		r, req := prepare(t, userSignup, 60)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupHeldForReviewCounterVec.WithLabelValues("internal"))
+	})
+}
@@ -2,6 +2,7 @@ package usersignup
 
 import (
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -22,5 +23,6 @@ func newSpace(userSignup *toolchainv1alpha1.UserSignup, targetCluster targetClus
 			TierName:      tier,
 		},
 	}
+	tracing.PropagateTraceContext(userSignup, space)
 	return space
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	commonCondition "github.com/codeready-toolchain/toolchain-common/pkg/condition"
 
 	"github.com/go-logr/logr"
@@ -12,8 +14,30 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// UserSignupUsernameAllocationFailedReason the reason of the `Complete` condition when no compliant username could be allocated to the user
+const UserSignupUsernameAllocationFailedReason = "UsernameAllocationFailed"
+
+// UserSignupProvisionedEventReason the reason of the event recorded when the provisioning of the user is complete
+const UserSignupProvisionedEventReason = "Provisioned"
+
+// userSignupEvents the type of the events recorded when the conditions of a UserSignup are set with the following reasons
+var userSignupEvents = events.ReasonTypes{
+	toolchainv1alpha1.UserSignupApprovedAutomaticallyReason:  corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupApprovedByAdminReason:        corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupPendingApprovalReason:        corev1.EventTypeNormal,
+	UserSignupRiskManualApprovalRequiredReason:               corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupNoClusterAvailableReason:     corev1.EventTypeWarning,
+	toolchainv1alpha1.UserSignupVerificationRequiredReason:   corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupUserBanningReason:            corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupUserBannedReason:             corev1.EventTypeWarning,
+	toolchainv1alpha1.UserSignupDeactivationInProgressReason: corev1.EventTypeNormal,
+	toolchainv1alpha1.UserSignupUserDeactivatedReason:        corev1.EventTypeNormal,
+}
+
 type StatusUpdater struct {
 	Client client.Client
+	// EventRecorder records the events of the state transitions and failures of the UserSignups
+	EventRecorder *events.Recorder
 }
 
 func (u *StatusUpdater) setStatusApprovedAutomatically(userSignup *toolchainv1alpha1.UserSignup, message string) error {
@@ -194,6 +218,17 @@ func (u *StatusUpdater) setStatusDeactivated(userSignup *toolchainv1alpha1.UserS
 		})
 }
 
+func (u *StatusUpdater) setStatusFailedToAllocateUsername(userSignup *toolchainv1alpha1.UserSignup, message string) error {
+	return u.updateStatusConditions(
+		userSignup,
+		toolchainv1alpha1.Condition{
+			Type:    toolchainv1alpha1.UserSignupComplete,
+			Status:  corev1.ConditionFalse,
+			Reason:  UserSignupUsernameAllocationFailedReason,
+			Message: message,
+		})
+}
+
 func (u *StatusUpdater) setStatusFailedToReadBannedUsers(userSignup *toolchainv1alpha1.UserSignup, message string) error {
 	return u.updateStatusConditions(
 		userSignup,
@@ -361,6 +396,7 @@ func (u *StatusUpdater) updateCompleteStatus(logger logr.Logger, compliantUserna
 	return func(userSignup *toolchainv1alpha1.UserSignup, message string) error {
 
 		usernameUpdated := userSignup.Status.CompliantUsername != compliantUsername
+		wasComplete := commonCondition.IsTrueWithReason(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete, "")
 		userSignup.Status.CompliantUsername = compliantUsername
 
 		var conditionUpdated bool
@@ -377,7 +413,13 @@ func (u *StatusUpdater) updateCompleteStatus(logger logr.Logger, compliantUserna
 			return nil
 		}
 
-		return u.Client.Status().Update(context.TODO(), userSignup)
+		if err := u.Client.Status().Update(context.TODO(), userSignup); err != nil {
+			return err
+		}
+		if !wasComplete {
+			u.EventRecorder.Normal(userSignup, UserSignupProvisionedEventReason, "the user was provisioned with the username '%s'", compliantUsername)
+		}
+		return nil
 	}
 }
 
@@ -390,15 +432,22 @@ func (u *StatusUpdater) wrapErrorWithStatusUpdate(logger logr.Logger, userSignup
 	if err := statusUpdater(userSignup, err.Error()); err != nil {
 		logger.Error(err, "Error updating UserSignup status")
 	}
+	metrics.IncrementControllerFailures("usersignup", userSignup.Status.Conditions, err.Error())
+	u.EventRecorder.Failure(userSignup, userSignup.Status.Conditions, err.Error())
 	return errs.Wrapf(err, format, args...)
 }
 
 func (u *StatusUpdater) updateStatusConditions(userSignup *toolchainv1alpha1.UserSignup, newConditions ...toolchainv1alpha1.Condition) error {
 	var updated bool
+	previous := append([]toolchainv1alpha1.Condition{}, userSignup.Status.Conditions...)
 	userSignup.Status.Conditions, updated = commonCondition.AddOrUpdateStatusConditions(userSignup.Status.Conditions, newConditions...)
 	if !updated {
 		// Nothing changed
 		return nil
 	}
-	return u.Client.Status().Update(context.TODO(), userSignup)
+	if err := u.Client.Status().Update(context.TODO(), userSignup); err != nil {
+		return err
+	}
+	u.EventRecorder.Transitions(userSignup, previous, userSignupEvents, newConditions...)
+	return nil
 }
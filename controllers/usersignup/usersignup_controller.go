@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/abuse"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/pkg/pending"
 	"github.com/codeready-toolchain/host-operator/pkg/segment"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/notificationtemplates"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
 	commoncontrollers "github.com/codeready-toolchain/toolchain-common/controllers"
 	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
@@ -20,7 +25,6 @@ import (
 	notify "github.com/codeready-toolchain/toolchain-common/pkg/notification"
 	"github.com/codeready-toolchain/toolchain-common/pkg/spacebinding"
 	"github.com/codeready-toolchain/toolchain-common/pkg/states"
-	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
 	"github.com/redhat-cop/operator-utils/pkg/util"
 
 	"github.com/go-logr/logr"
@@ -28,7 +32,6 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/validation"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -51,6 +54,9 @@ func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
 		Watches(
 			&source.Kind{Type: &toolchainv1alpha1.BannedUser{}},
 			handler.EnqueueRequestsFromMapFunc(MapBannedUserToUserSignup(mgr.GetClient()))).
+		Watches(
+			&source.Kind{Type: &toolchainv1alpha1.BannedUser{}},
+			r.BannedUsers.EventHandler()).
 		Watches(
 			&source.Kind{Type: &toolchainv1alpha1.Space{}},
 			handler.EnqueueRequestsFromMapFunc(commoncontrollers.MapToOwnerByLabel(r.Namespace, toolchainv1alpha1.SpaceCreatorLabelKey))).
@@ -73,6 +79,12 @@ type Reconciler struct {
 	Scheme            *runtime.Scheme
 	GetMemberClusters cluster.GetMemberClustersFunc
 	SegmentClient     *segment.Client
+	// BannedUsers the index of the BannedUsers which ban users by email domain, phone number hash, username pattern or company
+	BannedUsers *banneduser.Index
+	// RiskScorer computes the risk score of the users before they are approved (optional)
+	RiskScorer *abuse.Scorer
+	// UsernameAllocator allocates and reserves the compliant usernames of the users
+	UsernameAllocator *username.Allocator
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=usersignups,verbs=get;list;watch;create;update;patch;delete
@@ -99,8 +111,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// The username reservations are not owned by the UserSignup, as they are kept during the cool-down period.
 			// Return and don't requeue
-			return reconcile.Result{}, nil
+			return reconcile.Result{}, r.UsernameAllocator.Release(request.Name)
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
@@ -126,10 +139,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 		return reconcile.Result{}, err
 	}
 
-	banned, err := r.isUserBanned(logger, userSignup)
+	ban, err := r.isUserBanned(logger, config, userSignup)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
+	banned := ban != nil
+
+	// start the trace of the signup of a user who is not provisioned yet, which is then carried onto the resources provisioned for the user
+	if !banned && !states.Deactivated(userSignup) && userSignup.Status.CompliantUsername == "" &&
+		tracing.EnsureTraceContext(ctx, "signup", userSignup) {
+		if err := r.Client.Update(context.TODO(), userSignup); err != nil {
+			return reconcile.Result{}, errs.Wrap(err, "unable to store the trace context on the UserSignup")
+		}
+	}
+	_, span := tracing.StartReconcileSpan(ctx, "usersignup", userSignup)
+	defer span.End()
 
 	// If the usersignup is not banned and not deactivated then ensure the deactivated notification status is set to false.
 	// This is especially important for cases when a user is deactivated and then reactivated because the status is used to
@@ -162,12 +186,30 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 				r.setStatusDeactivatingNotificationCreationFailed, err, "Failed to create user deactivating notification")
 		}
 
+		if err := r.markDeactivationReminderSent(logger, userSignup); err != nil {
+			return reconcile.Result{}, err
+		}
+
 		if err := r.updateStatus(logger, userSignup, r.setStatusDeactivatingNotificationCreated); err != nil {
 			logger.Error(err, "Failed to update notification created status")
 			return reconcile.Result{}, err
 		}
 	}
 
+	// Send the subsequent deactivation reminder requested by the deactivation controller, if any
+	if states.Deactivating(userSignup) && condition.IsTrue(userSignup.Status.Conditions,
+		toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated) {
+		if _, due := userSignup.Annotations[deactivation.DeactivationReminderDueAnnotationKey]; due {
+			if err := r.sendDeactivationReminderNotification(logger, config, userSignup); err != nil {
+				logger.Error(err, "Failed to create user deactivation reminder notification")
+				return reconcile.Result{}, err
+			}
+			if err := r.markDeactivationReminderSent(logger, userSignup); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
 	if exists, err := r.checkIfMurAlreadyExists(logger, config, userSignup, banned); err != nil || exists {
 		return reconcile.Result{}, err
 	}
@@ -175,12 +217,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	// If there is no MasterUserRecord created, yet the UserSignup is Banned, simply set the status
 	// and return
 	if banned {
-		// if the UserSignup doesn't have the state=banned label set, then update it
-		if err := r.setStateLabel(logger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueBanned); err != nil {
-			return reconcile.Result{}, err
-		}
-
-		return reconcile.Result{}, r.updateStatus(logger, userSignup, r.setStatusBanned)
+		return r.handleBannedUserSignup(logger, userSignup, ban)
 	}
 
 	// Check if the user has been deactivated
@@ -191,6 +228,31 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	return reconcile.Result{}, r.ensureNewMurIfApproved(logger, config, userSignup)
 }
 
+// handleBannedUserSignup defines the workflow for banned users
+//
+// The user is moved to manual review while their appeal against the ban is pending, or is marked as banned otherwise.
+// In both cases, the UserSignup is reconciled again when the ban expires, so that the user is restored automatically.
+func (r *Reconciler) handleBannedUserSignup(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, ban *Ban) (ctrl.Result, error) {
+	result := reconcile.Result{RequeueAfter: ban.requeueAfter(time.Now())}
+	if ban.Appeal == BanAppealPending {
+		logger.Info("ban appeal is pending, waiting for a manual review")
+		if err := r.setStateLabel(logger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending); err != nil {
+			return reconcile.Result{}, err
+		}
+		msg := "the appeal against the ban is under review"
+		if m := ban.Message(); m != "" {
+			msg = fmt.Sprintf("%s (%s)", msg, m)
+		}
+		return result, r.set(statusPendingApproval, statusIncompletePendingApproval)(userSignup, msg)
+	}
+
+	// if the UserSignup doesn't have the state=banned label set, then update it
+	if err := r.setStateLabel(logger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueBanned); err != nil {
+		return reconcile.Result{}, err
+	}
+	return result, r.setStatusBanned(userSignup, ban.Message())
+}
+
 // handleDeactivatedUserSignup defines the workflow for deactivated users
 //
 // If there is no MasterUserRecord created, yet the UserSignup is marked as Deactivated, set the status,
@@ -230,9 +292,10 @@ func (r *Reconciler) handleDeactivatedUserSignup(logger logr.Logger, config tool
 
 // Is the user banned? To determine this we query the BannedUser resource for any matching entries.  The query
 // is based on the user's emailHash value - if there is a match, and the e-mail addresses are equal, then the
-// user is banned.
-func (r *Reconciler) isUserBanned(reqLogger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) (bool, error) {
-	banned := false
+// user is banned, unless the ban expired or its scope does not cover the user. The ban which applies is returned,
+// or nil if the user is not banned.
+func (r *Reconciler) isUserBanned(reqLogger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) (*Ban, error) {
+	var ban *Ban
 	// Lookup the user email annotation
 	if emailLbl, exists := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]; exists {
 
@@ -245,34 +308,44 @@ func (r *Reconciler) isUserBanned(reqLogger logr.Logger, userSignup *toolchainv1
 
 			// Query BannedUser for resources that match the same email hash
 			if err := r.Client.List(context.TODO(), bannedUserList, opts); err != nil {
-				return false, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToReadBannedUsers, err, "Failed to query BannedUsers")
+				return nil, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToReadBannedUsers, err, "Failed to query BannedUsers")
 			}
 
-			// One last check to confirm that the e-mail addresses match also (in case of the infinitesimal chance of a hash collision)
+			// One last check to confirm that the e-mail addresses match also (in case of the infinitesimal chance of a hash collision).
+			// The BannedUsers of purged users only retain the hash of the email address.
+			var bannedUsers []toolchainv1alpha1.BannedUser
 			for _, bannedUser := range bannedUserList.Items {
-				if bannedUser.Spec.Email == emailLbl {
-					banned = true
-					break
+				if bannedUser.Spec.Email == emailLbl || bannedUser.Spec.Email == emailHashLbl {
+					bannedUsers = append(bannedUsers, bannedUser)
 				}
 			}
+			// Also look for the BannedUsers which ban the user by email domain, phone number hash, username pattern or company
+			matchingRules, err := r.BannedUsers.Matching(userSignup)
+			if err != nil {
+				return nil, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToReadBannedUsers, err, "Failed to query BannedUsers")
+			}
+			bannedUsers = append(bannedUsers, matchingRules...)
+			if ban, err = r.applicableBan(reqLogger, config, userSignup, bannedUsers); err != nil {
+				return nil, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToReadBannedUsers, err, "Failed to check the scope of the BannedUsers")
+			}
 
 			hashIsValid := validateEmailHash(emailLbl, emailHashLbl)
 			if !hashIsValid {
 				err := fmt.Errorf("hash is invalid")
-				return banned, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusInvalidEmailHash, err, "the email hash '%s' is invalid ", emailHashLbl)
+				return ban, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusInvalidEmailHash, err, "the email hash '%s' is invalid ", emailHashLbl)
 			}
 		} else {
 			// If there isn't an email-hash label, then the state is invalid
 			err := fmt.Errorf("missing label at usersignup")
-			return banned, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusMissingEmailHash, err,
+			return ban, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusMissingEmailHash, err,
 				"the required label '%s' is not present", toolchainv1alpha1.UserSignupUserEmailHashLabelKey)
 		}
 	} else {
 		err := fmt.Errorf("missing annotation at usersignup")
-		return banned, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusInvalidMissingUserEmailAnnotation, err,
+		return ban, r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusInvalidMissingUserEmailAnnotation, err,
 			"the required annotation '%s' is not present", toolchainv1alpha1.UserSignupUserEmailAnnotationKey)
 	}
-	return banned, nil
+	return ban, nil
 }
 
 // checkIfMurAlreadyExists checks if there is already a MUR for the given UserSignup.
@@ -291,6 +364,16 @@ func (r *Reconciler) checkIfMurAlreadyExists(reqLogger logr.Logger, config toolc
 	}
 
 	murs := murList.Items
+	// While the user is being renamed, both the MasterUserRecord with the previous name and the one with the new name exist:
+	// the one recorded in the status is used until the rename switches the user to the new one
+	if len(murs) > 1 {
+		for i := range murs {
+			if murs[i].Name == userSignup.Status.CompliantUsername {
+				murs = murs[i : i+1]
+				break
+			}
+		}
+	}
 	// If we found more than one MasterUserRecord, then die
 	if len(murs) > 1 {
 		err := fmt.Errorf("multiple matching MasterUserRecord resources found")
@@ -313,6 +396,10 @@ func (r *Reconciler) checkIfMurAlreadyExists(reqLogger logr.Logger, config toolc
 			// We set the inProgressStatusUpdater parameter here to setStatusDeactivationInProgress, as a temporary status before
 			// the main reconcile function completes the deactivation process
 			reqLogger.Info("Deleting MasterUserRecord since user has been deactivated")
+			// record the tiers and space settings so that they can be restored if the user comes back
+			if err := r.recordLastProvisioning(reqLogger, userSignup, mur); err != nil {
+				return true, err
+			}
 			return true, r.deleteMasterUserRecord(mur, userSignup, reqLogger, r.setStatusDeactivationInProgress)
 		}
 
@@ -350,6 +437,12 @@ func (r *Reconciler) checkIfMurAlreadyExists(reqLogger logr.Logger, config toolc
 				return true, err
 			}
 
+			// the ownership of the Space was transferred to another user: the SpaceBinding of this user must not be recreated
+			if creator := space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey]; creator != "" && creator != userSignup.Name {
+				reqLogger.Info("Space was transferred to another user", "Space", space.Name, "creator", creator)
+				return true, r.updateStatus(reqLogger, userSignup, r.updateCompleteStatus(reqLogger, mur.Name))
+			}
+
 			if err = r.ensureSpaceBinding(reqLogger, userSignup, mur, space); err != nil {
 				return true, err
 			}
@@ -373,6 +466,30 @@ func (r *Reconciler) ensureNewMurIfApproved(reqLogger logr.Logger, config toolch
 		return r.updateStatus(reqLogger, userSignup, r.setStatusVerificationRequired)
 	}
 
+	// A user may need to be approved manually, depending on their risk score
+	assessment, err := r.assessRisk(reqLogger, userSignup)
+	if err != nil {
+		return r.wrapErrorWithStatusUpdate(reqLogger, userSignup, r.setStatusFailedToAssessRisk, err, "unable to assess the risk score of the user")
+	}
+	if !states.ApprovedManually(userSignup) && r.requiresManualApprovalForRisk(config, assessment) {
+		if !condition.IsFalseWithReason(userSignup.Status.Conditions, UserSignupRiskAssessed, UserSignupRiskManualApprovalRequiredReason) {
+			metrics.UserSignupHeldForReviewCounterVec.WithLabelValues(string(metrics.GetEmailDomain(userSignup))).Inc()
+		}
+		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending); err != nil {
+			return err
+		}
+		return r.updateStatus(reqLogger, userSignup, r.set(statusPendingApproval, statusIncompletePendingApproval, statusRiskManualApprovalRequired(assessment)))
+	}
+
+	// A returning user may need to be approved manually, depending on the number of times they were reactivated
+	lastProvisioning := getLastProvisioning(reqLogger, userSignup)
+	if lastProvisioning != nil && !states.ApprovedManually(userSignup) && r.requiresManualApprovalForReactivation(reqLogger, config, userSignup) {
+		if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValuePending); err != nil {
+			return err
+		}
+		return r.updateStatus(reqLogger, userSignup, r.set(statusPendingApproval, statusIncompletePendingApproval, statusReactivationManualApprovalRequired))
+	}
+
 	approved, targetCluster, err := getClusterIfApproved(r.Client, userSignup, r.GetMemberClusters)
 	reqLogger.Info("ensuring MUR", "approved", approved, "target_cluster", targetCluster, "error", err)
 	// if error was returned or no available cluster found
@@ -414,6 +531,13 @@ func (r *Reconciler) ensureNewMurIfApproved(reqLogger logr.Logger, config toolch
 			return err
 		}
 	}
+	// report how the tiers and space settings of a returning user are handled
+	if lastProvisioning != nil {
+		reason, message := r.reactivationPath(config, lastProvisioning)
+		if err := r.updateStatus(reqLogger, userSignup, r.set(statusReactivated(reason, message))); err != nil {
+			return err
+		}
+	}
 	// set the state label to approved
 	if err := r.setStateLabel(reqLogger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueApproved); err != nil {
 		return err
@@ -428,6 +552,15 @@ func (r *Reconciler) ensureNewMurIfApproved(reqLogger logr.Logger, config toolch
 }
 
 func (r *Reconciler) getUserTier(reqLogger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) (*toolchainv1alpha1.UserTier, error) {
+	if record := r.lastProvisioningToRestore(reqLogger, userSignup); record != nil && record.UserTier != "" {
+		reqLogger.Info("looking-up UserTier to restore", "name", record.UserTier)
+		userTier := &toolchainv1alpha1.UserTier{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: userSignup.Namespace, Name: record.UserTier}, userTier)
+		if err == nil || !errors.IsNotFound(err) {
+			return userTier, err
+		}
+		reqLogger.Info("UserTier to restore not found, using the default one", "name", record.UserTier)
+	}
 	tierName := config.Tiers().DefaultUserTier()
 	if event, err := r.getSocialEvent(userSignup); err != nil {
 		return nil, err
@@ -441,6 +574,15 @@ func (r *Reconciler) getUserTier(reqLogger logr.Logger, config toolchainconfig.T
 }
 
 func (r *Reconciler) getNSTemplateTier(reqLogger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) (*toolchainv1alpha1.NSTemplateTier, error) {
+	if record := r.lastProvisioningToRestore(reqLogger, userSignup); record != nil && record.SpaceTier != "" {
+		reqLogger.Info("looking-up NSTemplateTier to restore", "name", record.SpaceTier)
+		nstemplateTier := &toolchainv1alpha1.NSTemplateTier{}
+		err := r.Client.Get(context.TODO(), types.NamespacedName{Namespace: userSignup.Namespace, Name: record.SpaceTier}, nstemplateTier)
+		if err == nil || !errors.IsNotFound(err) {
+			return nstemplateTier, err
+		}
+		reqLogger.Info("NSTemplateTier to restore not found, using the default one", "name", record.SpaceTier)
+	}
 	tierName := config.Tiers().DefaultSpaceTier()
 	if event, err := r.getSocialEvent(userSignup); err != nil {
 		return nil, err
@@ -485,20 +627,23 @@ func (r *Reconciler) setStateLabel(logger logr.Logger, userSignup *toolchainv1al
 		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToUpdateStateLabel, err,
 			"unable to update state label at UserSignup resource")
 	}
-	r.updateUserSignupMetricsByState(logger, userSignup, oldState, state)
-	// increment the counter *only if the client update did not fail*
+	// increment the counters *only if the client update did not fail*
 	domain := metrics.GetEmailDomain(userSignup)
+	r.updateUserSignupMetricsByState(logger, userSignup, domain, oldState, state)
 	counter.UpdateUsersPerActivationCounters(logger, activations, domain) // will ignore if `activations == 0`
 	return nil
 }
 
-func (r *Reconciler) updateUserSignupMetricsByState(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, oldState string, newState string) {
+func (r *Reconciler) updateUserSignupMetricsByState(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup, domain metrics.Domain, oldState string, newState string) {
 	if oldState == "" {
-		metrics.UserSignupUniqueTotal.Inc()
+		metrics.UserSignupUniqueCounterVec.WithLabelValues(string(domain)).Inc()
 	}
 	switch newState {
 	case toolchainv1alpha1.UserSignupStateLabelValueApproved:
-		metrics.UserSignupApprovedTotal.Inc()
+		metrics.UserSignupApprovedCounterVec.WithLabelValues(string(domain)).Inc()
+		if userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey] == "1" {
+			metrics.UserSignupApprovalDurationHistogram.Observe(time.Since(userSignup.CreationTimestamp.Time).Seconds())
+		}
 		// track activation in Segment
 		if r.SegmentClient != nil {
 			r.SegmentClient.TrackAccountActivation(userSignup.Spec.Username)
@@ -507,60 +652,20 @@ func (r *Reconciler) updateUserSignupMetricsByState(logger logr.Logger, userSign
 		}
 	case toolchainv1alpha1.UserSignupStateLabelValueDeactivated:
 		if oldState == toolchainv1alpha1.UserSignupStateLabelValueApproved {
-			metrics.UserSignupDeactivatedTotal.Inc()
+			metrics.UserSignupDeactivatedCounterVec.WithLabelValues(string(domain)).Inc()
 		}
 	case toolchainv1alpha1.UserSignupStateLabelValueBanned:
-		metrics.UserSignupBannedTotal.Inc()
+		metrics.UserSignupBannedCounterVec.WithLabelValues(string(domain)).Inc()
 	}
 }
 
 func (r *Reconciler) generateCompliantUsername(config toolchainconfig.ToolchainConfig, instance *toolchainv1alpha1.UserSignup) (string, error) {
-	replaced := usersignup.TransformUsername(instance.Spec.Username)
-
-	// Check for any forbidden prefixes
-	for _, prefix := range config.Users().ForbiddenUsernamePrefixes() {
-		if strings.HasPrefix(replaced, prefix) {
-			replaced = fmt.Sprintf("%s%s", "crt-", replaced)
-			break
-		}
-	}
-
-	// Check for any forbidden suffixes
-	for _, suffix := range config.Users().ForbiddenUsernameSuffixes() {
-		if strings.HasSuffix(replaced, suffix) {
-			replaced = fmt.Sprintf("%s%s", replaced, "-crt")
-			break
-		}
-	}
-
-	validationErrors := validation.IsQualifiedName(replaced)
-	if len(validationErrors) > 0 {
-		return "", fmt.Errorf(fmt.Sprintf("transformed username [%s] is invalid", replaced))
-	}
-
-	transformed := replaced
-
-	for i := 2; i < 101; i++ { // No more than 100 attempts to find a vacant name
-		mur := &toolchainv1alpha1.MasterUserRecord{}
-		// Check if a MasterUserRecord exists with the same transformed name
-		namespacedMurName := types.NamespacedName{Namespace: instance.Namespace, Name: transformed}
-		err := r.Client.Get(context.TODO(), namespacedMurName, mur)
-		if err != nil {
-			if !errors.IsNotFound(err) {
-				return "", err
-			}
-			// If there was a NotFound error looking up the mur, it means we found an available name
-			return transformed, nil
-		} else if mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] == instance.Name {
-			// If the found MUR has the same UserID as the UserSignup, then *it* is the correct MUR -
-			// Return an error here and allow the reconcile() function to pick it up on the next loop
-			return "", fmt.Errorf(fmt.Sprintf("INFO: could not generate compliant username as MasterUserRecord with the same name [%s] and user id [%s] already exists. The next reconcile loop will pick it up.", mur.Name, instance.Name))
-		}
-
-		transformed = fmt.Sprintf("%s-%d", replaced, i)
+	base, err := r.UsernameAllocator.Base(instance, config.Users().ForbiddenUsernamePrefixes(), config.Users().ForbiddenUsernameSuffixes(),
+		config.Users().ForbiddenUsernameWords())
+	if err != nil {
+		return "", err
 	}
-
-	return "", fmt.Errorf(fmt.Sprintf("unable to transform username [%s] even after 100 attempts", instance.Spec.Username))
+	return r.UsernameAllocator.Allocate(instance, base)
 }
 
 // provisionMasterUserRecord does the work of provisioning the MasterUserRecord
@@ -576,7 +681,7 @@ func (r *Reconciler) provisionMasterUserRecord(logger logr.Logger, config toolch
 
 	compliantUsername, err := r.generateCompliantUsername(config, userSignup)
 	if err != nil {
-		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToCreateMUR, err,
+		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToAllocateUsername, err,
 			"Error generating compliant username for %s", userSignup.Spec.Username)
 	}
 
@@ -628,6 +733,15 @@ func (r *Reconciler) ensureSpace(logger logr.Logger, userSignup *toolchainv1alph
 	tCluster := targetCluster(mur.Spec.UserAccounts[0].TargetCluster)
 
 	space = newSpace(userSignup, tCluster, mur.Name, spaceTier.Name)
+	// restore the settings of the Space of a returning user, if applicable
+	if record := r.lastProvisioningToRestore(logger, userSignup); record != nil && record.SpaceTier == spaceTier.Name {
+		for key, value := range record.SpaceAnnotations {
+			if space.Annotations == nil {
+				space.Annotations = map[string]string{}
+			}
+			space.Annotations[key] = value
+		}
+	}
 
 	err = r.Client.Create(context.TODO(), space)
 	if err != nil {
@@ -665,6 +779,7 @@ func (r *Reconciler) ensureSpaceBinding(logger logr.Logger, userSignup *toolchai
 	}
 
 	spaceBinding := spacebinding.NewSpaceBinding(mur, space, userSignup.Name)
+	tracing.PropagateTraceContext(userSignup, spaceBinding)
 
 	if err := r.Client.Create(context.TODO(), spaceBinding); err != nil {
 		return r.wrapErrorWithStatusUpdate(logger, userSignup, r.setStatusFailedToCreateSpaceBinding, err,
@@ -733,6 +848,10 @@ func (r *Reconciler) sendDeactivatingNotification(logger logr.Logger, config too
 
 		keysAndVals := map[string]string{
 			toolchainconfig.NotificationContextRegistrationURLKey: config.RegistrationService().RegistrationServiceURL(),
+			toolchainconfig.NotificationContextDaysRemainingKey:   strconv.Itoa(config.Deactivation().DeactivatingNotificationDays()),
+		}
+		if days, found := userSignup.Annotations[deactivation.DeactivationReminderDueAnnotationKey]; found {
+			keysAndVals[toolchainconfig.NotificationContextDaysRemainingKey] = days
 		}
 
 		notification, err := notify.NewNotificationBuilder(r.Client, userSignup.Namespace).
@@ -753,6 +872,61 @@ func (r *Reconciler) sendDeactivatingNotification(logger logr.Logger, config too
 	return nil
 }
 
+// sendDeactivationReminderNotification sends the deactivation reminder specified by the `deactivation-reminder-due` annotation.
+// The name of the notification is derived from the number of days before the deactivation, so that the same reminder is not
+// sent twice
+func (r *Reconciler) sendDeactivationReminderNotification(logger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) error {
+	days, err := strconv.Atoi(userSignup.Annotations[deactivation.DeactivationReminderDueAnnotationKey])
+	if err != nil {
+		return errs.Wrapf(err, "invalid value for annotation '%s'", deactivation.DeactivationReminderDueAnnotationKey)
+	}
+	template := notificationtemplates.UserDeactivationReminder
+	if days <= 1 {
+		template = notificationtemplates.UserDeactivationFinalReminder
+	}
+	keysAndVals := map[string]string{
+		toolchainconfig.NotificationContextRegistrationURLKey: config.RegistrationService().RegistrationServiceURL(),
+		toolchainconfig.NotificationContextDaysRemainingKey:   strconv.Itoa(days),
+	}
+
+	notification, err := notify.NewNotificationBuilder(r.Client, userSignup.Namespace).
+		WithName(fmt.Sprintf("%s-%s-%d", userSignup.Status.CompliantUsername, toolchainv1alpha1.NotificationTypeDeactivating, days)).
+		WithTemplate(template.Name).
+		WithNotificationType(toolchainv1alpha1.NotificationTypeDeactivating).
+		WithControllerReference(userSignup, r.Scheme).
+		WithUserContext(userSignup).
+		WithKeysAndValues(keysAndVals).
+		Create(userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey])
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			logger.Info("Deactivation reminder notification already exists", "days", days)
+			return nil
+		}
+		return err
+	}
+	logger.Info(fmt.Sprintf("Deactivation reminder notification resource [%s] created", notification.Name), "days", days)
+	return nil
+}
+
+// markDeactivationReminderSent records the reminder specified by the `deactivation-reminder-due` annotation (if any)
+// as sent, so that the deactivation controller does not request it again
+func (r *Reconciler) markDeactivationReminderSent(logger logr.Logger, userSignup *toolchainv1alpha1.UserSignup) error {
+	value, found := userSignup.Annotations[deactivation.DeactivationReminderDueAnnotationKey]
+	if !found {
+		return nil
+	}
+	if days, err := strconv.Atoi(value); err == nil {
+		deactivation.MarkDeactivationReminderSent(userSignup, days)
+	} else {
+		logger.Error(err, "ignoring invalid deactivation reminder", "value", value)
+		delete(userSignup.Annotations, deactivation.DeactivationReminderDueAnnotationKey)
+	}
+	if err := r.Client.Update(context.TODO(), userSignup); err != nil {
+		return errs.Wrap(err, "unable to record the deactivation reminder as sent")
+	}
+	return nil
+}
+
 func (r *Reconciler) sendDeactivatedNotification(logger logr.Logger, config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup) error {
 	labels := map[string]string{
 		toolchainv1alpha1.NotificationUserNameLabelKey: userSignup.Status.CompliantUsername,
@@ -12,11 +12,16 @@ import (
 	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/pkg/segment"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
 	. "github.com/codeready-toolchain/host-operator/test"
 	ntest "github.com/codeready-toolchain/host-operator/test/notification"
 	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
@@ -42,6 +47,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -129,8 +135,8 @@ func TestUserSignupCreateMUROk(t *testing.T) {
 			default:
 				murtest.AssertThatMasterUserRecord(t, userSignup.Name, r.Client).HasTier(*deactivate30Tier)
 			}
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal) // zero because we started with a not-ready state instead of empty as per usual
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal")) // zero because we started with a not-ready state instead of empty as per usual
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 			AssertThatCountersAndMetrics(t).
@@ -258,6 +264,160 @@ func TestUserSignupCreateSpaceAndSpaceBindingOk(t *testing.T) {
 	}
 }
 
+func TestUserSignupTraceContext(t *testing.T) {
+	// given
+	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
+	recorder := SetupSpanRecorder(t)
+	defer counter.Reset()
+	userSignup := commonsignup.NewUserSignup(
+		commonsignup.ApprovedManually(),
+		commonsignup.WithTargetCluster("member1"),
+		commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueNotReady))
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, baseNSTemplateTier, deactivate30Tier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then the trace context is stored on the UserSignup and carried onto the MUR
+	require.NoError(t, err)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: userSignup.Name}, userSignup)
+	require.NoError(t, err)
+	traceContext := userSignup.Annotations[tracing.TraceContextAnnotationKey]
+	require.NotEmpty(t, traceContext)
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: userSignup.Name}, mur)
+	require.NoError(t, err)
+	assert.Equal(t, traceContext, mur.Annotations[tracing.TraceContextAnnotationKey])
+	// the span of the reconcile is linked to the trace of the signup
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "signup", spans[0].Name())
+	assert.Equal(t, "reconcile usersignup", spans[1].Name())
+	require.Len(t, spans[1].Links(), 1)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].Links()[0].SpanContext.TraceID())
+
+	t.Run("carried onto the Space and SpaceBinding", func(t *testing.T) {
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		space := &toolchainv1alpha1.Space{}
+		err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: userSignup.Name}, space)
+		require.NoError(t, err)
+		assert.Equal(t, traceContext, space.Annotations[tracing.TraceContextAnnotationKey])
+		spaceBindings := &toolchainv1alpha1.SpaceBindingList{}
+		err = r.Client.List(context.TODO(), spaceBindings, client.InNamespace(req.Namespace))
+		require.NoError(t, err)
+		require.Len(t, spaceBindings.Items, 1)
+		assert.Equal(t, traceContext, spaceBindings.Items[0].Annotations[tracing.TraceContextAnnotationKey])
+		// no new trace was started
+		assert.Len(t, recorder.Ended(), 4)
+	})
+
+	t.Run("no trace for a user who is not to be provisioned", func(t *testing.T) {
+		bannedUser := &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned",
+				Namespace: test.HostOperatorNs,
+				Labels: map[string]string{
+					toolchainv1alpha1.BannedUserEmailHashLabelKey: "fd2addbd8d82f0d2dc088fa122377eaa",
+				},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{
+				Email: "foo@redhat.com",
+			},
+		}
+		provisioned := commonsignup.NewUserSignup(
+			commonsignup.ApprovedManually(),
+			commonsignup.WithTargetCluster("member1"),
+			commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueApproved))
+		provisioned.Status.CompliantUsername = "foo"
+
+		for name, data := range map[string]struct {
+			userSignup *toolchainv1alpha1.UserSignup
+			objs       []runtime.Object
+		}{
+			"deactivated": {
+				userSignup: commonsignup.NewUserSignup(
+					commonsignup.Deactivated(),
+					commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueDeactivated)),
+			},
+			"banned": {
+				userSignup: commonsignup.NewUserSignup(
+					commonsignup.ApprovedManually(),
+					commonsignup.WithTargetCluster("member1"),
+					commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueNotReady)),
+				objs: []runtime.Object{bannedUser},
+			},
+			"provisioned": {
+				userSignup: provisioned,
+				objs: []runtime.Object{murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs),
+					murtest.WithOwnerLabel(provisioned.Name))},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// given
+				recorder := SetupSpanRecorder(t)
+				r, req, _ := prepareReconcile(t, data.userSignup.Name, NewGetMemberClusters(member),
+					append(data.objs, data.userSignup, baseNSTemplateTier, deactivate30Tier)...)
+				InitializeCounters(t, NewToolchainStatus())
+
+				// when
+				_, err := r.Reconcile(context.TODO(), req)
+
+				// then
+				require.NoError(t, err)
+				userSignup := &toolchainv1alpha1.UserSignup{}
+				err = r.Client.Get(context.TODO(), types.NamespacedName{Namespace: req.Namespace, Name: data.userSignup.Name}, userSignup)
+				require.NoError(t, err)
+				assert.NotContains(t, userSignup.Annotations, tracing.TraceContextAnnotationKey)
+				for _, span := range recorder.Ended() {
+					assert.NotEqual(t, "signup", span.Name())
+				}
+			})
+		}
+	})
+}
+
+func TestUserSignupWithTransferredSpace(t *testing.T) {
+	// given
+	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	defer counter.Reset()
+	userSignup := commonsignup.NewUserSignup(
+		commonsignup.ApprovedManually(),
+		commonsignup.WithTargetCluster("member1"),
+		commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueNotReady),
+		commonsignup.WithoutAnnotation(toolchainv1alpha1.SkipAutoCreateSpaceAnnotationKey))
+	mur := newMasterUserRecord(userSignup, "member1", deactivate30Tier.Name, "foo")
+	mur.Labels = map[string]string{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name}
+	// the Space of the user was transferred to another user
+	space := spacetest.NewSpace("foo",
+		spacetest.WithCreatorLabel("other"),
+		spacetest.WithSpecTargetCluster("member1"),
+		spacetest.WithCondition(spacetest.Ready()))
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(member), userSignup, mur, space, baseNSTemplateTier, deactivate30Tier)
+
+	// when
+	res, err := r.Reconcile(context.TODO(), req)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, res)
+	spacetest.AssertThatSpace(t, test.HostOperatorNs, "foo", r.Client).
+		Exists().
+		HasLabelWithValue(toolchainv1alpha1.SpaceCreatorLabelKey, "other")
+	// the SpaceBinding of the previous owner is not recreated
+	spacebindingtest.AssertThatSpaceBinding(t, test.HostOperatorNs, "foo", "foo", r.Client).
+		DoesNotExist()
+	userSignup = AssertThatUserSignup(t, req.Namespace, userSignup.Name, r.Client).Get()
+	assert.True(t, condition.IsTrue(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete))
+}
+
 func TestDeletingUserSignupShouldNotUpdateMetrics(t *testing.T) {
 	// given
 	member := NewMemberCluster(t, "member1", v1.ConditionTrue)
@@ -326,11 +486,12 @@ func TestUserSignupWithAutoApprovalWithoutTargetCluster(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
+	AssertMetricsHistogramSampleCount(t, 1, metrics.UserSignupApprovalDurationHistogram)
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
 	mur := murtest.AssertThatMasterUserRecord(t, userSignup.Spec.Username, r.Client).
@@ -429,11 +590,11 @@ func TestUserSignupWithAutoApprovalWithoutTargetCluster(t *testing.T) {
 				})
 		})
 	})
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 }
 
@@ -464,8 +625,9 @@ func TestUserSignupWithMissingEmailAnnotationFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueNotReady, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("external"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("external")) // no email address, hence `external`
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		toolchainv1alpha1.Condition{
 			Type:    toolchainv1alpha1.UserSignupComplete,
@@ -512,8 +674,8 @@ func TestUserSignupWithInvalidEmailHashLabelFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueNotReady, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		toolchainv1alpha1.Condition{
 			Type:    toolchainv1alpha1.UserSignupComplete,
@@ -572,8 +734,8 @@ func TestUpdateOfApprovedLabelFails(t *testing.T) {
 		HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
 			"1,internal": 0, // unchanged
 		})
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 }
 
 func TestUserSignupWithMissingEmailHashLabelFails(t *testing.T) {
@@ -605,8 +767,8 @@ func TestUserSignupWithMissingEmailHashLabelFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueNotReady, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		toolchainv1alpha1.Condition{
 			Type:    toolchainv1alpha1.UserSignupComplete,
@@ -654,11 +816,11 @@ func TestNonDefaultNSTemplateTier(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
@@ -809,9 +971,9 @@ func TestUserSignupFailedMissingTier(t *testing.T) {
 					Reason: "UserIsActive",
 				})
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)                         // incremented, even though the provisioning failed due to missing NSTemplateTier
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)                           // incremented, even though the provisioning failed due to missing NSTemplateTier
-			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated) // message sent, even though the provisioning failed due to missing NSTemplateTier
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal")) // incremented, even though the provisioning failed due to missing NSTemplateTier
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))   // incremented, even though the provisioning failed due to missing NSTemplateTier
+			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)          // message sent, even though the provisioning failed due to missing NSTemplateTier
 			AssertThatCountersAndMetrics(t).
 				HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
 					string(metrics.External): 1,
@@ -875,8 +1037,8 @@ func TestUnapprovedUserSignupWhenNoClusterReady(t *testing.T) {
 		})
 
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	AssertThatCountersAndMetrics(t).
 		HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
@@ -937,8 +1099,8 @@ func TestUserSignupFailedNoClusterWithCapacityAvailable(t *testing.T) {
 		})
 
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	AssertThatCountersAndMetrics(t).
 		HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
@@ -975,8 +1137,8 @@ func TestUserSignupWithManualApprovalApproved(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
 	mur := murtest.AssertThatMasterUserRecord(t, userSignup.Name, r.Client).
@@ -1045,8 +1207,8 @@ func TestUserSignupWithManualApprovalApproved(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, userSignup.Status.CompliantUsername, mur.Name)
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 				toolchainv1alpha1.Condition{
@@ -1110,8 +1272,8 @@ func TestUserSignupWithNoApprovalPolicyTreatedAsManualApproved(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
@@ -1184,8 +1346,8 @@ func TestUserSignupWithNoApprovalPolicyTreatedAsManualApproved(t *testing.T) {
 			require.Equal(t, userSignup.Status.CompliantUsername, mur.Name)
 
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -1247,8 +1409,8 @@ func TestUserSignupWithManualApprovalNotApproved(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	// There should be no MasterUserRecords
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
@@ -1309,8 +1471,8 @@ func TestUserSignupWithAutoApprovalWithTargetCluster(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(1)
@@ -1385,8 +1547,8 @@ func TestUserSignupWithAutoApprovalWithTargetCluster(t *testing.T) {
 			require.Equal(t, userSignup.Status.CompliantUsername, mur.Name)
 
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -1447,8 +1609,8 @@ func TestUserSignupWithMissingApprovalPolicyTreatedAsManual(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		toolchainv1alpha1.Condition{
@@ -1570,8 +1732,8 @@ func TestUserSignupMUROrSpaceOrSpaceBindingCreateFails(t *testing.T) {
 			err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 			require.NoError(t, err)
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 		})
 	}
@@ -1618,11 +1780,63 @@ func TestUserSignupMURReadFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 }
 
+func TestUserSignupUsernameReservationFails(t *testing.T) {
+	// given
+	userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(), commonsignup.WithUsername("foo"))
+
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, fakeClient := prepareReconcile(t, userSignup.Name, ready, userSignup, baseNSTemplateTier, deactivate30Tier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	fakeClient.MockCreate = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+		if _, ok := obj.(*v1.ConfigMap); ok {
+			return errors.New("mock error")
+		}
+		return fakeClient.Client.Create(ctx, obj, opts...)
+	}
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then
+	require.EqualError(t, err, "Error generating compliant username for foo: unable to reserve the username [foo]: mock error")
+	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
+	require.NoError(t, err)
+	test.AssertContainsCondition(t, userSignup.Status.Conditions, toolchainv1alpha1.Condition{
+		Type:    toolchainv1alpha1.UserSignupComplete,
+		Status:  v1.ConditionFalse,
+		Reason:  UserSignupUsernameAllocationFailedReason,
+		Message: "unable to reserve the username [foo]: mock error",
+	})
+}
+
+func TestUserSignupDeletedReleasesUsername(t *testing.T) {
+	// given
+	userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(), commonsignup.WithUsername("foo"))
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, baseNSTemplateTier, deactivate30Tier)
+	InitializeCounters(t, NewToolchainStatus())
+	_, err := r.Reconcile(context.TODO(), req)
+	require.NoError(t, err)
+	require.NoError(t, r.Client.Delete(context.TODO(), userSignup))
+
+	// when
+	_, err = r.Reconcile(context.TODO(), req)
+
+	// then
+	require.NoError(t, err)
+	reservation := &v1.ConfigMap{}
+	require.NoError(t, r.Client.Get(context.TODO(), types.NamespacedName{Name: username.ReservationNamePrefix + "foo", Namespace: req.Namespace}, reservation))
+	assert.Equal(t, userSignup.Name, reservation.Data[username.OwnerKey])
+	assert.NotEmpty(t, reservation.Data[username.ReleasedAtKey])
+}
+
 func TestUserSignupSetStatusApprovedByAdminFails(t *testing.T) {
 	// given
 	userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually())
@@ -1665,8 +1879,8 @@ func TestUserSignupSetStatusApprovedByAdminFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal) // zero since starting state was approved
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)   // zero since starting state was approved
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal")) // zero since starting state was approved
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))   // zero since starting state was approved
 	assert.Empty(t, userSignup.Status.Conditions)
 }
 
@@ -1711,8 +1925,8 @@ func TestUserSignupSetStatusApprovedAutomaticallyFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueNotReady, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	assert.Empty(t, userSignup.Status.Conditions)
 
 }
@@ -1762,8 +1976,8 @@ func TestUserSignupSetStatusNoClustersAvailableFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 }
 
@@ -1842,8 +2056,8 @@ func TestUserSignupWithExistingMUROK(t *testing.T) {
 		}, instance)
 		require.NoError(t, err)
 		assert.Equal(t, "approved", instance.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 		segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 		require.Equal(t, mur.Name, instance.Status.CompliantUsername)
@@ -1916,8 +2130,8 @@ func TestUserSignupWithExistingMURDifferentUserIDOK(t *testing.T) {
 	err = r.Client.Get(context.TODO(), key, instance)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", instance.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 	t.Run("second reconcile", func(t *testing.T) {
@@ -1931,8 +2145,8 @@ func TestUserSignupWithExistingMURDifferentUserIDOK(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, "approved", instance.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 		segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 		t.Run("verify usersignup on third reconcile", func(t *testing.T) {
@@ -2006,8 +2220,8 @@ func TestUserSignupWithSpecialCharOK(t *testing.T) {
 			"1,internal": 1,
 		})
 
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 }
 
@@ -2067,9 +2281,9 @@ func TestUserSignupDeactivatedAfterMURCreated(t *testing.T) {
 		require.NoError(t, err)
 		// The state label should still be set to approved until the controller reconciles the deactivation
 		assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal) // 0 because usersignup has not reconciled the deactivation
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)    // 0 because usersignup was originally deactivated
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)      // 0 because state was initially set to approved
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal")) // 0 because usersignup has not reconciled the deactivation
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))    // 0 because usersignup was originally deactivated
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))      // 0 because state was initially set to approved
 
 		// Confirm the status is now set to Deactivating
 		test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -2125,9 +2339,9 @@ func TestUserSignupDeactivatedAfterMURCreated(t *testing.T) {
 		err = r.Client.Get(context.TODO(), key, userSignup)
 		require.NoError(t, err)
 		assert.Equal(t, "deactivated", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedTotal) // one because the deactivation was reconciled
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal")) // one because the deactivation was reconciled
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 		// Confirm the status has been set to Deactivated
 		test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -2331,9 +2545,9 @@ func TestUserSignupFailedToCreateDeactivationNotification(t *testing.T) {
 				"1,external": 2,
 			})
 		assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 		// A deactivated notification should not have been created
 		notificationList := &toolchainv1alpha1.NotificationList{}
@@ -2439,9 +2653,9 @@ func TestUserSignupReactivateAfterDeactivated(t *testing.T) {
 		assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
 		// verify that the annotation was incremented
 		assert.Equal(t, "3", userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 		segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 
 		// There should not be a notification created because the user was reactivated
@@ -2527,9 +2741,9 @@ func TestUserSignupReactivateAfterDeactivated(t *testing.T) {
 
 		// State is still deactivated because the status update failed
 		assert.Equal(t, "deactivated", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 		// A deactivation notification should not be created because this is the reactivation case
 		ntest.AssertNoNotificationsExist(t, r.Client)
@@ -2647,9 +2861,9 @@ func TestUserSignupDeactivatedWhenMURAndSpaceAndSpaceBindingExists(t *testing.T)
 			err = r.Client.Get(context.TODO(), key, userSignup)
 			require.NoError(t, err)
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey]) // State should still be approved at this stage
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 			// Confirm the status is still set to Deactivating
 			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -2725,9 +2939,9 @@ func TestUserSignupDeactivatedWhenMURAndSpaceAndSpaceBindingExists(t *testing.T)
 					Reason: "UserNotInPreDeactivation",
 				})
 			// metrics should be the same after the 2nd reconcile
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 			// The Space and SpaceBinding should still exist because cleanup would be handled by the space cleanup controller
 			spacetest.AssertThatSpace(t, test.HostOperatorNs, space.Name, r.Client).Exists()
@@ -2796,6 +3010,7 @@ func TestUserSignupDeactivatingNotificationCreated(t *testing.T) {
 
 	require.Equal(t, "userdeactivating", notifications.Items[0].Spec.Template)
 	require.Equal(t, userSignup.Spec.Userid, notifications.Items[0].Spec.Context["UserID"])
+	require.Equal(t, "3", notifications.Items[0].Spec.Context["DaysRemaining"]) // no reminder due: defaults to the deactivating notification days
 
 	// Confirm the status is correct
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -2882,6 +3097,120 @@ func TestUserSignupDeactivatingNotificationCreated(t *testing.T) {
 	)
 }
 
+func TestUserSignupDeactivationReminderNotificationCreated(t *testing.T) {
+	newDeactivatingUserSignup := func(due, sent string, notificationCreated bool) *toolchainv1alpha1.UserSignup {
+		userSignup := commonsignup.NewUserSignup(commonsignup.ApprovedManually(), commonsignup.WithStateLabel("approved"))
+		userSignup.Spec.States = append(userSignup.Spec.States, toolchainv1alpha1.UserSignupStateDeactivating)
+		userSignup.Status.CompliantUsername = "foo"
+		userSignup.Annotations[deactivation.DeactivationReminderDueAnnotationKey] = due
+		if sent != "" {
+			userSignup.Annotations[deactivation.DeactivationRemindersSentAnnotationKey] = sent
+		}
+		if notificationCreated {
+			userSignup.Status.Conditions = append(userSignup.Status.Conditions, toolchainv1alpha1.Condition{
+				Type:   toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated,
+				Status: v1.ConditionTrue,
+				Reason: toolchainv1alpha1.UserSignupDeactivatingNotificationCRCreatedReason,
+			})
+		}
+		return userSignup
+	}
+	newMUR := func(userSignup *toolchainv1alpha1.UserSignup) *toolchainv1alpha1.MasterUserRecord {
+		mur := murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs))
+		mur.Labels = map[string]string{
+			toolchainv1alpha1.MasterUserRecordOwnerLabelKey: userSignup.Name,
+		}
+		return mur
+	}
+
+	for _, tc := range []struct {
+		name                string
+		due                 string
+		sent                string
+		notificationCreated bool
+		expectedTemplate    string
+		expectedSent        string
+	}{
+		{
+			name:             "first reminder",
+			due:              "14",
+			expectedTemplate: "userdeactivating",
+			expectedSent:     "14",
+		},
+		{
+			name:                "intermediate reminder",
+			due:                 "7",
+			sent:                "14",
+			notificationCreated: true,
+			expectedTemplate:    "userdeactivationreminder",
+			expectedSent:        "14,7",
+		},
+		{
+			name:                "final reminder",
+			due:                 "1",
+			sent:                "14,7",
+			notificationCreated: true,
+			expectedTemplate:    "userdeactivationfinalreminder",
+			expectedSent:        "14,7,1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			userSignup := newDeactivatingUserSignup(tc.due, tc.sent, tc.notificationCreated)
+			mur := newMUR(userSignup)
+			r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, mur,
+				commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+			InitializeCounters(t, NewToolchainStatus())
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			notifications := &toolchainv1alpha1.NotificationList{}
+			require.NoError(t, r.Client.List(context.TODO(), notifications))
+			require.Len(t, notifications.Items, 1)
+			assert.Equal(t, tc.expectedTemplate, notifications.Items[0].Spec.Template)
+			assert.Equal(t, tc.due, notifications.Items[0].Spec.Context["DaysRemaining"])
+			require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+			assert.Equal(t, tc.expectedSent, userSignup.Annotations[deactivation.DeactivationRemindersSentAnnotationKey])
+			assert.NotContains(t, userSignup.Annotations, deactivation.DeactivationReminderDueAnnotationKey)
+			assert.True(t, condition.IsTrue(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated))
+		})
+	}
+
+	t.Run("reminder not sent twice", func(t *testing.T) {
+		// given the reminder was created but the UserSignup failed to be updated afterwards
+		userSignup := newDeactivatingUserSignup("7", "14", true)
+		mur := newMUR(userSignup)
+		r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, mur,
+			commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+		InitializeCounters(t, NewToolchainStatus())
+		_, err := r.Reconcile(context.TODO(), req)
+		require.NoError(t, err)
+		notifications := &toolchainv1alpha1.NotificationList{}
+		require.NoError(t, r.Client.List(context.TODO(), notifications))
+		require.Len(t, notifications.Items, 1)
+		userSignup = newDeactivatingUserSignup("7", "14", true)
+		mur = newMUR(userSignup)
+		r, req, _ = prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, mur, &notifications.Items[0],
+			commonconfig.NewToolchainConfigObjWithReset(t), baseNSTemplateTier, deactivate30Tier)
+		InitializeCounters(t, NewToolchainStatus())
+
+		// when
+		_, err = r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		notifications = &toolchainv1alpha1.NotificationList{}
+		require.NoError(t, r.Client.List(context.TODO(), notifications))
+		require.Len(t, notifications.Items, 1)
+		require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Equal(t, "14,7", userSignup.Annotations[deactivation.DeactivationRemindersSentAnnotationKey])
+		assert.NotContains(t, userSignup.Annotations, deactivation.DeactivationReminderDueAnnotationKey)
+	})
+}
+
 func TestUserSignupBannedWithoutMURAndSpace(t *testing.T) {
 	// given
 	userSignup := commonsignup.NewUserSignup()
@@ -2917,10 +3246,10 @@ func TestUserSignupBannedWithoutMURAndSpace(t *testing.T) {
 	err = r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "banned", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	// Confirm the status is set to Banned
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -2946,6 +3275,71 @@ func TestUserSignupBannedWithoutMURAndSpace(t *testing.T) {
 		})
 }
 
+func TestUserSignupBannedEvents(t *testing.T) {
+	// given
+	userSignup := commonsignup.NewUserSignup()
+	userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey] = "approved"
+	bannedUser := &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				toolchainv1alpha1.BannedUserEmailHashLabelKey: "fd2addbd8d82f0d2dc088fa122377eaa",
+			},
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email: "foo@redhat.com",
+		},
+	}
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, bannedUser, commonconfig.NewToolchainConfigObjWithReset(t, testconfig.AutomaticApproval().Enabled(true)), baseNSTemplateTier)
+	fakeRecorder := record.NewFakeRecorder(10)
+	r.StatusUpdater.EventRecorder = events.NewRecorder(fakeRecorder, nil)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then
+	require.NoError(t, err)
+	require.Len(t, fakeRecorder.Events, 1)
+	assert.Equal(t, "Warning Banned the Complete condition is True", <-fakeRecorder.Events)
+
+	t.Run("not recorded again when the user is still banned", func(t *testing.T) {
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, fakeRecorder.Events)
+	})
+}
+
+func TestUserSignupBannedWithPurgedBannedUser(t *testing.T) {
+	// given a BannedUser which only retains the hash of the email address
+	userSignup := commonsignup.NewUserSignup()
+	bannedUser := &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "purged",
+			Namespace: test.HostOperatorNs,
+			Labels: map[string]string{
+				toolchainv1alpha1.BannedUserEmailHashLabelKey: "fd2addbd8d82f0d2dc088fa122377eaa",
+			},
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email: "fd2addbd8d82f0d2dc088fa122377eaa",
+		},
+	}
+	r, req, _ := prepareReconcile(t, userSignup.Name, NewGetMemberClusters(), userSignup, bannedUser, commonconfig.NewToolchainConfigObjWithReset(t, testconfig.AutomaticApproval().Enabled(true)), baseNSTemplateTier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then
+	require.NoError(t, err)
+	require.NoError(t, r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueBanned, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
+	murtest.AssertThatMasterUserRecords(t, r.Client).HaveCount(0)
+}
+
 func TestUserSignupVerificationRequired(t *testing.T) {
 	// given
 	userSignup := commonsignup.NewUserSignup(commonsignup.VerificationRequired(0))
@@ -2968,10 +3362,10 @@ func TestUserSignupVerificationRequired(t *testing.T) {
 	err = r.Client.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, toolchainv1alpha1.UserSignupStateLabelValueNotReady, userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	// Confirm the status is set to VerificationRequired
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -3065,10 +3459,10 @@ func TestUserSignupBannedMURAndSpaceExists(t *testing.T) {
 	err = r.Client.Get(context.TODO(), key, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "banned", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	// Confirm the status is set to Banning
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -3109,10 +3503,10 @@ func TestUserSignupBannedMURAndSpaceExists(t *testing.T) {
 
 		assert.Equal(t, "banned", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
 		// metrics should be the same after the 2nd reconcile
-		AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+		AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 		// Confirm the status is now set to Banned
 		test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -3248,9 +3642,9 @@ func TestUserSignupDeactivatedButMURDeleteFails(t *testing.T) {
 			err = r.Client.Get(context.TODO(), key, userSignup)
 			require.NoError(t, err)
 			assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 			// Confirm the status is set to UnableToDeleteMUR
 			test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -3283,9 +3677,9 @@ func TestUserSignupDeactivatedButMURDeleteFails(t *testing.T) {
 				ntest.AssertNoNotificationsExist(t, r.Client)
 				assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey]) // UserSignup should still be approved
 				// the metrics should be the same, deactivation should only be counted once
-				AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-				AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-				AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+				AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+				AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+				AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 				// The Space and SpaceBinding should still exist because cleanup would be handled by the space cleanup controller
 				spacetest.AssertThatSpaces(t, r.Client).HaveCount(1)
@@ -3356,9 +3750,9 @@ func TestUserSignupDeactivatedButStatusUpdateFails(t *testing.T) {
 	err = r.Client.Get(context.TODO(), key, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	// Status unchanged since it could not be updated
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
@@ -3380,8 +3774,8 @@ func TestUserSignupDeactivatedButStatusUpdateFails(t *testing.T) {
 		})
 }
 
-// TestDeathBy100Signups tests the logic of generateCompliantUsername() which allows no more than 100 attempts to find a vacant name
-func TestDeathBy100Signups(t *testing.T) {
+// TestMoreThan100Signups tests that a vacant name is found even when the 100 first candidates are used by existing MasterUserRecords
+func TestMoreThan100Signups(t *testing.T) {
 	// given
 	logf.SetLogger(zap.New(zap.UseDevMode(true)))
 	userSignup := commonsignup.NewUserSignup(
@@ -3425,53 +3819,62 @@ func TestDeathBy100Signups(t *testing.T) {
 	))
 
 	// when
-	res, err := r.Reconcile(context.TODO(), req)
+	_, err := r.Reconcile(context.TODO(), req)
 
 	// then
-	require.Error(t, err)
-	assert.EqualError(t, err, "Error generating compliant username for foo@redhat.com: unable to transform username [foo@redhat.com] even after 100 attempts")
-	require.Equal(t, reconcile.Result{}, res)
-
-	// Lookup the user signup again
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
-	assert.Equal(t, "approved", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
-	segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
-
-	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
-		toolchainv1alpha1.Condition{
-			Type:    toolchainv1alpha1.UserSignupComplete,
-			Status:  v1.ConditionFalse,
-			Reason:  "UnableToCreateMUR",
-			Message: "unable to transform username [foo@redhat.com] even after 100 attempts",
-		},
-		toolchainv1alpha1.Condition{
-			Type:   toolchainv1alpha1.UserSignupApproved,
-			Status: v1.ConditionTrue,
-			Reason: "ApprovedByAdmin",
-		},
-		toolchainv1alpha1.Condition{
-			Type:   toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated,
-			Status: v1.ConditionFalse,
-			Reason: "UserNotInPreDeactivation",
-		},
-		toolchainv1alpha1.Condition{
-			Type:   toolchainv1alpha1.UserSignupUserDeactivatedNotificationCreated,
-			Status: v1.ConditionFalse,
-			Reason: "UserIsActive",
-		},
-	)
+	murtest.AssertThatMasterUserRecord(t, "foo-101", r.Client).
+		Exists().
+		HasLabelWithValue(toolchainv1alpha1.MasterUserRecordOwnerLabelKey, userSignup.Name)
+	// the names of the existing MasterUserRecords are now reserved
+	reservations := &v1.ConfigMapList{}
+	require.NoError(t, r.Client.List(context.TODO(), reservations, client.MatchingLabels{username.ReservationLabelKey: "foo"}))
+	assert.Len(t, reservations.Items, 101)
 	AssertThatCountersAndMetrics(t).
 		HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
-			string(metrics.External): 100, // unchanged
-		}).
-		HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
-			"1,external": 100,
-			"1,internal": 1, // was incremented, even though associated MUR could not be created
+			string(metrics.External): 100,
+			string(metrics.Internal): 1,
 		})
+
+	t.Run("next signup", func(t *testing.T) {
+		// given
+		other := commonsignup.NewUserSignup(
+			commonsignup.WithName("foo2@redhat.com"),
+			commonsignup.WithUsername("foo"),
+			commonsignup.ApprovedManually())
+		require.NoError(t, r.Client.Create(context.TODO(), other))
+
+		// when
+		_, err := r.Reconcile(context.TODO(), newReconcileRequest(other.Name))
+
+		// then the reservations are used rather than looking up the MasterUserRecords one by one
+		require.NoError(t, err)
+		murtest.AssertThatMasterUserRecord(t, "foo-102", r.Client).
+			Exists().
+			HasLabelWithValue(toolchainv1alpha1.MasterUserRecordOwnerLabelKey, other.Name)
+	})
+}
+
+func TestUserSignupWithMultipleExistingMURDuringRename(t *testing.T) {
+	// given
+	userSignup := commonsignup.NewUserSignup()
+	userSignup.Status.CompliantUsername = "foo"
+	mur := murtest.NewMasterUserRecord(t, "foo", murtest.MetaNamespace(test.HostOperatorNs), murtest.WithOwnerLabel(userSignup.Name),
+		murtest.TierName("deactivate30"))
+	renamed := murtest.NewMasterUserRecord(t, "bar", murtest.MetaNamespace(test.HostOperatorNs), murtest.WithOwnerLabel(userSignup.Name),
+		murtest.TierName("deactivate30"))
+
+	ready := NewGetMemberClusters(NewMemberCluster(t, "member1", v1.ConditionTrue))
+	r, req, _ := prepareReconcile(t, userSignup.Name, ready, userSignup, mur, renamed, commonconfig.NewToolchainConfigObjWithReset(t, testconfig.AutomaticApproval().Enabled(true)), baseNSTemplateTier, deactivate30Tier)
+	InitializeCounters(t, NewToolchainStatus())
+
+	// when
+	_, err := r.Reconcile(context.TODO(), req)
+
+	// then the MasterUserRecord in the status is used until the user is switched to the new one
+	require.NoError(t, err)
+	spacetest.AssertThatSpace(t, test.HostOperatorNs, "foo", r.Client).Exists()
+	spacetest.AssertThatSpace(t, test.HostOperatorNs, "bar", r.Client).DoesNotExist()
 }
 
 func TestUserSignupWithMultipleExistingMURNotOK(t *testing.T) {
@@ -3546,9 +3949,9 @@ func TestUserSignupWithMultipleExistingMURNotOK(t *testing.T) {
 		HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
 			"1,external": 1,
 		})
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 }
 
 func TestApprovedManuallyUserSignupWhenNoMembersAvailable(t *testing.T) {
@@ -3581,9 +3984,9 @@ func TestApprovedManuallyUserSignupWhenNoMembersAvailable(t *testing.T) {
 	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: userSignup.Name, Namespace: req.Namespace}, userSignup)
 	require.NoError(t, err)
 	assert.Equal(t, "pending", userSignup.Labels[toolchainv1alpha1.UserSignupStateLabelKey])
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+	AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 
 	test.AssertConditionsMatch(t, userSignup.Status.Conditions,
 		toolchainv1alpha1.Condition{
@@ -3643,6 +4046,8 @@ func prepareReconcile(t *testing.T, name string, getMemberClusters cluster.GetMe
 		Scheme:            s,
 		GetMemberClusters: getMemberClusters,
 		SegmentClient:     segment.NewClient(segmenttest.NewClient()),
+		BannedUsers:       banneduser.NewIndex(fakeClient, test.HostOperatorNs),
+		UsernameAllocator: username.NewAllocator(fakeClient, test.HostOperatorNs),
 	}
 	return r, newReconcileRequest(name), fakeClient
 }
@@ -3887,13 +4292,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "", toolchainv1alpha1.UserSignupStateLabelValueNotReady)
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "", toolchainv1alpha1.UserSignupStateLabelValueNotReady)
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -3904,13 +4309,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, toolchainv1alpha1.UserSignupStateLabelValueNotReady, "pending")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, toolchainv1alpha1.UserSignupStateLabelValueNotReady, "pending")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -3921,13 +4326,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "pending", "approved")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "pending", "approved")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertMessageQueued(t, r.SegmentClient, userSignup, segment.AccountActivated)
 		})
 
@@ -3938,13 +4343,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "approved", "deactivated")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "approved", "deactivated")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -3955,13 +4360,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "pending", "deactivated")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "pending", "deactivated")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -3972,13 +4377,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "deactivated", "banned")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "deactivated", "banned")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 1, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 	})
@@ -3991,13 +4396,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "any-value", "")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "any-value", "")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -4008,13 +4413,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "any-value", toolchainv1alpha1.UserSignupStateLabelValueNotReady)
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "any-value", toolchainv1alpha1.UserSignupStateLabelValueNotReady)
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 
@@ -4025,13 +4430,13 @@ func TestUpdateMetricsByState(t *testing.T) {
 				SegmentClient: segment.NewClient(segmenttest.NewClient()),
 			}
 			// when
-			r.updateUserSignupMetricsByState(logger, userSignup, "any-value", "x")
+			r.updateUserSignupMetricsByState(logger, userSignup, metrics.Internal, "any-value", "x")
 			// then
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedTotal)
-			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueTotal)
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupAutoDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupBannedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupDeactivatedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupApprovedCounterVec.WithLabelValues("internal"))
+			AssertMetricsCounterEquals(t, 0, metrics.UserSignupUniqueCounterVec.WithLabelValues("internal"))
 			segmenttest.AssertNoMessageQueued(t, r.SegmentClient)
 		})
 	})
@@ -2,14 +2,20 @@ package usersignupcleanup
 
 import (
 	"context"
+	"strconv"
+	"sync"
 	"time"
 
 	errs "github.com/pkg/errors"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/controllers/usersignup"
+	"github.com/codeready-toolchain/host-operator/pkg/archive"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
 	"github.com/codeready-toolchain/toolchain-common/pkg/states"
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -24,6 +30,21 @@ import (
 
 type StatusUpdater func(userAcc *toolchainv1alpha1.UserSignup, message string) error
 
+// ArchivedAnnotationKey the annotation on the UserSignup which records the time at which the UserSignup was archived,
+// so that it is not archived twice if its deletion fails
+const ArchivedAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "archived"
+
+const (
+	// DeactivatedRetentionExpiredReason the reason recorded in the archive when a deactivated UserSignup is deleted
+	DeactivatedRetentionExpiredReason = "DeactivatedRetentionExpired"
+	// UnverifiedRetentionExpiredReason the reason recorded in the archive when an unverified UserSignup is deleted
+	UnverifiedRetentionExpiredReason = "UnverifiedRetentionExpired"
+)
+
+// VerificationExpiredEventReason the reason of the event recorded when a reactivated UserSignup which was not verified
+// in time is returned to the deactivated state. The events of the deletion have the same reasons as in the archive.
+const VerificationExpiredEventReason = "VerificationExpired"
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -35,11 +56,21 @@ func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
 type Reconciler struct {
 	Client client.Client
 	Scheme *runtime.Scheme
+	// Archiver the archiver called before deleting a UserSignup (optional, overrides the archiver configured in the ToolchainConfig)
+	Archiver archive.Archiver
+	// EventRecorder records the events of the deletion and of the deactivation of the UserSignups
+	EventRecorder *events.Recorder
+
+	archiverLock sync.Mutex
+	// archiverConfig the configuration of the archiver below, so that it is only created again when the configuration changed
+	archiverConfig archive.Config
+	archiver       archive.Archiver
 }
 
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=masteruserrecords/finalizers,verbs=update
+//+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=bannedusers,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a UserSignup object and makes changes based on the state read
 // and what is in the UserSignup.Spec
@@ -82,7 +113,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 
 			if createdTime.Time.Before(unverifiedThreshold) {
 				reqLogger.Info("Deleting UserSignup due to exceeding unverified retention period")
-				return reconcile.Result{}, r.DeleteUserSignup(instance, reqLogger)
+				return reconcile.Result{}, r.DeleteUserSignup(config, instance, reqLogger)
 			}
 
 			// Requeue this for reconciliation after the time has passed between the last active time
@@ -111,7 +142,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 				states.SetVerificationRequired(instance, false)
 
 				reqLogger.Info("Resetting UserSignup back to deactivated state due to exceeding unverified period threshold")
-				return reconcile.Result{}, r.Client.Update(ctx, instance)
+				if err := r.Client.Update(ctx, instance); err != nil {
+					return reconcile.Result{}, err
+				}
+				r.EventRecorder.Normal(instance, VerificationExpiredEventReason, "the UserSignup was deactivated again because it was not verified in time")
+				return reconcile.Result{}, nil
 			}
 		}
 	}
@@ -132,7 +167,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 
 		if cond.LastTransitionTime.Time.Before(deactivatedThreshold) {
 			reqLogger.Info("Deleting UserSignup due to exceeding deactivated retention period")
-			return reconcile.Result{}, r.DeleteUserSignup(instance, reqLogger)
+			return reconcile.Result{}, r.DeleteUserSignup(config, instance, reqLogger)
 		}
 
 		// Requeue this for reconciliation after the time has passed between the last transition time
@@ -149,8 +184,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.
 	return reconcile.Result{}, nil
 }
 
-// DeleteUserSignup deletes the specified UserSignup
-func (r *Reconciler) DeleteUserSignup(userSignup *toolchainv1alpha1.UserSignup, logger logr.Logger) error {
+// DeleteUserSignup deletes the specified UserSignup, once it was archived (if an archiver is configured)
+func (r *Reconciler) DeleteUserSignup(config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup, logger logr.Logger) error {
+	if err := r.archiveUserSignup(config, userSignup, logger); err != nil {
+		return err
+	}
+
 	// before deleting the resource, we want to "remember" if the user triggered a phone verification or not,
 	// based on the presence of the `toolchain.dev.openshift.com/verification-code` annotation
 	_, phoneVerificationTriggered := userSignup.Annotations[toolchainv1alpha1.UserSignupVerificationCodeAnnotationKey]
@@ -163,12 +202,113 @@ func (r *Reconciler) DeleteUserSignup(userSignup *toolchainv1alpha1.UserSignup,
 		return err
 	}
 	logger.Info("Deleted UserSignup", "name", userSignup.Name)
+	if states.Deactivated(userSignup) {
+		r.EventRecorder.Normal(userSignup, DeactivatedRetentionExpiredReason, "the UserSignup was deleted after the retention period of the deactivated UserSignups")
+	} else {
+		r.EventRecorder.Normal(userSignup, UnverifiedRetentionExpiredReason, "the UserSignup was deleted after the retention period of the unverified UserSignups")
+	}
 	// increment the appropriate counter, based whether the phone verification was triggered or not
+	domain := string(metrics.GetEmailDomain(userSignup))
 	if phoneVerificationTriggered {
-		metrics.UserSignupDeletedWithInitiatingVerificationTotal.Inc()
+		metrics.UserSignupDeletedWithInitiatingVerificationCounterVec.WithLabelValues(domain).Inc()
 	} else {
-		metrics.UserSignupDeletedWithoutInitiatingVerificationTotal.Inc()
+		metrics.UserSignupDeletedWithoutInitiatingVerificationCounterVec.WithLabelValues(domain).Inc()
 	}
 	logger.Info("incremented counter", "name", userSignup.Name, "phone verification triggered", phoneVerificationTriggered)
 	return nil
 }
+
+// archiveUserSignup writes the record of the given UserSignup with the configured archiver, unless it was already archived
+func (r *Reconciler) archiveUserSignup(config toolchainconfig.ToolchainConfig, userSignup *toolchainv1alpha1.UserSignup, logger logr.Logger) error {
+	archiver, err := r.getArchiver(config, userSignup.Namespace)
+	if err != nil {
+		return errs.Wrap(err, "invalid UserSignup archive configuration")
+	}
+	if archiver == nil {
+		return nil
+	}
+	if _, archived := userSignup.Annotations[ArchivedAnnotationKey]; archived {
+		logger.Info("UserSignup already archived", "name", userSignup.Name)
+		return nil
+	}
+	record, err := r.newArchiveRecord(userSignup)
+	if err != nil {
+		return err
+	}
+	if err := archiver.Archive(context.TODO(), record); err != nil {
+		return errs.Wrapf(err, "unable to archive UserSignup '%s'", userSignup.Name)
+	}
+	logger.Info("Archived UserSignup", "name", userSignup.Name)
+	if userSignup.Annotations == nil {
+		userSignup.Annotations = map[string]string{}
+	}
+	userSignup.Annotations[ArchivedAnnotationKey] = record.DeletionTime.Format(time.RFC3339)
+	return r.Client.Update(context.TODO(), userSignup)
+}
+
+// getArchiver returns the archiver set on the Reconciler, or else the archiver configured in the ToolchainConfig
+// (nil if the UserSignups are not archived)
+func (r *Reconciler) getArchiver(config toolchainconfig.ToolchainConfig, namespace string) (archive.Archiver, error) {
+	if r.Archiver != nil {
+		return r.Archiver, nil
+	}
+	archiverConfig := archive.Config{
+		Sink:                archive.Sink(config.UserSignupArchive().Sink()),
+		FilePath:            config.UserSignupArchive().FilePath(),
+		ConfigMapName:       config.UserSignupArchive().ConfigMapName(),
+		ConfigMapMaxRecords: config.UserSignupArchive().ConfigMapMaxRecords(),
+		URL:                 config.UserSignupArchive().URL(),
+		Timeout:             config.UserSignupArchive().Timeout(),
+	}
+	r.archiverLock.Lock()
+	defer r.archiverLock.Unlock()
+	if r.archiver != nil && r.archiverConfig == archiverConfig {
+		return r.archiver, nil
+	}
+	archiver, err := archive.New(r.Client, namespace, archiverConfig)
+	if err != nil {
+		return nil, err
+	}
+	r.archiver = archiver
+	r.archiverConfig = archiverConfig
+	return archiver, nil
+}
+
+// newArchiveRecord returns the anonymised record of the given UserSignup
+func (r *Reconciler) newArchiveRecord(userSignup *toolchainv1alpha1.UserSignup) (archive.Record, error) {
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	emailHash, found := userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey]
+	if !found {
+		emailHash = hash.EncodeString(email)
+	}
+	activations, _ := strconv.Atoi(userSignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey])
+	userTier, spaceTier := usersignup.LastProvisionedTiers(userSignup)
+	record := archive.Record{
+		EmailHash:    emailHash,
+		Activations:  activations,
+		CreationTime: userSignup.CreationTimestamp,
+		DeletionTime: metav1.NewTime(time.Now().Truncate(time.Second)),
+		UserTier:     userTier,
+		SpaceTier:    spaceTier,
+		Reason:       UnverifiedRetentionExpiredReason,
+	}
+	if states.Deactivated(userSignup) {
+		record.Reason = DeactivatedRetentionExpiredReason
+		if cond, found := condition.FindConditionByType(userSignup.Status.Conditions, toolchainv1alpha1.UserSignupComplete); found {
+			record.DeactivationTime = &cond.LastTransitionTime
+		}
+	}
+
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	if err := r.Client.List(context.TODO(), bannedUsers, client.InNamespace(userSignup.Namespace),
+		client.MatchingLabels{toolchainv1alpha1.BannedUserEmailHashLabelKey: emailHash}); err != nil {
+		return record, errs.Wrap(err, "unable to list the BannedUsers to archive the UserSignup")
+	}
+	for _, bannedUser := range bannedUsers.Items {
+		if bannedUser.Spec.Email == email {
+			record.Banned = true
+			break
+		}
+	}
+	return record, nil
+}
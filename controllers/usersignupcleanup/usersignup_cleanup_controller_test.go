@@ -9,8 +9,14 @@ import (
 	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/controllers/usersignup"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/archive"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
 	"github.com/codeready-toolchain/toolchain-common/pkg/states"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
@@ -18,11 +24,13 @@ import (
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -83,9 +91,13 @@ func TestUserCleanup(t *testing.T) {
 		)
 
 		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		_, err := r.Reconcile(context.TODO(), req)
 		require.NoError(t, err)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal DeactivatedRetentionExpired the UserSignup was deleted after the retention period of the deactivated UserSignups", <-fakeRecorder.Events)
 
 		// Confirm the UserSignup has been deleted
 		key := test.NamespacedName(test.HostOperatorNs, userSignup.Name)
@@ -106,9 +118,13 @@ func TestUserCleanup(t *testing.T) {
 		)
 
 		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		_, err := r.Reconcile(context.TODO(), req)
 		require.NoError(t, err)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal UnverifiedRetentionExpired the UserSignup was deleted after the retention period of the unverified UserSignups", <-fakeRecorder.Events)
 
 		// Confirm the UserSignup has been deleted
 		key := test.NamespacedName(test.HostOperatorNs, userSignup.Name)
@@ -138,8 +154,8 @@ func TestUserCleanup(t *testing.T) {
 		require.True(t, apierrors.IsNotFound(err))
 		assert.Errorf(t, err, "usersignups.toolchain.dev.openshift.com \"%s\" not found", key.Name)
 		// and verify the metrics
-		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.UserSignupDeletedWithInitiatingVerificationTotal))    // unchanged
-		assert.Equal(t, float64(1), promtestutil.ToFloat64(metrics.UserSignupDeletedWithoutInitiatingVerificationTotal)) // incremented
+		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.UserSignupDeletedWithInitiatingVerificationCounterVec.WithLabelValues("internal")))    // unchanged
+		assert.Equal(t, float64(1), promtestutil.ToFloat64(metrics.UserSignupDeletedWithoutInitiatingVerificationCounterVec.WithLabelValues("internal"))) // incremented
 	})
 
 	t.Run("with phone verification initiated", func(t *testing.T) {
@@ -160,8 +176,8 @@ func TestUserCleanup(t *testing.T) {
 		require.True(t, apierrors.IsNotFound(err))
 		assert.Errorf(t, err, "usersignups.toolchain.dev.openshift.com \"%s\" not found", key.Name)
 		// and verify the metrics
-		assert.Equal(t, float64(1), promtestutil.ToFloat64(metrics.UserSignupDeletedWithInitiatingVerificationTotal))    // incremented
-		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.UserSignupDeletedWithoutInitiatingVerificationTotal)) // unchanged
+		assert.Equal(t, float64(1), promtestutil.ToFloat64(metrics.UserSignupDeletedWithInitiatingVerificationCounterVec.WithLabelValues("internal")))    // incremented
+		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.UserSignupDeletedWithoutInitiatingVerificationCounterVec.WithLabelValues("internal"))) // unchanged
 	})
 
 	t.Run("test that recently reactivated, unverified UserSignup is NOT deleted", func(t *testing.T) {
@@ -196,9 +212,13 @@ func TestUserCleanup(t *testing.T) {
 		)
 
 		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+		fakeRecorder := record.NewFakeRecorder(10)
+		r.EventRecorder = events.NewRecorder(fakeRecorder, nil)
 
 		_, err := r.Reconcile(context.TODO(), req)
 		require.NoError(t, err)
+		require.Len(t, fakeRecorder.Events, 1)
+		assert.Equal(t, "Normal VerificationExpired the UserSignup was deactivated again because it was not verified in time", <-fakeRecorder.Events)
 
 		// Confirm the UserSignup has been deleted
 		key := test.NamespacedName(test.HostOperatorNs, userSignup.Name)
@@ -254,6 +274,178 @@ func TestUserCleanup(t *testing.T) {
 
 }
 
+func TestUserCleanupArchive(t *testing.T) {
+	fiveYears := time.Duration(time.Hour * 24 * 365 * 5)
+	newDeactivatedUserSignup := func(modifiers ...commonsignup.Modifier) *toolchainv1alpha1.UserSignup {
+		modifiers = append(modifiers,
+			commonsignup.WithStateLabel(toolchainv1alpha1.UserSignupStateLabelValueDeactivated),
+			commonsignup.ApprovedManuallyAgo(fiveYears),
+			commonsignup.DeactivatedWithLastTransitionTime(fiveYears),
+			commonsignup.CreatedBefore(fiveYears),
+			commonsignup.WithActivations("2"),
+		)
+		return commonsignup.NewUserSignup(modifiers...)
+	}
+
+	t.Run("deactivated UserSignup is archived before deletion", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatedUserSignup(
+			commonsignup.WithAnnotation(usersignup.LastProvisioningAnnotationKey, `{"userTier":"deactivate30","spaceTier":"base"}`))
+		bannedUser := &toolchainv1alpha1.BannedUser{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "banned",
+				Namespace: test.HostOperatorNs,
+				Labels: map[string]string{
+					toolchainv1alpha1.BannedUserEmailHashLabelKey: userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey],
+				},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{
+				Email: userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey],
+			},
+		}
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup, bannedUser)
+		archiver := &fakeArchiver{}
+		r.Archiver = archiver
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		err = r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.True(t, apierrors.IsNotFound(err))
+		require.Len(t, archiver.records, 1)
+		record := archiver.records[0]
+		assert.Equal(t, userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey], record.EmailHash)
+		assert.Equal(t, 2, record.Activations)
+		assert.Equal(t, "deactivate30", record.UserTier)
+		assert.Equal(t, "base", record.SpaceTier)
+		assert.True(t, record.Banned)
+		assert.Equal(t, DeactivatedRetentionExpiredReason, record.Reason)
+		require.NotNil(t, record.DeactivationTime)
+		assert.False(t, record.DeletionTime.IsZero())
+	})
+
+	t.Run("unverified UserSignup is archived before deletion", func(t *testing.T) {
+		// given
+		userSignup := commonsignup.NewUserSignup(
+			commonsignup.CreatedBefore(days(8)),
+			commonsignup.VerificationRequired(days(8)),
+		)
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+		archiver := &fakeArchiver{}
+		r.Archiver = archiver
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		err = r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.True(t, apierrors.IsNotFound(err))
+		require.Len(t, archiver.records, 1)
+		assert.Equal(t, UnverifiedRetentionExpiredReason, archiver.records[0].Reason)
+		assert.Nil(t, archiver.records[0].DeactivationTime)
+		assert.False(t, archiver.records[0].Banned)
+	})
+
+	t.Run("UserSignup is not deleted when archive fails", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatedUserSignup()
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup)
+		r.Archiver = &fakeArchiver{err: fmt.Errorf("sink unavailable")}
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, fmt.Sprintf("unable to archive UserSignup '%s': sink unavailable", userSignup.Name))
+		require.NoError(t, r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.NotContains(t, userSignup.Annotations, ArchivedAnnotationKey)
+	})
+
+	t.Run("archiver configured in the ToolchainConfig", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatedUserSignup()
+		config := commonconfig.NewToolchainConfigObjWithReset(t,
+			ConfigAnnotation(toolchainconfig.UserSignupArchiveSinkAnnotationKey, "configmap"),
+			ConfigAnnotation(toolchainconfig.UserSignupArchiveConfigMapNameAnnotationKey, "archive"))
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.NoError(t, err)
+		err = r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+		require.True(t, apierrors.IsNotFound(err))
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, "archive"), cm))
+		assert.Contains(t, cm.Data[archive.ConfigMapRecordsKey], DeactivatedRetentionExpiredReason)
+	})
+
+	t.Run("UserSignup is not deleted when the archive configuration is invalid", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatedUserSignup()
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.UserSignupArchiveSinkAnnotationKey, "file"))
+		r, req, _ := prepareReconcile(t, userSignup.Name, userSignup, config)
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.EqualError(t, err, "invalid UserSignup archive configuration: missing file path for the 'file' archive sink")
+		require.NoError(t, r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+	})
+
+	t.Run("UserSignup is not archived twice", func(t *testing.T) {
+		// given
+		userSignup := newDeactivatedUserSignup()
+		r, req, fakeClient := prepareReconcile(t, userSignup.Name, userSignup)
+		archiver := &fakeArchiver{}
+		r.Archiver = archiver
+		fakeClient.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+			return fmt.Errorf("unable to delete")
+		}
+
+		// when
+		_, err := r.Reconcile(context.TODO(), req)
+
+		// then
+		require.Error(t, err)
+		require.Len(t, archiver.records, 1)
+		require.NoError(t, r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup))
+		assert.Contains(t, userSignup.Annotations, ArchivedAnnotationKey)
+
+		t.Run("deleted on next reconcile without being archived again", func(t *testing.T) {
+			// given
+			fakeClient.MockDelete = nil
+
+			// when
+			_, err := r.Reconcile(context.TODO(), req)
+
+			// then
+			require.NoError(t, err)
+			require.Len(t, archiver.records, 1)
+			err = r.Client.Get(context.Background(), test.NamespacedName(test.HostOperatorNs, userSignup.Name), userSignup)
+			require.True(t, apierrors.IsNotFound(err))
+		})
+	})
+}
+
+type fakeArchiver struct {
+	records []archive.Record
+	err     error
+}
+
+func (a *fakeArchiver) Archive(_ context.Context, record archive.Record) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.records = append(a.records, record)
+	return nil
+}
+
 func expectRequeue(t *testing.T, res reconcile.Result, margin int) {
 	// We expect the requeue duration to be approximately equal to the default retention time of 1460 days. Let's
 	// accept any value here between the range of 364 days and 366 days
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
@@ -17,17 +18,29 @@ import (
 	"github.com/codeready-toolchain/host-operator/controllers/spacebindingcleanup"
 	"github.com/codeready-toolchain/host-operator/controllers/spacecleanup"
 	"github.com/codeready-toolchain/host-operator/controllers/spacecompletion"
+	"github.com/codeready-toolchain/host-operator/controllers/spaceinvitation"
+	"github.com/codeready-toolchain/host-operator/controllers/tiertemplatecleanup"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/controllers/toolchainstatus"
+	"github.com/codeready-toolchain/host-operator/controllers/userpurge"
+	"github.com/codeready-toolchain/host-operator/controllers/userrename"
 	"github.com/codeready-toolchain/host-operator/controllers/usersignup"
 	"github.com/codeready-toolchain/host-operator/controllers/usersignupcleanup"
+	"github.com/codeready-toolchain/host-operator/pkg/abuse"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
 	"github.com/codeready-toolchain/host-operator/pkg/cluster"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/pkg/segment"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/assets"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/nstemplatetiers"
+	"github.com/codeready-toolchain/host-operator/pkg/templates/tiersource"
 	"github.com/codeready-toolchain/host-operator/pkg/templates/usertiers"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
+	"github.com/codeready-toolchain/host-operator/pkg/webhooks"
 	"github.com/codeready-toolchain/host-operator/version"
 	"github.com/codeready-toolchain/toolchain-common/controllers/toolchaincluster"
 	commoncluster "github.com/codeready-toolchain/toolchain-common/pkg/cluster"
@@ -86,6 +99,7 @@ func printVersion() {
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=toolchainclusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=toolchain.dev.openshift.com,resources=toolchainclusters/finalizers,verbs=update
 
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="",resources=secrets;configmaps;services;services/finalizers;serviceaccounts;pods,verbs=get;list;watch;update;patch;create;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments;deployments/finalizers;replicasets,verbs=get;list;watch;update;patch;create;delete
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;update;patch;create;delete
@@ -187,6 +201,18 @@ func main() { // nolint:gocyclo
 		}()
 	}
 
+	// initialize the tracer provider
+	shutdownTracing, err := setupTracing(crtConfig)
+	if err != nil {
+		setupLog.Error(err, "unable to init the tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "error while shutting down the tracing")
+		}
+	}()
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -206,6 +232,10 @@ func main() { // nolint:gocyclo
 		os.Exit(1)
 	}
 
+	eventRecorderFor := func(name string) *events.Recorder {
+		return events.NewRecorder(mgr.GetEventRecorderFor(name+"-controller"), mgr.GetClient())
+	}
+
 	// Setup all Controllers
 	if err = toolchaincluster.NewReconciler(
 		mgr,
@@ -216,8 +246,9 @@ func main() { // nolint:gocyclo
 		os.Exit(1)
 	}
 	if err := (&deactivation.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: eventRecorderFor("deactivation"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Deactivation")
 		os.Exit(1)
@@ -227,20 +258,23 @@ func main() { // nolint:gocyclo
 		Scheme:         mgr.GetScheme(),
 		Namespace:      namespace,
 		MemberClusters: memberClusters,
+		EventRecorder:  eventRecorderFor("masteruserrecord"),
 	}).SetupWithManager(mgr, memberClusters); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MasterUserRecord")
 		os.Exit(1)
 	}
 	if err := (&notification.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: eventRecorderFor("notification"),
 	}).SetupWithManager(mgr, crtConfig); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Notification")
 		os.Exit(1)
 	}
 	if err := (&nstemplatetier.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: eventRecorderFor("nstemplatetier"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NSTemplateTier")
 		os.Exit(1)
@@ -259,25 +293,33 @@ func main() { // nolint:gocyclo
 		HTTPClientImpl: &http.Client{},
 		GetMembersFunc: commoncluster.GetMemberClusters,
 		Namespace:      namespace,
+		EventRecorder:  eventRecorderFor("toolchainstatus"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ToolchainStatus")
 		os.Exit(1)
 	}
+	usernameAllocator := username.NewAllocator(mgr.GetClient(), namespace)
+	bannedUsers := banneduser.NewIndex(mgr.GetClient(), namespace)
 	if err := (&usersignup.Reconciler{
 		StatusUpdater: &usersignup.StatusUpdater{
-			Client: mgr.GetClient(),
+			Client:        mgr.GetClient(),
+			EventRecorder: eventRecorderFor("usersignup"),
 		},
 		Namespace:         namespace,
 		Scheme:            mgr.GetScheme(),
 		GetMemberClusters: commoncluster.GetMemberClusters,
 		SegmentClient:     segmentClient,
+		BannedUsers:       bannedUsers,
+		RiskScorer:        abuse.NewScorer(mgr.GetClient(), namespace, bannedUsers),
+		UsernameAllocator: usernameAllocator,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UserSignup")
 		os.Exit(1)
 	}
 	if err := (&usersignupcleanup.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		EventRecorder: eventRecorderFor("usersignupcleanup"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UserSignupCleanup")
 		os.Exit(1)
@@ -286,6 +328,7 @@ func main() { // nolint:gocyclo
 		Client:         mgr.GetClient(),
 		Namespace:      namespace,
 		MemberClusters: memberClusters,
+		EventRecorder:  eventRecorderFor("space"),
 	}).SetupWithManager(mgr, memberClusters); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Space")
 		os.Exit(1)
@@ -294,37 +337,99 @@ func main() { // nolint:gocyclo
 		Client:            mgr.GetClient(),
 		Namespace:         namespace,
 		GetMemberClusters: commoncluster.GetMemberClusters,
+		EventRecorder:     eventRecorderFor("spacecompletion"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SpaceCompletion")
 		os.Exit(1)
 	}
 	if err = (&spacebindingcleanup.Reconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Namespace: namespace,
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Namespace:     namespace,
+		EventRecorder: eventRecorderFor("spacebindingcleanup"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SpaceBindingCleanup")
 		os.Exit(1)
 	}
 	if err = (&spacecleanup.Reconciler{
-		Client:    mgr.GetClient(),
-		Namespace: namespace,
+		Client:        mgr.GetClient(),
+		Namespace:     namespace,
+		EventRecorder: eventRecorderFor("spacecleanup"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SpaceCleanup")
 		os.Exit(1)
 	}
+	if err = (&spaceinvitation.Reconciler{
+		Client:        mgr.GetClient(),
+		Namespace:     namespace,
+		EventRecorder: eventRecorderFor("spaceinvitation"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SpaceInvitation")
+		os.Exit(1)
+	}
 	if err = (&socialevent.Reconciler{
 		Client:    mgr.GetClient(),
 		Namespace: namespace,
 		StatusUpdater: &socialevent.StatusUpdater{
-			Client: mgr.GetClient(),
+			Client:        mgr.GetClient(),
+			EventRecorder: eventRecorderFor("socialevent"),
 		},
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SocialEvent")
 		os.Exit(1)
 	}
+	if err = (&userpurge.Reconciler{
+		Client:         mgr.GetClient(),
+		Namespace:      namespace,
+		MemberClusters: memberClusters,
+		EventRecorder:  eventRecorderFor("userpurge"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UserPurge")
+		os.Exit(1)
+	}
+	if err = (&userrename.Reconciler{
+		Client:            mgr.GetClient(),
+		Namespace:         namespace,
+		UsernameAllocator: usernameAllocator,
+		EventRecorder:     eventRecorderFor("userrename"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "UserRename")
+		os.Exit(1)
+	}
+	if err = (&tiertemplatecleanup.Reconciler{
+		Client:         mgr.GetClient(),
+		Namespace:      namespace,
+		MemberClusters: memberClusters,
+		EventRecorder:  eventRecorderFor("tiertemplatecleanup"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TierTemplateCleanup")
+		os.Exit(1)
+	}
+	if err = mgr.Add(&counter.Reconciler{
+		Client:    mgr.GetClient(),
+		Informers: mgr.GetCache(),
+		Namespace: namespace,
+	}); err != nil {
+		setupLog.Error(err, "unable to add the counter reconciler")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
+	tierSource, err := tiersource.New(crtConfig.Tiers().Source(),
+		assets.NewAssets(nstemplatetiers.AssetNames, nstemplatetiers.Asset),
+		assets.NewAssets(usertiers.AssetNames, usertiers.Asset))
+	if err != nil {
+		setupLog.Error(err, "unable to init the tier source")
+		os.Exit(1)
+	}
+	tierSyncer := &tiersource.Syncer{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Namespace: namespace,
+		Source:    tierSource,
+		Interval:  crtConfig.Tiers().SourceSyncInterval(),
+	}
+
 	stopChannel := ctrl.SetupSignalHandler()
 
 	go func() {
@@ -345,25 +450,30 @@ func main() { // nolint:gocyclo
 		}
 		setupLog.Info("Created/updated the ToolchainStatus resource")
 
-		// create or update all NSTemplateTiers on the cluster at startup
-		setupLog.Info("Creating/updating the NSTemplateTier resources")
-		nstemplatetierAssets := assets.NewAssets(nstemplatetiers.AssetNames, nstemplatetiers.Asset)
-		if err := nstemplatetiers.CreateOrUpdateResources(mgr.GetScheme(), mgr.GetClient(), namespace, nstemplatetierAssets); err != nil {
+		// create or update all NSTemplateTiers and UserTiers on the cluster at startup
+		setupLog.Info("Creating/updating the NSTemplateTier and UserTier resources")
+		if _, err := tierSyncer.Sync(); err != nil {
 			setupLog.Error(err, "")
 			os.Exit(1)
 		}
-		setupLog.Info("Created/updated the NSTemplateTier resources")
+		setupLog.Info("Created/updated the NSTemplateTier and UserTier resources")
 
-		// create or update all UserTiers on the cluster at startup
-		setupLog.Info("Creating/updating the UserTier resources")
-		usertierAssets := assets.NewAssets(usertiers.AssetNames, usertiers.Asset)
-		if err := usertiers.CreateOrUpdateResources(mgr.GetScheme(), mgr.GetClient(), namespace, usertierAssets); err != nil {
+		// then keep the tiers up-to-date with their source, unless they were embedded in the operator binary
+		if err := tierSyncer.Start(stopChannel); err != nil {
 			setupLog.Error(err, "")
-			os.Exit(1)
 		}
-		setupLog.Info("Created/updated the UserTier resources")
 	}()
 
+	if crtConfig.Webhooks().IsEnabled() {
+		hooks, err := webhooks.New(mgr.GetClient(), mgr.GetScheme(), namespace, commoncluster.GetMemberClusters)
+		if err != nil {
+			setupLog.Error(err, "unable to create the webhooks")
+			os.Exit(1)
+		}
+		hooks.Register(mgr.GetWebhookServer())
+		setupLog.Info("registered the webhooks")
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -380,6 +490,16 @@ func main() { // nolint:gocyclo
 	}
 }
 
+// setupTracing configures the tracer provider with the tracing settings of the ToolchainConfig
+func setupTracing(crtConfig toolchainconfig.ToolchainConfig) (tracing.ShutdownFunc, error) {
+	return tracing.Setup(tracing.Config{
+		Exporter:     tracing.Exporter(crtConfig.Tracing().Exporter()),
+		FilePath:     crtConfig.Tracing().FilePath(),
+		OTLPEndpoint: crtConfig.Tracing().OTLPEndpoint(),
+		OTLPInsecure: crtConfig.Tracing().OTLPInsecure(),
+	})
+}
+
 func addMemberClusters(mgr ctrl.Manager, cl client.Client, namespace string) (map[string]cluster.Cluster, error) {
 	memberConfigs, err := commoncluster.ListToolchainClusterConfigs(cl, namespace, commoncluster.Member, memberClientTimeout)
 	if err != nil {
@@ -2,6 +2,7 @@
 CONTROLLER_GEN_VERSION=v0.9.2
 KUSTOMIZE_VERSION=v4.5.5
 GO_BINDATA_VERSION=v3.1.2
+SETUP_ENVTEST_VERSION=latest
 
 CONTROLLER_GEN = $(shell pwd)/bin/controller-gen
 controller-gen: ## Download controller-gen locally if necessary.
@@ -15,6 +16,10 @@ KUSTOMIZE = $(shell pwd)/bin/kustomize
 kustomize: ## Download kustomize locally if necessary.
 	$(call go-get-tool,$(KUSTOMIZE),sigs.k8s.io/kustomize/kustomize/v4,$(KUSTOMIZE_VERSION))
 
+ENVTEST = $(shell pwd)/bin/setup-envtest
+setup-envtest: ## Download setup-envtest locally if necessary.
+	$(call go-get-tool,$(ENVTEST),sigs.k8s.io/controller-runtime/tools/setup-envtest,${SETUP_ENVTEST_VERSION})
+
 # go-get-tool will 'go get' any package $2 with version $3 and install it to $1.
 PROJECT_DIR := $(shell pwd)
 VERSIONS_FILE := $(PROJECT_DIR)/bin/version
@@ -34,8 +34,13 @@ generate-rbac: build controller-gen
 	@echo "Re-generating ClusterRole ..."
 	$(Q)$(CONTROLLER_GEN) rbac:roleName=manager-role paths=./...
 
+.PHONY: generate-webhooks
+generate-webhooks: controller-gen
+	@echo "Re-generating the webhook configurations ..."
+	$(Q)$(CONTROLLER_GEN) webhook paths=./pkg/webhooks/... output:webhook:artifacts:config=config/webhook
+
 .PHONY: bundle
-bundle: clean-bundle generate-rbac kustomize ## Generate bundle manifests and metadata, then validate generated files.
+bundle: clean-bundle generate-rbac generate-webhooks kustomize ## Generate bundle manifests and metadata, then validate generated files.
 	operator-sdk generate kustomize manifests -q
 	$(KUSTOMIZE) build config/manifests | operator-sdk generate bundle --overwrite --version=${NEXT_VERSION} --channels ${CHANNEL} --default-channel ${CHANNEL} --package toolchain-host-operator
 	operator-sdk bundle validate ./bundle
@@ -10,6 +10,16 @@ test: generate
 	@echo "running the tests without coverage and excluding E2E tests..."
 	$(Q)go test ${V_FLAG} -race $(shell go list ./... | grep -v /test/e2e) -failfast
 
+# version of the API server and etcd used by the envtest tests (see the k8s.io dependencies in go.mod)
+ENVTEST_K8S_VERSION = 1.24.x
+
+.PHONY: test-envtest
+## runs the tests of the webhooks against a local API server and etcd installed with setup-envtest
+test-envtest: setup-envtest
+	@echo "running the tests of the webhooks against a local API server..."
+	$(Q)ASSETS="$$($(ENVTEST) use $(ENVTEST_K8S_VERSION) --bin-dir $(PROJECT_DIR)/bin -p path)" && \
+		KUBEBUILDER_ASSETS="$${ASSETS}" go test ${V_FLAG} ./pkg/webhooks/... -run TestWebhooksOnEnvTest -count=1
+
 
 ############################################################
 #
@@ -0,0 +1,224 @@
+package abuse
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+
+	errs "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RiskScoreAnnotationKey the annotation on the UserSignup with the risk score of the user, between 0 and 100
+	RiskScoreAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "risk-score"
+	// RiskSignalsAnnotationKey the annotation on the UserSignup with the signals which contributed to the risk score,
+	// along with their points (eg: `domain-velocity=20,reactivations=10`)
+	RiskSignalsAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "risk-signals"
+	// RiskLevelLabelKey the label on the UserSignup with the level of the risk score (see RiskLevel)
+	RiskLevelLabelKey = toolchainv1alpha1.LabelKeyPrefix + "risk-level"
+)
+
+// RiskLevel the level of a risk score
+type RiskLevel string
+
+const (
+	// RiskLevelLow a score below 40
+	RiskLevelLow RiskLevel = "low"
+	// RiskLevelMedium a score between 40 and 69
+	RiskLevelMedium RiskLevel = "medium"
+	// RiskLevelHigh a score of 70 or more
+	RiskLevelHigh RiskLevel = "high"
+)
+
+// the names of the signals
+const (
+	DomainVelocitySignal           = "domain-velocity"
+	ReactivationsSignal            = "reactivations"
+	VerificationRetriesSignal      = "verification-retries"
+	BannedUsernameSimilaritySignal = "banned-username-similarity"
+)
+
+const (
+	maxScore = 100
+	// minSimilarity the minimum similarity between the username of the user and a banned username for the signal to be raised
+	minSimilarity = 0.8
+)
+
+// Signal a signal which contributed to the risk score of a user
+type Signal struct {
+	Name   string
+	Points int
+}
+
+// Assessment the risk score of a user, along with the signals which contributed to it
+type Assessment struct {
+	Score   int
+	Signals []Signal
+}
+
+// Level returns the level of the risk score
+func (a Assessment) Level() RiskLevel {
+	switch {
+	case a.Score >= 70:
+		return RiskLevelHigh
+	case a.Score >= 40:
+		return RiskLevelMedium
+	default:
+		return RiskLevelLow
+	}
+}
+
+// SignalsValue returns the signals as the value of the RiskSignalsAnnotationKey annotation
+func (a Assessment) SignalsValue() string {
+	signals := make([]string, len(a.Signals))
+	for i, s := range a.Signals {
+		signals[i] = fmt.Sprintf("%s=%d", s.Name, s.Points)
+	}
+	return strings.Join(signals, ",")
+}
+
+// Scorer computes the risk score of the users from the following signals:
+// - the number of signups with the same email domain during the velocity window (when above the velocity threshold),
+// - the number of reactivations of the user (from the activation counter annotation),
+// - the number of times the user requested a new phone verification code,
+// - the similarity of the username (or the local part of the email address) with the usernames of the BannedUsers.
+//
+// Note: the member clusters do not report their resource usage per user, so resource spikes are not part of the signals.
+type Scorer struct {
+	client      client.Client
+	bannedUsers *banneduser.Index
+	velocity    *domainVelocity
+}
+
+// NewScorer returns a new Scorer of the UserSignups in the given namespace. The window and the threshold of the domain velocity signal
+// are read from the ToolchainConfig each time a UserSignup is assessed.
+func NewScorer(cl client.Client, namespace string, bannedUsers *banneduser.Index) *Scorer {
+	return &Scorer{
+		client:      cl,
+		bannedUsers: bannedUsers,
+		velocity:    newDomainVelocity(cl, namespace),
+	}
+}
+
+// Assess computes the risk score of the given UserSignup
+func (s *Scorer) Assess(userSignup *toolchainv1alpha1.UserSignup) (Assessment, error) {
+	assessment := Assessment{}
+	add := func(name string, points int) {
+		if points > 0 {
+			assessment.Signals = append(assessment.Signals, Signal{Name: name, Points: points})
+			assessment.Score += points
+		}
+	}
+
+	config, err := toolchainconfig.GetToolchainConfig(s.client)
+	if err != nil {
+		return assessment, errs.Wrap(err, "unable to get ToolchainConfig")
+	}
+	if threshold := config.RiskScore().DomainVelocityThreshold(); threshold > 0 {
+		signups, err := s.velocity.count(userSignup, config.RiskScore().DomainVelocityWindow())
+		if err != nil {
+			return assessment, err
+		}
+		if signups > threshold {
+			add(DomainVelocitySignal, minInt(40, 10*(signups/threshold)))
+		}
+	}
+	// the first activation is not a reactivation
+	add(ReactivationsSignal, minInt(30, 10*(counter(userSignup, toolchainv1alpha1.UserSignupActivationCounterAnnotationKey)-1)))
+	// the first verification code is not a retry
+	add(VerificationRetriesSignal, minInt(20, 5*(counter(userSignup, toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey)-1)))
+
+	if s.bannedUsers != nil {
+		usernames, err := s.bannedUsers.Usernames()
+		if err != nil {
+			return assessment, err
+		}
+		if similarity := maxSimilarity(userSignup, usernames); similarity >= minSimilarity {
+			add(BannedUsernameSimilaritySignal, int(50*similarity))
+		}
+	}
+
+	sort.Slice(assessment.Signals, func(i, j int) bool {
+		return assessment.Signals[i].Name < assessment.Signals[j].Name
+	})
+	assessment.Score = minInt(maxScore, assessment.Score)
+	return assessment, nil
+}
+
+// counter returns the value of the given counter annotation, or 0 if it is missing or invalid
+func counter(userSignup *toolchainv1alpha1.UserSignup, key string) int {
+	value, err := strconv.Atoi(userSignup.Annotations[key])
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// maxSimilarity returns the highest similarity between the username (or the local part of the email address) of the user
+// and the given banned usernames
+func maxSimilarity(userSignup *toolchainv1alpha1.UserSignup, bannedUsernames []string) float64 {
+	candidates := []string{strings.ToLower(userSignup.Spec.Username)}
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	if at := strings.LastIndex(email, "@"); at > 0 {
+		candidates = append(candidates, strings.ToLower(email[:at]))
+	}
+	var result float64
+	for _, bannedUsername := range bannedUsernames {
+		for _, candidate := range candidates {
+			if candidate == "" {
+				continue
+			}
+			if s := similarity(candidate, bannedUsername); s > result {
+				result = s
+			}
+		}
+	}
+	return result
+}
+
+// similarity returns the similarity of the given strings, between 0 (completely different) and 1 (identical),
+// based on their Levenshtein distance
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,280 @@
+package abuse_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/abuse"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+	. "github.com/codeready-toolchain/host-operator/test"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAssess(t *testing.T) {
+	// given
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	bannedUser := &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "banned-miner",
+			Namespace: test.HostOperatorNs,
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email: "cryptominer@gmail.com",
+		},
+	}
+
+	t.Run("no signal", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("joe", "joe@redhat.com")
+		scorer := newScorer(t, 10, userSignup, bannedUser)
+
+		// when
+		assessment, err := scorer.Assess(userSignup)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 0, assessment.Score)
+		assert.Empty(t, assessment.Signals)
+		assert.Equal(t, abuse.RiskLevelLow, assessment.Level())
+		assert.Empty(t, assessment.SignalsValue())
+	})
+
+	t.Run("reactivations and verification retries", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("joe", "joe@redhat.com",
+			commonsignup.WithActivations("3"),
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "4"))
+		scorer := newScorer(t, 10, userSignup, bannedUser)
+
+		// when
+		assessment, err := scorer.Assess(userSignup)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 35, assessment.Score)
+		assert.Equal(t, "reactivations=20,verification-retries=15", assessment.SignalsValue())
+		assert.Equal(t, abuse.RiskLevelLow, assessment.Level())
+	})
+
+	t.Run("signals are capped", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("joe", "joe@redhat.com",
+			commonsignup.WithActivations("10"),
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "10"))
+		scorer := newScorer(t, 10, userSignup, bannedUser)
+
+		// when
+		assessment, err := scorer.Assess(userSignup)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 50, assessment.Score)
+		assert.Equal(t, "reactivations=30,verification-retries=20", assessment.SignalsValue())
+		assert.Equal(t, abuse.RiskLevelMedium, assessment.Level())
+	})
+
+	t.Run("similar to a banned username", func(t *testing.T) {
+		for username, expected := range map[string]string{
+			"cryptominer":  "banned-username-similarity=50",
+			"cryptominer2": "banned-username-similarity=45",
+			"joe":          "",
+		} {
+			t.Run(username, func(t *testing.T) {
+				// given
+				userSignup := newUserSignup(username, "someone@redhat.com")
+				scorer := newScorer(t, 10, userSignup, bannedUser)
+
+				// when
+				assessment, err := scorer.Assess(userSignup)
+
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, expected, assessment.SignalsValue())
+			})
+		}
+
+		t.Run("local part of the email address", func(t *testing.T) {
+			// given
+			userSignup := newUserSignup("joe", "CryptoMiner1@example.com")
+			scorer := newScorer(t, 10, userSignup, bannedUser)
+
+			// when
+			assessment, err := scorer.Assess(userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "banned-username-similarity=45", assessment.SignalsValue())
+		})
+	})
+
+	t.Run("domain velocity", func(t *testing.T) {
+		// given
+		objs := []runtime.Object{bannedUser}
+		for i := 0; i < 20; i++ {
+			objs = append(objs, newUserSignup(fmt.Sprintf("user-%d", i), fmt.Sprintf("user-%d@throwaway.io", i)))
+		}
+		// signups with the same domain but outside of the window
+		for i := 0; i < 5; i++ {
+			userSignup := newUserSignup(fmt.Sprintf("old-user-%d", i), fmt.Sprintf("old-user-%d@throwaway.io", i))
+			userSignup.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			objs = append(objs, userSignup)
+		}
+		userSignup := newUserSignup("joe", "joe@Throwaway.io")
+
+		t.Run("above the threshold", func(t *testing.T) {
+			scorer := newScorer(t, 10, append(objs, userSignup)...)
+
+			// when
+			assessment, err := scorer.Assess(userSignup)
+
+			// then 21 signups during the window
+			require.NoError(t, err)
+			assert.Equal(t, "domain-velocity=20", assessment.SignalsValue())
+		})
+
+		t.Run("new signups are counted", func(t *testing.T) {
+			scorer := newScorer(t, 10, objs...)
+			assessment, err := scorer.Assess(newUserSignup("user-0", "user-0@throwaway.io"))
+			require.NoError(t, err)
+			assert.Equal(t, "domain-velocity=20", assessment.SignalsValue())
+
+			// when
+			for i := 0; i < 10; i++ {
+				_, err := scorer.Assess(newUserSignup(fmt.Sprintf("new-user-%d", i), fmt.Sprintf("new-user-%d@throwaway.io", i)))
+				require.NoError(t, err)
+			}
+			assessment, err = scorer.Assess(userSignup)
+
+			// then 31 signups during the window
+			require.NoError(t, err)
+			assert.Equal(t, "domain-velocity=30", assessment.SignalsValue())
+		})
+
+		t.Run("below the threshold", func(t *testing.T) {
+			scorer := newScorer(t, 25, append(objs, userSignup)...)
+
+			// when
+			assessment, err := scorer.Assess(userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, assessment.Signals)
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			scorer := newScorer(t, 0, append(objs, userSignup)...)
+
+			// when
+			assessment, err := scorer.Assess(userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.Empty(t, assessment.Signals)
+		})
+	})
+
+	t.Run("score is capped", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("cryptominer", "cryptominer@redhat.com",
+			commonsignup.WithActivations("10"),
+			commonsignup.WithAnnotation(toolchainv1alpha1.UserSignupVerificationCounterAnnotationKey, "10"))
+		scorer := newScorer(t, 10, userSignup, bannedUser)
+
+		// when
+		assessment, err := scorer.Assess(userSignup)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, 100, assessment.Score)
+		assert.Equal(t, "banned-username-similarity=50,reactivations=30,verification-retries=20", assessment.SignalsValue())
+		assert.Equal(t, abuse.RiskLevelHigh, assessment.Level())
+	})
+
+	t.Run("failures", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("joe", "joe@redhat.com")
+		newClient := func(t *testing.T, config *toolchainv1alpha1.ToolchainConfig) *test.FakeClient {
+			cl := test.NewFakeClient(t, userSignup, bannedUser, config)
+			cl.MockList = func(ctx context.Context, list runtimeclient.ObjectList, opts ...runtimeclient.ListOption) error {
+				switch list.(type) {
+				case *toolchainv1alpha1.UserSignupList, *toolchainv1alpha1.BannedUserList:
+					return fmt.Errorf("mock error")
+				default:
+					return cl.Client.List(ctx, list, opts...)
+				}
+			}
+			return cl
+		}
+
+		t.Run("unable to load the recent UserSignups", func(t *testing.T) {
+			// given
+			cl := newClient(t, commonconfig.NewToolchainConfigObjWithReset(t))
+
+			// when
+			_, err := abuse.NewScorer(cl, test.HostOperatorNs, nil).Assess(userSignup)
+
+			// then
+			require.EqualError(t, err, "unable to load the recent UserSignups: mock error")
+		})
+
+		t.Run("unable to load the BannedUsers", func(t *testing.T) {
+			// given
+			cl := newClient(t, commonconfig.NewToolchainConfigObjWithReset(t,
+				ConfigAnnotation(toolchainconfig.RiskScoreDomainVelocityThresholdAnnotationKey, "0")))
+
+			// when
+			_, err := abuse.NewScorer(cl, test.HostOperatorNs, banneduser.NewIndex(cl, test.HostOperatorNs)).Assess(userSignup)
+
+			// then
+			require.EqualError(t, err, "unable to load the index of BannedUsers: mock error")
+		})
+
+		t.Run("unable to get the ToolchainConfig", func(t *testing.T) {
+			// given
+			cl := newClient(t, commonconfig.NewToolchainConfigObjWithReset(t))
+			cl.MockGet = func(ctx context.Context, key runtimeclient.ObjectKey, obj runtimeclient.Object) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			_, err := abuse.NewScorer(cl, test.HostOperatorNs, nil).Assess(userSignup)
+
+			// then
+			require.EqualError(t, err, "unable to get ToolchainConfig: mock error")
+		})
+	})
+}
+
+func newScorer(t *testing.T, velocityThreshold int, objs ...runtime.Object) *abuse.Scorer {
+	config := commonconfig.NewToolchainConfigObjWithReset(t,
+		ConfigAnnotation(toolchainconfig.RiskScoreDomainVelocityWindowAnnotationKey, "1h"),
+		ConfigAnnotation(toolchainconfig.RiskScoreDomainVelocityThresholdAnnotationKey, strconv.Itoa(velocityThreshold)))
+	cl := test.NewFakeClient(t, append(objs, config)...)
+	return abuse.NewScorer(cl, test.HostOperatorNs, banneduser.NewIndex(cl, test.HostOperatorNs))
+}
+
+func newUserSignup(username, email string, modifiers ...commonsignup.Modifier) *toolchainv1alpha1.UserSignup {
+	modifiers = append([]commonsignup.Modifier{
+		commonsignup.WithName(username),
+		commonsignup.WithUsername(username),
+		commonsignup.WithEmail(email),
+		func(userSignup *toolchainv1alpha1.UserSignup) {
+			userSignup.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute))
+		},
+	}, modifiers...)
+	return commonsignup.NewUserSignup(modifiers...)
+}
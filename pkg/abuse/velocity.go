@@ -0,0 +1,102 @@
+package abuse
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	errs "github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// domainVelocity keeps track of the email domain of the UserSignups created during the window.
+// It is loaded from the UserSignups of the namespace on first use (and again if the window was extended since then),
+// and is then fed with the assessed UserSignups.
+type domainVelocity struct {
+	sync.Mutex
+	client    client.Client
+	namespace string
+	// window the window covered by the recorded UserSignups
+	window time.Duration
+	loaded bool
+	// signups the email domain and creation time of the recent UserSignups, indexed by name
+	signups map[string]signup
+}
+
+type signup struct {
+	domain  string
+	created time.Time
+}
+
+func newDomainVelocity(cl client.Client, namespace string) *domainVelocity {
+	return &domainVelocity{
+		client:    cl,
+		namespace: namespace,
+		signups:   map[string]signup{},
+	}
+}
+
+// count returns the number of UserSignups created with the same email domain as the given UserSignup during the given window
+// (including the given UserSignup, if it was created during the window)
+func (v *domainVelocity) count(userSignup *toolchainv1alpha1.UserSignup, window time.Duration) (int, error) {
+	v.Lock()
+	defer v.Unlock()
+	if err := v.load(window); err != nil {
+		return 0, err
+	}
+	since := time.Now().Add(-window)
+	v.record(userSignup, since)
+	domain := emailDomain(userSignup)
+	count := 0
+	for name, s := range v.signups {
+		if s.created.Before(since) {
+			delete(v.signups, name)
+			continue
+		}
+		if domain != "" && s.domain == domain {
+			count++
+		}
+	}
+	// the older UserSignups were forgotten
+	v.window = window
+	return count, nil
+}
+
+// load lists the UserSignups of the namespace, unless they were already loaded with the same or a larger window.
+// Must be called with the lock.
+func (v *domainVelocity) load(window time.Duration) error {
+	if v.loaded && v.window >= window {
+		return nil
+	}
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := v.client.List(context.TODO(), userSignups, client.InNamespace(v.namespace)); err != nil {
+		return errs.Wrap(err, "unable to load the recent UserSignups")
+	}
+	since := time.Now().Add(-window)
+	for i := range userSignups.Items {
+		v.record(&userSignups.Items[i], since)
+	}
+	v.loaded = true
+	return nil
+}
+
+func (v *domainVelocity) record(userSignup *toolchainv1alpha1.UserSignup, since time.Time) {
+	if userSignup.CreationTimestamp.Time.Before(since) {
+		return
+	}
+	v.signups[userSignup.Name] = signup{
+		domain:  emailDomain(userSignup),
+		created: userSignup.CreationTimestamp.Time,
+	}
+}
+
+func emailDomain(userSignup *toolchainv1alpha1.UserSignup) string {
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		return strings.ToLower(email[at+1:])
+	}
+	return ""
+}
@@ -0,0 +1,95 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Record the minimal and anonymised record of a UserSignup which is archived before the resource is deleted
+type Record struct {
+	// EmailHash the hash of the email address of the user (same as the `toolchain.dev.openshift.com/email-hash` label)
+	EmailHash string `json:"emailHash"`
+	// Activations the number of times the user was activated
+	Activations int `json:"activations"`
+	// CreationTime the time at which the UserSignup was created
+	CreationTime metav1.Time `json:"creationTime"`
+	// DeactivationTime the time at which the user was deactivated, if applicable
+	DeactivationTime *metav1.Time `json:"deactivationTime,omitempty"`
+	// DeletionTime the time at which the UserSignup was deleted
+	DeletionTime metav1.Time `json:"deletionTime"`
+	// UserTier the last UserTier of the user, if known
+	UserTier string `json:"userTier,omitempty"`
+	// SpaceTier the last NSTemplateTier of the Space of the user, if known
+	SpaceTier string `json:"spaceTier,omitempty"`
+	// Banned whether the user is banned
+	Banned bool `json:"banned"`
+	// Reason the reason why the UserSignup is deleted
+	Reason string `json:"reason"`
+}
+
+// Archiver writes the record of a UserSignup to a sink before the UserSignup is deleted
+type Archiver interface {
+	// Archive writes the given record. The UserSignup must not be deleted if an error is returned.
+	Archive(ctx context.Context, record Record) error
+}
+
+// Sink the kind of sink to which the records are written
+type Sink string
+
+const (
+	// FileSink the records are appended as JSON lines to a local file (eg, on a PersistentVolume)
+	FileSink Sink = "file"
+	// ConfigMapSink the records are written as JSON lines in a ConfigMap, which only retains the most recent records
+	ConfigMapSink Sink = "configmap"
+	// HTTPSink the records are sent as JSON to an HTTP endpoint
+	HTTPSink Sink = "http"
+)
+
+// Config the configuration of the archiver
+type Config struct {
+	// Sink the kind of sink, or an empty value if the records are not archived
+	Sink Sink
+	// FilePath the path to the file in which the records are written (`file` sink)
+	FilePath string
+	// ConfigMapName the name of the ConfigMap in which the records are written (`configmap` sink)
+	ConfigMapName string
+	// ConfigMapMaxRecords the maximum number of records retained in the ConfigMap (`configmap` sink)
+	ConfigMapMaxRecords int
+	// URL the endpoint to which the records are sent (`http` sink)
+	URL string
+	// Timeout the timeout of the requests to the endpoint (`http` sink)
+	Timeout time.Duration
+}
+
+// New returns a new Archiver for the given configuration, or nil if the records should not be archived
+func New(cl client.Client, namespace string, config Config) (Archiver, error) {
+	switch config.Sink {
+	case "":
+		return nil, nil
+	case FileSink:
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("missing file path for the '%s' archive sink", config.Sink)
+		}
+		return NewFileArchiver(config.FilePath), nil
+	case ConfigMapSink:
+		if config.ConfigMapName == "" {
+			return nil, fmt.Errorf("missing ConfigMap name for the '%s' archive sink", config.Sink)
+		}
+		if config.ConfigMapMaxRecords <= 0 {
+			return nil, fmt.Errorf("invalid maximum number of records for the '%s' archive sink: %d", config.Sink, config.ConfigMapMaxRecords)
+		}
+		return NewConfigMapArchiver(cl, namespace, config.ConfigMapName, config.ConfigMapMaxRecords), nil
+	case HTTPSink:
+		if config.URL == "" {
+			return nil, fmt.Errorf("missing URL for the '%s' archive sink", config.Sink)
+		}
+		return NewHTTPArchiver(config.URL, &http.Client{Timeout: config.Timeout}), nil
+	default:
+		return nil, fmt.Errorf("unknown archive sink '%s'", config.Sink)
+	}
+}
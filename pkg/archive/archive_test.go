@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	cl := test.NewFakeClient(t)
+
+	t.Run("no sink", func(t *testing.T) {
+		// when
+		a, err := New(cl, test.HostOperatorNs, Config{})
+
+		// then
+		require.NoError(t, err)
+		assert.Nil(t, a)
+	})
+
+	t.Run("valid sinks", func(t *testing.T) {
+		for _, config := range []Config{
+			{Sink: FileSink, FilePath: "/tmp/archive.jsonl"},
+			{Sink: ConfigMapSink, ConfigMapName: "archive", ConfigMapMaxRecords: 10},
+			{Sink: HTTPSink, URL: "http://archive"},
+		} {
+			t.Run(string(config.Sink), func(t *testing.T) {
+				// when
+				a, err := New(cl, test.HostOperatorNs, config)
+
+				// then
+				require.NoError(t, err)
+				assert.NotNil(t, a)
+			})
+		}
+	})
+
+	t.Run("invalid configs", func(t *testing.T) {
+		for msg, config := range map[string]Config{
+			"missing file path for the 'file' archive sink":                         {Sink: FileSink},
+			"missing ConfigMap name for the 'configmap' archive sink":               {Sink: ConfigMapSink, ConfigMapMaxRecords: 10},
+			"invalid maximum number of records for the 'configmap' archive sink: 0": {Sink: ConfigMapSink, ConfigMapName: "archive"},
+			"missing URL for the 'http' archive sink":                               {Sink: HTTPSink},
+			"unknown archive sink 'kafka'":                                          {Sink: "kafka"},
+		} {
+			t.Run(msg, func(t *testing.T) {
+				// when
+				_, err := New(cl, test.HostOperatorNs, config)
+
+				// then
+				require.EqualError(t, err, msg)
+			})
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMapRecordsKey the key of the ConfigMap data which contains the records, as JSON lines
+const ConfigMapRecordsKey = "records.jsonl"
+
+// ConfigMapArchiver writes the records as JSON lines in a ConfigMap, which only retains the most recent records
+type ConfigMapArchiver struct {
+	client     client.Client
+	namespace  string
+	name       string
+	maxRecords int
+}
+
+var _ Archiver = &ConfigMapArchiver{}
+
+// NewConfigMapArchiver returns a new ConfigMapArchiver which writes the records in the ConfigMap with the given name,
+// retaining at most the given number of records
+func NewConfigMapArchiver(cl client.Client, namespace, name string, maxRecords int) *ConfigMapArchiver {
+	return &ConfigMapArchiver{
+		client:     cl,
+		namespace:  namespace,
+		name:       name,
+		maxRecords: maxRecords,
+	}
+}
+
+// Archive appends the given record to the ConfigMap, removing the oldest records if needed. The ConfigMap is created if it does not exist yet.
+// A conflict while updating the ConfigMap results in an error, so that the record is archived again during the next reconcile loop.
+func (a *ConfigMapArchiver) Archive(ctx context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{}
+	if err := a.client.Get(ctx, types.NamespacedName{Namespace: a.namespace, Name: a.name}, cm); err != nil {
+		if !errors.IsNotFound(err) {
+			return errs.Wrapf(err, "unable to get archive ConfigMap '%s'", a.name)
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: a.namespace,
+				Name:      a.name,
+			},
+			Data: map[string]string{
+				ConfigMapRecordsKey: string(line) + "\n",
+			},
+		}
+		if err := a.client.Create(ctx, cm); err != nil {
+			return errs.Wrapf(err, "unable to create archive ConfigMap '%s'", a.name)
+		}
+		return nil
+	}
+	lines := []string{}
+	if existing := strings.TrimSpace(cm.Data[ConfigMapRecordsKey]); existing != "" {
+		lines = strings.Split(existing, "\n")
+	}
+	lines = append(lines, string(line))
+	if len(lines) > a.maxRecords {
+		lines = lines[len(lines)-a.maxRecords:]
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[ConfigMapRecordsKey] = strings.Join(lines, "\n") + "\n"
+	if err := a.client.Update(ctx, cm); err != nil {
+		return errs.Wrapf(err, "unable to update archive ConfigMap '%s'", a.name)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestConfigMapArchiver(t *testing.T) {
+	getRecords := func(t *testing.T, cl client.Client) []Record {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "archive"), cm))
+		records := []Record{}
+		for _, line := range strings.Split(strings.TrimSpace(cm.Data[ConfigMapRecordsKey]), "\n") {
+			record := Record{}
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		return records
+	}
+
+	t.Run("create, append and trim", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+		a := NewConfigMapArchiver(cl, test.HostOperatorNs, "archive", 2)
+
+		// when
+		err := a.Archive(context.TODO(), Record{EmailHash: "a"})
+
+		// then
+		require.NoError(t, err)
+		records := getRecords(t, cl)
+		require.Len(t, records, 1)
+		assert.Equal(t, "a", records[0].EmailHash)
+
+		// when
+		require.NoError(t, a.Archive(context.TODO(), Record{EmailHash: "b"}))
+		require.NoError(t, a.Archive(context.TODO(), Record{EmailHash: "c"}))
+
+		// then the oldest record was removed
+		records = getRecords(t, cl)
+		require.Len(t, records, 2)
+		assert.Equal(t, "b", records[0].EmailHash)
+		assert.Equal(t, "c", records[1].EmailHash)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+		a := NewConfigMapArchiver(cl, test.HostOperatorNs, "archive", 2)
+		require.NoError(t, a.Archive(context.TODO(), Record{EmailHash: "a"}))
+		cl.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+			return fmt.Errorf("conflict")
+		}
+
+		// when
+		err := a.Archive(context.TODO(), Record{EmailHash: "b"})
+
+		// then
+		require.EqualError(t, err, "unable to update archive ConfigMap 'archive': conflict")
+		assert.Len(t, getRecords(t, cl), 1)
+	})
+}
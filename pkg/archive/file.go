@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	errs "github.com/pkg/errors"
+)
+
+// FileArchiver appends the records as JSON lines to a local file
+type FileArchiver struct {
+	path string
+	lock sync.Mutex
+}
+
+var _ Archiver = &FileArchiver{}
+
+// NewFileArchiver returns a new FileArchiver which writes the records in the file at the given path
+func NewFileArchiver(path string) *FileArchiver {
+	return &FileArchiver{
+		path: path,
+	}
+}
+
+// Archive appends the given record to the file, and syncs the file so that the record is not lost if the process stops
+func (a *FileArchiver) Archive(_ context.Context, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return errs.Wrapf(err, "unable to open archive file '%s'", a.path)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return errs.Wrapf(err, "unable to write in archive file '%s'", a.path)
+	}
+	if err := f.Sync(); err != nil {
+		return errs.Wrapf(err, "unable to sync archive file '%s'", a.path)
+	}
+	return nil
+}
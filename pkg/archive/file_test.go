@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileArchiver(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	a := NewFileArchiver(path)
+
+	// when
+	err1 := a.Archive(context.TODO(), Record{EmailHash: "a", Reason: "DeactivatedRetentionExpired"})
+	err2 := a.Archive(context.TODO(), Record{EmailHash: "b", Reason: "UnverifiedRetentionExpired"})
+
+	// then
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	record := Record{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "b", record.EmailHash)
+	assert.Equal(t, "UnverifiedRetentionExpired", record.Reason)
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		a := NewFileArchiver(filepath.Join(t.TempDir(), "missing", "archive.jsonl"))
+
+		// when
+		err := a.Archive(context.TODO(), Record{EmailHash: "a"})
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to open archive file")
+	})
+}
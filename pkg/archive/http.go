@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	errs "github.com/pkg/errors"
+)
+
+// HTTPArchiver sends the records as JSON to an HTTP endpoint
+type HTTPArchiver struct {
+	url    string
+	client *http.Client
+}
+
+var _ Archiver = &HTTPArchiver{}
+
+// NewHTTPArchiver returns a new HTTPArchiver which sends the records to the given URL
+func NewHTTPArchiver(url string, client *http.Client) *HTTPArchiver {
+	return &HTTPArchiver{
+		url:    url,
+		client: client,
+	}
+}
+
+// Archive sends the given record in a POST request. Any response status other than 2xx is considered as a failure.
+func (a *HTTPArchiver) Archive(ctx context.Context, record Record) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return errs.Wrap(err, "unable to create archive request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return errs.Wrap(err, "unable to send archive request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("archive request failed with status %d: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPArchiver(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// given
+		received := Record{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer server.Close()
+		a := NewHTTPArchiver(server.URL, server.Client())
+
+		// when
+		err := a.Archive(context.TODO(), Record{EmailHash: "a", Activations: 3})
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "a", received.EmailHash)
+		assert.Equal(t, 3, received.Activations)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("unavailable"))
+		}))
+		defer server.Close()
+		a := NewHTTPArchiver(server.URL, server.Client())
+
+		// when
+		err := a.Archive(context.TODO(), Record{EmailHash: "a"})
+
+		// then
+		require.EqualError(t, err, "archive request failed with status 500: unavailable")
+	})
+}
@@ -0,0 +1,353 @@
+package banneduser
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	errs "github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var log = logf.Log.WithName("banned_user_index")
+
+const (
+	// EmailDomainAnnotationKey the annotation on the BannedUser which bans all the users whose email address belongs to the given domain
+	// (or to one of its subdomains)
+	EmailDomainAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-email-domain"
+	// PhoneHashAnnotationKey the annotation on the BannedUser which bans all the users whose phone number has the given hash
+	PhoneHashAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-phone-hash"
+	// UsernamePatternAnnotationKey the annotation on the BannedUser which bans all the users whose username matches the given regular expression
+	UsernamePatternAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-username-pattern"
+	// CompanyAnnotationKey the annotation on the BannedUser which bans all the users of the given company (case-insensitive)
+	CompanyAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "ban-company"
+)
+
+// rule the criteria of a BannedUser, besides its email address. A user matches the rule if they match all its criteria.
+type rule struct {
+	bannedUser      toolchainv1alpha1.BannedUser
+	emailDomain     string
+	phoneHash       string
+	company         string
+	usernamePattern *regexp.Regexp
+}
+
+// newRule returns the rule of the given BannedUser, or nil if the BannedUser only bans a single email address.
+// Note: the phone number hash label of the BannedUser is not a rule, since it is also set on the BannedUsers of a single email address.
+func newRule(bannedUser *toolchainv1alpha1.BannedUser) (*rule, error) {
+	r := &rule{
+		bannedUser:  *bannedUser,
+		emailDomain: strings.ToLower(strings.TrimPrefix(bannedUser.Annotations[EmailDomainAnnotationKey], "@")),
+		phoneHash:   strings.TrimSpace(bannedUser.Annotations[PhoneHashAnnotationKey]),
+		company:     strings.ToLower(strings.TrimSpace(bannedUser.Annotations[CompanyAnnotationKey])),
+	}
+	if pattern := bannedUser.Annotations[UsernamePatternAnnotationKey]; pattern != "" {
+		usernamePattern, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid username pattern '%s': %w", pattern, err)
+		}
+		r.usernamePattern = usernamePattern
+	}
+	if r.emailDomain == "" && r.phoneHash == "" && r.company == "" && r.usernamePattern == nil {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// ValidateRule returns an error if the rule of the given BannedUser is invalid
+func ValidateRule(bannedUser *toolchainv1alpha1.BannedUser) error {
+	_, err := newRule(bannedUser)
+	return err
+}
+
+// IsRule returns `true` if the given BannedUser bans users by email domain, phone number hash, username pattern or company
+func IsRule(bannedUser *toolchainv1alpha1.BannedUser) bool {
+	r, err := newRule(bannedUser)
+	return err == nil && r != nil
+}
+
+func (r *rule) matches(userSignup *toolchainv1alpha1.UserSignup) bool {
+	if r.emailDomain != "" && !isInDomain(emailDomain(userSignup), r.emailDomain) {
+		return false
+	}
+	if r.phoneHash != "" && userSignup.Labels[toolchainv1alpha1.UserSignupUserPhoneHashLabelKey] != r.phoneHash {
+		return false
+	}
+	if r.company != "" && strings.ToLower(strings.TrimSpace(userSignup.Spec.Company)) != r.company {
+		return false
+	}
+	if r.usernamePattern != nil && !r.usernamePattern.MatchString(userSignup.Spec.Username) {
+		return false
+	}
+	return true
+}
+
+func emailDomain(userSignup *toolchainv1alpha1.UserSignup) string {
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		return strings.ToLower(email[i+1:])
+	}
+	return ""
+}
+
+func isInDomain(domain, parent string) bool {
+	return domain == parent || strings.HasSuffix(domain, "."+parent)
+}
+
+// Index an in-memory index of the BannedUsers which ban users by email domain, phone number hash, username pattern or company.
+// The rules are indexed by their email domain, phone number hash or company (in this order of precedence), and
+// the rules which only have a username pattern are evaluated one by one.
+//
+// The index also retains the usernames (ie, the local part of the email address) of the BannedUsers which ban a single email address.
+//
+// The index is loaded from the BannedUsers of the namespace on first use, and is then kept up-to-date by its EventHandler.
+type Index struct {
+	sync.Mutex
+	client    client.Client
+	namespace string
+	loaded    bool
+	rules     map[string]*rule
+	byDomain  map[string]map[string]bool
+	byPhone   map[string]map[string]bool
+	byCompany map[string]map[string]bool
+	patterns  map[string]bool
+	usernames map[string]string
+}
+
+// NewIndex returns a new index of the BannedUsers in the given namespace
+func NewIndex(cl client.Client, namespace string) *Index {
+	i := &Index{
+		client:    cl,
+		namespace: namespace,
+	}
+	i.reset()
+	return i
+}
+
+func (i *Index) reset() {
+	i.rules = map[string]*rule{}
+	i.byDomain = map[string]map[string]bool{}
+	i.byPhone = map[string]map[string]bool{}
+	i.byCompany = map[string]map[string]bool{}
+	i.patterns = map[string]bool{}
+	i.usernames = map[string]string{}
+}
+
+// load lists all the BannedUsers of the namespace, unless the index is already loaded. Must be called with the write lock.
+func (i *Index) load() error {
+	if i.loaded {
+		return nil
+	}
+	bannedUsers := &toolchainv1alpha1.BannedUserList{}
+	if err := i.client.List(context.TODO(), bannedUsers, client.InNamespace(i.namespace)); err != nil {
+		return errs.Wrap(err, "unable to load the index of BannedUsers")
+	}
+	i.reset()
+	for index := range bannedUsers.Items {
+		i.set(&bannedUsers.Items[index])
+	}
+	i.loaded = true
+	log.Info("index of BannedUsers loaded", "rules", len(i.rules))
+	return nil
+}
+
+// Set adds or replaces the given BannedUser in the index
+func (i *Index) Set(bannedUser *toolchainv1alpha1.BannedUser) {
+	i.Lock()
+	defer i.Unlock()
+	i.set(bannedUser)
+}
+
+func (i *Index) set(bannedUser *toolchainv1alpha1.BannedUser) {
+	i.delete(bannedUser.Name)
+	r, err := newRule(bannedUser)
+	if err != nil {
+		log.Error(err, "ignoring the invalid rule of the BannedUser", "name", bannedUser.Name)
+		return
+	}
+	if r == nil {
+		// the email address may also be the hash of the address, once the user was purged
+		if at := strings.LastIndex(bannedUser.Spec.Email, "@"); at > 0 {
+			i.usernames[bannedUser.Name] = strings.ToLower(bannedUser.Spec.Email[:at])
+		}
+		return
+	}
+	i.rules[bannedUser.Name] = r
+	switch {
+	case r.emailDomain != "":
+		add(i.byDomain, r.emailDomain, bannedUser.Name)
+	case r.phoneHash != "":
+		add(i.byPhone, r.phoneHash, bannedUser.Name)
+	case r.company != "":
+		add(i.byCompany, r.company, bannedUser.Name)
+	default:
+		i.patterns[bannedUser.Name] = true
+	}
+}
+
+func add(index map[string]map[string]bool, key, name string) {
+	if index[key] == nil {
+		index[key] = map[string]bool{}
+	}
+	index[key][name] = true
+}
+
+// Delete removes the BannedUser with the given name from the index
+func (i *Index) Delete(name string) {
+	i.Lock()
+	defer i.Unlock()
+	i.delete(name)
+}
+
+func (i *Index) delete(name string) {
+	delete(i.usernames, name)
+	r, found := i.rules[name]
+	if !found {
+		return
+	}
+	delete(i.rules, name)
+	switch {
+	case r.emailDomain != "":
+		remove(i.byDomain, r.emailDomain, name)
+	case r.phoneHash != "":
+		remove(i.byPhone, r.phoneHash, name)
+	case r.company != "":
+		remove(i.byCompany, r.company, name)
+	default:
+		delete(i.patterns, name)
+	}
+}
+
+func remove(index map[string]map[string]bool, key, name string) {
+	delete(index[key], name)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// Matching returns the BannedUsers whose rule matches the given UserSignup
+func (i *Index) Matching(userSignup *toolchainv1alpha1.UserSignup) ([]toolchainv1alpha1.BannedUser, error) {
+	i.Lock()
+	defer i.Unlock()
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+	return i.matching(userSignup), nil
+}
+
+// Usernames returns the usernames of the BannedUsers which ban a single email address
+func (i *Index) Usernames() ([]string, error) {
+	i.Lock()
+	defer i.Unlock()
+	if err := i.load(); err != nil {
+		return nil, err
+	}
+	usernames := make([]string, 0, len(i.usernames))
+	for _, username := range i.usernames {
+		usernames = append(usernames, username)
+	}
+	return usernames, nil
+}
+
+func (i *Index) matching(userSignup *toolchainv1alpha1.UserSignup) []toolchainv1alpha1.BannedUser {
+	candidates := map[string]bool{}
+	for name := range i.patterns {
+		candidates[name] = true
+	}
+	// the rules of the domain of the user, and of all its parent domains
+	domain := emailDomain(userSignup)
+	for domain != "" {
+		for name := range i.byDomain[domain] {
+			candidates[name] = true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+	if phoneHash := userSignup.Labels[toolchainv1alpha1.UserSignupUserPhoneHashLabelKey]; phoneHash != "" {
+		for name := range i.byPhone[phoneHash] {
+			candidates[name] = true
+		}
+	}
+	if company := strings.ToLower(strings.TrimSpace(userSignup.Spec.Company)); company != "" {
+		for name := range i.byCompany[company] {
+			candidates[name] = true
+		}
+	}
+	var matching []toolchainv1alpha1.BannedUser
+	for name := range candidates {
+		if r := i.rules[name]; r.matches(userSignup) {
+			matching = append(matching, r.bannedUser)
+		}
+	}
+	return matching
+}
+
+// EventHandler returns the handler which keeps the index up-to-date with the BannedUser events, and which enqueues
+// the requests for all the UserSignups matching the rule of the BannedUser, so that they are all banned (or restored) in bulk
+func (i *Index) EventHandler() handler.EventHandler {
+	return handler.Funcs{
+		CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+			if bannedUser, ok := e.Object.(*toolchainv1alpha1.BannedUser); ok {
+				i.Set(bannedUser)
+				i.enqueueMatchingUserSignups(q, bannedUser)
+			}
+		},
+		UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			if bannedUser, ok := e.ObjectNew.(*toolchainv1alpha1.BannedUser); ok {
+				i.Set(bannedUser)
+				i.enqueueMatchingUserSignups(q, bannedUser)
+			}
+			if oldBannedUser, ok := e.ObjectOld.(*toolchainv1alpha1.BannedUser); ok {
+				i.enqueueMatchingUserSignups(q, oldBannedUser)
+			}
+		},
+		DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			if bannedUser, ok := e.Object.(*toolchainv1alpha1.BannedUser); ok {
+				i.Delete(bannedUser.Name)
+				i.enqueueMatchingUserSignups(q, bannedUser)
+			}
+		},
+	}
+}
+
+func (i *Index) enqueueMatchingUserSignups(q workqueue.RateLimitingInterface, bannedUser *toolchainv1alpha1.BannedUser) {
+	for _, req := range i.MatchingUserSignups(bannedUser) {
+		q.Add(req)
+	}
+}
+
+// MatchingUserSignups returns the requests for all the UserSignups of the namespace which match the rule of the given BannedUser
+func (i *Index) MatchingUserSignups(bannedUser *toolchainv1alpha1.BannedUser) []reconcile.Request {
+	r, err := newRule(bannedUser)
+	if err != nil || r == nil {
+		return nil
+	}
+	userSignups := &toolchainv1alpha1.UserSignupList{}
+	if err := i.client.List(context.TODO(), userSignups, client.InNamespace(i.namespace)); err != nil {
+		log.Error(err, "unable to list the UserSignups matching the rule of the BannedUser", "name", bannedUser.Name)
+		return nil
+	}
+	var requests []reconcile.Request
+	for index := range userSignups.Items {
+		if r.matches(&userSignups.Items[index]) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: i.namespace, Name: userSignups.Items[index].Name},
+			})
+		}
+	}
+	log.Info("UserSignups matching the rule of the BannedUser", "name", bannedUser.Name, "count", len(requests))
+	return requests
+}
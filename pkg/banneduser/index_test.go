@@ -0,0 +1,302 @@
+package banneduser_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestIndexMatching(t *testing.T) {
+	// given
+	byDomain := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "throwaway.io"}, nil)
+	byPhone := newBannedUser("by-phone", map[string]string{banneduser.PhoneHashAnnotationKey: "fd276563a8232d16620da8ec85d0575f"}, nil)
+	byCompany := newBannedUser("by-company", map[string]string{banneduser.CompanyAnnotationKey: "ACME"}, nil)
+	byPattern := newBannedUser("by-pattern", map[string]string{banneduser.UsernamePatternAnnotationKey: "^bot-[0-9]+$"}, nil)
+	byDomainAndCompany := newBannedUser("by-domain-and-company", map[string]string{
+		banneduser.EmailDomainAnnotationKey: "example.com",
+		banneduser.CompanyAnnotationKey:     "evil corp",
+	}, nil)
+	byEmail := newBannedUser("by-email", nil, map[string]string{toolchainv1alpha1.BannedUserPhoneNumberHashLabelKey: "2b7df4a81b3aa1ac1c35fd2aa8df6e2d"})
+	byEmail.Spec.Email = "foo@redhat.com"
+	index := banneduser.NewIndex(test.NewFakeClient(t, byDomain, byPhone, byCompany, byPattern, byDomainAndCompany, byEmail), test.HostOperatorNs)
+
+	for name, data := range map[string]struct {
+		userSignup *toolchainv1alpha1.UserSignup
+		expected   []string
+	}{
+		"domain": {
+			userSignup: newUserSignup("joe@throwaway.io"),
+			expected:   []string{"by-domain"},
+		},
+		"subdomain": {
+			userSignup: newUserSignup("joe@eu.Throwaway.io"),
+			expected:   []string{"by-domain"},
+		},
+		"other domain with same suffix": {
+			userSignup: newUserSignup("joe@notthrowaway.io"),
+		},
+		"phone hash": {
+			userSignup: newUserSignup("joe@redhat.com", commonsignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, "fd276563a8232d16620da8ec85d0575f")),
+			expected:   []string{"by-phone"},
+		},
+		"phone hash label of a single email address": {
+			userSignup: newUserSignup("joe@redhat.com", commonsignup.WithLabel(toolchainv1alpha1.UserSignupUserPhoneHashLabelKey, "2b7df4a81b3aa1ac1c35fd2aa8df6e2d")),
+		},
+		"company": {
+			userSignup: newUserSignup("joe@redhat.com", withCompany(" acme ")),
+			expected:   []string{"by-company"},
+		},
+		"username pattern": {
+			userSignup: newUserSignup("joe@redhat.com", commonsignup.WithUsername("bot-123")),
+			expected:   []string{"by-pattern"},
+		},
+		"all criteria": {
+			userSignup: newUserSignup("joe@example.com", withCompany("Evil Corp")),
+			expected:   []string{"by-domain-and-company"},
+		},
+		"only some criteria": {
+			userSignup: newUserSignup("joe@example.com", withCompany("Good Corp")),
+		},
+		"several rules": {
+			userSignup: newUserSignup("bot-1@throwaway.io", commonsignup.WithUsername("bot-1"), withCompany("acme")),
+			expected:   []string{"by-company", "by-domain", "by-pattern"},
+		},
+		"email address only": {
+			userSignup: newUserSignup("foo@redhat.com"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			matching, err := index.Matching(data.userSignup)
+
+			// then
+			require.NoError(t, err)
+			assert.ElementsMatch(t, data.expected, names(matching))
+		})
+	}
+}
+
+func TestIndexSetAndDelete(t *testing.T) {
+	// given
+	byDomain := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "throwaway.io"}, nil)
+	index := banneduser.NewIndex(test.NewFakeClient(t, byDomain), test.HostOperatorNs)
+	userSignup := newUserSignup("joe@throwaway.io", withCompany("acme"))
+
+	t.Run("loaded on first use", func(t *testing.T) {
+		matching, err := index.Matching(userSignup)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"by-domain"}, names(matching))
+	})
+
+	t.Run("rule changed", func(t *testing.T) {
+		// when
+		index.Set(newBannedUser("by-domain", map[string]string{banneduser.CompanyAnnotationKey: "other"}, nil))
+
+		// then
+		matching, err := index.Matching(userSignup)
+		require.NoError(t, err)
+		assert.Empty(t, matching)
+	})
+
+	t.Run("new rule", func(t *testing.T) {
+		// when
+		index.Set(newBannedUser("by-company", map[string]string{banneduser.CompanyAnnotationKey: "acme"}, nil))
+
+		// then
+		matching, err := index.Matching(userSignup)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"by-company"}, names(matching))
+	})
+
+	t.Run("invalid rule is ignored", func(t *testing.T) {
+		// when
+		index.Set(newBannedUser("by-company", map[string]string{
+			banneduser.CompanyAnnotationKey:         "acme",
+			banneduser.UsernamePatternAnnotationKey: "[a-z",
+		}, nil))
+
+		// then
+		matching, err := index.Matching(userSignup)
+		require.NoError(t, err)
+		assert.Empty(t, matching)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		// given
+		index.Set(newBannedUser("by-company", map[string]string{banneduser.CompanyAnnotationKey: "acme"}, nil))
+
+		// when
+		index.Delete("by-company")
+
+		// then
+		matching, err := index.Matching(userSignup)
+		require.NoError(t, err)
+		assert.Empty(t, matching)
+	})
+
+	t.Run("loading fails", func(t *testing.T) {
+		// given
+		cl := test.NewFakeClient(t)
+		cl.MockList = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		_, err := banneduser.NewIndex(cl, test.HostOperatorNs).Matching(userSignup)
+
+		// then
+		require.EqualError(t, err, "unable to load the index of BannedUsers: mock error")
+	})
+}
+
+func TestIndexUsernames(t *testing.T) {
+	// given
+	byEmail := newBannedUser("by-email", nil, nil)
+	byEmail.Spec.Email = "Joe.Miner@redhat.com"
+	purged := newBannedUser("purged", nil, nil)
+	purged.Spec.Email = "fd2addbd8d82f0d2dc088fa122377eaa"
+	byDomain := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "throwaway.io"}, nil)
+	byDomain.Spec.Email = "joe@throwaway.io"
+	index := banneduser.NewIndex(test.NewFakeClient(t, byEmail, purged, byDomain), test.HostOperatorNs)
+
+	t.Run("only the BannedUsers of a single email address", func(t *testing.T) {
+		// when
+		usernames, err := index.Usernames()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []string{"joe.miner"}, usernames)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		// when
+		index.Delete("by-email")
+
+		// then
+		usernames, err := index.Usernames()
+		require.NoError(t, err)
+		assert.Empty(t, usernames)
+	})
+}
+
+func TestIndexEventHandler(t *testing.T) {
+	// given
+	joe := newUserSignup("joe@throwaway.io")
+	jane := newUserSignup("jane@eu.throwaway.io")
+	john := newUserSignup("john@redhat.com")
+	byDomain := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "throwaway.io"}, nil)
+	index := banneduser.NewIndex(test.NewFakeClient(t, joe, jane, john, byDomain), test.HostOperatorNs)
+
+	t.Run("create", func(t *testing.T) {
+		// given
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+
+		// when
+		index.EventHandler().Create(event.CreateEvent{Object: byDomain}, q)
+
+		// then
+		assert.ElementsMatch(t, []reconcile.Request{request(joe), request(jane)}, drain(q))
+		matching, err := index.Matching(joe)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"by-domain"}, names(matching))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		// given
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		updated := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "redhat.com"}, nil)
+
+		// when
+		index.EventHandler().Update(event.UpdateEvent{ObjectOld: byDomain, ObjectNew: updated}, q)
+
+		// then the users of the previous and the new domain are reconciled
+		assert.ElementsMatch(t, []reconcile.Request{request(joe), request(jane), request(john)}, drain(q))
+		matching, err := index.Matching(joe)
+		require.NoError(t, err)
+		assert.Empty(t, matching)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		// given
+		q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+		deleted := newBannedUser("by-domain", map[string]string{banneduser.EmailDomainAnnotationKey: "redhat.com"}, nil)
+
+		// when
+		index.EventHandler().Delete(event.DeleteEvent{Object: deleted}, q)
+
+		// then
+		assert.ElementsMatch(t, []reconcile.Request{request(john)}, drain(q))
+		matching, err := index.Matching(john)
+		require.NoError(t, err)
+		assert.Empty(t, matching)
+	})
+}
+
+func TestValidateRule(t *testing.T) {
+	assert.NoError(t, banneduser.ValidateRule(newBannedUser("valid", map[string]string{banneduser.UsernamePatternAnnotationKey: "^bot-.*"}, nil)))
+	assert.EqualError(t, banneduser.ValidateRule(newBannedUser("invalid", map[string]string{banneduser.UsernamePatternAnnotationKey: "[a-z"}, nil)),
+		"invalid username pattern '[a-z': error parsing regexp: missing closing ]: `[a-z`")
+	assert.True(t, banneduser.IsRule(newBannedUser("rule", map[string]string{banneduser.CompanyAnnotationKey: "acme"}, nil)))
+	assert.False(t, banneduser.IsRule(newBannedUser("email", nil, nil)))
+}
+
+func newBannedUser(name string, annotations, labels map[string]string) *toolchainv1alpha1.BannedUser {
+	return &toolchainv1alpha1.BannedUser{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   test.HostOperatorNs,
+			Annotations: annotations,
+			Labels:      labels,
+		},
+		Spec: toolchainv1alpha1.BannedUserSpec{
+			Email: name,
+		},
+	}
+}
+
+func newUserSignup(email string, modifiers ...commonsignup.Modifier) *toolchainv1alpha1.UserSignup {
+	modifiers = append([]commonsignup.Modifier{commonsignup.WithEmail(email)}, modifiers...)
+	return commonsignup.NewUserSignup(modifiers...)
+}
+
+func withCompany(company string) commonsignup.Modifier {
+	return func(userSignup *toolchainv1alpha1.UserSignup) {
+		userSignup.Spec.Company = company
+	}
+}
+
+func names(bannedUsers []toolchainv1alpha1.BannedUser) []string {
+	var names []string
+	for _, bannedUser := range bannedUsers {
+		names = append(names, bannedUser.Name)
+	}
+	return names
+}
+
+func request(userSignup *toolchainv1alpha1.UserSignup) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{Namespace: test.HostOperatorNs, Name: userSignup.Name}}
+}
+
+func drain(q workqueue.RateLimitingInterface) []reconcile.Request {
+	var requests []reconcile.Request
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		requests = append(requests, item.(reconcile.Request))
+		q.Done(item)
+	}
+	return requests
+}
@@ -30,7 +30,7 @@ var cachedCounts = cache{
 
 // Counts is type that contains number of MURs and number of UserAccounts per member cluster
 type Counts struct {
-	// MasterUserRecordPerDomainCounts the number of MasterUserRecords per email address domain (`internal` vs `external`)
+	// MasterUserRecordPerDomainCounts the number of MasterUserRecords per email address domain class (eg: `internal` vs `external`)
 	MasterUserRecordPerDomainCounts map[string]int
 	// UserAccountsPerClusterCounts the number of UserAccounts by cluster name
 	UserAccountsPerClusterCounts map[string]int
@@ -52,10 +52,11 @@ func write(operation func()) {
 	operation()
 }
 
-// Reset resets the cached counter - is supposed to be used only in tests
+// Reset resets the cached counter and the email domain classes - is supposed to be used only in tests
 func Reset() {
 	write(func() {
 		reset()
+		metrics.SetDomainClasses(metrics.DefaultDomainClasses)
 	})
 }
 
@@ -73,8 +74,13 @@ func reset() {
 	metrics.MasterUserRecordGaugeVec.Reset()
 }
 
+// MasterUserRecords returns the total number of MasterUserRecords, regardless of their email address domain
 func (c Counts) MasterUserRecords() int {
-	return c.MasterUserRecordPerDomainCounts[string(metrics.Internal)] + c.MasterUserRecordPerDomainCounts[string(metrics.External)]
+	count := 0
+	for _, domainCount := range c.MasterUserRecordPerDomainCounts {
+		count += domainCount
+	}
+	return count
 }
 
 // IncrementMasterUserRecordCount increments the number of MasterUserRecord in the cached counter
@@ -187,12 +193,26 @@ func GetCounts() (Counts, error) {
 //
 // If the cached counter is initialized and ToolchainStatus contains already some numbers
 // then it updates the ToolchainStatus numbers with the one taken from the cached counter
+//
+// If the email domain classes of the ToolchainConfig changed since the cached counter was initialized,
+// then all counts are rebuilt from the existing resources
 func Synchronize(cl client.Client, toolchainStatus *toolchainv1alpha1.ToolchainStatus) error {
 	cachedCounts.Lock()
 	defer cachedCounts.Unlock()
 
+	config, err := toolchainconfig.GetToolchainConfig(cl)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize counter cache")
+	}
+	if metrics.SetDomainClasses(config.Metrics().DomainClasses()) && cachedCounts.initialized {
+		log.Info("email domain classes changed, rebuilding the counters from resources")
+		if err := initializeFromResources(cl, toolchainStatus.Namespace); err != nil {
+			return err
+		}
+	}
+
 	// initialize the cached counters (if needed)
-	if err := initialize(cl, toolchainStatus); err != nil {
+	if err := initialize(cl, config, toolchainStatus); err != nil {
 		return err
 	}
 
@@ -253,45 +273,82 @@ func indexOfMember(members []toolchainv1alpha1.Member, name string) int {
 	return -1
 }
 
-func initialize(cl client.Client, toolchainStatus *toolchainv1alpha1.ToolchainStatus) error {
+func initialize(cl client.Client, config toolchainconfig.ToolchainConfig, toolchainStatus *toolchainv1alpha1.ToolchainStatus) error {
 	// skip if cached counters are already initialized
 	if cachedCounts.initialized {
 		return nil
 	}
 
 	// initialize the cached counters from the UserSignup and MasterUserRecord resources.
-	config, err := toolchainconfig.GetToolchainConfig(cl)
-	if err != nil {
-		return errors.Wrap(err, "unable to initialize counter cache")
-	}
-	_, masterUserRecordsPerDomainMetricExists := toolchainStatus.Status.Metrics[toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey]
-	_, usersPerActivationAndDomainMetricKeyExists := toolchainStatus.Status.Metrics[toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey]
+	masterUserRecordsPerDomainMetric, masterUserRecordsPerDomainMetricExists := toolchainStatus.Status.Metrics[toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey]
+	usersPerActivationAndDomainMetric, usersPerActivationAndDomainMetricKeyExists := toolchainStatus.Status.Metrics[toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey]
 	if config.Metrics().ForceSynchronization() ||
 		!usersPerActivationAndDomainMetricKeyExists ||
-		!masterUserRecordsPerDomainMetricExists {
+		!masterUserRecordsPerDomainMetricExists ||
+		!hasKnownDomains(masterUserRecordsPerDomainMetric, usersPerActivationAndDomainMetric) {
 		return initializeFromResources(cl, toolchainStatus.Namespace)
 	}
 	// otherwise, initialize the cached counters from the ToolchainStatus resource.
 	return initializeFromToolchainStatus(toolchainStatus)
 }
 
+// hasKnownDomains returns `false` if the given metrics contain a domain which is not one of the current email domain classes,
+// ie, if the metrics were computed with other domain classes
+func hasKnownDomains(masterUserRecordsPerDomainMetric, usersPerActivationAndDomainMetric toolchainv1alpha1.Metric) bool {
+	for domain := range masterUserRecordsPerDomainMetric {
+		if !metrics.IsKnownDomain(domain) {
+			return false
+		}
+	}
+	for key := range usersPerActivationAndDomainMetric {
+		labels := splitLabelValues(key) // returns the values of the `activations` and `domain` labels
+		if len(labels) != 2 || !metrics.IsKnownDomain(labels[1]) {
+			return false
+		}
+	}
+	return true
+}
+
 // initialize the cached counters from the UserSignup and MasterUserRecord resources.
 // this func lists all UserSignup and MasterUserRecord resources
 func initializeFromResources(cl client.Client, namespace string) error {
 	log.Info("initializing counters from resources")
+	counts, err := countResources(cl, namespace)
+	if err != nil {
+		return err
+	}
+	reset()
+	cachedCounts.Counts = counts
+	cachedCounts.initialized = true
+	log.Info("cached counts initialized from UserSignups and MasterUserRecords",
+		"MasterUserRecordPerDomainCounts", cachedCounts.MasterUserRecordPerDomainCounts,
+		"UserAccountsPerClusterCounts", cachedCounts.UserAccountsPerClusterCounts,
+		"UserSignupsPerActivationAndDomainCounts", cachedCounts.UserSignupsPerActivationAndDomainCounts,
+		"SpacesPerClusterCounts", cachedCounts.SpacesPerClusterCounts,
+	)
+	return nil
+}
+
+// countResources lists all UserSignup, MasterUserRecord and Space resources in the given namespace and counts them
+func countResources(cl client.Client, namespace string) (Counts, error) {
+	counts := Counts{
+		SpacesPerClusterCounts:                  map[string]int{},
+		UserAccountsPerClusterCounts:            map[string]int{},
+		UserSignupsPerActivationAndDomainCounts: map[string]int{},
+		MasterUserRecordPerDomainCounts:         map[string]int{},
+	}
 	usersignups := &toolchainv1alpha1.UserSignupList{}
 	if err := cl.List(context.TODO(), usersignups, client.InNamespace(namespace)); err != nil {
-		return err
+		return counts, err
 	}
 	murs := &toolchainv1alpha1.MasterUserRecordList{}
 	if err := cl.List(context.TODO(), murs, client.InNamespace(namespace)); err != nil {
-		return err
+		return counts, err
 	}
 	spaces := &toolchainv1alpha1.SpaceList{}
 	if err := cl.List(context.TODO(), spaces, client.InNamespace(namespace)); err != nil {
-		return err
+		return counts, err
 	}
-	reset()
 	for _, usersignup := range usersignups.Items {
 		activations, activationsExists := usersignup.Annotations[toolchainv1alpha1.UserSignupActivationCounterAnnotationKey]
 		if activationsExists {
@@ -301,27 +358,20 @@ func initializeFromResources(cl client.Client, namespace string) error {
 				continue
 			}
 			domain := metrics.GetEmailDomain(&usersignup) // nolint:gosec
-			cachedCounts.UserSignupsPerActivationAndDomainCounts[joinLabelValues(activations, string(domain))]++
+			counts.UserSignupsPerActivationAndDomainCounts[joinLabelValues(activations, string(domain))]++
 		}
 	}
 	for _, mur := range murs.Items {
 		domain := metrics.GetEmailDomain(&mur) // nolint:gosec
-		cachedCounts.MasterUserRecordPerDomainCounts[string(domain)]++
+		counts.MasterUserRecordPerDomainCounts[string(domain)]++
 		for _, ua := range mur.Spec.UserAccounts {
-			cachedCounts.UserAccountsPerClusterCounts[ua.TargetCluster]++
+			counts.UserAccountsPerClusterCounts[ua.TargetCluster]++
 		}
 	}
 	for _, space := range spaces.Items {
-		cachedCounts.SpacesPerClusterCounts[space.Spec.TargetCluster]++
+		counts.SpacesPerClusterCounts[space.Spec.TargetCluster]++
 	}
-	cachedCounts.initialized = true
-	log.Info("cached counts initialized from UserSignups and MasterUserRecords",
-		"MasterUserRecordPerDomainCounts", cachedCounts.MasterUserRecordPerDomainCounts,
-		"UserAccountsPerClusterCounts", cachedCounts.UserAccountsPerClusterCounts,
-		"UserSignupsPerActivationAndDomainCounts", cachedCounts.UserSignupsPerActivationAndDomainCounts,
-		"SpacesPerClusterCounts", cachedCounts.SpacesPerClusterCounts,
-	)
-	return nil
+	return counts, nil
 }
 
 // initialize the cached counters from the ToolchainStatud resource.
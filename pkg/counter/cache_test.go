@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
 	"github.com/codeready-toolchain/host-operator/pkg/counter"
 	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	. "github.com/codeready-toolchain/host-operator/test"
@@ -502,6 +503,97 @@ func TestForceInitializeCounterByLoadingExistingResources(t *testing.T) {
 		HasNoMetric("outdated")
 }
 
+func TestRebuildCountersWhenDomainClassesChange(t *testing.T) {
+	// given
+	usersignups := CreateMultipleUserSignups("user-", 3) // all users have an `@redhat.com` email address
+	murs := CreateMultipleMurs(t, "user-", 3, "member-1")
+	toolchainStatus := NewToolchainStatus(
+		WithMember("member-1", WithUserAccountCount(0), WithSpaceCount(0)))
+	toolchainConfig := commonconfig.NewToolchainConfigObjWithReset(t)
+	initObjs := append([]runtime.Object{}, murs...)
+	initObjs = append(initObjs, usersignups...)
+	initObjs = append(initObjs, toolchainConfig)
+	InitializeCounters(t, toolchainStatus, initObjs...)
+	fakeClient := test.NewFakeClient(t, initObjs...)
+
+	t.Run("no change", func(t *testing.T) {
+		// given
+		err := fakeClient.Create(context.TODO(), masteruserrecord.NewMasterUserRecord(t, "ignored", masteruserrecord.TargetCluster("member-1")))
+		require.NoError(t, err)
+
+		// when
+		err = counter.Synchronize(fakeClient, toolchainStatus)
+
+		// then
+		require.NoError(t, err)
+		AssertThatCountersAndMetrics(t).
+			HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+				string(metrics.Internal): 3, // the new MUR is not counted since the counters are not rebuilt
+			})
+	})
+
+	t.Run("new domain classes", func(t *testing.T) {
+		// given
+		toolchainConfig.Annotations = map[string]string{
+			toolchainconfig.EmailDomainClassesAnnotationKey: `[{"name":"redhatter","domains":["redhat.com"]}]`,
+		}
+		err := fakeClient.Update(context.TODO(), toolchainConfig)
+		require.NoError(t, err)
+		commonconfig.ResetCache()
+
+		// when
+		err = counter.Synchronize(fakeClient, toolchainStatus)
+
+		// then
+		require.NoError(t, err)
+		AssertThatCountersAndMetrics(t).
+			HaveUserAccountsForCluster("member-1", 4).
+			HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
+				"1,redhatter": 1,
+				"2,redhatter": 1,
+				"3,redhatter": 1,
+			}).
+			HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+				"redhatter": 4,
+			})
+		AssertThatGivenToolchainStatus(t, toolchainStatus).
+			HasMasterUserRecordsPerDomain(map[string]int{
+				"redhatter": 4,
+			})
+	})
+}
+
+func TestInitializeCounterFromResourcesWhenToolchainStatusHasUnknownDomains(t *testing.T) {
+	// given
+	murs := CreateMultipleMurs(t, "user-", 3, "member-1")
+	// the metrics were computed with other domain classes
+	toolchainStatus := NewToolchainStatus(
+		WithMember("member-1", WithUserAccountCount(0), WithSpaceCount(0)),
+		WithMetric(toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey, toolchainv1alpha1.Metric{
+			"partner":                1,
+			string(metrics.External): 1,
+		}),
+		WithMetric(toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey, toolchainv1alpha1.Metric{
+			"1,partner":  1,
+			"1,external": 1,
+		}),
+	)
+
+	// when
+	InitializeCounters(t, toolchainStatus, murs...)
+
+	// then
+	AssertThatCountersAndMetrics(t).
+		HaveUserAccountsForCluster("member-1", 3).
+		HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+			string(metrics.Internal): 3, // all MURs have `@redhat.com` email address
+		})
+	AssertThatGivenToolchainStatus(t, toolchainStatus).
+		HasMasterUserRecordsPerDomain(map[string]int{
+			string(metrics.Internal): 3,
+		})
+}
+
 func TestShouldNotInitializeAgain(t *testing.T) {
 	// given
 	//this will be ignored by resetting when loading existing MURs
@@ -0,0 +1,225 @@
+package counter
+
+import (
+	"context"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+
+	"github.com/pkg/errors"
+	toolscache "k8s.io/client-go/tools/cache"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// names of the counters, as used in the `counter` label of the drift metrics
+const (
+	MasterUserRecordsCounter = "master_user_records"
+	UserAccountsCounter      = "user_accounts"
+	SpacesCounter            = "spaces"
+	UserSignupsCounter       = "usersignups_per_activation"
+)
+
+// resyncFactor the number of intervals after which the counts are recomputed, even if no resource changed in the meantime
+const resyncFactor = 10
+
+// disabledPollInterval the interval between two checks of the configuration while the recomputation is disabled
+const disabledPollInterval = time.Minute
+
+// Reconciler keeps the cached counts consistent with the MasterUserRecords and Spaces held by the informers of the manager.
+//
+// The counts are recomputed from the cached resources each time one of them changes (at most once per interval configured
+// in the ToolchainConfig, the recomputation being disabled when the interval is `0`), and compared
+// with the counts maintained incrementally by the controllers. The difference is reported in the `counter_drift` metric.
+// A difference which is still the same during the following check is not caused by an update in flight, and the cached count
+// is then replaced with the recomputed value.
+// Nothing is compared until the counter was initialized by the ToolchainStatus controller, so that the counts restored from
+// the ToolchainStatus are not overridden.
+//
+// Note: the number of users per activations and domain also accounts for the deleted UserSignups, which cannot be recomputed
+// from the resources. The number recomputed from the existing UserSignups is thus only a lower bound: the cached count is
+// corrected when it is below this lower bound, but a cached count above it is not reported as a drift.
+type Reconciler struct {
+	// Client the client to list the resources, which is expected to read from the informers of the manager
+	Client client.Client
+	// Informers the informers of the manager, to recompute the counts when the MasterUserRecords, Spaces or UserSignups change.
+	// When not set, the counts are only recomputed periodically
+	Informers runtimecache.Informers
+	Namespace string
+
+	changes   chan struct{}
+	lastDrift map[driftKey]int
+}
+
+type driftKey struct {
+	counter string
+	key     string
+}
+
+// Start recomputes the counts until the given context is done. Errors are logged, and the counts are recomputed again on the next change.
+func (r *Reconciler) Start(ctx context.Context) error {
+	r.changes = make(chan struct{}, 1)
+	if r.Informers != nil {
+		for _, obj := range []client.Object{&toolchainv1alpha1.MasterUserRecord{}, &toolchainv1alpha1.Space{}, &toolchainv1alpha1.UserSignup{}} {
+			informer, err := r.Informers.GetInformer(ctx, obj)
+			if err != nil {
+				return errors.Wrapf(err, "unable to watch the %T resources", obj)
+			}
+			informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+				AddFunc:    func(interface{}) { r.notify() },
+				UpdateFunc: func(interface{}, interface{}) { r.notify() },
+				DeleteFunc: func(interface{}) { r.notify() },
+			})
+		}
+	}
+	for {
+		interval, err := r.interval()
+		if err != nil {
+			log.Error(err, "unable to get the interval of the recomputation of the counts")
+		}
+		if interval <= 0 {
+			// disabled (or unknown) for now, check the configuration again later
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(disabledPollInterval):
+			}
+			continue
+		}
+		if err := r.Recompute(); err != nil {
+			log.Error(err, "unable to recompute the counts")
+		}
+		// wait for the interval, so that all changes occurring in the meantime are accounted for in a single recomputation
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.changes:
+		case <-time.After((resyncFactor - 1) * interval):
+		}
+	}
+}
+
+// interval returns the minimum duration between two recomputations of the counts, as configured in the ToolchainConfig
+func (r *Reconciler) interval() (time.Duration, error) {
+	config, err := toolchainconfig.GetToolchainConfig(r.Client)
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get ToolchainConfig")
+	}
+	return config.Metrics().CounterReconcileInterval(), nil
+}
+
+// notify records that a resource changed, without blocking the informer
+func (r *Reconciler) notify() {
+	select {
+	case r.changes <- struct{}{}:
+	default:
+	}
+}
+
+// Recompute counts the resources, reports the drift of the cached counts and corrects the counts whose drift did not
+// change since the previous call. Does nothing if the cached counts are not initialized yet.
+func (r *Reconciler) Recompute() error {
+	if !initialized() {
+		log.Info("cached counts not initialized yet, skipping the recomputation")
+		return nil
+	}
+	recomputed, err := countResources(r.Client, r.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "unable to count the resources")
+	}
+	cachedCounts.Lock()
+	defer cachedCounts.Unlock()
+
+	if !cachedCounts.initialized {
+		// reset in the meantime
+		r.lastDrift = nil
+		return nil
+	}
+
+	metrics.CounterDriftGaugeVec.Reset()
+	drift := map[driftKey]int{}
+	corrected := false
+	for _, c := range []struct {
+		name       string
+		cached     map[string]int
+		recomputed map[string]int
+		// lowerBound `true` if the recomputed count is only the minimum value of the cached count
+		lowerBound bool
+	}{
+		{name: MasterUserRecordsCounter, cached: cachedCounts.MasterUserRecordPerDomainCounts, recomputed: recomputed.MasterUserRecordPerDomainCounts},
+		{name: UserAccountsCounter, cached: cachedCounts.UserAccountsPerClusterCounts, recomputed: recomputed.UserAccountsPerClusterCounts},
+		{name: SpacesCounter, cached: cachedCounts.SpacesPerClusterCounts, recomputed: recomputed.SpacesPerClusterCounts},
+		{name: UserSignupsCounter, cached: cachedCounts.UserSignupsPerActivationAndDomainCounts, recomputed: recomputed.UserSignupsPerActivationAndDomainCounts, lowerBound: true},
+	} {
+		for _, key := range keys(c.cached, c.recomputed) {
+			d := c.cached[key] - c.recomputed[key]
+			if c.lowerBound && d > 0 {
+				// accounts for the deleted UserSignups
+				d = 0
+			}
+			metrics.CounterDriftGaugeVec.WithLabelValues(c.name, key).Set(float64(d))
+			if d == 0 {
+				continue
+			}
+			k := driftKey{counter: c.name, key: key}
+			if r.lastDrift[k] == d {
+				log.Info("correcting drifted count", "counter", c.name, "key", key, "cached", c.cached[key], "recomputed", c.recomputed[key])
+				c.cached[key] = c.recomputed[key]
+				metrics.CounterDriftCorrectedCounterVec.WithLabelValues(c.name).Inc()
+				corrected = true
+				continue
+			}
+			log.Info("detected drifted count", "counter", c.name, "key", key, "cached", c.cached[key], "recomputed", c.recomputed[key])
+			drift[k] = d
+		}
+	}
+	r.lastDrift = drift
+	if corrected {
+		setGauges()
+	}
+	return nil
+}
+
+// keys returns the keys of both given maps
+func keys(m1, m2 map[string]int) []string {
+	var result []string
+	for k := range m1 {
+		result = append(result, k)
+	}
+	for k := range m2 {
+		if _, found := m1[k]; !found {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+// setGauges sets the gauges with the values of the cached counts
+func setGauges() {
+	for domain, count := range cachedCounts.MasterUserRecordPerDomainCounts {
+		metrics.MasterUserRecordGaugeVec.WithLabelValues(domain).Set(float64(count))
+	}
+	for cluster, count := range cachedCounts.UserAccountsPerClusterCounts {
+		metrics.UserAccountGaugeVec.WithLabelValues(cluster).Set(float64(count))
+	}
+	for cluster, count := range cachedCounts.SpacesPerClusterCounts {
+		metrics.SpaceGaugeVec.WithLabelValues(cluster).Set(float64(count))
+	}
+	for key, count := range cachedCounts.UserSignupsPerActivationAndDomainCounts {
+		metrics.UserSignupsPerActivationAndDomainGaugeVec.WithLabelValues(splitLabelValues(key)...).Set(float64(count))
+	}
+}
+
+// initialized returns `true` if the cached counts were initialized
+func initialized() bool {
+	cachedCounts.RLock()
+	defer cachedCounts.RUnlock()
+	return cachedCounts.initialized
+}
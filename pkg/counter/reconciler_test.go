@@ -0,0 +1,289 @@
+package counter_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/counter"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
+	. "github.com/codeready-toolchain/host-operator/test"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRecompute(t *testing.T) {
+
+	newReconciler := func(t *testing.T) *counter.Reconciler {
+		initObjs := append([]runtime.Object{}, CreateMultipleMurs(t, "user-", 3, "member-1")...)
+		initObjs = append(initObjs, CreateMultipleUserSignups("user-", 3)...)
+		initObjs = append(initObjs, CreateMultipleSpaces("user-", 3, "member-1")...)
+		return &counter.Reconciler{
+			Client:    test.NewFakeClient(t, initObjs...),
+			Namespace: test.HostOperatorNs,
+		}
+	}
+
+	// initializes the counters as the ToolchainStatus controller does, with the given number of MasterUserRecords
+	initializeCounters := func(t *testing.T, murs int) {
+		InitializeCounters(t, NewToolchainStatus(), CreateMultipleMurs(t, "user-", murs, "member-1")...)
+		for i := 0; i < 3; i++ {
+			counter.IncrementSpaceCount(logger, "member-1")
+		}
+	}
+
+	t.Run("does not initialize the counters", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		counter.Reset()
+		t.Cleanup(counter.Reset)
+		r := newReconciler(t)
+
+		// when
+		err := r.Recompute()
+
+		// then
+		require.NoError(t, err)
+		AssertThatUninitializedCounters(t)
+		AssertMetricsGaugeEquals(t, 0, metrics.MasterUserRecordGaugeVec.WithLabelValues(string(metrics.Internal)))
+
+		t.Run("counts restored from the ToolchainStatus are kept", func(t *testing.T) {
+			// given
+			InitializeCounters(t, NewToolchainStatus(
+				WithMetric(toolchainv1alpha1.UserSignupsPerActivationAndDomainMetricKey, toolchainv1alpha1.Metric{
+					"1,internal": 10,
+				}),
+				WithMetric(toolchainv1alpha1.MasterUserRecordsPerDomainMetricKey, toolchainv1alpha1.Metric{
+					string(metrics.Internal): 3,
+				})))
+
+			// when
+			err := r.Recompute()
+
+			// then
+			require.NoError(t, err)
+			AssertThatCountersAndMetrics(t).
+				HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
+					"1,internal": 10,
+				}).
+				HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+					string(metrics.Internal): 3,
+				})
+		})
+	})
+
+	t.Run("no drift", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		r := newReconciler(t)
+		initializeCounters(t, 3)
+
+		// when
+		err := r.Recompute()
+
+		// then
+		require.NoError(t, err)
+		AssertMetricsGaugeEquals(t, 0, metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal)))
+		AssertMetricsGaugeEquals(t, 0, metrics.CounterDriftGaugeVec.WithLabelValues(counter.SpacesCounter, "member-1"))
+		AssertMetricsCounterEquals(t, 0, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.MasterUserRecordsCounter))
+	})
+
+	t.Run("users per activations and domain only corrected when below the existing UserSignups", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		r := newReconciler(t)
+		initializeCounters(t, 3)
+		counter.UpdateUsersPerActivationCounters(logger, 1, metrics.Internal)
+		// a deleted UserSignup is still accounted for
+		counter.UpdateUsersPerActivationCounters(logger, 1, metrics.External)
+		// but the users with 2 and 3 activations were not counted, eg: because the operator crashed before incrementing the count
+
+		// when
+		err := r.Recompute()
+
+		// then
+		require.NoError(t, err)
+		AssertMetricsGaugeEquals(t, 0, metrics.CounterDriftGaugeVec.WithLabelValues(counter.UserSignupsCounter, "1,external"))
+		AssertMetricsGaugeEquals(t, -1, metrics.CounterDriftGaugeVec.WithLabelValues(counter.UserSignupsCounter, "2,internal"))
+		AssertMetricsCounterEquals(t, 0, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.UserSignupsCounter))
+
+		t.Run("corrected when still the same on the next check", func(t *testing.T) {
+			// when
+			err := r.Recompute()
+
+			// then
+			require.NoError(t, err)
+			AssertMetricsCounterEquals(t, 2, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.UserSignupsCounter))
+			AssertThatCountersAndMetrics(t).
+				HaveUsersPerActivationsAndDomain(toolchainv1alpha1.Metric{
+					"1,internal": 1,
+					"1,external": 1,
+					"2,internal": 1,
+					"3,internal": 1,
+				})
+		})
+	})
+
+	t.Run("drift", func(t *testing.T) {
+
+		prepare := func(t *testing.T) *counter.Reconciler {
+			metrics.Reset()
+			r := newReconciler(t)
+			initializeCounters(t, 3)
+			// a decrement which was lost, eg: because the operator crashed while deleting a MUR
+			counter.IncrementMasterUserRecordCount(logger, metrics.Internal)
+			counter.IncrementUserAccountCount(logger, "member-1")
+			return r
+		}
+
+		t.Run("reported but not corrected on the first check", func(t *testing.T) {
+			// given
+			r := prepare(t)
+
+			// when
+			err := r.Recompute()
+
+			// then
+			require.NoError(t, err)
+			AssertMetricsGaugeEquals(t, 1, metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal)))
+			AssertMetricsGaugeEquals(t, 1, metrics.CounterDriftGaugeVec.WithLabelValues(counter.UserAccountsCounter, "member-1"))
+			AssertMetricsGaugeEquals(t, 0, metrics.CounterDriftGaugeVec.WithLabelValues(counter.SpacesCounter, "member-1"))
+			AssertMetricsCounterEquals(t, 0, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.MasterUserRecordsCounter))
+			AssertThatCountersAndMetrics(t).
+				HaveUserAccountsForCluster("member-1", 4).
+				HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+					string(metrics.Internal): 4,
+				})
+
+			t.Run("corrected when still the same on the next check", func(t *testing.T) {
+				// when
+				err := r.Recompute()
+
+				// then
+				require.NoError(t, err)
+				AssertMetricsGaugeEquals(t, 1, metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal)))
+				AssertMetricsCounterEquals(t, 1, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.MasterUserRecordsCounter))
+				AssertMetricsCounterEquals(t, 1, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.UserAccountsCounter))
+				AssertThatCountersAndMetrics(t).
+					HaveUserAccountsForCluster("member-1", 3).
+					HaveSpacesForCluster("member-1", 3).
+					HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+						string(metrics.Internal): 3,
+					})
+
+				t.Run("no drift after the correction", func(t *testing.T) {
+					// when
+					err := r.Recompute()
+
+					// then
+					require.NoError(t, err)
+					AssertMetricsGaugeEquals(t, 0, metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal)))
+					AssertMetricsCounterEquals(t, 1, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.MasterUserRecordsCounter))
+				})
+			})
+		})
+
+		t.Run("not corrected when changed since the previous check", func(t *testing.T) {
+			// given
+			r := prepare(t)
+			require.NoError(t, r.Recompute())
+			// an increment in flight, the MUR is not in the informer cache yet
+			counter.IncrementMasterUserRecordCount(logger, metrics.Internal)
+
+			// when
+			err := r.Recompute()
+
+			// then
+			require.NoError(t, err)
+			AssertMetricsGaugeEquals(t, 2, metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal)))
+			AssertMetricsCounterEquals(t, 0, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.MasterUserRecordsCounter))
+			// but the UserAccounts count did not change in the meantime
+			AssertMetricsCounterEquals(t, 1, metrics.CounterDriftCorrectedCounterVec.WithLabelValues(counter.UserAccountsCounter))
+			AssertThatCountersAndMetrics(t).
+				HaveUserAccountsForCluster("member-1", 3).
+				HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+					string(metrics.Internal): 5,
+				})
+		})
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		r := newReconciler(t)
+		initializeCounters(t, 4)
+		r.Client.(*test.FakeClient).MockList = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		err := r.Recompute()
+
+		// then
+		require.EqualError(t, err, "unable to count the resources: mock error")
+		AssertThatCountersAndMetrics(t).
+			HaveMasterUserRecordsPerDomain(toolchainv1alpha1.Metric{
+				string(metrics.Internal): 4,
+			})
+	})
+}
+
+func TestStartReconciler(t *testing.T) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+
+	t.Run("recomputes the counts", func(t *testing.T) {
+		// given
+		metrics.Reset()
+		InitializeCounters(t, NewToolchainStatus(), CreateMultipleMurs(t, "user-", 2, "member-1")...)
+		counter.IncrementMasterUserRecordCount(logger, metrics.Internal)
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.CounterReconcileIntervalAnnotationKey, "1h"))
+		r := &counter.Reconciler{
+			Client:    test.NewFakeClient(t, append(CreateMultipleMurs(t, "user-", 2, "member-1"), config)...),
+			Namespace: test.HostOperatorNs,
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+
+		// when
+		go func() {
+			done <- r.Start(ctx)
+		}()
+
+		// then
+		require.Eventually(t, func() bool {
+			return promtestutil.ToFloat64(metrics.CounterDriftGaugeVec.WithLabelValues(counter.MasterUserRecordsCounter, string(metrics.Internal))) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		// given
+		counter.Reset()
+		t.Cleanup(counter.Reset)
+		config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.CounterReconcileIntervalAnnotationKey, "0"))
+		r := &counter.Reconciler{
+			Client:    test.NewFakeClient(t, append(CreateMultipleMurs(t, "user-", 2, "member-1"), config)...),
+			Namespace: test.HostOperatorNs,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := r.Start(ctx)
+
+		// then
+		require.NoError(t, err)
+		_, err = counter.GetCounts()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,156 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/toolchain-common/pkg/condition"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("events")
+
+// ReconcileFailedReason the reason of the warning events recorded for a failure which did not set any status condition
+const ReconcileFailedReason = "ReconcileFailed"
+
+// maxEntries the number of recorded events above which the entries older than the interval are purged
+const maxEntries = 1000
+
+// Recorder records the Kubernetes Events of a controller.
+//
+// The same event (ie, with the same object, type, reason and message) is recorded at most once per interval configured
+// in the ToolchainConfig, so that a reconcile loop which keeps failing or requeuing does not flood the event stream.
+// As a convenience for the tests, a nil Recorder does not record anything.
+type Recorder struct {
+	recorder record.EventRecorder
+	client   client.Client
+
+	mu       sync.Mutex
+	recorded map[eventKey]time.Time
+}
+
+type eventKey struct {
+	object    types.UID
+	name      types.NamespacedName
+	eventType string
+	reason    string
+	message   string
+}
+
+// NewRecorder returns a new Recorder which records the events with the given recorder, at most once per interval
+// for the same event, as configured in the ToolchainConfig retrieved with the given client (all events are recorded if the client is nil)
+func NewRecorder(recorder record.EventRecorder, cl client.Client) *Recorder {
+	return &Recorder{
+		recorder: recorder,
+		client:   cl,
+		recorded: map[eventKey]time.Time{},
+	}
+}
+
+// Normal records an event of type `Normal` for the given object
+func (r *Recorder) Normal(obj client.Object, reason, messageFmt string, args ...interface{}) {
+	r.record(obj, corev1.EventTypeNormal, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// Warning records an event of type `Warning` for the given object
+func (r *Recorder) Warning(obj client.Object, reason, messageFmt string, args ...interface{}) {
+	r.record(obj, corev1.EventTypeWarning, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// Failure records an event of type `Warning` for the given object, with the reason of the condition which was set with
+// the given failure message, or with the `ReconcileFailed` reason if none of the conditions has this message
+func (r *Recorder) Failure(obj client.Object, conditions []toolchainv1alpha1.Condition, message string) {
+	reason := ReconcileFailedReason
+	for _, c := range conditions {
+		if c.Message == message && c.Reason != "" {
+			reason = c.Reason
+			break
+		}
+	}
+	r.record(obj, corev1.EventTypeWarning, reason, message)
+}
+
+// ReasonTypes the type of the event (`Normal` or `Warning`) to record when a condition is set with a given reason
+type ReasonTypes map[string]string
+
+// Transitions records an event for each of the given conditions which changes the status or the reason of the condition of the same type
+// in the existing conditions, if the type of the event is defined for its reason. At most one event is recorded per reason.
+// It must be called before the existing conditions are updated.
+func (r *Recorder) Transitions(obj client.Object, existing []toolchainv1alpha1.Condition, reasons ReasonTypes, conditions ...toolchainv1alpha1.Condition) {
+	recorded := map[string]bool{}
+	for _, c := range conditions {
+		eventType, found := reasons[c.Reason]
+		if !found || recorded[c.Reason] {
+			continue
+		}
+		if previous, found := condition.FindConditionByType(existing, c.Type); found && previous.Status == c.Status && previous.Reason == c.Reason {
+			continue
+		}
+		message := c.Message
+		if message == "" {
+			message = fmt.Sprintf("the %s condition is %s", c.Type, c.Status)
+		}
+		r.record(obj, eventType, c.Reason, message)
+		recorded[c.Reason] = true
+	}
+}
+
+func (r *Recorder) record(obj client.Object, eventType, reason, message string) {
+	if r == nil {
+		return
+	}
+	if !r.allow(eventKey{
+		object:    obj.GetUID(),
+		name:      types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()},
+		eventType: eventType,
+		reason:    reason,
+		message:   message,
+	}) {
+		return
+	}
+	r.recorder.Event(obj, eventType, reason, message)
+}
+
+// allow returns `true` if the event with the given key was not recorded during the interval, and then marks it as recorded
+func (r *Recorder) allow(key eventKey) bool {
+	interval := r.interval()
+	if interval <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if last, found := r.recorded[key]; found && now.Sub(last) < interval {
+		return false
+	}
+	if len(r.recorded) >= maxEntries {
+		for k, last := range r.recorded {
+			if now.Sub(last) >= interval {
+				delete(r.recorded, k)
+			}
+		}
+	}
+	r.recorded[key] = now
+	return true
+}
+
+// interval returns the interval during which the same event is recorded only once, or `0` if it cannot be determined
+func (r *Recorder) interval() time.Duration {
+	if r.client == nil {
+		return 0
+	}
+	config, err := toolchainconfig.GetToolchainConfig(r.client)
+	if err != nil {
+		log.Error(err, "unable to get ToolchainConfig, recording the event without rate limiting")
+		return 0
+	}
+	return config.Events().RateLimitInterval()
+}
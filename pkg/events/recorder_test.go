@@ -0,0 +1,266 @@
+package events_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/events"
+	. "github.com/codeready-toolchain/host-operator/test"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestNormalAndWarning(t *testing.T) {
+	// given
+	fakeRecorder := record.NewFakeRecorder(10)
+	recorder := events.NewRecorder(fakeRecorder, nil)
+	userSignup := newUserSignup()
+
+	// when
+	recorder.Normal(userSignup, "Approved", "the user was approved by %s", "an admin")
+	recorder.Warning(userSignup, "Banned", "the user is banned")
+
+	// then
+	assertEvents(t, fakeRecorder,
+		"Normal Approved the user was approved by an admin",
+		"Warning Banned the user is banned")
+}
+
+func TestFailure(t *testing.T) {
+
+	t.Run("with the reason of the condition", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+		conditions := []toolchainv1alpha1.Condition{
+			{
+				Type:    toolchainv1alpha1.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  toolchainv1alpha1.UserSignupNoClusterAvailableReason,
+				Message: "no suitable member cluster found",
+			},
+		}
+
+		// when
+		recorder.Failure(newUserSignup(), conditions, "no suitable member cluster found")
+
+		// then
+		assertEvents(t, fakeRecorder, "Warning NoClusterAvailable no suitable member cluster found")
+	})
+
+	t.Run("without matching condition", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		recorder.Failure(newUserSignup(), nil, "mock error")
+
+		// then
+		assertEvents(t, fakeRecorder, "Warning ReconcileFailed mock error")
+	})
+}
+
+func TestTransitions(t *testing.T) {
+	reasons := events.ReasonTypes{
+		toolchainv1alpha1.UserSignupApprovedByAdminReason:    corev1.EventTypeNormal,
+		toolchainv1alpha1.UserSignupUserBannedReason:         corev1.EventTypeWarning,
+		toolchainv1alpha1.UserSignupNoClusterAvailableReason: corev1.EventTypeWarning,
+	}
+	approved := toolchainv1alpha1.Condition{
+		Type:   toolchainv1alpha1.UserSignupApproved,
+		Status: corev1.ConditionTrue,
+		Reason: toolchainv1alpha1.UserSignupApprovedByAdminReason,
+	}
+
+	t.Run("new condition", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		recorder.Transitions(newUserSignup(), nil, reasons, approved)
+
+		// then
+		assertEvents(t, fakeRecorder, "Normal ApprovedByAdmin the Approved condition is True")
+	})
+
+	t.Run("unchanged condition", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		recorder.Transitions(newUserSignup(), []toolchainv1alpha1.Condition{approved}, reasons, approved)
+
+		// then
+		assertEvents(t, fakeRecorder)
+	})
+
+	t.Run("changed reason", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+		existing := []toolchainv1alpha1.Condition{
+			{
+				Type:   toolchainv1alpha1.UserSignupComplete,
+				Status: corev1.ConditionTrue,
+			},
+		}
+
+		// when
+		recorder.Transitions(newUserSignup(), existing, reasons, toolchainv1alpha1.Condition{
+			Type:    toolchainv1alpha1.UserSignupComplete,
+			Status:  corev1.ConditionTrue,
+			Reason:  toolchainv1alpha1.UserSignupUserBannedReason,
+			Message: "the user is banned",
+		})
+
+		// then
+		assertEvents(t, fakeRecorder, "Warning Banned the user is banned")
+	})
+
+	t.Run("once per reason and only for known reasons", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, nil)
+
+		// when
+		recorder.Transitions(newUserSignup(), nil, reasons,
+			toolchainv1alpha1.Condition{
+				Type:    toolchainv1alpha1.ConditionReady,
+				Status:  corev1.ConditionFalse,
+				Reason:  toolchainv1alpha1.UserSignupNoClusterAvailableReason,
+				Message: "no suitable member cluster found",
+			},
+			toolchainv1alpha1.Condition{
+				Type:    toolchainv1alpha1.UserSignupComplete,
+				Status:  corev1.ConditionFalse,
+				Reason:  toolchainv1alpha1.UserSignupNoClusterAvailableReason,
+				Message: "no suitable member cluster found",
+			},
+			toolchainv1alpha1.Condition{
+				Type:   toolchainv1alpha1.UserSignupUserDeactivatingNotificationCreated,
+				Status: corev1.ConditionTrue,
+				Reason: toolchainv1alpha1.UserSignupDeactivatingNotificationCRCreatedReason,
+			})
+
+		// then
+		assertEvents(t, fakeRecorder, "Warning NoClusterAvailable no suitable member cluster found")
+	})
+}
+
+func TestRateLimiting(t *testing.T) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+
+	t.Run("same event recorded once per interval", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, newClient(t, "1h"))
+		userSignup := newUserSignup()
+
+		// when
+		for i := 0; i < 3; i++ {
+			recorder.Warning(userSignup, "Banned", "the user is banned")
+		}
+
+		// then
+		assertEvents(t, fakeRecorder, "Warning Banned the user is banned")
+
+		t.Run("other events still recorded", func(t *testing.T) {
+			// when
+			recorder.Warning(userSignup, "Banned", "the user is still banned")
+			recorder.Warning(newUserSignup("other"), "Banned", "the user is banned")
+
+			// then
+			assertEvents(t, fakeRecorder,
+				"Warning Banned the user is still banned",
+				"Warning Banned the user is banned")
+		})
+	})
+
+	t.Run("same event recorded again after the interval", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, newClient(t, "1ms"))
+		userSignup := newUserSignup()
+		recorder.Warning(userSignup, "Banned", "the user is banned")
+
+		// when
+		time.Sleep(5 * time.Millisecond)
+		recorder.Warning(userSignup, "Banned", "the user is banned")
+
+		// then
+		assertEvents(t, fakeRecorder,
+			"Warning Banned the user is banned",
+			"Warning Banned the user is banned")
+	})
+
+	t.Run("all events recorded without interval", func(t *testing.T) {
+		// given
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := events.NewRecorder(fakeRecorder, newClient(t, "0"))
+		userSignup := newUserSignup()
+
+		// when
+		recorder.Warning(userSignup, "Banned", "the user is banned")
+		recorder.Warning(userSignup, "Banned", "the user is banned")
+
+		// then
+		assertEvents(t, fakeRecorder,
+			"Warning Banned the user is banned",
+			"Warning Banned the user is banned")
+	})
+}
+
+func TestNilRecorder(t *testing.T) {
+	// given
+	var recorder *events.Recorder
+
+	// when/then
+	assert.NotPanics(t, func() {
+		recorder.Normal(newUserSignup(), "Approved", "the user was approved")
+		recorder.Failure(newUserSignup(), nil, "mock error")
+		recorder.Transitions(newUserSignup(), nil, events.ReasonTypes{"Approved": corev1.EventTypeNormal},
+			toolchainv1alpha1.Condition{Type: toolchainv1alpha1.UserSignupApproved, Reason: "Approved"})
+	})
+}
+
+func newUserSignup(name ...string) *toolchainv1alpha1.UserSignup {
+	userSignup := &toolchainv1alpha1.UserSignup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "johny",
+			Namespace: "toolchain-host-operator",
+		},
+	}
+	if len(name) > 0 {
+		userSignup.Name = name[0]
+	}
+	return userSignup
+}
+
+func assertEvents(t *testing.T, recorder *record.FakeRecorder, expected ...string) {
+	var actual []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			actual = append(actual, event)
+		default:
+			assert.Equal(t, expected, actual)
+			return
+		}
+	}
+}
+
+func newClient(t *testing.T, interval string) *test.FakeClient {
+	config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.EventRateLimitIntervalAnnotationKey, interval))
+	return test.NewFakeClient(t, config)
+}
@@ -1,7 +1,11 @@
 package metrics
 
 import (
-	"regexp"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 
@@ -13,22 +17,113 @@ type Domain string
 const Internal Domain = "internal"
 const External Domain = "external"
 
-var internalDomainPattern = regexp.MustCompile(`^.*@redhat.com$|^.*@((.+)\.)?ibm.com$`)
+// DomainClass a named class of email address domains. A domain pattern is either a domain name (eg: `redhat.com`)
+// or a wildcard matching all its subdomains (eg: `*.ibm.com`)
+type DomainClass struct {
+	Name    string   `json:"name"`
+	Domains []string `json:"domains"`
+}
+
+// DefaultDomainClasses the domain classes used when none are configured
+var DefaultDomainClasses = []DomainClass{
+	{
+		Name:    string(Internal),
+		Domains: []string{"redhat.com", "ibm.com", "*.ibm.com"},
+	},
+}
+
+var domainClasses = struct {
+	sync.RWMutex
+	classes []DomainClass
+}{
+	classes: DefaultDomainClasses,
+}
+
+// ParseDomainClasses parses the given JSON array of domain classes. The classes are evaluated in order,
+// and email addresses which match none of them belong to the `external` class.
+func ParseDomainClasses(value string) ([]DomainClass, error) {
+	classes := []DomainClass{}
+	if err := json.Unmarshal([]byte(value), &classes); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, class := range classes {
+		switch {
+		case class.Name == "":
+			return nil, fmt.Errorf("the name of a domain class is missing")
+		case class.Name == string(External):
+			return nil, fmt.Errorf("the domain class name '%s' is reserved", External)
+		case strings.Contains(class.Name, ","):
+			return nil, fmt.Errorf("the domain class name '%s' must not contain a comma", class.Name)
+		case names[class.Name]:
+			return nil, fmt.Errorf("the domain class '%s' is defined more than once", class.Name)
+		case len(class.Domains) == 0:
+			return nil, fmt.Errorf("the domain class '%s' has no domain", class.Name)
+		}
+		names[class.Name] = true
+	}
+	return classes, nil
+}
+
+// SetDomainClasses sets the domain classes used to classify the email addresses,
+// and returns `true` if they differ from the ones which were used until now
+func SetDomainClasses(classes []DomainClass) bool {
+	domainClasses.Lock()
+	defer domainClasses.Unlock()
+	if reflect.DeepEqual(domainClasses.classes, classes) {
+		return false
+	}
+	log.Info("setting the email domain classes", "classes", classes)
+	domainClasses.classes = classes
+	return true
+}
+
+// IsKnownDomain returns `true` if the given domain is `external` or the name of one of the current domain classes
+func IsKnownDomain(domain string) bool {
+	if domain == string(External) {
+		return true
+	}
+	domainClasses.RLock()
+	defer domainClasses.RUnlock()
+	for _, class := range domainClasses.classes {
+		if class.Name == domain {
+			return true
+		}
+	}
+	return false
+}
 
 // GetEmailDomain retrieves the email address for the given object
-// returns the associated domain (`Internal` or `External`)
+// returns the name of the first domain class which matches the email address, or `External` if none matches
 // Note: if given email address is empty (ie, it does not exist - which should not happen),
 // then an error is logged and the returned domain is `external`
 func GetEmailDomain(obj RuntimeObject) Domain {
 	emailAddress, exists := obj.GetAnnotations()[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
 	if !exists {
 		log.Error(nil, "no email address found in annotations", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "name", obj.GetName())
-	} else if internalDomainPattern.MatchString(emailAddress) {
-		return Internal
+		return External
+	}
+	domain := strings.ToLower(emailAddress[strings.LastIndex(emailAddress, "@")+1:])
+	domainClasses.RLock()
+	defer domainClasses.RUnlock()
+	for _, class := range domainClasses.classes {
+		for _, pattern := range class.Domains {
+			if matchesDomain(domain, strings.ToLower(pattern)) {
+				return Domain(class.Name)
+			}
+		}
 	}
 	return External
 }
 
+// matchesDomain returns `true` if the domain is the same as the pattern, or if it is a subdomain of a `*.` pattern
+func matchesDomain(domain, pattern string) bool {
+	if strings.HasPrefix(pattern, "*.") {
+		return strings.HasSuffix(domain, pattern[1:])
+	}
+	return domain == pattern
+}
+
 type RuntimeObject interface {
 	GetAnnotations() map[string]string
 	GetName() string
@@ -8,6 +8,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetEmailDomain(t *testing.T) {
@@ -103,3 +104,82 @@ func TestGetEmailDomain(t *testing.T) {
 		})
 	}
 }
+
+func TestGetEmailDomainWithCustomClasses(t *testing.T) {
+	// given
+	metrics.SetDomainClasses([]metrics.DomainClass{
+		{Name: "internal", Domains: []string{"redhat.com"}},
+		{Name: "partner", Domains: []string{"partner.com", "*.partner.com"}},
+	})
+	defer metrics.SetDomainClasses(metrics.DefaultDomainClasses)
+
+	for email, expectedDomain := range map[string]metrics.Domain{
+		"joe@redhat.com":        metrics.Internal,
+		"joe@RedHat.com":        metrics.Internal,
+		"joe@partner.com":       "partner",
+		"joe@eu.partner.com":    "partner",
+		"joe@otherpartner.com":  metrics.External,
+		"joe@ibm.com":           metrics.External,
+		"joe@partner.com.evil":  metrics.External,
+		"joe@sub.redhat.com.io": metrics.External,
+	} {
+		t.Run(email, func(t *testing.T) {
+			// when
+			domain := metrics.GetEmailDomain(&toolchainv1alpha1.UserSignup{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "joe",
+					Annotations: map[string]string{
+						toolchainv1alpha1.UserSignupUserEmailAnnotationKey: email,
+					},
+				},
+			})
+
+			// then
+			assert.Equal(t, expectedDomain, domain)
+		})
+	}
+}
+
+func TestSetDomainClasses(t *testing.T) {
+	defer metrics.SetDomainClasses(metrics.DefaultDomainClasses)
+	partner := []metrics.DomainClass{{Name: "partner", Domains: []string{"partner.com"}}}
+
+	assert.False(t, metrics.SetDomainClasses(metrics.DefaultDomainClasses))
+	assert.True(t, metrics.SetDomainClasses(partner))
+	assert.False(t, metrics.SetDomainClasses(partner))
+	assert.True(t, metrics.IsKnownDomain("partner"))
+	assert.True(t, metrics.IsKnownDomain(string(metrics.External)))
+	assert.False(t, metrics.IsKnownDomain(string(metrics.Internal)))
+}
+
+func TestParseDomainClasses(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		// when
+		classes, err := metrics.ParseDomainClasses(`[{"name":"internal","domains":["redhat.com"]},{"name":"partner","domains":["*.partner.com"]}]`)
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, []metrics.DomainClass{
+			{Name: "internal", Domains: []string{"redhat.com"}},
+			{Name: "partner", Domains: []string{"*.partner.com"}},
+		}, classes)
+	})
+
+	for value, msg := range map[string]string{
+		`{"name":"internal"}`:                            "cannot unmarshal",
+		`[{"domains":["redhat.com"]}]`:                   "the name of a domain class is missing",
+		`[{"name":"external","domains":["redhat.com"]}]`: "the domain class name 'external' is reserved",
+		`[{"name":"a,b","domains":["redhat.com"]}]`:      "the domain class name 'a,b' must not contain a comma",
+		`[{"name":"internal","domains":["redhat.com"]},{"name":"internal","domains":["ibm.com"]}]`: "the domain class 'internal' is defined more than once",
+		`[{"name":"internal","domains":[]}]`: "the domain class 'internal' has no domain",
+	} {
+		t.Run(msg, func(t *testing.T) {
+			// when
+			_, err := metrics.ParseDomainClasses(value)
+
+			// then
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), msg)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
 	"github.com/prometheus/client_golang/prometheus"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	k8smetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -10,26 +12,35 @@ var log = logf.Log.WithName("toolchain_metrics")
 
 // counters
 var (
-	// UserSignupUniqueTotal is incremented only the first time a user signup is created, there is 1 for each unique user
-	UserSignupUniqueTotal prometheus.Counter
-
-	// UserSignupApprovedTotal is incremented each time a user signup is approved, can be multiple times per user if they reactivate multiple times
-	UserSignupApprovedTotal prometheus.Counter
-
-	// UserSignupBannedTotal is incremented each time a user signup is banned
-	UserSignupBannedTotal prometheus.Counter
-
-	// UserSignupDeactivatedTotal is incremented each time a user signup is deactivated, can be multiple times per user if they reactivate multiple times
-	UserSignupDeactivatedTotal prometheus.Counter
-
-	// UserSignupAutoDeactivatedTotal is incremented each time a user signup is automatically deactivated, can be multiple times per user if they reactivate multiple times
-	UserSignupAutoDeactivatedTotal prometheus.Counter
-
-	// UserSignupDeletedWithInitiatingVerificationTotal is incremented each time a user signup is deleted due to verification time trial expired, and verification was initiated
-	UserSignupDeletedWithInitiatingVerificationTotal prometheus.Counter
+	// TierTemplatesDeletedTotal is incremented each time an unreferenced TierTemplate is deleted
+	TierTemplatesDeletedTotal prometheus.Counter
+)
 
-	// UserSignupDeletedWithoutInitiatingVerificationTotal is incremented each time a user signup is deleted due to verification time trial expired, and verification was NOT initiated
-	UserSignupDeletedWithoutInitiatingVerificationTotal prometheus.Counter
+// counters with labels
+var (
+	// UserSignupUniqueCounterVec is incremented only the first time a user signup is created, there is 1 for each unique user, with a label to partition per email address domain class
+	UserSignupUniqueCounterVec *prometheus.CounterVec
+	// UserSignupApprovedCounterVec is incremented each time a user signup is approved, can be multiple times per user if they reactivate multiple times, with a label to partition per email address domain class
+	UserSignupApprovedCounterVec *prometheus.CounterVec
+	// UserSignupBannedCounterVec is incremented each time a user signup is banned, with a label to partition per email address domain class
+	UserSignupBannedCounterVec *prometheus.CounterVec
+	// UserSignupDeactivatedCounterVec is incremented each time a user signup is deactivated, can be multiple times per user if they reactivate multiple times, with a label to partition per email address domain class
+	UserSignupDeactivatedCounterVec *prometheus.CounterVec
+	// UserSignupAutoDeactivatedCounterVec is incremented each time a user signup is automatically deactivated, can be multiple times per user if they reactivate multiple times, with a label to partition per email address domain class
+	UserSignupAutoDeactivatedCounterVec *prometheus.CounterVec
+	// UserSignupDeletedWithInitiatingVerificationCounterVec is incremented each time a user signup is deleted due to verification time trial expired, and verification was initiated, with a label to partition per email address domain class
+	UserSignupDeletedWithInitiatingVerificationCounterVec *prometheus.CounterVec
+	// UserSignupDeletedWithoutInitiatingVerificationCounterVec is incremented each time a user signup is deleted due to verification time trial expired, and verification was NOT initiated, with a label to partition per email address domain class
+	UserSignupDeletedWithoutInitiatingVerificationCounterVec *prometheus.CounterVec
+	// UserSignupHeldForReviewCounterVec is incremented each time a user signup is held for a manual approval because of its risk score, with a label to partition per email address domain class
+	UserSignupHeldForReviewCounterVec *prometheus.CounterVec
+	// UserSignupDeactivationExtendedCounterVec is incremented each time the deactivation of a user signup is extended, with a label to partition per UserTier
+	UserSignupDeactivationExtendedCounterVec *prometheus.CounterVec
+	// CounterDriftCorrectedCounterVec is incremented each time a cached count is replaced with the value recomputed from the resources, with a label to partition per counter
+	CounterDriftCorrectedCounterVec *prometheus.CounterVec
+	// ControllerFailuresCounterVec is incremented each time a controller sets a failure reason in the status conditions of a resource,
+	// with labels to partition per controller and reason
+	ControllerFailuresCounterVec *prometheus.CounterVec
 )
 
 // gauge with labels
@@ -40,15 +51,45 @@ var (
 	UserAccountGaugeVec *prometheus.GaugeVec
 	// UserSignupsPerActivationAndDomainGaugeVec reflects the number of users labelled with on their current number of activations and email address domain
 	UserSignupsPerActivationAndDomainGaugeVec *prometheus.GaugeVec
-	// MasterUserRecordGaugeVec reflects the current number of MasterUserRecords, labelled with their email address domain (`internal` vs `external`)
+	// MasterUserRecordGaugeVec reflects the current number of MasterUserRecords, labelled with the class of their email address domain (eg: `internal` vs `external`)
 	MasterUserRecordGaugeVec *prometheus.GaugeVec
+	// TierTemplatesUnreferencedGaugeVec reflects the number of unreferenced TierTemplates which can be deleted (or were deleted, unless in dry-run mode) during the last check, per tier
+	TierTemplatesUnreferencedGaugeVec *prometheus.GaugeVec
+	// CounterDriftGaugeVec reflects the difference between the incrementally maintained counts and the counts recomputed from the resources
+	// during the last check, labelled with the counter and the key in this counter (eg: the member cluster or the email address domain class)
+	CounterDriftGaugeVec *prometheus.GaugeVec
+	// PendingObjectsGaugeVec reflects the number of resources pending approval or provisioning, as loaded during the last lookup of the oldest one, with a label to partition per kind
+	PendingObjectsGaugeVec *prometheus.GaugeVec
+	// OldestPendingObjectAgeGaugeVec reflects the age (in seconds) of the oldest resource pending approval or provisioning, with a label to partition per kind
+	OldestPendingObjectAgeGaugeVec *prometheus.GaugeVec
+)
+
+// histograms
+var (
+	// UserSignupRiskScoreHistogram observes the risk score of the user signups, each time it is computed with a different value
+	UserSignupRiskScoreHistogram prometheus.Histogram
+	// UserSignupApprovalDurationHistogram observes the duration (in seconds) between the creation of the user signups and their first approval
+	UserSignupApprovalDurationHistogram prometheus.Histogram
+	// MasterUserRecordProvisioningDurationHistogram observes the duration (in seconds) between the creation of the master user records,
+	// ie, the approval of the user signups, and their first provisioning
+	MasterUserRecordProvisioningDurationHistogram prometheus.Histogram
+	// SpaceProvisioningDurationHistogram observes the duration (in seconds) between the creation of the spaces and their first provisioning
+	SpaceProvisioningDurationHistogram prometheus.Histogram
+	// NSTemplateSetUpdateDurationHistogram observes the duration (in seconds) of the updates of the NSTemplateSets of the spaces
+	NSTemplateSetUpdateDurationHistogram prometheus.Histogram
+	// SpaceRetargetDurationHistogram observes the duration (in seconds) of the removal of the spaces from their previous member cluster when they are retargeted
+	SpaceRetargetDurationHistogram prometheus.Histogram
+	// NotificationDeliveryDurationHistogram observes the duration (in seconds) between the creation of the notifications and their delivery
+	NotificationDeliveryDurationHistogram prometheus.Histogram
 )
 
 // collections
 var (
-	allCounters  = []prometheus.Counter{}
-	allGauges    = []prometheus.Gauge{}
-	allGaugeVecs = []*prometheus.GaugeVec{}
+	allCounters    = []prometheus.Counter{}
+	allCounterVecs = []*prometheus.CounterVec{}
+	allGauges      = []prometheus.Gauge{}
+	allGaugeVecs   = []*prometheus.GaugeVec{}
+	allHistograms  = []prometheus.Histogram{}
 )
 
 func init() {
@@ -60,18 +101,36 @@ const metricsPrefix = "sandbox_"
 func initMetrics() {
 	log.Info("initializing custom metrics")
 	// Counters
-	UserSignupUniqueTotal = newCounter("user_signups_total", "Total number of unique UserSignups")
-	UserSignupApprovedTotal = newCounter("user_signups_approved_total", "Total number of approved UserSignups")
-	UserSignupBannedTotal = newCounter("user_signups_banned_total", "Total number of banned UserSignups")
-	UserSignupDeactivatedTotal = newCounter("user_signups_deactivated_total", "Total number of deactivated UserSignups")
-	UserSignupAutoDeactivatedTotal = newCounter("user_signups_auto_deactivated_total", "Total number of automatically deactivated UserSignups")
-	UserSignupDeletedWithInitiatingVerificationTotal = newCounter("user_signups_deleted_with_initiating_verification_total", "Total number of UserSignups deleted after verification time trial and with verification initiated")
-	UserSignupDeletedWithoutInitiatingVerificationTotal = newCounter("user_signups_deleted_without_initiating_verification_total", "Total number of deleted UserSignups after verification time trial but without verification initiated")
+	TierTemplatesDeletedTotal = newCounter("tier_templates_deleted_total", "Total number of deleted unreferenced TierTemplates")
+	// Counters with labels
+	UserSignupUniqueCounterVec = newCounterVec("user_signups_total", "Total number of unique UserSignups (per email address domain class)", "domain")
+	UserSignupApprovedCounterVec = newCounterVec("user_signups_approved_total", "Total number of approved UserSignups (per email address domain class)", "domain")
+	UserSignupBannedCounterVec = newCounterVec("user_signups_banned_total", "Total number of banned UserSignups (per email address domain class)", "domain")
+	UserSignupDeactivatedCounterVec = newCounterVec("user_signups_deactivated_total", "Total number of deactivated UserSignups (per email address domain class)", "domain")
+	UserSignupAutoDeactivatedCounterVec = newCounterVec("user_signups_auto_deactivated_total", "Total number of automatically deactivated UserSignups (per email address domain class)", "domain")
+	UserSignupDeletedWithInitiatingVerificationCounterVec = newCounterVec("user_signups_deleted_with_initiating_verification_total", "Total number of UserSignups deleted after verification time trial and with verification initiated (per email address domain class)", "domain")
+	UserSignupDeletedWithoutInitiatingVerificationCounterVec = newCounterVec("user_signups_deleted_without_initiating_verification_total", "Total number of deleted UserSignups after verification time trial but without verification initiated (per email address domain class)", "domain")
+	UserSignupHeldForReviewCounterVec = newCounterVec("user_signups_held_for_review_total", "Total number of UserSignups held for a manual approval because of their risk score (per email address domain class)", "domain")
+	UserSignupDeactivationExtendedCounterVec = newCounterVec("user_signups_deactivation_extended_total", "Total number of extensions of the deactivation of UserSignups (per UserTier)", "tier")
+	CounterDriftCorrectedCounterVec = newCounterVec("counter_drift_corrected_total", "Total number of cached counts corrected with the values recomputed from the resources (per counter)", "counter")
+	ControllerFailuresCounterVec = newCounterVec("controller_failures_total", "Total number of failure reasons set in the status of the resources (per controller and reason)", []string{"controller", "reason"}...)
 	// Gauges with labels
 	SpaceGaugeVec = newGaugeVec("spaces_current", "Current number of Spaces (per member cluster)", "cluster_name")
 	UserAccountGaugeVec = newGaugeVec("user_accounts_current", "Current number of UserAccounts (per member cluster)", "cluster_name")
 	UserSignupsPerActivationAndDomainGaugeVec = newGaugeVec("users_per_activations_and_domain", "Number of UserSignups per activations and domain", []string{"activations", "domain"}...)
-	MasterUserRecordGaugeVec = newGaugeVec("master_user_records", "Number of MasterUserRecords per email address domain ('internal' vs 'external')", "domain")
+	MasterUserRecordGaugeVec = newGaugeVec("master_user_records", "Number of MasterUserRecords per email address domain class", "domain")
+	TierTemplatesUnreferencedGaugeVec = newGaugeVec("tier_templates_unreferenced", "Number of unreferenced TierTemplates which can be deleted (per tier)", "tier")
+	CounterDriftGaugeVec = newGaugeVec("counter_drift", "Difference between the cached counts and the counts recomputed from the resources (per counter and key)", []string{"counter", "key"}...)
+	PendingObjectsGaugeVec = newGaugeVec("pending_objects", "Number of resources pending approval or provisioning (per kind)", "kind")
+	OldestPendingObjectAgeGaugeVec = newGaugeVec("oldest_pending_object_age_seconds", "Age of the oldest resource pending approval or provisioning (per kind)", "kind")
+	// Histograms
+	UserSignupRiskScoreHistogram = newHistogram("user_signups_risk_score", "Risk score of the UserSignups", prometheus.LinearBuckets(10, 10, 10))
+	UserSignupApprovalDurationHistogram = newHistogram("user_signup_approval_duration_seconds", "Duration between the creation of the UserSignups and their first approval", prometheus.ExponentialBuckets(1, 4, 10))
+	MasterUserRecordProvisioningDurationHistogram = newHistogram("master_user_record_provisioning_duration_seconds", "Duration between the creation of the MasterUserRecords and their first provisioning", prometheus.ExponentialBuckets(1, 2, 12))
+	SpaceProvisioningDurationHistogram = newHistogram("space_provisioning_duration_seconds", "Duration between the creation of the Spaces and their first provisioning", prometheus.ExponentialBuckets(1, 2, 12))
+	NSTemplateSetUpdateDurationHistogram = newHistogram("nstemplateset_update_duration_seconds", "Duration of the updates of the NSTemplateSets", prometheus.ExponentialBuckets(1, 2, 12))
+	SpaceRetargetDurationHistogram = newHistogram("space_retarget_duration_seconds", "Duration of the removal of the Spaces from their previous member cluster when retargeted", prometheus.ExponentialBuckets(1, 2, 12))
+	NotificationDeliveryDurationHistogram = newHistogram("notification_delivery_duration_seconds", "Duration between the creation of the Notifications and their delivery", prometheus.ExponentialBuckets(0.1, 2, 12))
 	log.Info("custom metrics initialized")
 }
 
@@ -90,6 +149,15 @@ func newCounter(name, help string) prometheus.Counter {
 	return c
 }
 
+func newCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	v := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + name,
+		Help: help,
+	}, labels)
+	allCounterVecs = append(allCounterVecs, v)
+	return v
+}
+
 func newGauge(name, help string) prometheus.Gauge {
 	g := prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: metricsPrefix + name,
@@ -108,17 +176,44 @@ func newGaugeVec(name, help string, labels ...string) *prometheus.GaugeVec {
 	return v
 }
 
+func newHistogram(name, help string, buckets []float64) prometheus.Histogram {
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    metricsPrefix + name,
+		Help:    help,
+		Buckets: buckets,
+	})
+	allHistograms = append(allHistograms, h)
+	return h
+}
+
+// IncrementControllerFailures increments the ControllerFailuresCounterVec for the given controller, with the reason of the condition
+// which was set with the given failure message. Nothing is counted if none of the conditions has this message.
+func IncrementControllerFailures(controller string, conditions []toolchainv1alpha1.Condition, message string) {
+	for _, c := range conditions {
+		if c.Message == message && c.Reason != "" {
+			ControllerFailuresCounterVec.WithLabelValues(controller, c.Reason).Inc()
+			return
+		}
+	}
+}
+
 // RegisterCustomMetrics registers the custom metrics
 func RegisterCustomMetrics() {
 	// register metrics
 	for _, c := range allCounters {
 		k8smetrics.Registry.MustRegister(c)
 	}
+	for _, v := range allCounterVecs {
+		k8smetrics.Registry.MustRegister(v)
+	}
 	for _, g := range allGauges {
 		k8smetrics.Registry.MustRegister(g)
 	}
 	for _, v := range allGaugeVecs {
 		k8smetrics.Registry.MustRegister(v)
 	}
+	for _, h := range allHistograms {
+		k8smetrics.Registry.MustRegister(h)
+	}
 	log.Info("custom metrics registered")
 }
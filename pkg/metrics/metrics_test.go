@@ -3,8 +3,12 @@ package metrics
 import (
 	"testing"
 
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	k8smetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
@@ -19,6 +23,19 @@ func TestInitCounter(t *testing.T) {
 	assert.Equal(t, float64(1), promtestutil.ToFloat64(m))
 }
 
+func TestInitCounterVec(t *testing.T) {
+	// given
+	m := newCounterVec("test_counter_vec", "test counter description", "tier")
+
+	// when
+	m.WithLabelValues("deactivate30").Inc()
+	m.WithLabelValues("deactivate90").Add(2)
+
+	// then
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(m.WithLabelValues("deactivate30")))
+	assert.Equal(t, float64(2), promtestutil.ToFloat64(m.WithLabelValues("deactivate90")))
+}
+
 func TestInitGauge(t *testing.T) {
 	// given
 	m := newGauge("test_gauge", "test gauge description")
@@ -43,6 +60,21 @@ func TestInitGaugeVec(t *testing.T) {
 	assert.Equal(t, float64(2), promtestutil.ToFloat64(m.WithLabelValues("member-2")))
 }
 
+func TestInitHistogram(t *testing.T) {
+	// given
+	m := newHistogram("test_histogram", "test histogram description", []float64{10, 20})
+
+	// when
+	m.Observe(5)
+	m.Observe(15)
+
+	// then
+	metric := &dto.Metric{}
+	require.NoError(t, m.Write(metric))
+	assert.Equal(t, uint64(2), metric.GetHistogram().GetSampleCount())
+	assert.Equal(t, float64(20), metric.GetHistogram().GetSampleSum())
+}
+
 func TestRegisterCustomMetrics(t *testing.T) {
 	// when
 	RegisterCustomMetrics()
@@ -53,6 +85,10 @@ func TestRegisterCustomMetrics(t *testing.T) {
 		assert.True(t, k8smetrics.Registry.Unregister(m))
 	}
 
+	for _, m := range allCounterVecs {
+		assert.True(t, k8smetrics.Registry.Unregister(m))
+	}
+
 	for _, m := range allGauges {
 		assert.True(t, k8smetrics.Registry.Unregister(m))
 	}
@@ -60,17 +96,56 @@ func TestRegisterCustomMetrics(t *testing.T) {
 	for _, m := range allGaugeVecs {
 		assert.True(t, k8smetrics.Registry.Unregister(m))
 	}
+
+	for _, m := range allHistograms {
+		assert.True(t, k8smetrics.Registry.Unregister(m))
+	}
 }
 
 func TestResetMetrics(t *testing.T) {
 
 	// when
-	UserSignupUniqueTotal.Inc()
+	UserSignupUniqueCounterVec.WithLabelValues("internal").Inc()
 	UserAccountGaugeVec.WithLabelValues("member-1").Set(20)
 
 	Reset()
 
 	// then
-	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserSignupUniqueTotal))
+	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserSignupUniqueCounterVec.WithLabelValues("internal")))
 	assert.Equal(t, float64(0), promtestutil.ToFloat64(UserAccountGaugeVec.WithLabelValues("member-1")))
 }
+
+func TestIncrementControllerFailures(t *testing.T) {
+	// given
+	conditions := []toolchainv1alpha1.Condition{
+		{
+			Type:   toolchainv1alpha1.UserSignupApproved,
+			Status: corev1.ConditionTrue,
+			Reason: toolchainv1alpha1.UserSignupApprovedAutomaticallyReason,
+		},
+		{
+			Type:    toolchainv1alpha1.UserSignupComplete,
+			Status:  corev1.ConditionFalse,
+			Reason:  toolchainv1alpha1.UserSignupUnableToCreateMURReason,
+			Message: "mock error",
+		},
+	}
+	Reset()
+
+	t.Run("reason of the condition with the message", func(t *testing.T) {
+		// when
+		IncrementControllerFailures("usersignup", conditions, "mock error")
+
+		// then
+		assert.Equal(t, float64(1), promtestutil.ToFloat64(ControllerFailuresCounterVec.WithLabelValues("usersignup", toolchainv1alpha1.UserSignupUnableToCreateMURReason)))
+	})
+
+	t.Run("no condition with the message", func(t *testing.T) {
+		// when
+		IncrementControllerFailures("usersignup", conditions, "another error")
+
+		// then
+		assert.Equal(t, float64(1), promtestutil.ToFloat64(ControllerFailuresCounterVec.WithLabelValues("usersignup", toolchainv1alpha1.UserSignupUnableToCreateMURReason)))
+		assert.Equal(t, float64(0), promtestutil.ToFloat64(ControllerFailuresCounterVec.WithLabelValues("usersignup", toolchainv1alpha1.UserSignupApprovedAutomaticallyReason)))
+	})
+}
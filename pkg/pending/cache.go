@@ -3,10 +3,13 @@ package pending
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sort"
 	"sync"
+	"time"
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	errs "github.com/pkg/errors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -34,9 +37,22 @@ func (c *cache) getOldestPendingObject(namespace string) client.Object {
 		c.loadLatest(namespace)
 		oldest = c.getFirstExisting(namespace)
 	}
+	c.updateMetrics(oldest)
 	return oldest
 }
 
+// updateMetrics sets the number of pending objects (as loaded during the last lookup, minus those which are not pending anymore)
+// and the age of the oldest one
+func (c *cache) updateMetrics(oldest client.Object) {
+	kind := reflect.TypeOf(c.objectType).Elem().Name()
+	metrics.PendingObjectsGaugeVec.WithLabelValues(kind).Set(float64(len(c.sortedObjectNames)))
+	age := 0.0
+	if oldest != nil {
+		age = time.Since(oldest.GetCreationTimestamp().Time).Seconds()
+	}
+	metrics.OldestPendingObjectAgeGaugeVec.WithLabelValues(kind).Set(age)
+}
+
 func (c *cache) loadLatest(namespace string) { //nolint:unparam
 	labels := map[string]string{toolchainv1alpha1.StateLabelKey: toolchainv1alpha1.StateLabelValuePending}
 	opts := client.MatchingLabels(labels)
@@ -8,9 +8,11 @@ import (
 
 	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
 	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/metrics"
 	"github.com/codeready-toolchain/host-operator/test/space"
 	"github.com/codeready-toolchain/toolchain-common/pkg/test"
 	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -35,6 +37,7 @@ func TestGetOldestSignupPendingApproval(t *testing.T) {
 	// then
 	assert.Len(t, cache.sortedObjectNames, 1)
 	assert.Equal(t, pending.Name, foundPending.GetName())
+	assert.Equal(t, float64(1), promtestutil.ToFloat64(metrics.PendingObjectsGaugeVec.WithLabelValues("UserSignup")))
 	approve(t, cl, pending)
 
 	t.Run("won't return any since all are approved", func(t *testing.T) {
@@ -44,6 +47,8 @@ func TestGetOldestSignupPendingApproval(t *testing.T) {
 		// then
 		assert.Empty(t, cache.sortedObjectNames)
 		assert.Nil(t, foundPending)
+		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.PendingObjectsGaugeVec.WithLabelValues("UserSignup")))
+		assert.Equal(t, float64(0), promtestutil.ToFloat64(metrics.OldestPendingObjectAgeGaugeVec.WithLabelValues("UserSignup")))
 	})
 
 	t.Run("will pick the newly added (reactivated) pending UserSignup", func(t *testing.T) {
@@ -12,6 +12,8 @@ var notificationTemplates map[string]NotificationTemplate
 var UserProvisioned, _, _ = GetNotificationTemplate("userprovisioned")
 var UserDeactivated, _, _ = GetNotificationTemplate("userdeactivated")
 var UserDeactivating, _, _ = GetNotificationTemplate("userdeactivating")
+var UserDeactivationReminder, _, _ = GetNotificationTemplate("userdeactivationreminder")
+var UserDeactivationFinalReminder, _, _ = GetNotificationTemplate("userdeactivationfinalreminder")
 var IdlerTriggered, _, _ = GetNotificationTemplate("idlertriggered")
 
 // NotificationTemplate contains the template subject and content
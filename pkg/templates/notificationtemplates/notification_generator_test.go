@@ -1,7 +1,9 @@
 package notificationtemplates
 
 import (
+	"bytes"
 	"testing"
+	texttemplate "text/template"
 
 	"github.com/codeready-toolchain/host-operator/pkg/templates/assets"
 
@@ -60,8 +62,46 @@ func TestGetNotificationTemplate(t *testing.T) {
 			require.NotNil(t, template)
 			assert.True(t, found)
 			assert.Equal(t, "Notice: Your Developer Sandbox for Red Hat OpenShift account will be deactivated soon", template.Subject)
-			assert.Contains(t, template.Content, "Your sandbox will expire in 3 days.  We recommend you save your work as all data in your sandbox will be\n        deleted upon expiry.")
+			assert.Contains(t, template.Content, "Your sandbox will expire in {{.DaysRemaining}} days.  We recommend you save your work as all data in your sandbox will be\n        deleted upon expiry.")
 
+			t.Run("rendered with the days remaining", func(t *testing.T) {
+				// given
+				tmpl, err := texttemplate.New("template").Parse(template.Content)
+				require.NoError(t, err)
+				content := &bytes.Buffer{}
+
+				// when
+				err = tmpl.Execute(content, map[string]string{"DaysRemaining": "7"})
+
+				// then
+				require.NoError(t, err)
+				assert.Contains(t, content.String(), "Your sandbox will expire in 7 days.")
+				assert.NotContains(t, content.String(), "3 days")
+			})
+		})
+
+		t.Run("get userdeactivationreminder notification template", func(t *testing.T) {
+			// when
+			defer resetNotificationTemplateCache()
+			template, found, err := GetNotificationTemplate("userdeactivationreminder")
+			// then
+			require.NoError(t, err)
+			require.NotNil(t, template)
+			assert.True(t, found)
+			assert.Equal(t, "Reminder: Your Developer Sandbox for Red Hat OpenShift account will be deactivated in {{.DaysRemaining}} days", template.Subject)
+			assert.Contains(t, template.Content, "This is a reminder that your sandbox will expire in {{.DaysRemaining}} days.")
+		})
+
+		t.Run("get userdeactivationfinalreminder notification template", func(t *testing.T) {
+			// when
+			defer resetNotificationTemplateCache()
+			template, found, err := GetNotificationTemplate("userdeactivationfinalreminder")
+			// then
+			require.NoError(t, err)
+			require.NotNil(t, template)
+			assert.True(t, found)
+			assert.Equal(t, "Final reminder: Your Developer Sandbox for Red Hat OpenShift account will be deactivated tomorrow", template.Subject)
+			assert.Contains(t, template.Content, "This is a final reminder that your sandbox will expire tomorrow.")
 		})
 
 		t.Run("get idlertriggered notification template", func(t *testing.T) {
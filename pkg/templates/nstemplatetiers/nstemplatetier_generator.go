@@ -13,6 +13,8 @@ import (
 	commonTemplate "github.com/codeready-toolchain/toolchain-common/pkg/template"
 
 	"github.com/davecgh/go-spew/spew"
+	jsonpatch "github.com/evanphx/json-patch"
+	ghodssyaml "github.com/ghodss/yaml"
 	templatev1 "github.com/openshift/api/template/v1"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -21,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -111,20 +114,56 @@ func newNSTemplateTierGenerator(s *runtime.Scheme, client client.Client, namespa
 	return c, nil
 }
 
-// BasedOnTier defines which tier is supposed to be reused and which parameters should be modified
+// BasedOnTier defines which tier is supposed to be reused, which parameters should be modified
+// and which objects of the reused templates should be added, removed or patched.
 // An example:
 //
-// from: base
-// parameters:
-//   - name: IDLER_TIMEOUT_SECONDS
-//     value: 43200
+//	from: base
+//	parameters:
+//	  - name: IDLER_TIMEOUT_SECONDS
+//	    value: 43200
 //
-// Which defines that for creating baseextendedidling tier the base tier should be used and
-// the parameter IDLER_TIMEOUT_SECONDS should be set to 43200
+//	templates:
+//	  - name: ns_dev
+//	    remove:
+//	      - kind: LimitRange
+//	        name: resource-limits
+//
+// Which defines that for creating baseextendedidling tier the base tier should be used,
+// the parameter IDLER_TIMEOUT_SECONDS should be set to 43200 and the `resource-limits` LimitRange
+// should be removed from the `dev` namespace template.
+// Note that the tier referred by `from` can itself be based on another tier.
 type BasedOnTier struct {
 	Revision   string
 	From       string                 `json:"from"`
 	Parameters []templatev1.Parameter `json:"parameters,omitempty" protobuf:"bytes,4,rep,name=parameters"`
+	// Templates the modifications to apply on the objects of the templates of the reused tier.
+	// Since these modifications contain arbitrary objects, they are decoded from the JSON representation of the file
+	Templates []TemplateModification `json:"templates,omitempty" yaml:"-"`
+}
+
+// TemplateModification defines the objects to add, remove or patch in a template of the reused tier
+type TemplateModification struct {
+	// Name the name of the template file, without its extension (eg: `ns_dev`, `spacerole_admin` or `cluster`)
+	Name string `json:"name"`
+	// Add the objects to append to the template
+	Add []runtime.RawExtension `json:"add,omitempty"`
+	// Remove the objects to remove from the template
+	Remove []TemplateObjectRef `json:"remove,omitempty"`
+	// Patch the strategic merge patches to apply on the objects of the template
+	Patch []TemplateObjectPatch `json:"patch,omitempty"`
+}
+
+// TemplateObjectRef identifies an object in a template by its kind and (unprocessed) name
+type TemplateObjectRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// TemplateObjectPatch a strategic merge patch to apply on the object of a template identified by its kind and (unprocessed) name
+type TemplateObjectPatch struct {
+	TemplateObjectRef `json:",inline"`
+	Patch             runtime.RawExtension `json:"patch"`
 }
 
 // loadTemplatesByTiers loads the assets and dispatches them by tiers, assuming the given `assets` has the following structure:
@@ -207,6 +246,11 @@ func loadTemplatesByTiers(assets assets.Assets) (map[string]*tierData, error) {
 			if err := yaml.Unmarshal(content, basedOnTier); err != nil {
 				return nil, errors.Wrapf(err, "unable to unmarshal '%s'", name)
 			}
+			modifications := &BasedOnTier{}
+			if err := ghodssyaml.Unmarshal(content, modifications); err != nil {
+				return nil, errors.Wrapf(err, "unable to unmarshal the template modifications of '%s'", name)
+			}
+			basedOnTier.Templates = modifications.Templates
 			results[tier].rawTemplates.basedOnTier = &tmpl
 			results[tier].basedOnTier = basedOnTier
 		default:
@@ -226,6 +270,51 @@ func loadTemplatesByTiers(assets assets.Assets) (map[string]*tierData, error) {
 	return results, nil
 }
 
+// inheritedTier the result of the resolution of the chain of `based_on_tier.yaml` files of a tier
+type inheritedTier struct {
+	// source the tier at the end of the chain, which provides the templates
+	source *tierData
+	// revision the combined revisions of the `based_on_tier.yaml` files of the chain, starting with the tier itself
+	// (empty if the tier is not based on another tier)
+	revision string
+	// parameters the parameters to set, in the order in which they should be set (ie, the ones of the tier itself come last)
+	parameters []templatev1.Parameter
+	// modifications the modifications of the template objects, in the order in which they should be applied (ie, the ones of the tier itself come last)
+	modifications []TemplateModification
+}
+
+// resolveBasedOnTier follows the chain of `based_on_tier.yaml` files of the given tier until it reaches
+// a tier which provides its own templates. Returns an error if the chain refers to an unknown tier or contains a cycle.
+func (t *tierGenerator) resolveBasedOnTier(tier string) (*inheritedTier, error) {
+	result := &inheritedTier{}
+	revisions := []string{}
+	chain := []string{tier}
+	current, found := t.templatesByTier[tier]
+	if !found {
+		return nil, fmt.Errorf("unknown tier '%s'", tier)
+	}
+	for current.basedOnTier != nil {
+		revisions = append(revisions, current.rawTemplates.basedOnTier.revision)
+		// ancestors' parameters and modifications are applied first, so they can be overridden by the descendants
+		result.parameters = append(append([]templatev1.Parameter{}, current.basedOnTier.Parameters...), result.parameters...)
+		result.modifications = append(append([]TemplateModification{}, current.basedOnTier.Templates...), result.modifications...)
+
+		from := current.basedOnTier.From
+		for _, visited := range chain {
+			if visited == from {
+				return nil, fmt.Errorf("the tier '%s' is part of a cycle of based_on_tier.yaml files: %s -> %s", tier, strings.Join(chain, " -> "), from)
+			}
+		}
+		chain = append(chain, from)
+		if current, found = t.templatesByTier[from]; !found {
+			return nil, fmt.Errorf("the tier '%s' is based on the unknown tier '%s'", chain[len(chain)-2], from)
+		}
+	}
+	result.source = current
+	result.revision = strings.Join(revisions, "-")
+	return result, nil
+}
+
 // initTierTemplates generates all TierTemplate resources, and adds them to the tier map indexed by tier name
 func (t *tierGenerator) initTierTemplates() error {
 
@@ -236,15 +325,11 @@ func (t *tierGenerator) initTierTemplates() error {
 	}
 	sort.Strings(tiers)
 	for _, tier := range tiers {
-		tierData := t.templatesByTier[tier]
-		basedOnTierFileRevision := ""
-		var parameters []templatev1.Parameter
-		if tierData.basedOnTier != nil {
-			parameters = tierData.basedOnTier.Parameters
-			basedOnTierFileRevision = tierData.rawTemplates.basedOnTier.revision
-			tierData = t.templatesByTier[tierData.basedOnTier.From]
-		}
-		tierTemplates, err := t.newTierTemplates(basedOnTierFileRevision, tierData, tier, parameters)
+		inherited, err := t.resolveBasedOnTier(tier)
+		if err != nil {
+			return err
+		}
+		tierTemplates, err := t.newTierTemplates(inherited.revision, inherited.source, tier, inherited.parameters, inherited.modifications)
 		if err != nil {
 			return err
 		}
@@ -254,9 +339,16 @@ func (t *tierGenerator) initTierTemplates() error {
 	return nil
 }
 
-func (t *tierGenerator) newTierTemplates(basedOnTierFileRevision string, tierData *tierData, tier string, parameters []templatev1.Parameter) ([]*toolchainv1alpha1.TierTemplate, error) {
+func (t *tierGenerator) newTierTemplates(basedOnTierFileRevision string, tierData *tierData, tier string, parameters []templatev1.Parameter, modifications []TemplateModification) ([]*toolchainv1alpha1.TierTemplate, error) {
 	decoder := serializer.NewCodecFactory(t.scheme).UniversalDeserializer()
 
+	// verify that all modifications apply to an existing template
+	for _, m := range modifications {
+		if !tierData.rawTemplates.hasTemplate(m.Name) {
+			return nil, fmt.Errorf("unable to modify the '%s' template of tier '%s': no such template in tier '%s'", m.Name, tier, tierData.name)
+		}
+	}
+
 	// namespace templates
 	kinds := make([]string, 0, len(tierData.rawTemplates.namespaceTemplates))
 	for kind := range tierData.rawTemplates.namespaceTemplates {
@@ -266,7 +358,7 @@ func (t *tierGenerator) newTierTemplates(basedOnTierFileRevision string, tierDat
 	sort.Strings(kinds)
 	for _, kind := range kinds {
 		tmpl := tierData.rawTemplates.namespaceTemplates[kind]
-		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, kind, tmpl, parameters)
+		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, kind, tmpl, parameters, modificationsOf("ns_"+kind, modifications)...)
 		if err != nil {
 			return nil, err
 		}
@@ -280,7 +372,7 @@ func (t *tierGenerator) newTierTemplates(basedOnTierFileRevision string, tierDat
 	sort.Strings(roles)
 	for _, role := range roles {
 		tmpl := tierData.rawTemplates.spaceroleTemplates[role]
-		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, role, tmpl, parameters)
+		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, role, tmpl, parameters, modificationsOf("spacerole_"+role, modifications)...)
 		if err != nil {
 			return nil, err
 		}
@@ -288,7 +380,7 @@ func (t *tierGenerator) newTierTemplates(basedOnTierFileRevision string, tierDat
 	}
 	// cluster resources templates
 	if tierData.rawTemplates.clusterTemplate != nil {
-		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, toolchainv1alpha1.ClusterResourcesTemplateType, *tierData.rawTemplates.clusterTemplate, parameters)
+		tierTmpl, err := t.newTierTemplate(decoder, basedOnTierFileRevision, tier, toolchainv1alpha1.ClusterResourcesTemplateType, *tierData.rawTemplates.clusterTemplate, parameters, modificationsOf("cluster", modifications)...)
 		if err != nil {
 			return nil, err
 		}
@@ -314,8 +406,34 @@ func (t *tierGenerator) createTierTemplates() error {
 	return nil
 }
 
+// hasTemplate returns true if there is a template with the given name (eg: `ns_dev`, `spacerole_admin` or `cluster`)
+func (t *templates) hasTemplate(name string) bool {
+	switch {
+	case name == "cluster":
+		return t.clusterTemplate != nil
+	case strings.HasPrefix(name, "ns_"):
+		_, found := t.namespaceTemplates[strings.TrimPrefix(name, "ns_")]
+		return found
+	case strings.HasPrefix(name, "spacerole_"):
+		_, found := t.spaceroleTemplates[strings.TrimPrefix(name, "spacerole_")]
+		return found
+	}
+	return false
+}
+
+// modificationsOf returns the modifications which apply to the template with the given name
+func modificationsOf(name string, modifications []TemplateModification) []TemplateModification {
+	var result []TemplateModification
+	for _, m := range modifications {
+		if m.Name == name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
 // newTierTemplate generates a TierTemplate resource for a given tier and kind
-func (t *tierGenerator) newTierTemplate(decoder runtime.Decoder, basedOnTierFileRevision, tier, kind string, tmpl template, parameters []templatev1.Parameter) (*toolchainv1alpha1.TierTemplate, error) {
+func (t *tierGenerator) newTierTemplate(decoder runtime.Decoder, basedOnTierFileRevision, tier, kind string, tmpl template, parameters []templatev1.Parameter, modifications ...TemplateModification) (*toolchainv1alpha1.TierTemplate, error) {
 	if basedOnTierFileRevision == "" {
 		basedOnTierFileRevision = tmpl.revision
 	}
@@ -326,6 +444,11 @@ func (t *tierGenerator) newTierTemplate(decoder runtime.Decoder, basedOnTierFile
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to generate '%s' TierTemplate manifest", name)
 	}
+	for _, m := range modifications {
+		if err := t.applyModification(m, tmplObj); err != nil {
+			return nil, errors.Wrapf(err, "unable to generate '%s' TierTemplate manifest", name)
+		}
+	}
 	setParams(parameters, tmplObj)
 
 	return &toolchainv1alpha1.TierTemplate{
@@ -342,6 +465,63 @@ func (t *tierGenerator) newTierTemplate(decoder runtime.Decoder, basedOnTierFile
 	}, nil
 }
 
+// applyModification removes, patches and adds the objects of the given template, in that order
+func (t *tierGenerator) applyModification(modification TemplateModification, tmpl *templatev1.Template) error {
+	for _, ref := range modification.Remove {
+		i, err := indexOfObject(tmpl, ref)
+		if err != nil {
+			return err
+		}
+		tmpl.Objects = append(tmpl.Objects[:i], tmpl.Objects[i+1:]...)
+	}
+	for _, p := range modification.Patch {
+		i, err := indexOfObject(tmpl, p.TemplateObjectRef)
+		if err != nil {
+			return err
+		}
+		patched, err := t.strategicMergePatch(tmpl.Objects[i].Raw, p.Patch.Raw)
+		if err != nil {
+			return errors.Wrapf(err, "unable to patch the %s '%s' in template '%s'", p.Kind, p.Name, modification.Name)
+		}
+		tmpl.Objects[i] = runtime.RawExtension{Raw: patched}
+	}
+	for _, obj := range modification.Add {
+		tmpl.Objects = append(tmpl.Objects, runtime.RawExtension{Raw: obj.Raw})
+	}
+	return nil
+}
+
+// indexOfObject returns the index of the object with the given kind and name in the given template
+func indexOfObject(tmpl *templatev1.Template, ref TemplateObjectRef) (int, error) {
+	for i, obj := range tmpl.Objects {
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(obj.Raw); err != nil {
+			return -1, errors.Wrapf(err, "unable to decode object #%d of template '%s'", i, tmpl.Name)
+		}
+		if u.GetKind() == ref.Kind && u.GetName() == ref.Name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no %s named '%s' in template '%s'", ref.Kind, ref.Name, tmpl.Name)
+}
+
+// strategicMergePatch applies the given strategic merge patch on the given object. If the object's kind is not
+// registered in the scheme, then the patch is applied as a JSON merge patch.
+func (t *tierGenerator) strategicMergePatch(original, patch []byte) ([]byte, error) {
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(original); err != nil {
+		return nil, err
+	}
+	typed, err := t.scheme.New(u.GroupVersionKind())
+	if err != nil {
+		if !runtime.IsNotRegisteredError(err) {
+			return nil, err
+		}
+		return jsonpatch.MergePatch(original, patch)
+	}
+	return strategicpatch.StrategicMergePatch(original, patch, typed)
+}
+
 // setParams sets the value for each of the keys in the given parameter set to the template, but only if the key exists there
 func setParams(parametersToSet []templatev1.Parameter, tmpl *templatev1.Template) {
 	for _, paramToSet := range parametersToSet {
@@ -364,17 +544,15 @@ func NewTierTemplateName(tier, kind, revision string) string {
 func (t *tierGenerator) initNSTemplateTiers() error {
 
 	for tierName, tierData := range t.templatesByTier {
-		nsTemplateTier := tierData.rawTemplates.nsTemplateTier
-		tierTemplates := tierData.tierTemplates
-		sourceTierName := tierName
-		var parameters []templatev1.Parameter
-		if tierData.basedOnTier != nil {
-			parameters = tierData.basedOnTier.Parameters
-			fromData := t.templatesByTier[tierData.basedOnTier.From]
-			nsTemplateTier = fromData.rawTemplates.nsTemplateTier
-			sourceTierName = fromData.name
-		}
-		objs, err := t.newNSTemplateTier(sourceTierName, tierName, *nsTemplateTier, tierTemplates, parameters)
+		inherited, err := t.resolveBasedOnTier(tierName)
+		if err != nil {
+			return err
+		}
+		nsTemplateTier := inherited.source.rawTemplates.nsTemplateTier
+		if nsTemplateTier == nil {
+			return fmt.Errorf("tier %s is missing a tier.yaml file", inherited.source.name)
+		}
+		objs, err := t.newNSTemplateTier(inherited.source.name, tierName, *nsTemplateTier, tierData.tierTemplates, inherited.parameters)
 		if err != nil {
 			return err
 		}
@@ -19,6 +19,7 @@ import (
 	templatev1 "github.com/openshift/api/template/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -740,3 +741,311 @@ func runtimeObjectToNSTemplateTier(t *testing.T, s *runtime.Scheme, tierObj runt
 	require.NoError(t, err)
 	return tier
 }
+
+// withExtraTiers returns the test assets along with the given extra files (and their revisions in the metadata)
+func withExtraTiers(files map[string]string, revisions map[string]string) assets.Assets {
+	return assets.NewAssets(func() []string {
+		names := testnstemplatetiers.AssetNames()
+		for name := range files {
+			names = append(names, name)
+		}
+		return names
+	}, func(name string) ([]byte, error) {
+		if content, found := files[name]; found {
+			return []byte(content), nil
+		}
+		if name == "metadata.yaml" {
+			metadata, err := testnstemplatetiers.Asset(name)
+			if err != nil {
+				return nil, err
+			}
+			for file, revision := range revisions {
+				metadata = append(metadata, []byte(fmt.Sprintf("\n%s: %q", file, revision))...)
+			}
+			return metadata, nil
+		}
+		return testnstemplatetiers.Asset(name)
+	})
+}
+
+func TestBasedOnTierChain(t *testing.T) {
+
+	s := scheme.Scheme
+	err := apis.AddToScheme(s)
+	require.NoError(t, err)
+	namespace := "host-operator-" + uuid.Must(uuid.NewV4()).String()[:7]
+
+	t.Run("ok", func(t *testing.T) {
+		// given
+		testassets := withExtraTiers(map[string]string{
+			"intermediate/based_on_tier.yaml": `from: advanced
+parameters:
+- name: CPU_LIMIT
+  value: 2000m`,
+			"chained/based_on_tier.yaml": `from: intermediate`,
+			"override/based_on_tier.yaml": `from: intermediate
+parameters:
+- name: CPU_LIMIT
+  value: 1000m`,
+		}, map[string]string{
+			"intermediate/based_on_tier": "bbbb222",
+			"chained/based_on_tier":      "cccc333",
+			"override/based_on_tier":     "dddd444",
+		})
+
+		// when
+		tc, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+		// then
+		require.NoError(t, err)
+		tierData := tc.templatesByTier["chained"]
+		require.NotNil(t, tierData)
+		names := []string{}
+		for _, tierTmpl := range tierData.tierTemplates {
+			names = append(names, tierTmpl.Name)
+			assert.Equal(t, "chained", tierTmpl.Spec.TierName)
+			if tierTmpl.Spec.Type == "clusterresources" {
+				// inherited from the intermediate tier
+				assertParameterValue(t, tierTmpl.Spec.Template, "CPU_LIMIT", "2000m")
+			}
+		}
+		assert.ElementsMatch(t, []string{
+			"chained-clusterresources-cccc333-bbbb222-abcd123-654321a",
+			"chained-dev-cccc333-bbbb222-abcd123-123456b",
+			"chained-stage-cccc333-bbbb222-abcd123-123456c",
+			"chained-admin-cccc333-bbbb222-abcd123-123456d",
+		}, names)
+		require.Len(t, tierData.objects, 1)
+		tier := runtimeObjectToNSTemplateTier(t, s, tierData.objects[0])
+		assert.Equal(t, "chained", tier.Name)
+		require.NotNil(t, tier.Spec.ClusterResources)
+		assert.Equal(t, "chained-clusterresources-cccc333-bbbb222-abcd123-654321a", tier.Spec.ClusterResources.TemplateRef)
+		assert.Equal(t, "chained-admin-cccc333-bbbb222-abcd123-123456d", tier.Spec.SpaceRoles["admin"].TemplateRef)
+
+		t.Run("closest tier parameters take precedence", func(t *testing.T) {
+			for _, tierTmpl := range tc.templatesByTier["override"].tierTemplates {
+				if tierTmpl.Spec.Type == "clusterresources" {
+					assertParameterValue(t, tierTmpl.Spec.Template, "CPU_LIMIT", "1000m")
+					assert.Equal(t, "dddd444-bbbb222-abcd123-654321a", tierTmpl.Spec.Revision)
+				}
+			}
+		})
+
+		t.Run("single level revisions are unchanged", func(t *testing.T) {
+			for _, tierTmpl := range tc.templatesByTier["advanced"].tierTemplates {
+				assert.True(t, strings.HasPrefix(tierTmpl.Spec.Revision, "abcd123-"), tierTmpl.Spec.Revision)
+				assert.Len(t, strings.Split(tierTmpl.Spec.Revision, "-"), 2)
+			}
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("cycle", func(t *testing.T) {
+			// given
+			testassets := withExtraTiers(map[string]string{
+				"cycle1/based_on_tier.yaml": `from: cycle2`,
+				"cycle2/based_on_tier.yaml": `from: cycle3`,
+				"cycle3/based_on_tier.yaml": `from: cycle1`,
+			}, nil)
+
+			// when
+			_, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+			// then
+			require.EqualError(t, err, "the tier 'cycle1' is part of a cycle of based_on_tier.yaml files: cycle1 -> cycle2 -> cycle3 -> cycle1")
+		})
+
+		t.Run("self reference", func(t *testing.T) {
+			// given
+			testassets := withExtraTiers(map[string]string{
+				"self/based_on_tier.yaml": `from: self`,
+			}, nil)
+
+			// when
+			_, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+			// then
+			require.EqualError(t, err, "the tier 'self' is part of a cycle of based_on_tier.yaml files: self -> self")
+		})
+
+		t.Run("unknown tier", func(t *testing.T) {
+			// given
+			testassets := withExtraTiers(map[string]string{
+				"chained/based_on_tier.yaml":      `from: intermediate`,
+				"intermediate/based_on_tier.yaml": `from: unknown`,
+			}, nil)
+
+			// when
+			_, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+			// then
+			require.EqualError(t, err, "the tier 'intermediate' is based on the unknown tier 'unknown'")
+		})
+	})
+}
+
+func TestBasedOnTierTemplateModifications(t *testing.T) {
+
+	s := scheme.Scheme
+	err := apis.AddToScheme(s)
+	require.NoError(t, err)
+	namespace := "host-operator-" + uuid.Must(uuid.NewV4()).String()[:7]
+	decoder := serializer.NewCodecFactory(s).UniversalDeserializer()
+
+	t.Run("ok", func(t *testing.T) {
+		// given
+		testassets := withExtraTiers(map[string]string{
+			"intermediate/based_on_tier.yaml": `from: base
+templates:
+- name: ns_dev
+  patch:
+  - kind: Namespace
+    name: ${USERNAME}-dev
+    patch:
+      metadata:
+        labels:
+          tier: intermediate
+          name: null
+  add:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: settings
+      namespace: ${USERNAME}-dev
+- name: cluster
+  patch:
+  - kind: ClusterResourceQuota
+    name: for-${USERNAME}
+    patch:
+      spec:
+        quota:
+          hard:
+            limits.memory: 1Gi`,
+			"custom/based_on_tier.yaml": `from: intermediate
+templates:
+- name: spacerole_admin
+  remove:
+  - kind: RoleBinding
+    name: ${USERNAME}-rbac-edit
+- name: ns_dev
+  patch:
+  - kind: Namespace
+    name: ${USERNAME}-dev
+    patch:
+      metadata:
+        labels:
+          tier: custom`,
+		}, map[string]string{
+			"intermediate/based_on_tier": "bbbb222",
+			"custom/based_on_tier":       "cccc333",
+		})
+
+		// when
+		tc, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+		// then
+		require.NoError(t, err)
+		for _, tierTmpl := range tc.templatesByTier["custom"].tierTemplates {
+			objs := decodeTemplateObjects(t, decoder, tierTmpl.Spec.Template)
+			switch tierTmpl.Spec.Type {
+			case "dev":
+				require.Len(t, objs, 2)
+				ns := objs[0]
+				assert.Equal(t, "Namespace", ns.GetKind())
+				// modifications of the intermediate tier are applied first
+				assert.Equal(t, map[string]string{
+					"toolchain.dev.openshift.com/provider": "codeready-toolchain",
+					"tier":                                 "custom",
+				}, ns.GetLabels())
+				assert.Equal(t, "ConfigMap", objs[1].GetKind())
+				assert.Equal(t, "settings", objs[1].GetName())
+			case "stage":
+				// not modified
+				assertNamespaceTemplate(t, decoder, tierTmpl.Spec.Template, testassets, map[string]bool{}, "base", "stage")
+			case "admin":
+				assert.Empty(t, objs)
+			case "clusterresources":
+				require.Len(t, objs, 1)
+				hard, _, err := unstructured.NestedStringMap(objs[0].Object, "spec", "quota", "hard")
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{
+					"limits.cpu":             "${CPU_LIMIT}",
+					"limits.memory":          "1Gi",
+					"requests.storage":       "7Gi",
+					"persistentvolumeclaims": "5",
+				}, hard)
+			}
+		}
+		// the parent tier is not affected by the modifications of the child tier
+		for _, tierTmpl := range tc.templatesByTier["intermediate"].tierTemplates {
+			if tierTmpl.Spec.Type == "admin" {
+				assert.Len(t, tierTmpl.Spec.Template.Objects, 1)
+			}
+		}
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("unknown template", func(t *testing.T) {
+			// given
+			testassets := withExtraTiers(map[string]string{
+				"custom/based_on_tier.yaml": `from: base
+templates:
+- name: ns_prod
+  remove:
+  - kind: Namespace
+    name: ${USERNAME}-prod`,
+			}, map[string]string{"custom/based_on_tier": "cccc333"})
+
+			// when
+			_, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+			// then
+			require.EqualError(t, err, "unable to modify the 'ns_prod' template of tier 'custom': no such template in tier 'base'")
+		})
+
+		t.Run("unknown object", func(t *testing.T) {
+			// given
+			testassets := withExtraTiers(map[string]string{
+				"custom/based_on_tier.yaml": `from: base
+templates:
+- name: ns_dev
+  patch:
+  - kind: Namespace
+    name: unknown
+    patch:
+      metadata:
+        labels:
+          tier: custom`,
+			}, map[string]string{"custom/based_on_tier": "cccc333"})
+
+			// when
+			_, err := newNSTemplateTierGenerator(s, nil, namespace, testassets)
+
+			// then
+			require.EqualError(t, err, "unable to generate 'custom-dev-cccc333-123456b' TierTemplate manifest: no Namespace named 'unknown' in template 'base-dev'")
+		})
+	})
+}
+
+func assertParameterValue(t *testing.T, tmpl templatev1.Template, name, expected string) {
+	for _, param := range tmpl.Parameters {
+		if param.Name == name {
+			assert.Equal(t, expected, param.Value)
+			return
+		}
+	}
+	t.Errorf("missing parameter '%s' in template '%s'", name, tmpl.Name)
+}
+
+func decodeTemplateObjects(t *testing.T, decoder runtime.Decoder, tmpl templatev1.Template) []*unstructured.Unstructured {
+	objs := make([]*unstructured.Unstructured, 0, len(tmpl.Objects))
+	for _, raw := range tmpl.Objects {
+		obj := &unstructured.Unstructured{}
+		_, _, err := decoder.Decode(raw.Raw, nil, obj)
+		require.NoError(t, err)
+		objs = append(objs, obj)
+	}
+	return objs
+}
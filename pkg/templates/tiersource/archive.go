@@ -0,0 +1,184 @@
+package tiersource
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// archiveSource the tiers stored in a tarball
+type archiveSource struct {
+	file string
+}
+
+var _ TierSource = &archiveSource{}
+
+// NewArchiveSource returns a TierSource which reads the templates from the given tarball (gzipped or not),
+// which is expected to have the same structure as the directory of a directory source (see `NewDirectorySource`).
+// The digest of the snapshots is the SHA-256 checksum of the tarball.
+func NewArchiveSource(file string) TierSource {
+	return &archiveSource{
+		file: file,
+	}
+}
+
+func (s *archiveSource) Load() (*Snapshot, error) {
+	content, err := os.ReadFile(s.file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load the tiers from archive '%s'", s.file)
+	}
+	files := map[string][]byte{}
+	if err := extract(content, files); err != nil {
+		return nil, errors.Wrapf(err, "unable to load the tiers from archive '%s'", s.file)
+	}
+	return newSnapshot(files, fmt.Sprintf("sha256:%x", sha256.Sum256(content)))
+}
+
+func (s *archiveSource) IsStatic() bool {
+	return false
+}
+
+const (
+	ociLayoutFile = "oci-layout"
+	ociIndexFile  = "index.json"
+)
+
+// ociLayoutSource the tiers stored in the layers of an image in an OCI image layout directory
+// (see https://github.com/opencontainers/image-spec/blob/main/image-layout.md)
+type ociLayoutSource struct {
+	root string
+}
+
+var _ TierSource = &ociLayoutSource{}
+
+// NewOCILayoutSource returns a TierSource which reads the templates from the layers of the (single) image of the given OCI image layout directory.
+// The layers are applied in order, and the resulting file system is expected to have the same structure as the
+// directory of a directory source (see `NewDirectorySource`).
+// The digest of the snapshots is the digest of the image manifest.
+func NewOCILayoutSource(root string) TierSource {
+	return &ociLayoutSource{
+		root: root,
+	}
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func (s *ociLayoutSource) Load() (*Snapshot, error) {
+	files := map[string][]byte{}
+	manifestDigest, err := s.load(files)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load the tiers from OCI layout '%s'", s.root)
+	}
+	return newSnapshot(files, manifestDigest)
+}
+
+func (s *ociLayoutSource) IsStatic() bool {
+	return false
+}
+
+func (s *ociLayoutSource) load(files map[string][]byte) (string, error) {
+	content, err := os.ReadFile(filepath.Join(s.root, ociIndexFile))
+	if err != nil {
+		return "", err
+	}
+	index := ociIndex{}
+	if err := json.Unmarshal(content, &index); err != nil {
+		return "", errors.Wrapf(err, "invalid %s", ociIndexFile)
+	}
+	if len(index.Manifests) != 1 {
+		return "", fmt.Errorf("expected a single manifest in %s, found %d", ociIndexFile, len(index.Manifests))
+	}
+	content, err = s.blob(index.Manifests[0].Digest)
+	if err != nil {
+		return "", err
+	}
+	manifest := ociManifest{}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return "", errors.Wrapf(err, "invalid manifest '%s'", index.Manifests[0].Digest)
+	}
+	for _, layer := range manifest.Layers {
+		content, err := s.blob(layer.Digest)
+		if err != nil {
+			return "", err
+		}
+		if err := extract(content, files); err != nil {
+			return "", errors.Wrapf(err, "invalid layer '%s'", layer.Digest)
+		}
+	}
+	return index.Manifests[0].Digest, nil
+}
+
+// blob returns the content of the blob with the given digest, after verifying that it matches the digest
+func (s *ociLayoutSource) blob(digest string) ([]byte, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || parts[0] != "sha256" || strings.ContainsAny(parts[1], `/\.`) {
+		return nil, fmt.Errorf("unsupported digest '%s'", digest)
+	}
+	content, err := os.ReadFile(filepath.Join(s.root, "blobs", parts[0], parts[1]))
+	if err != nil {
+		return nil, err
+	}
+	if actual := fmt.Sprintf("%x", sha256.Sum256(content)); actual != parts[1] {
+		return nil, fmt.Errorf("the content of the blob '%s' does not match its digest (actual: 'sha256:%s')", digest, actual)
+	}
+	return content, nil
+}
+
+// extract reads the regular files of the given tarball (gzipped or not), and stores them in the given map indexed by their path.
+// Existing entries in the map are overridden.
+func extract(content []byte, files map[string][]byte) error {
+	var r io.Reader = bytes.NewReader(content)
+	if len(content) > 2 && content[0] == 0x1f && content[1] == 0x8b { // gzip magic number
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean(header.Name), "/")
+		if strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		files[name] = data
+	}
+}
@@ -0,0 +1,99 @@
+package tiersource
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// directorySource the tiers stored in a local directory
+type directorySource struct {
+	root string
+}
+
+var _ TierSource = &directorySource{}
+
+// NewDirectorySource returns a TierSource which reads the templates from the `nstemplatetiers` and `usertiers`
+// subdirectories of the given directory, eg:
+//
+// nstemplatetiers/
+//
+//	metadata.yaml (optional)
+//	base/
+//	  cluster.yaml
+//	  ns_dev.yaml
+//	  ...
+//
+// usertiers/
+//
+//	deactivate30/
+//	  tier.yaml
+//
+// Hidden files and directories are ignored, which makes it possible to use a mounted ConfigMap volume
+// (whose items are projected in the expected paths) as the source.
+func NewDirectorySource(root string) TierSource {
+	return &directorySource{
+		root: root,
+	}
+}
+
+func (s *directorySource) Load() (*Snapshot, error) {
+	files := map[string][]byte{}
+	for _, dir := range []string{NSTemplateTiersDir, UserTiersDir} {
+		if err := readDir(filepath.Join(s.root, dir), dir, files); err != nil {
+			return nil, errors.Wrapf(err, "unable to load the tiers from directory '%s'", s.root)
+		}
+	}
+	// the directory is not content-addressed, hence the digest is computed from the content itself
+	snapshot, err := newSnapshot(files, "")
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Digest, err = digestOf(snapshot.NSTemplateTiers, snapshot.UserTiers); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (s *directorySource) IsStatic() bool {
+	return false
+}
+
+// readDir reads all the non-hidden files of the given directory and its subdirectories, and stores them
+// in the given map, indexed by their path prefixed with the given name. Symlinks are followed, since
+// that's how the keys of a ConfigMap are projected in a volume.
+// A missing directory is ignored.
+func readDir(dir, name string, files map[string][]byte) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(p) // follows symlinks
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if err := readDir(p, path.Join(name, entry.Name()), files); err != nil {
+				return err
+			}
+			continue
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(name, entry.Name())] = content
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package tiersource
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/codeready-toolchain/host-operator/pkg/templates/assets"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// NSTemplateTiersDir the directory of the source which contains the NSTemplateTier templates
+	NSTemplateTiersDir = "nstemplatetiers"
+	// UserTiersDir the directory of the source which contains the UserTier templates
+	UserTiersDir = "usertiers"
+
+	metadataFile = "metadata.yaml"
+)
+
+// TierSource provides the assets from which the NSTemplateTiers and UserTiers are generated
+type TierSource interface {
+	// Load returns the current content of the source
+	Load() (*Snapshot, error)
+	// IsStatic returns true if the content of the source can't change while the operator is running
+	// (in which case there is no need to check it periodically)
+	IsStatic() bool
+}
+
+// Snapshot the content of a TierSource at a given point in time
+type Snapshot struct {
+	// NSTemplateTiers the assets to generate the NSTemplateTiers and their TierTemplates from
+	NSTemplateTiers assets.Assets
+	// UserTiers the assets to generate the UserTiers from
+	UserTiers assets.Assets
+	// Digest a value which identifies the content of the source. Two snapshots with the same digest have the same content.
+	Digest string
+}
+
+// New returns the TierSource for the given location:
+// - if the location is empty, then the source is the given assets, embedded in the operator binary at build time
+// - if the location is an OCI image layout directory (ie, it contains an `oci-layout` file), then the source is the content of the layers of its image
+// - if the location is a directory (eg: a mounted ConfigMap volume), then the source is the content of its `nstemplatetiers` and `usertiers` subdirectories
+// - if the location is a file, then the source is the content of this tarball (gzipped or not)
+func New(location string, nstemplatetiers, usertiers assets.Assets) (TierSource, error) {
+	if location == "" {
+		return NewEmbeddedSource(nstemplatetiers, usertiers), nil
+	}
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid tier source '%s'", location)
+	}
+	if !info.IsDir() {
+		return NewArchiveSource(location), nil
+	}
+	if _, err := os.Stat(filepath.Join(location, ociLayoutFile)); err == nil {
+		return NewOCILayoutSource(location), nil
+	}
+	return NewDirectorySource(location), nil
+}
+
+// embeddedSource the tiers embedded in the operator binary at build time
+type embeddedSource struct {
+	snapshot Snapshot
+}
+
+var _ TierSource = &embeddedSource{}
+
+// NewEmbeddedSource returns a TierSource which provides the given assets
+func NewEmbeddedSource(nstemplatetiers, usertiers assets.Assets) TierSource {
+	return &embeddedSource{
+		snapshot: Snapshot{
+			NSTemplateTiers: nstemplatetiers,
+			UserTiers:       usertiers,
+		},
+	}
+}
+
+func (s *embeddedSource) Load() (*Snapshot, error) {
+	snapshot := s.snapshot
+	if snapshot.Digest == "" {
+		digest, err := digestOf(snapshot.NSTemplateTiers, snapshot.UserTiers)
+		if err != nil {
+			return nil, err
+		}
+		s.snapshot.Digest = digest
+		snapshot.Digest = digest
+	}
+	return &snapshot, nil
+}
+
+func (s *embeddedSource) IsStatic() bool {
+	return true
+}
+
+// digestOf computes a digest of the names and contents of the given assets
+func digestOf(all ...assets.Assets) (string, error) {
+	h := sha256.New()
+	for _, a := range all {
+		for _, name := range sortedNames(a) {
+			content, err := a.Asset(name)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(h, "%s\n%d\n", name, len(content))
+			h.Write(content) // nolint:errcheck // never returns an error
+		}
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+func sortedNames(a assets.Assets) []string {
+	if a.Names == nil {
+		return nil
+	}
+	names := append([]string{}, a.Names()...)
+	sort.Strings(names)
+	return names
+}
+
+// newSnapshot dispatches the given files (indexed by their path relative to the root of the source) into the NSTemplateTier and UserTier assets.
+// Files outside of the `nstemplatetiers` and `usertiers` directories are ignored.
+// If the source does not provide the `metadata.yaml` file with the revisions of the NSTemplateTier templates,
+// then the revision of each template is computed from its content, so that a modified template results in a new TierTemplate.
+func newSnapshot(files map[string][]byte, digest string) (*Snapshot, error) {
+	nstemplatetiers := map[string][]byte{}
+	usertiers := map[string][]byte{}
+	for name, content := range files {
+		switch {
+		case strings.HasPrefix(name, NSTemplateTiersDir+"/"):
+			nstemplatetiers[strings.TrimPrefix(name, NSTemplateTiersDir+"/")] = content
+		case strings.HasPrefix(name, UserTiersDir+"/"):
+			usertiers[strings.TrimPrefix(name, UserTiersDir+"/")] = content
+		}
+	}
+	if _, found := nstemplatetiers[metadataFile]; !found && len(nstemplatetiers) > 0 {
+		metadata, err := contentRevisions(nstemplatetiers)
+		if err != nil {
+			return nil, err
+		}
+		nstemplatetiers[metadataFile] = metadata
+	}
+	return &Snapshot{
+		NSTemplateTiers: inMemoryAssets(nstemplatetiers),
+		UserTiers:       inMemoryAssets(usertiers),
+		Digest:          digest,
+	}, nil
+}
+
+// contentRevisions returns the content of a `metadata.yaml` file in which the revision of each
+// template is the (short) SHA-256 checksum of its content
+func contentRevisions(files map[string][]byte) ([]byte, error) {
+	revisions := make(map[string]string, len(files))
+	for name, content := range files {
+		revisions[strings.TrimSuffix(name, ".yaml")] = fmt.Sprintf("%x", sha256.Sum256(content))[:7]
+	}
+	return yaml.Marshal(revisions)
+}
+
+// inMemoryAssets returns the assets wrapping the given files
+func inMemoryAssets(files map[string][]byte) assets.Assets {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return assets.NewAssets(func() []string {
+		return names
+	}, func(name string) ([]byte, error) {
+		content, found := files[name]
+		if !found {
+			return nil, fmt.Errorf("asset %s not found", name)
+		}
+		return content, nil
+	})
+}
@@ -0,0 +1,347 @@
+package tiersource_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeready-toolchain/host-operator/pkg/templates/assets"
+	"github.com/codeready-toolchain/host-operator/pkg/templates/tiersource"
+	testnstemplatetiers "github.com/codeready-toolchain/host-operator/test/templates/nstemplatetiers"
+	testusertiers "github.com/codeready-toolchain/host-operator/test/templates/usertiers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestNew(t *testing.T) {
+	// given
+	embedded := assets.NewAssets(testnstemplatetiers.AssetNames, testnstemplatetiers.Asset)
+
+	t.Run("embedded", func(t *testing.T) {
+		// when
+		source, err := tiersource.New("", embedded, embedded)
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, source.IsStatic())
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		// when
+		source, err := tiersource.New(t.TempDir(), embedded, embedded)
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, source.IsStatic())
+	})
+
+	t.Run("unknown location", func(t *testing.T) {
+		// when
+		_, err := tiersource.New("/does/not/exist", embedded, embedded)
+
+		// then
+		require.EqualError(t, err, "invalid tier source '/does/not/exist': stat /does/not/exist: no such file or directory")
+	})
+}
+
+func TestEmbeddedSource(t *testing.T) {
+	// given
+	nstemplatetiers := assets.NewAssets(testnstemplatetiers.AssetNames, testnstemplatetiers.Asset)
+	usertiers := assets.NewAssets(testusertiers.AssetNames, testusertiers.Asset)
+	source := tiersource.NewEmbeddedSource(nstemplatetiers, usertiers)
+
+	// when
+	snapshot, err := source.Load()
+
+	// then
+	require.NoError(t, err)
+	assert.ElementsMatch(t, testnstemplatetiers.AssetNames(), snapshot.NSTemplateTiers.Names())
+	assert.ElementsMatch(t, testusertiers.AssetNames(), snapshot.UserTiers.Names())
+	assert.NotEmpty(t, snapshot.Digest)
+
+	t.Run("same digest when loaded again", func(t *testing.T) {
+		// when
+		again, err := source.Load()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, snapshot.Digest, again.Digest)
+	})
+}
+
+func TestDirectorySource(t *testing.T) {
+
+	t.Run("with metadata", func(t *testing.T) {
+		// given
+		root := t.TempDir()
+		writeFiles(t, root, testFiles(t))
+
+		// when
+		snapshot, err := tiersource.NewDirectorySource(root).Load()
+
+		// then
+		require.NoError(t, err)
+		assertTestAssets(t, snapshot)
+		metadata, err := snapshot.NSTemplateTiers.Asset("metadata.yaml")
+		require.NoError(t, err)
+		expected, err := testnstemplatetiers.Asset("metadata.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, expected, metadata)
+	})
+
+	t.Run("without metadata", func(t *testing.T) {
+		// given
+		root := t.TempDir()
+		files := testFiles(t)
+		delete(files, "nstemplatetiers/metadata.yaml")
+		writeFiles(t, root, files)
+
+		// when
+		snapshot, err := tiersource.NewDirectorySource(root).Load()
+
+		// then
+		require.NoError(t, err)
+		revisions := metadataOf(t, snapshot)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(files["nstemplatetiers/base/ns_dev.yaml"]))[:7], revisions["base/ns_dev"])
+		assert.Len(t, revisions, len(files)-len(testusertiers.AssetNames()))
+
+		t.Run("revision and digest change when the content changes", func(t *testing.T) {
+			// given
+			files["nstemplatetiers/base/ns_dev.yaml"] = append(files["nstemplatetiers/base/ns_dev.yaml"], []byte("\n# a comment")...)
+			writeFiles(t, root, files)
+
+			// when
+			updated, err := tiersource.NewDirectorySource(root).Load()
+
+			// then
+			require.NoError(t, err)
+			updatedRevisions := metadataOf(t, updated)
+			assert.NotEqual(t, revisions["base/ns_dev"], updatedRevisions["base/ns_dev"])
+			assert.Equal(t, revisions["base/ns_stage"], updatedRevisions["base/ns_stage"])
+			assert.NotEqual(t, snapshot.Digest, updated.Digest)
+		})
+	})
+
+	t.Run("mounted configmap", func(t *testing.T) {
+		// given a layout similar to a ConfigMap volume, in which the projected items are symlinks to a hidden, timestamped directory
+		root := t.TempDir()
+		data := filepath.Join(root, "..2022_12_01_10_00_00.123456789")
+		writeFiles(t, data, testFiles(t))
+		require.NoError(t, os.Symlink(data, filepath.Join(root, "..data")))
+		require.NoError(t, os.Symlink(filepath.Join("..data", "nstemplatetiers"), filepath.Join(root, "nstemplatetiers")))
+		require.NoError(t, os.Symlink(filepath.Join("..data", "usertiers"), filepath.Join(root, "usertiers")))
+
+		// when
+		snapshot, err := tiersource.NewDirectorySource(root).Load()
+
+		// then
+		require.NoError(t, err)
+		assertTestAssets(t, snapshot)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		// when
+		snapshot, err := tiersource.NewDirectorySource(t.TempDir()).Load()
+
+		// then
+		require.NoError(t, err)
+		assert.Empty(t, snapshot.NSTemplateTiers.Names())
+		assert.Empty(t, snapshot.UserTiers.Names())
+	})
+}
+
+func TestArchiveSource(t *testing.T) {
+
+	for _, compressed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("compressed=%t", compressed), func(t *testing.T) {
+			// given
+			content := newTarball(t, testFiles(t), compressed)
+			file := filepath.Join(t.TempDir(), "tiers.tar.gz")
+			require.NoError(t, os.WriteFile(file, content, 0600))
+
+			// when
+			snapshot, err := tiersource.NewArchiveSource(file).Load()
+
+			// then
+			require.NoError(t, err)
+			assertTestAssets(t, snapshot)
+			assert.Equal(t, fmt.Sprintf("sha256:%x", sha256.Sum256(content)), snapshot.Digest)
+		})
+	}
+
+	t.Run("invalid archive", func(t *testing.T) {
+		// given
+		file := filepath.Join(t.TempDir(), "tiers.tar")
+		require.NoError(t, os.WriteFile(file, []byte("invalid"), 0600))
+
+		// when
+		_, err := tiersource.NewArchiveSource(file).Load()
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to load the tiers from archive")
+	})
+}
+
+func TestOCILayoutSource(t *testing.T) {
+
+	t.Run("ok", func(t *testing.T) {
+		// given
+		files := testFiles(t)
+		override := map[string][]byte{
+			"usertiers/base/tier.yaml": []byte("overridden"),
+		}
+		root, manifestDigest := newOCILayout(t, newTarball(t, files, true), newTarball(t, override, false))
+
+		// when
+		source, err := tiersource.New(root, assets.Assets{}, assets.Assets{})
+		require.NoError(t, err)
+		snapshot, err := source.Load()
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, manifestDigest, snapshot.Digest)
+		assert.ElementsMatch(t, testnstemplatetiers.AssetNames(), snapshot.NSTemplateTiers.Names())
+		content, err := snapshot.UserTiers.Asset("base/tier.yaml")
+		require.NoError(t, err)
+		assert.Equal(t, "overridden", string(content)) // layers are applied in order
+	})
+
+	t.Run("corrupted blob", func(t *testing.T) {
+		// given
+		root, _ := newOCILayout(t, newTarball(t, testFiles(t), true))
+		layers, err := filepath.Glob(filepath.Join(root, "blobs", "sha256", "*"))
+		require.NoError(t, err)
+		for _, layer := range layers {
+			content, err := os.ReadFile(layer)
+			require.NoError(t, err)
+			if !bytes.HasPrefix(content, []byte("{")) { // not the manifest
+				require.NoError(t, os.WriteFile(layer, append(content, 0), 0600))
+			}
+		}
+
+		// when
+		_, err = tiersource.NewOCILayoutSource(root).Load()
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match its digest")
+	})
+}
+
+// testFiles returns the test NSTemplateTier and UserTier templates, indexed by their path in a tier source
+func testFiles(t *testing.T) map[string][]byte {
+	files := map[string][]byte{}
+	for _, name := range testnstemplatetiers.AssetNames() {
+		content, err := testnstemplatetiers.Asset(name)
+		require.NoError(t, err)
+		files["nstemplatetiers/"+name] = content
+	}
+	for _, name := range testusertiers.AssetNames() {
+		content, err := testusertiers.Asset(name)
+		require.NoError(t, err)
+		files["usertiers/"+name] = content
+	}
+	return files
+}
+
+func writeFiles(t *testing.T, root string, files map[string][]byte) {
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.NoError(t, os.WriteFile(p, content, 0600))
+	}
+}
+
+func newTarball(t *testing.T, files map[string][]byte, compressed bool) []byte {
+	buf := &bytes.Buffer{}
+	var gz *gzip.Writer
+	tw := tar.NewWriter(buf)
+	if compressed {
+		gz = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gz)
+	}
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     "./" + name,
+			Mode:     0600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gz != nil {
+		require.NoError(t, gz.Close())
+	}
+	return buf.Bytes()
+}
+
+// newOCILayout creates an OCI image layout directory with an image made of the given layers. Returns the path to the directory and the digest of the image manifest
+func newOCILayout(t *testing.T, layers ...[]byte) (string, string) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "blobs", "sha256"), 0700))
+	writeBlob := func(content []byte) string {
+		digest := fmt.Sprintf("%x", sha256.Sum256(content))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "blobs", "sha256", digest), content, 0600))
+		return "sha256:" + digest
+	}
+	descriptors := []map[string]interface{}{}
+	for _, layer := range layers {
+		descriptors = append(descriptors, map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    writeBlob(layer),
+			"size":      len(layer),
+		})
+	}
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"layers":        descriptors,
+	})
+	require.NoError(t, err)
+	manifestDigest := writeBlob(manifest)
+	index, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests": []map[string]interface{}{
+			{
+				"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"digest":    manifestDigest,
+				"size":      len(manifest),
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "index.json"), index, 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "oci-layout"), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0600))
+	return root, manifestDigest
+}
+
+func assertTestAssets(t *testing.T, snapshot *tiersource.Snapshot) {
+	assert.ElementsMatch(t, testnstemplatetiers.AssetNames(), snapshot.NSTemplateTiers.Names())
+	assert.ElementsMatch(t, testusertiers.AssetNames(), snapshot.UserTiers.Names())
+	for _, name := range testnstemplatetiers.AssetNames() {
+		expected, err := testnstemplatetiers.Asset(name)
+		require.NoError(t, err)
+		actual, err := snapshot.NSTemplateTiers.Asset(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+	assert.NotEmpty(t, snapshot.Digest)
+}
+
+func metadataOf(t *testing.T, snapshot *tiersource.Snapshot) map[string]string {
+	content, err := snapshot.NSTemplateTiers.Asset("metadata.yaml")
+	require.NoError(t, err)
+	revisions := map[string]string{}
+	require.NoError(t, yaml.Unmarshal(content, &revisions))
+	return revisions
+}
@@ -0,0 +1,79 @@
+package tiersource
+
+import (
+	"context"
+	"time"
+
+	"github.com/codeready-toolchain/host-operator/pkg/templates/nstemplatetiers"
+	"github.com/codeready-toolchain/host-operator/pkg/templates/usertiers"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("tiersource")
+
+// Syncer creates or updates the NSTemplateTiers, TierTemplates and UserTiers from the content of a TierSource
+type Syncer struct {
+	Client    client.Client
+	Scheme    *runtime.Scheme
+	Namespace string
+	Source    TierSource
+	// Interval the duration between two checks of the source when running periodically
+	Interval time.Duration
+
+	lastDigest string
+}
+
+// Sync creates or updates the NSTemplateTiers, TierTemplates and UserTiers if the content of the source changed since the last sync.
+// Returns `true` if the resources were created or updated.
+func (s *Syncer) Sync() (bool, error) {
+	snapshot, err := s.Source.Load()
+	if err != nil {
+		return false, err
+	}
+	if snapshot.Digest == s.lastDigest {
+		return false, nil
+	}
+	logger := log.WithValues("digest", snapshot.Digest, "previous_digest", s.lastDigest)
+	if len(sortedNames(snapshot.NSTemplateTiers)) > 0 {
+		logger.Info("Creating/updating the NSTemplateTier resources")
+		if err := nstemplatetiers.CreateOrUpdateResources(s.Scheme, s.Client, s.Namespace, snapshot.NSTemplateTiers); err != nil {
+			return false, errors.Wrap(err, "unable to create/update the NSTemplateTier resources")
+		}
+	} else {
+		logger.Info("No NSTemplateTier in the source")
+	}
+	if len(sortedNames(snapshot.UserTiers)) > 0 {
+		logger.Info("Creating/updating the UserTier resources")
+		if err := usertiers.CreateOrUpdateResources(s.Scheme, s.Client, s.Namespace, snapshot.UserTiers); err != nil {
+			return false, errors.Wrap(err, "unable to create/update the UserTier resources")
+		}
+	} else {
+		logger.Info("No UserTier in the source")
+	}
+	s.lastDigest = snapshot.Digest
+	logger.Info("Created/updated the tier resources")
+	return true, nil
+}
+
+// Start checks the source periodically until the given context is done. Errors are logged, and the source is checked again on the next tick.
+func (s *Syncer) Start(ctx context.Context) error {
+	if s.Source.IsStatic() || s.Interval <= 0 {
+		return nil
+	}
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := s.Sync(); err != nil {
+				log.Error(err, "unable to sync the tiers from the source")
+			}
+		}
+	}
+}
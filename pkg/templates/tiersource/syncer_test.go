@@ -0,0 +1,130 @@
+package tiersource_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/templates/tiersource"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSyncer(t *testing.T) {
+	// given
+	s := scheme.Scheme
+	require.NoError(t, apis.AddToScheme(s))
+	root := t.TempDir()
+	files := testFiles(t)
+	delete(files, "nstemplatetiers/metadata.yaml")
+	writeFiles(t, root, files)
+	cl := test.NewFakeClient(t)
+	syncer := &tiersource.Syncer{
+		Client:    cl,
+		Scheme:    s,
+		Namespace: test.HostOperatorNs,
+		Source:    tiersource.NewDirectorySource(root),
+		Interval:  10 * time.Millisecond,
+	}
+
+	// when
+	synced, err := syncer.Sync()
+
+	// then
+	require.NoError(t, err)
+	assert.True(t, synced)
+	assertTierCount(t, cl, 4, 2)
+	tierTemplates := &toolchainv1alpha1.TierTemplateList{}
+	require.NoError(t, cl.List(context.TODO(), tierTemplates, client.InNamespace(test.HostOperatorNs)))
+	initialTierTemplates := len(tierTemplates.Items)
+
+	t.Run("nothing to do when the content did not change", func(t *testing.T) {
+		// when
+		synced, err := syncer.Sync()
+
+		// then
+		require.NoError(t, err)
+		assert.False(t, synced)
+	})
+
+	t.Run("new tier templates when the content changed", func(t *testing.T) {
+		// given
+		files["nstemplatetiers/base/ns_dev.yaml"] = append(files["nstemplatetiers/base/ns_dev.yaml"], []byte("\n# a comment")...)
+		writeFiles(t, root, files)
+
+		// when
+		synced, err := syncer.Sync()
+
+		// then
+		require.NoError(t, err)
+		assert.True(t, synced)
+		require.NoError(t, cl.List(context.TODO(), tierTemplates, client.InNamespace(test.HostOperatorNs)))
+		// the `dev` template of the `base` and `advanced` tiers changed
+		assert.Len(t, tierTemplates.Items, initialTierTemplates+2)
+	})
+
+	t.Run("periodic sync", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- syncer.Start(ctx)
+		}()
+
+		// when
+		files["usertiers/base/tier.yaml"] = bytes.Replace(files["usertiers/base/tier.yaml"], []byte(`value: "30"`), []byte(`value: "45"`), 1)
+		writeFiles(t, root, files)
+
+		// then
+		require.Eventually(t, func() bool {
+			tier := &toolchainv1alpha1.UserTier{}
+			err := cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, "base"), tier)
+			return err == nil && tier.Spec.DeactivationTimeoutDays == 45
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		// given
+		require.NoError(t, os.WriteFile(filepath.Join(root, "nstemplatetiers", "base", "unknown.yaml"), []byte("foo"), 0600))
+
+		// when
+		synced, err := syncer.Sync()
+
+		// then
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unable to create/update the NSTemplateTier resources")
+		assert.False(t, synced)
+
+		t.Run("retried on next sync", func(t *testing.T) {
+			// given
+			require.NoError(t, os.Remove(filepath.Join(root, "nstemplatetiers", "base", "unknown.yaml")))
+
+			// when
+			_, err := syncer.Sync()
+
+			// then
+			require.NoError(t, err)
+		})
+	})
+}
+
+func assertTierCount(t *testing.T, cl client.Client, nstemplatetiers, usertiers int) {
+	nsTemplateTiers := &toolchainv1alpha1.NSTemplateTierList{}
+	require.NoError(t, cl.List(context.TODO(), nsTemplateTiers, client.InNamespace(test.HostOperatorNs)))
+	assert.Len(t, nsTemplateTiers.Items, nstemplatetiers)
+	userTiers := &toolchainv1alpha1.UserTierList{}
+	require.NoError(t, cl.List(context.TODO(), userTiers, client.InNamespace(test.HostOperatorNs)))
+	assert.Len(t, userTiers.Items, usertiers)
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporter the kind of exporter to which the spans are sent
+type Exporter string
+
+const (
+	// NoExporter the spans are not recorded
+	NoExporter Exporter = "none"
+	// StdoutExporter the spans are written as JSON to the standard output (for local testing)
+	StdoutExporter Exporter = "stdout"
+	// FileExporter the spans are appended as JSON to a local file (for local testing)
+	FileExporter Exporter = "file"
+	// OTLPExporter the spans are sent to an OpenTelemetry collector using the OTLP/HTTP protocol
+	OTLPExporter Exporter = "otlp"
+)
+
+// ServiceName the name of the service in the resource of the spans
+const ServiceName = "host-operator"
+
+// Config the configuration of the tracing
+type Config struct {
+	// Exporter the kind of exporter, or an empty value if the spans are not recorded
+	Exporter Exporter
+	// FilePath the path to the file in which the spans are written (`file` exporter)
+	FilePath string
+	// OTLPEndpoint the host and port of the collector (`otlp` exporter)
+	OTLPEndpoint string
+	// OTLPInsecure whether the connection to the collector uses HTTP instead of HTTPS (`otlp` exporter)
+	OTLPInsecure bool
+}
+
+// ShutdownFunc flushes the pending spans and releases the resources of the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup configures the global tracer provider with the exporter of the given configuration.
+// The global tracer provider is left unchanged (ie, a no-op provider) if no exporter is configured.
+func Setup(config Config) (ShutdownFunc, error) {
+	exporter, closer, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		if err := provider.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "unable to shutdown the tracer provider")
+		}
+		if closer != nil {
+			return closer.Close()
+		}
+		return nil
+	}, nil
+}
+
+// newExporter returns the exporter for the given configuration (or nil if no exporter is configured),
+// along with the file to close when the exporter is shut down, if any
+func newExporter(config Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch config.Exporter {
+	case "", NoExporter:
+		return nil, nil, nil
+	case StdoutExporter:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to create the '%s' trace exporter", config.Exporter)
+		}
+		return exporter, nil, nil
+	case FileExporter:
+		if config.FilePath == "" {
+			return nil, nil, fmt.Errorf("missing file path for the '%s' trace exporter", config.Exporter)
+		}
+		f, err := os.OpenFile(config.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to open the file of the '%s' trace exporter", config.Exporter)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, errors.Wrapf(err, "unable to create the '%s' trace exporter", config.Exporter)
+		}
+		return exporter, f, nil
+	case OTLPExporter:
+		if config.OTLPEndpoint == "" {
+			return nil, nil, fmt.Errorf("missing endpoint for the '%s' trace exporter", config.Exporter)
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.OTLPEndpoint)}
+		if config.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		// the client connects lazily, so the operator can start even if the collector is not available yet
+		exporter, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "unable to create the '%s' trace exporter", config.Exporter)
+		}
+		return exporter, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter '%s'", config.Exporter)
+	}
+}
@@ -0,0 +1,111 @@
+package tracing_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetup(t *testing.T) {
+
+	t.Run("no exporter", func(t *testing.T) {
+		for _, exporter := range []tracing.Exporter{"", tracing.NoExporter} {
+			t.Run(string(exporter), func(t *testing.T) {
+				// given
+				previous := otel.GetTracerProvider()
+
+				// when
+				shutdown, err := tracing.Setup(tracing.Config{Exporter: exporter})
+
+				// then
+				require.NoError(t, err)
+				assert.Equal(t, previous, otel.GetTracerProvider())
+				assert.NoError(t, shutdown(context.TODO()))
+			})
+		}
+	})
+
+	t.Run("file exporter", func(t *testing.T) {
+		// given
+		t.Cleanup(func() {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		})
+		path := filepath.Join(t.TempDir(), "spans.json")
+		shutdown, err := tracing.Setup(tracing.Config{
+			Exporter: tracing.FileExporter,
+			FilePath: path,
+		})
+		require.NoError(t, err)
+		userSignup := newUserSignup()
+
+		// when
+		require.True(t, tracing.EnsureTraceContext(context.TODO(), "signup", userSignup))
+		_, span := tracing.StartReconcileSpan(context.TODO(), "usersignup", userSignup)
+		span.End()
+		err = shutdown(context.TODO())
+
+		// then
+		require.NoError(t, err)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"Name":"signup"`)
+		assert.Contains(t, string(content), `"Name":"reconcile usersignup"`)
+		assert.Contains(t, string(content), tracing.TraceContext(userSignup).TraceID().String())
+	})
+
+	t.Run("otlp exporter", func(t *testing.T) {
+		// given
+		t.Cleanup(func() {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		})
+
+		// when
+		shutdown, err := tracing.Setup(tracing.Config{
+			Exporter:     tracing.OTLPExporter,
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+		})
+
+		// then
+		require.NoError(t, err)
+		assert.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+		assert.NoError(t, shutdown(context.TODO()))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			config        tracing.Config
+			expectedError string
+		}{
+			"missing file path": {
+				config:        tracing.Config{Exporter: tracing.FileExporter},
+				expectedError: "missing file path for the 'file' trace exporter",
+			},
+			"missing endpoint": {
+				config:        tracing.Config{Exporter: tracing.OTLPExporter},
+				expectedError: "missing endpoint for the 'otlp' trace exporter",
+			},
+			"unknown exporter": {
+				config:        tracing.Config{Exporter: "jaeger"},
+				expectedError: "unknown trace exporter 'jaeger'",
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := tracing.Setup(tc.config)
+
+				// then
+				require.EqualError(t, err, tc.expectedError)
+			})
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package tracing
+
+import (
+	"context"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TraceContextAnnotationKey the annotation in which the context of the trace of a signup is stored (in the W3C `traceparent` format).
+// The annotation is set on the UserSignup and copied onto the resources which are provisioned for the user, so that the spans
+// of their reconciliations can be linked to the same trace.
+const TraceContextAnnotationKey = toolchainv1alpha1.LabelKeyPrefix + "trace-context"
+
+const (
+	tracerName     = "github.com/codeready-toolchain/host-operator"
+	traceParentKey = "traceparent"
+)
+
+var propagator = propagation.TraceContext{}
+
+// EnsureTraceContext starts a new trace with the given name for the given object if it has no trace context yet, and stores the context of the trace
+// in the `toolchain.dev.openshift.com/trace-context` annotation of the object.
+// Returns `true` if the annotation was set (and the object needs to be updated), `false` if the object already had a trace context
+// or if tracing is disabled.
+func EnsureTraceContext(ctx context.Context, name string, obj client.Object) bool {
+	if _, found := obj.GetAnnotations()[TraceContextAnnotationKey]; found {
+		return false
+	}
+	_, span := otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithNewRoot(),
+		trace.WithAttributes(objectAttributes(obj)...))
+	defer span.End()
+	if !span.SpanContext().IsValid() {
+		// tracing is disabled
+		return false
+	}
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(trace.ContextWithSpanContext(ctx, span.SpanContext()), carrier)
+	setAnnotation(obj, carrier.Get(traceParentKey))
+	return true
+}
+
+// PropagateTraceContext copies the trace context of the given source onto the given target, if any
+func PropagateTraceContext(source, target client.Object) {
+	if traceContext, found := source.GetAnnotations()[TraceContextAnnotationKey]; found {
+		setAnnotation(target, traceContext)
+	}
+}
+
+// TraceContext returns the context of the trace stored in the annotations of the given object, or an invalid span context if there is none
+func TraceContext(obj client.Object) trace.SpanContext {
+	carrier := propagation.MapCarrier{
+		traceParentKey: obj.GetAnnotations()[TraceContextAnnotationKey],
+	}
+	return trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+}
+
+// StartReconcileSpan starts the span of the reconciliation of the given object by the given controller.
+// The span is linked to the trace stored in the annotations of the object, if any. The caller must end the span.
+func StartReconcileSpan(ctx context.Context, controller string, obj client.Object) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{
+		trace.WithAttributes(append(objectAttributes(obj), attribute.String("controller", controller))...),
+	}
+	if traceContext := TraceContext(obj); traceContext.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: traceContext}))
+	}
+	return otel.Tracer(tracerName).Start(ctx, "reconcile "+controller, opts...)
+}
+
+func objectAttributes(obj client.Object) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("namespace", obj.GetNamespace()),
+		attribute.String("name", obj.GetName()),
+	}
+}
+
+func setAnnotation(obj client.Object, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TraceContextAnnotationKey] = value
+	obj.SetAnnotations(annotations)
+}
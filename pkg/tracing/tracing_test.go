@@ -0,0 +1,171 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/tracing"
+	. "github.com/codeready-toolchain/host-operator/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEnsureTraceContext(t *testing.T) {
+
+	t.Run("disabled", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup()
+
+		// when
+		updated := tracing.EnsureTraceContext(context.TODO(), "signup", userSignup)
+
+		// then
+		assert.False(t, updated)
+		assert.NotContains(t, userSignup.Annotations, tracing.TraceContextAnnotationKey)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		// given
+		recorder := SetupSpanRecorder(t)
+		userSignup := newUserSignup()
+
+		// when
+		updated := tracing.EnsureTraceContext(context.TODO(), "signup", userSignup)
+
+		// then
+		assert.True(t, updated)
+		require.Contains(t, userSignup.Annotations, tracing.TraceContextAnnotationKey)
+		traceContext := tracing.TraceContext(userSignup)
+		require.True(t, traceContext.IsValid())
+		require.Len(t, recorder.Ended(), 1)
+		assert.Equal(t, "signup", recorder.Ended()[0].Name())
+		assert.Equal(t, recorder.Ended()[0].SpanContext().TraceID(), traceContext.TraceID())
+
+		t.Run("unchanged when already set", func(t *testing.T) {
+			// when
+			updated := tracing.EnsureTraceContext(context.TODO(), "signup", userSignup)
+
+			// then
+			assert.False(t, updated)
+			assert.Equal(t, traceContext, tracing.TraceContext(userSignup))
+			assert.Len(t, recorder.Ended(), 1)
+		})
+	})
+}
+
+func TestPropagateTraceContext(t *testing.T) {
+
+	t.Run("copied", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup()
+		userSignup.Annotations[tracing.TraceContextAnnotationKey] = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+		space := &toolchainv1alpha1.Space{}
+
+		// when
+		tracing.PropagateTraceContext(userSignup, space)
+
+		// then
+		assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", space.Annotations[tracing.TraceContextAnnotationKey])
+	})
+
+	t.Run("nothing to copy", func(t *testing.T) {
+		// given
+		space := &toolchainv1alpha1.Space{}
+
+		// when
+		tracing.PropagateTraceContext(newUserSignup(), space)
+
+		// then
+		assert.Empty(t, space.Annotations)
+	})
+}
+
+func TestTraceContext(t *testing.T) {
+
+	t.Run("valid", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup()
+		userSignup.Annotations[tracing.TraceContextAnnotationKey] = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+		// when
+		traceContext := tracing.TraceContext(userSignup)
+
+		// then
+		require.True(t, traceContext.IsValid())
+		assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", traceContext.TraceID().String())
+		assert.Equal(t, "b7ad6b7169203331", traceContext.SpanID().String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, value := range []string{"", "invalid", "00-00000000000000000000000000000000-b7ad6b7169203331-01"} {
+			t.Run(value, func(t *testing.T) {
+				// given
+				userSignup := newUserSignup()
+				userSignup.Annotations[tracing.TraceContextAnnotationKey] = value
+
+				// when
+				traceContext := tracing.TraceContext(userSignup)
+
+				// then
+				assert.False(t, traceContext.IsValid())
+			})
+		}
+	})
+}
+
+func TestStartReconcileSpan(t *testing.T) {
+
+	t.Run("linked to the trace of the signup", func(t *testing.T) {
+		// given
+		recorder := SetupSpanRecorder(t)
+		userSignup := newUserSignup()
+		require.True(t, tracing.EnsureTraceContext(context.TODO(), "signup", userSignup))
+		mur := &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "johny",
+				Namespace: "toolchain-host-operator",
+			},
+		}
+		tracing.PropagateTraceContext(userSignup, mur)
+
+		// when
+		_, span := tracing.StartReconcileSpan(context.TODO(), "masteruserrecord", mur)
+		span.End()
+
+		// then
+		require.Len(t, recorder.Ended(), 2)
+		reconcileSpan := recorder.Ended()[1]
+		assert.Equal(t, "reconcile masteruserrecord", reconcileSpan.Name())
+		require.Len(t, reconcileSpan.Links(), 1)
+		assert.Equal(t, tracing.TraceContext(userSignup).TraceID(), reconcileSpan.Links()[0].SpanContext.TraceID())
+		assert.Contains(t, reconcileSpan.Attributes(), attribute.String("name", "johny"))
+		assert.Contains(t, reconcileSpan.Attributes(), attribute.String("controller", "masteruserrecord"))
+	})
+
+	t.Run("without trace context", func(t *testing.T) {
+		// given
+		recorder := SetupSpanRecorder(t)
+
+		// when
+		_, span := tracing.StartReconcileSpan(context.TODO(), "usersignup", newUserSignup())
+		span.End()
+
+		// then
+		require.Len(t, recorder.Ended(), 1)
+		assert.Empty(t, recorder.Ended()[0].Links())
+	})
+}
+
+func newUserSignup() *toolchainv1alpha1.UserSignup {
+	return &toolchainv1alpha1.UserSignup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "johny",
+			Namespace:   "toolchain-host-operator",
+			Annotations: map[string]string{},
+		},
+	}
+}
@@ -0,0 +1,423 @@
+package username
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/toolchain-common/pkg/usersignup"
+
+	errs "github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var log = logf.Log.WithName("username_allocator")
+
+const (
+	// ReservationLabelKey the label of the ConfigMaps which reserve a compliant username. The value of the label is the base name
+	// from which the username was derived (eg: `john` for `john-2`)
+	ReservationLabelKey = toolchainv1alpha1.LabelKeyPrefix + "username-reservation"
+	// ReservationOwnerLabelKey the label with the name of the UserSignup which owns the reservation
+	ReservationOwnerLabelKey = toolchainv1alpha1.LabelKeyPrefix + "username-reservation-owner"
+
+	// ReservationNamePrefix the prefix of the name of the reservation ConfigMaps, followed by the username
+	ReservationNamePrefix = "username-reservation-"
+
+	// UsernameKey the key of the reserved username in the data of the reservation ConfigMap
+	UsernameKey = "username"
+	// OwnerKey the key of the name of the UserSignup which owns the reservation in the data of the reservation ConfigMap.
+	// A reservation without owner is reserved by an administrator, and is never allocated to a user.
+	OwnerKey = "owner"
+	// ReleasedAtKey the key of the time (RFC3339) at which the owner of the reservation was deleted, in the data of the
+	// reservation ConfigMap. The username can be allocated to another user once the cool-down period has elapsed.
+	ReleasedAtKey = "released-at"
+	// RenamedToKey the key of the username to which the user was renamed, in the data of the reservation ConfigMap.
+	// The previous username remains reserved for the user, and allocating it to the user returns the new username instead.
+	RenamedToKey = "renamed-to"
+)
+
+// maxCandidates the maximum number of usernames which are considered for a given base name
+const maxCandidates = 1000
+
+// Allocator allocates the compliant usernames of the users. Each username is reserved with a ConfigMap whose name
+// derives from the username, so that two users cannot be allocated the same username, even when their UserSignups are
+// reconciled concurrently. The candidates for a given base name are, in this order: `<base>`, `<base>-2`, `<base>-3`, etc.
+//
+// Administrators can reserve a username by creating a `username-reservation-<username>` ConfigMap in the host operator
+// namespace, with the username in its data and without owner.
+//
+// The username of a deleted user cannot be allocated to another user during the cool-down period configured in the ToolchainConfig.
+type Allocator struct {
+	client    client.Client
+	namespace string
+}
+
+// NewAllocator returns a new Allocator of the usernames of the UserSignups in the given namespace
+func NewAllocator(cl client.Client, namespace string) *Allocator {
+	return &Allocator{
+		client:    cl,
+		namespace: namespace,
+	}
+}
+
+// Base returns the base name of the compliant username of the given user, ie, the transformed username, where the
+// forbidden prefixes, suffixes and words were handled. Each occurrence of a forbidden word is replaced with `crt`.
+func (a *Allocator) Base(userSignup *toolchainv1alpha1.UserSignup, forbiddenPrefixes, forbiddenSuffixes, forbiddenWords []string) (string, error) {
+	base := usersignup.TransformUsername(userSignup.Spec.Username)
+	for _, word := range forbiddenWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			base = strings.ReplaceAll(base, word, "crt")
+		}
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if strings.HasPrefix(base, prefix) {
+			base = fmt.Sprintf("%s%s", "crt-", base)
+			break
+		}
+	}
+	for _, suffix := range forbiddenSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			base = fmt.Sprintf("%s%s", base, "-crt")
+			break
+		}
+	}
+	if validationErrors := validation.IsQualifiedName(base); len(validationErrors) > 0 {
+		return "", fmt.Errorf("transformed username [%s] is invalid", base)
+	}
+	return base, nil
+}
+
+// Allocate reserves a compliant username for the given user, derived from the given base name, and returns it.
+// If the user already owns a reservation for one of the candidates, then this username is returned.
+func (a *Allocator) Allocate(userSignup *toolchainv1alpha1.UserSignup, base string) (string, error) {
+	coolDown, err := a.coolDown()
+	if err != nil {
+		return "", err
+	}
+	reservations := &corev1.ConfigMapList{}
+	if err := a.client.List(context.TODO(), reservations, client.InNamespace(a.namespace),
+		client.MatchingLabels{ReservationLabelKey: base}); err != nil {
+		return "", errs.Wrap(err, "unable to list the username reservations")
+	}
+	// the candidates which are known to be taken, so that they can be skipped without further requests
+	taken := map[string]bool{}
+	for i := range reservations.Items {
+		reservation := &reservations.Items[i]
+		owner := reservation.Data[OwnerKey]
+		if owner == userSignup.Name {
+			if renamedTo := reservation.Data[RenamedToKey]; renamedTo != "" {
+				return a.Reserve(userSignup, a.latestUsername(userSignup.Name, renamedTo))
+			}
+			return a.claim(reservation, userSignup)
+		}
+		if !reusable(reservation, coolDown) {
+			taken[reservation.Data[UsernameKey]] = true
+		}
+	}
+
+	candidate := base
+	for i := 2; i <= maxCandidates+1; i++ {
+		if !taken[candidate] {
+			allocated, err := a.reserve(userSignup, base, candidate, coolDown)
+			if err != nil {
+				return "", err
+			}
+			if allocated {
+				return candidate, nil
+			}
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	return "", fmt.Errorf("unable to allocate a username for [%s]: the %d candidates derived from [%s] are taken",
+		userSignup.Spec.Username, maxCandidates, base)
+}
+
+// Reserve reserves the given username for the user, regardless of the base name of the user. Returns an error if the
+// username is already taken.
+func (a *Allocator) Reserve(userSignup *toolchainv1alpha1.UserSignup, username string) (string, error) {
+	coolDown, err := a.coolDown()
+	if err != nil {
+		return "", err
+	}
+	reserved, err := a.reserve(userSignup, username, username, coolDown)
+	if err != nil {
+		return "", err
+	}
+	if !reserved {
+		return "", fmt.Errorf("the username [%s] is already taken", username)
+	}
+	return username, nil
+}
+
+// latestUsername follows the renames of the user from the given username, and returns the last username of the user
+func (a *Allocator) latestUsername(userSignupName, username string) string {
+	// the number of renames is bounded in case of a cycle
+	for i := 0; i < 10; i++ {
+		reservation := &corev1.ConfigMap{}
+		if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: ReservationNamePrefix + username}, reservation); err != nil {
+			return username
+		}
+		renamedTo := reservation.Data[RenamedToKey]
+		if reservation.Data[OwnerKey] != userSignupName || renamedTo == "" {
+			return username
+		}
+		username = renamedTo
+	}
+	return username
+}
+
+// ReservedBy returns the name of the UserSignup which owns the reservation of the given username, or an empty string if
+// the username is not reserved (or reserved by an administrator)
+func (a *Allocator) ReservedBy(username string) (string, error) {
+	reservation := &corev1.ConfigMap{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: ReservationNamePrefix + username}, reservation); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errs.Wrapf(err, "unable to get the reservation of the username [%s]", username)
+	}
+	return reservation.Data[OwnerKey], nil
+}
+
+// Unreserve deletes the reservation of the given username if it is owned by the given UserSignup, so that the username
+// can be allocated to another user immediately
+func (a *Allocator) Unreserve(userSignupName, username string) error {
+	reservation := &corev1.ConfigMap{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: ReservationNamePrefix + username}, reservation); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return errs.Wrapf(err, "unable to get the reservation of the username [%s]", username)
+	}
+	if reservation.Data[OwnerKey] != userSignupName {
+		return nil
+	}
+	if err := a.client.Delete(context.TODO(), reservation); err != nil && !errors.IsNotFound(err) {
+		return errs.Wrapf(err, "unable to delete the reservation of the username [%s]", username)
+	}
+	log.Info("username reservation deleted", "username", username, "owner", userSignupName)
+	return nil
+}
+
+// SetRenamedTo records in the reservation of the given username of the user that the user was renamed (or removes the
+// record if `renamedTo` is empty). The reservation is created if it does not exist yet (eg, for a user provisioned before
+// the reservations were introduced).
+func (a *Allocator) SetRenamedTo(userSignupName, username, renamedTo string) error {
+	reservation := &corev1.ConfigMap{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: ReservationNamePrefix + username}, reservation); err != nil {
+		if !errors.IsNotFound(err) {
+			return errs.Wrapf(err, "unable to get the reservation of the username [%s]", username)
+		}
+		if renamedTo == "" {
+			return nil
+		}
+		reservation = newReservation(a.namespace, username, username, userSignupName)
+		reservation.Data[RenamedToKey] = renamedTo
+		if err := a.client.Create(context.TODO(), reservation); err != nil {
+			return errs.Wrapf(err, "unable to reserve the username [%s]", username)
+		}
+		return nil
+	}
+	if reservation.Data[OwnerKey] != userSignupName {
+		return fmt.Errorf("the username [%s] is not reserved by [%s]", username, userSignupName)
+	}
+	if reservation.Data[RenamedToKey] == renamedTo {
+		return nil
+	}
+	if renamedTo == "" {
+		delete(reservation.Data, RenamedToKey)
+	} else {
+		reservation.Data[RenamedToKey] = renamedTo
+	}
+	if err := a.client.Update(context.TODO(), reservation); err != nil {
+		return errs.Wrapf(err, "unable to update the reservation of the username [%s]", username)
+	}
+	return nil
+}
+
+// reserve tries to reserve the given candidate for the user, and returns `true` if it succeeded
+func (a *Allocator) reserve(userSignup *toolchainv1alpha1.UserSignup, base, candidate string, coolDown time.Duration) (bool, error) {
+	// a MasterUserRecord created before the reservations were introduced may already use the name
+	mur := &toolchainv1alpha1.MasterUserRecord{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: candidate}, mur); err == nil {
+		owner := mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey]
+		if owner == userSignup.Name {
+			// If the found MUR has the same UserID as the UserSignup, then *it* is the correct MUR -
+			// Return an error here and allow the reconcile() function to pick it up on the next loop
+			return false, fmt.Errorf("INFO: could not generate compliant username as MasterUserRecord with the same name [%s] and user id [%s] already exists. The next reconcile loop will pick it up.", mur.Name, userSignup.Name)
+		}
+		// record the reservation of the existing user, so that the name is not reused immediately once the user is deleted
+		if err := a.client.Create(context.TODO(), newReservation(a.namespace, base, candidate, owner)); err != nil && !errors.IsAlreadyExists(err) {
+			return false, errs.Wrapf(err, "unable to reserve the username [%s] of the existing MasterUserRecord", candidate)
+		}
+		return false, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	err := a.client.Create(context.TODO(), newReservation(a.namespace, base, candidate, userSignup.Name))
+	if err == nil {
+		log.Info("username reserved", "username", candidate, "owner", userSignup.Name)
+		return true, nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return false, errs.Wrapf(err, "unable to reserve the username [%s]", candidate)
+	}
+	// the candidate was reserved in the meantime, or is reserved by an administrator, or by a deleted user
+	reservation := &corev1.ConfigMap{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: ReservationNamePrefix + candidate}, reservation); err != nil {
+		if errors.IsNotFound(err) {
+			// the reservation is not in the cache yet (or was deleted in the meantime): return an error so that the reconcile is requeued,
+			// instead of moving on to the next candidate and leaving a reservation which may belong to this user
+			return false, errs.Wrapf(err, "unable to get the reservation of the username [%s] which already exists", candidate)
+		}
+		return false, err
+	}
+	owner := reservation.Data[OwnerKey]
+	if owner != userSignup.Name && !reusable(reservation, coolDown) {
+		if owner != "" && reservation.Data[ReleasedAtKey] == "" {
+			return false, a.releaseIfOwnerIsDeleted(reservation)
+		}
+		return false, nil
+	}
+	if _, err := a.claim(reservation, userSignup); err != nil {
+		if errors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// claim makes the given user the owner of the reservation, and returns the reserved username
+func (a *Allocator) claim(reservation *corev1.ConfigMap, userSignup *toolchainv1alpha1.UserSignup) (string, error) {
+	if reservation.Data[OwnerKey] == userSignup.Name && reservation.Data[ReleasedAtKey] == "" {
+		return reservation.Data[UsernameKey], nil
+	}
+	reservation.Data[OwnerKey] = userSignup.Name
+	delete(reservation.Data, ReleasedAtKey)
+	if reservation.Labels == nil {
+		reservation.Labels = map[string]string{}
+	}
+	reservation.Labels[ReservationOwnerLabelKey] = userSignup.Name
+	if err := a.client.Update(context.TODO(), reservation); err != nil {
+		return "", err
+	}
+	log.Info("username reservation claimed", "username", reservation.Data[UsernameKey], "owner", userSignup.Name)
+	return reservation.Data[UsernameKey], nil
+}
+
+// coolDown returns the period during which the username of a deleted user cannot be allocated to another user
+func (a *Allocator) coolDown() (time.Duration, error) {
+	config, err := toolchainconfig.GetToolchainConfig(a.client)
+	if err != nil {
+		return 0, errs.Wrap(err, "unable to get ToolchainConfig")
+	}
+	return config.Users().UsernameReuseCoolDown(), nil
+}
+
+// reusable returns `true` if the owner of the reservation was deleted, and the cool-down period has elapsed
+func reusable(reservation *corev1.ConfigMap, coolDown time.Duration) bool {
+	value, found := reservation.Data[ReleasedAtKey]
+	if !found || reservation.Data[OwnerKey] == "" {
+		return false
+	}
+	releasedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Info("invalid release time of the username reservation", "name", reservation.Name, "value", value)
+		return false
+	}
+	return !time.Now().Before(releasedAt.Add(coolDown))
+}
+
+// releaseIfOwnerIsDeleted releases the given reservation if its owner no longer exists (eg: the UserSignup was deleted while
+// the operator was not running)
+func (a *Allocator) releaseIfOwnerIsDeleted(reservation *corev1.ConfigMap) error {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := a.client.Get(context.TODO(), types.NamespacedName{Namespace: a.namespace, Name: reservation.Data[OwnerKey]}, userSignup); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	return a.release(reservation)
+}
+
+// Release releases the username reservations of the given (deleted) UserSignup. The usernames can be allocated to
+// other users once the cool-down period has elapsed. The reservations of the deleted users whose cool-down period has
+// already elapsed are deleted along the way.
+func (a *Allocator) Release(userSignupName string) error {
+	if err := a.deleteExpiredReservations(); err != nil {
+		return err
+	}
+	reservations := &corev1.ConfigMapList{}
+	if err := a.client.List(context.TODO(), reservations, client.InNamespace(a.namespace),
+		client.MatchingLabels{ReservationOwnerLabelKey: userSignupName}); err != nil {
+		return errs.Wrap(err, "unable to list the username reservations")
+	}
+	for i := range reservations.Items {
+		if reservations.Items[i].Data[ReleasedAtKey] != "" {
+			continue
+		}
+		if err := a.release(&reservations.Items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExpiredReservations deletes the reservations of the deleted users whose cool-down period has elapsed. The reservations
+// made by an administrator and the reservations of the previous usernames of a renamed user are kept.
+func (a *Allocator) deleteExpiredReservations() error {
+	coolDown, err := a.coolDown()
+	if err != nil {
+		return err
+	}
+	reservations := &corev1.ConfigMapList{}
+	if err := a.client.List(context.TODO(), reservations, client.InNamespace(a.namespace), client.HasLabels{ReservationLabelKey}); err != nil {
+		return errs.Wrap(err, "unable to list the username reservations")
+	}
+	for i := range reservations.Items {
+		reservation := &reservations.Items[i]
+		if reservation.Data[RenamedToKey] != "" || !reusable(reservation, coolDown) {
+			continue
+		}
+		if err := a.client.Delete(context.TODO(), reservation); err != nil && !errors.IsNotFound(err) {
+			return errs.Wrapf(err, "unable to delete the expired username reservation [%s]", reservation.Name)
+		}
+		log.Info("expired username reservation deleted", "username", reservation.Data[UsernameKey], "owner", reservation.Data[OwnerKey])
+	}
+	return nil
+}
+
+func (a *Allocator) release(reservation *corev1.ConfigMap) error {
+	reservation.Data[ReleasedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	if err := a.client.Update(context.TODO(), reservation); err != nil {
+		return errs.Wrapf(err, "unable to release the username reservation [%s]", reservation.Name)
+	}
+	log.Info("username reservation released", "username", reservation.Data[UsernameKey], "owner", reservation.Data[OwnerKey])
+	return nil
+}
+
+func newReservation(namespace, base, username, owner string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReservationNamePrefix + username,
+			Namespace: namespace,
+			Labels: map[string]string{
+				ReservationLabelKey:      base,
+				ReservationOwnerLabelKey: owner,
+			},
+		},
+		Data: map[string]string{
+			UsernameKey: username,
+			OwnerKey:    owner,
+		},
+	}
+}
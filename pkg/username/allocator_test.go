@@ -0,0 +1,586 @@
+package username_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/host-operator/pkg/username"
+	. "github.com/codeready-toolchain/host-operator/test"
+	commonconfig "github.com/codeready-toolchain/toolchain-common/pkg/configuration"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBase(t *testing.T) {
+	// given
+	allocator := username.NewAllocator(test.NewFakeClient(t), test.HostOperatorNs)
+	prefixes := []string{"openshift", "kube"}
+	suffixes := []string{"admin"}
+	words := []string{"Admin", " "}
+
+	for name, data := range map[string]struct {
+		username string
+		expected string
+	}{
+		"transformed":         {username: "john.doe@redhat.com", expected: "john-doe"},
+		"forbidden word":      {username: "superadminuser", expected: "supercrtuser"},
+		"forbidden prefix":    {username: "kube-john", expected: "crt-kube-john"},
+		"forbidden suffix":    {username: "john-admin", expected: "john-crt"},
+		"prefix after a word": {username: "openshift-admin", expected: "crt-openshift-crt"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// when
+			base, err := allocator.Base(commonsignup.NewUserSignup(commonsignup.WithUsername(data.username)), prefixes, suffixes, words)
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, data.expected, base)
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+
+	t.Run("first candidate", func(t *testing.T) {
+		// given
+		allocator, cl := newAllocator(t, 0)
+		userSignup := newUserSignup("john")
+
+		// when
+		name, err := allocator.Allocate(userSignup, "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "john", name)
+		assertReservation(t, cl, "john", "john", userSignup.Name)
+
+		t.Run("same name for the same user", func(t *testing.T) {
+			// when
+			name, err := allocator.Allocate(userSignup, "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john", name)
+		})
+
+		t.Run("next candidate for another user", func(t *testing.T) {
+			// given
+			other := newUserSignup("other-john")
+
+			// when
+			name, err := allocator.Allocate(other, "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john-2", name)
+			assertReservation(t, cl, "john-2", "john", other.Name)
+		})
+	})
+
+	t.Run("concurrent allocations", func(t *testing.T) {
+		// given
+		allocator, _ := newAllocator(t, 0)
+		names := make([]string, 10)
+		var wg sync.WaitGroup
+
+		// when
+		for i := range names {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				name, err := allocator.Allocate(newUserSignup(fmt.Sprintf("john-%d", i)), "john")
+				assert.NoError(t, err)
+				names[i] = name
+			}(i)
+		}
+		wg.Wait()
+
+		// then all the names are different
+		unique := map[string]bool{}
+		for _, name := range names {
+			unique[name] = true
+		}
+		assert.Len(t, unique, len(names))
+		assert.Contains(t, unique, "john")
+	})
+
+	t.Run("reserved by an administrator", func(t *testing.T) {
+		// given
+		allocator, _ := newAllocator(t, 0, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      username.ReservationNamePrefix + "john",
+				Namespace: test.HostOperatorNs,
+			},
+			Data: map[string]string{
+				username.UsernameKey: "john",
+			},
+		})
+
+		// when
+		name, err := allocator.Allocate(newUserSignup("john"), "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "john-2", name)
+	})
+
+	t.Run("existing MasterUserRecord", func(t *testing.T) {
+		// given
+		userSignup := newUserSignup("john")
+		mur := &toolchainv1alpha1.MasterUserRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "john",
+				Namespace: test.HostOperatorNs,
+				Labels:    map[string]string{toolchainv1alpha1.MasterUserRecordOwnerLabelKey: "previous-john"},
+			},
+		}
+
+		t.Run("of another user", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 0, mur)
+
+			// when
+			name, err := allocator.Allocate(userSignup, "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john-2", name)
+			assertReservation(t, cl, "john", "john", "previous-john")
+		})
+
+		t.Run("of the same user", func(t *testing.T) {
+			// given
+			mur := mur.DeepCopy()
+			mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] = userSignup.Name
+			allocator, _ := newAllocator(t, 0, mur)
+
+			// when
+			_, err := allocator.Allocate(userSignup, "john")
+
+			// then
+			require.EqualError(t, err, "INFO: could not generate compliant username as MasterUserRecord with the same name [john] and user id [john] already exists. The next reconcile loop will pick it up.")
+		})
+	})
+
+	t.Run("username of a deleted user", func(t *testing.T) {
+		// given
+		released := func(releasedAt time.Time) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      username.ReservationNamePrefix + "john",
+					Namespace: test.HostOperatorNs,
+					Labels: map[string]string{
+						username.ReservationLabelKey:      "john",
+						username.ReservationOwnerLabelKey: "deleted-john",
+					},
+				},
+				Data: map[string]string{
+					username.UsernameKey:   "john",
+					username.OwnerKey:      "deleted-john",
+					username.ReleasedAtKey: releasedAt.UTC().Format(time.RFC3339),
+				},
+			}
+		}
+
+		t.Run("not reused during the cool-down period", func(t *testing.T) {
+			// given
+			allocator, _ := newAllocator(t, 24*time.Hour, released(time.Now().Add(-time.Hour)))
+
+			// when
+			name, err := allocator.Allocate(newUserSignup("john"), "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john-2", name)
+		})
+
+		t.Run("reused after the cool-down period", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 24*time.Hour, released(time.Now().Add(-25*time.Hour)))
+			userSignup := newUserSignup("john")
+
+			// when
+			name, err := allocator.Allocate(userSignup, "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john", name)
+			reservation := assertReservation(t, cl, "john", "john", userSignup.Name)
+			assert.NotContains(t, reservation.Data, username.ReleasedAtKey)
+		})
+
+		t.Run("reclaimed by the same user", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 24*time.Hour, released(time.Now().Add(-time.Hour)))
+			userSignup := newUserSignup("deleted-john")
+
+			// when
+			name, err := allocator.Allocate(userSignup, "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john", name)
+			reservation := assertReservation(t, cl, "john", "john", userSignup.Name)
+			assert.NotContains(t, reservation.Data, username.ReleasedAtKey)
+		})
+
+		t.Run("released when the owner no longer exists", func(t *testing.T) {
+			// given the reservation is not in the cache yet, and its owner was deleted
+			reservation := released(time.Now())
+			delete(reservation.Data, username.ReleasedAtKey)
+			delete(reservation.Labels, username.ReservationLabelKey)
+			allocator, cl := newAllocator(t, 24*time.Hour, reservation)
+
+			// when
+			name, err := allocator.Allocate(newUserSignup("john"), "john")
+
+			// then
+			require.NoError(t, err)
+			assert.Equal(t, "john-2", name)
+			reservation = assertReservation(t, cl, "john", "", "deleted-john")
+			assert.Contains(t, reservation.Data, username.ReleasedAtKey)
+		})
+	})
+
+	t.Run("failures", func(t *testing.T) {
+
+		t.Run("unable to list the reservations", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 0)
+			cl.MockList = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*corev1.ConfigMapList); ok {
+					return fmt.Errorf("mock error")
+				}
+				return cl.Client.List(ctx, list, opts...)
+			}
+
+			// when
+			_, err := allocator.Allocate(newUserSignup("john"), "john")
+
+			// then
+			require.EqualError(t, err, "unable to list the username reservations: mock error")
+		})
+
+		t.Run("unable to get the ToolchainConfig", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 0)
+			cl.MockGet = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			_, err := allocator.Allocate(newUserSignup("john"), "john")
+
+			// then
+			require.EqualError(t, err, "unable to get ToolchainConfig: mock error")
+		})
+
+		t.Run("unable to create the reservation", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 0)
+			cl.MockCreate = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+				return fmt.Errorf("mock error")
+			}
+
+			// when
+			_, err := allocator.Allocate(newUserSignup("john"), "john")
+
+			// then
+			require.EqualError(t, err, "unable to reserve the username [john]: mock error")
+		})
+
+		t.Run("reservation not in the cache yet", func(t *testing.T) {
+			// given
+			allocator, cl := newAllocator(t, 0)
+			_, err := allocator.Allocate(newUserSignup("john"), "john")
+			require.NoError(t, err)
+			// the cache does not contain the reservation which was just created
+			cl.MockList = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+				if _, ok := list.(*corev1.ConfigMapList); ok {
+					return nil
+				}
+				return cl.Client.List(ctx, list, opts...)
+			}
+			cl.MockGet = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				if _, ok := obj.(*corev1.ConfigMap); ok {
+					return errors.NewNotFound(corev1.Resource("configmaps"), key.Name)
+				}
+				return cl.Client.Get(ctx, key, obj)
+			}
+
+			// when
+			_, err = allocator.Allocate(newUserSignup("john"), "john")
+
+			// then the allocation is retried instead of reserving another username
+			require.EqualError(t, err, `unable to get the reservation of the username [john] which already exists: configmaps "`+username.ReservationNamePrefix+`john" not found`)
+			cl.MockList = nil
+			cl.MockGet = nil
+			assertReservation(t, cl, "john", "john", "john")
+			reservations := &corev1.ConfigMapList{}
+			require.NoError(t, cl.List(context.TODO(), reservations, client.InNamespace(test.HostOperatorNs)))
+			assert.Len(t, reservations.Items, 1)
+		})
+	})
+}
+
+func TestRelease(t *testing.T) {
+	// given
+	allocator, cl := newAllocator(t, 0)
+	userSignup := newUserSignup("john")
+	_, err := allocator.Allocate(userSignup, "john")
+	require.NoError(t, err)
+
+	// when
+	err = allocator.Release(userSignup.Name)
+
+	// then
+	require.NoError(t, err)
+	reservation := assertReservation(t, cl, "john", "john", userSignup.Name)
+	releasedAt, err := time.Parse(time.RFC3339, reservation.Data[username.ReleasedAtKey])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), releasedAt, time.Minute)
+
+	t.Run("released only once", func(t *testing.T) {
+		// given
+		cl.MockUpdate = func(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		err := allocator.Release(userSignup.Name)
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("username available immediately without cool-down", func(t *testing.T) {
+		// given
+		cl.MockUpdate = nil
+
+		// when
+		name, err := allocator.Allocate(newUserSignup("other-john"), "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "john", name)
+	})
+}
+
+func TestReleaseDeletesExpiredReservations(t *testing.T) {
+	// given
+	reservation := func(name, owner string, data map[string]string) *corev1.ConfigMap {
+		r := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      username.ReservationNamePrefix + name,
+				Namespace: test.HostOperatorNs,
+				Labels: map[string]string{
+					username.ReservationLabelKey:      name,
+					username.ReservationOwnerLabelKey: owner,
+				},
+			},
+			Data: map[string]string{
+				username.UsernameKey: name,
+				username.OwnerKey:    owner,
+			},
+		}
+		for k, v := range data {
+			r.Data[k] = v
+		}
+		return r
+	}
+	expired := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	coolingDown := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	allocator, cl := newAllocator(t, time.Hour,
+		reservation("expired", "deleted-user", map[string]string{username.ReleasedAtKey: expired}),
+		reservation("cooling-down", "deleted-user", map[string]string{username.ReleasedAtKey: coolingDown}),
+		reservation("renamed", "deleted-user", map[string]string{username.ReleasedAtKey: expired, username.RenamedToKey: "other"}),
+		reservation("admin", "", map[string]string{username.ReleasedAtKey: expired}),
+		reservation("active", "active-user", nil),
+		reservation("john", "john", nil))
+
+	// when
+	err := allocator.Release("john")
+
+	// then
+	require.NoError(t, err)
+	assertReservationDeleted(t, cl, "expired")
+	assertReservation(t, cl, "cooling-down", "cooling-down", "deleted-user")
+	assertReservation(t, cl, "renamed", "renamed", "deleted-user")
+	assertReservation(t, cl, "admin", "admin", "")
+	assertReservation(t, cl, "active", "active", "active-user")
+	assert.Contains(t, assertReservation(t, cl, "john", "john", "john").Data, username.ReleasedAtKey)
+
+	t.Run("deletion fails", func(t *testing.T) {
+		// given
+		allocator, cl := newAllocator(t, time.Hour, reservation("expired", "deleted-user", map[string]string{username.ReleasedAtKey: expired}))
+		cl.MockDelete = func(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+			return fmt.Errorf("mock error")
+		}
+
+		// when
+		err := allocator.Release("john")
+
+		// then
+		require.EqualError(t, err, "unable to delete the expired username reservation [username-reservation-expired]: mock error")
+	})
+}
+
+func newAllocator(t *testing.T, coolDown time.Duration, objs ...runtime.Object) (*username.Allocator, *test.FakeClient) {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	config := commonconfig.NewToolchainConfigObjWithReset(t, ConfigAnnotation(toolchainconfig.UsernameReuseCoolDownAnnotationKey, coolDown.String()))
+	cl := test.NewFakeClient(t, append(objs, config)...)
+	return username.NewAllocator(cl, test.HostOperatorNs), cl
+}
+
+func newUserSignup(name string) *toolchainv1alpha1.UserSignup {
+	return commonsignup.NewUserSignup(commonsignup.WithName(name), commonsignup.WithUsername(name))
+}
+
+func assertReservation(t *testing.T, cl client.Client, name, base, owner string) *corev1.ConfigMap {
+	reservation := &corev1.ConfigMap{}
+	require.NoError(t, cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, username.ReservationNamePrefix+name), reservation))
+	assert.Equal(t, name, reservation.Data[username.UsernameKey])
+	assert.Equal(t, owner, reservation.Data[username.OwnerKey])
+	assert.Equal(t, owner, reservation.Labels[username.ReservationOwnerLabelKey])
+	assert.Equal(t, base, reservation.Labels[username.ReservationLabelKey])
+	return reservation
+}
+
+func TestReserve(t *testing.T) {
+	// given
+	allocator, cl := newAllocator(t, 0)
+	userSignup := newUserSignup("john")
+
+	// when
+	name, err := allocator.Reserve(userSignup, "jsmith")
+
+	// then
+	require.NoError(t, err)
+	assert.Equal(t, "jsmith", name)
+	assertReservation(t, cl, "jsmith", "jsmith", userSignup.Name)
+	owner, err := allocator.ReservedBy("jsmith")
+	require.NoError(t, err)
+	assert.Equal(t, userSignup.Name, owner)
+
+	t.Run("taken by another user", func(t *testing.T) {
+		// when
+		_, err := allocator.Reserve(newUserSignup("other-john"), "jsmith")
+
+		// then
+		require.EqualError(t, err, "the username [jsmith] is already taken")
+	})
+
+	t.Run("unreserved", func(t *testing.T) {
+		// given the reservation of another user is not deleted
+		require.NoError(t, allocator.Unreserve("other-john", "jsmith"))
+		owner, err := allocator.ReservedBy("jsmith")
+		require.NoError(t, err)
+		require.Equal(t, userSignup.Name, owner)
+
+		// when
+		err = allocator.Unreserve(userSignup.Name, "jsmith")
+
+		// then
+		require.NoError(t, err)
+		owner, err = allocator.ReservedBy("jsmith")
+		require.NoError(t, err)
+		assert.Empty(t, owner)
+	})
+}
+
+func TestSetRenamedTo(t *testing.T) {
+	// given
+	allocator, cl := newAllocator(t, 0)
+	userSignup := newUserSignup("john")
+	_, err := allocator.Allocate(userSignup, "john")
+	require.NoError(t, err)
+	_, err = allocator.Reserve(userSignup, "jsmith")
+	require.NoError(t, err)
+
+	// when
+	err = allocator.SetRenamedTo(userSignup.Name, "john", "jsmith")
+
+	// then
+	require.NoError(t, err)
+	reservation := assertReservation(t, cl, "john", "john", userSignup.Name)
+	assert.Equal(t, "jsmith", reservation.Data[username.RenamedToKey])
+
+	t.Run("allocates the new username to the user", func(t *testing.T) {
+		// when
+		name, err := allocator.Allocate(userSignup, "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "jsmith", name)
+	})
+
+	t.Run("follows the successive renames", func(t *testing.T) {
+		// given
+		_, err := allocator.Reserve(userSignup, "john-smith")
+		require.NoError(t, err)
+		require.NoError(t, allocator.SetRenamedTo(userSignup.Name, "jsmith", "john-smith"))
+
+		// when
+		name, err := allocator.Allocate(userSignup, "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "john-smith", name)
+	})
+
+	t.Run("not allocated to another user", func(t *testing.T) {
+		// when
+		name, err := allocator.Allocate(newUserSignup("other-john"), "john")
+
+		// then
+		require.NoError(t, err)
+		assert.Equal(t, "john-2", name)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		// when
+		err := allocator.SetRenamedTo(userSignup.Name, "john", "")
+
+		// then
+		require.NoError(t, err)
+		reservation := assertReservation(t, cl, "john", "john", userSignup.Name)
+		assert.NotContains(t, reservation.Data, username.RenamedToKey)
+	})
+
+	t.Run("reservation created if missing", func(t *testing.T) {
+		// when
+		err := allocator.SetRenamedTo(userSignup.Name, "legacy-john", "john")
+
+		// then
+		require.NoError(t, err)
+		reservation := assertReservation(t, cl, "legacy-john", "legacy-john", userSignup.Name)
+		assert.Equal(t, "john", reservation.Data[username.RenamedToKey])
+	})
+
+	t.Run("reserved by another user", func(t *testing.T) {
+		// when
+		err := allocator.SetRenamedTo("other-john", "john", "jsmith")
+
+		// then
+		require.EqualError(t, err, "the username [john] is not reserved by [other-john]")
+	})
+}
+
+func assertReservationDeleted(t *testing.T, cl client.Client, name string) {
+	err := cl.Get(context.TODO(), test.NamespacedName(test.HostOperatorNs, username.ReservationNamePrefix+name), &corev1.ConfigMap{})
+	require.True(t, errors.IsNotFound(err), "reservation '%s' should be deleted: %v", name, err)
+}
@@ -0,0 +1,85 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/toolchainconfig"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// MutateUserSignup sets the email hash label of the UserSignup if it is missing
+func (w *Webhooks) MutateUserSignup(_ context.Context, req admission.Request) admission.Response {
+	userSignup := &toolchainv1alpha1.UserSignup{}
+	if err := w.decoder.Decode(req, userSignup); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	email := userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey]
+	if email == "" || userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey] != "" {
+		return admission.Allowed("")
+	}
+	if userSignup.Labels == nil {
+		userSignup.Labels = map[string]string{}
+	}
+	userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey] = hash.EncodeString(email)
+	return patch(req, userSignup)
+}
+
+// MutateSpace sets the tier name of a new Space to the default space tier if it is missing.
+// The target cluster is still set by the SpaceCompletion controller, since it depends on the capacity of the member clusters.
+func (w *Webhooks) MutateSpace(_ context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1.Create {
+		return admission.Allowed("")
+	}
+	space := &toolchainv1alpha1.Space{}
+	if err := w.decoder.Decode(req, space); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if space.Spec.TierName != "" {
+		return admission.Allowed("")
+	}
+	config, err := toolchainconfig.GetToolchainConfig(w.Client)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	space.Spec.TierName = config.Tiers().DefaultSpaceTier()
+	return patch(req, space)
+}
+
+// MutateSpaceBinding sets the MasterUserRecord and Space labels of the SpaceBinding from its spec
+func (w *Webhooks) MutateSpaceBinding(_ context.Context, req admission.Request) admission.Response {
+	binding := &toolchainv1alpha1.SpaceBinding{}
+	if err := w.decoder.Decode(req, binding); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if binding.Labels[toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey] == binding.Spec.MasterUserRecord &&
+		binding.Labels[toolchainv1alpha1.SpaceBindingSpaceLabelKey] == binding.Spec.Space {
+		return admission.Allowed("")
+	}
+	if binding.Labels == nil {
+		binding.Labels = map[string]string{}
+	}
+	binding.Labels[toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey] = binding.Spec.MasterUserRecord
+	binding.Labels[toolchainv1alpha1.SpaceBindingSpaceLabelKey] = binding.Spec.Space
+	return patch(req, binding)
+}
+
+// MutateBannedUser sets the email hash label of the BannedUser if it is missing
+func (w *Webhooks) MutateBannedUser(_ context.Context, req admission.Request) admission.Response {
+	bannedUser := &toolchainv1alpha1.BannedUser{}
+	if err := w.decoder.Decode(req, bannedUser); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if bannedUser.Spec.Email == "" || bannedUser.Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey] != "" {
+		return admission.Allowed("")
+	}
+	if bannedUser.Labels == nil {
+		bannedUser.Labels = map[string]string{}
+	}
+	bannedUser.Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey] = hash.EncodeString(bannedUser.Spec.Email)
+	return patch(req, bannedUser)
+}
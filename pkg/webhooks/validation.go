@@ -0,0 +1,303 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
+	"github.com/codeready-toolchain/host-operator/controllers/spaceinvitation"
+	"github.com/codeready-toolchain/host-operator/controllers/usersignup"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// validation a function which returns a non-empty message if the object of the request is invalid
+type validation func(ctx context.Context) (string, error)
+
+// validate runs the given validations in order, and denies the request with the message of the first validation that fails
+func validate(validations ...validation) admission.Response {
+	for _, v := range validations {
+		msg, err := v(context.TODO())
+		if err != nil {
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		if msg != "" {
+			return admission.Denied(msg)
+		}
+	}
+	return admission.Allowed("")
+}
+
+// ValidateUserSignup verifies that the email hash label matches the email address, and that the target cluster is known
+func (w *Webhooks) ValidateUserSignup(_ context.Context, req admission.Request) admission.Response {
+	userSignup, oldUserSignup := &toolchainv1alpha1.UserSignup{}, &toolchainv1alpha1.UserSignup{}
+	isUpdate, err := w.decode(req, userSignup, oldUserSignup)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(_ context.Context) (string, error) {
+			return checkEmailHash(userSignup.Annotations[toolchainv1alpha1.UserSignupUserEmailAnnotationKey],
+				userSignup.Labels[toolchainv1alpha1.UserSignupUserEmailHashLabelKey]), nil
+		},
+		func(_ context.Context) (string, error) {
+			if userSignup.Spec.TargetCluster == "" || (isUpdate && userSignup.Spec.TargetCluster == oldUserSignup.Spec.TargetCluster) {
+				return "", nil
+			}
+			return w.checkTargetCluster(userSignup.Spec.TargetCluster), nil
+		})
+}
+
+// ValidateSpace verifies that the tier exists and that the target cluster is known.
+// On update, the fields are only verified when they change.
+func (w *Webhooks) ValidateSpace(_ context.Context, req admission.Request) admission.Response {
+	space, oldSpace := &toolchainv1alpha1.Space{}, &toolchainv1alpha1.Space{}
+	isUpdate, err := w.decode(req, space, oldSpace)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(ctx context.Context) (string, error) {
+			if space.Spec.TierName == "" || (isUpdate && space.Spec.TierName == oldSpace.Spec.TierName) {
+				return "", nil
+			}
+			return w.checkTier(ctx, space.Spec.TierName)
+		},
+		func(_ context.Context) (string, error) {
+			if space.Spec.TargetCluster == "" || (isUpdate && space.Spec.TargetCluster == oldSpace.Spec.TargetCluster) {
+				return "", nil
+			}
+			return w.checkTargetCluster(space.Spec.TargetCluster), nil
+		})
+}
+
+// ValidateSpaceBinding verifies that the Space exists, that the role is one of the space roles of its tier, that there is
+// no other SpaceBinding for the same MasterUserRecord and Space, and that the new SpaceBinding does not exceed the limits of the tier
+// (see `spaceinvitation.MaxMembersPerSpaceAnnotationKey` and `spaceinvitation.MaxSpacesPerUserAnnotationKey`)
+func (w *Webhooks) ValidateSpaceBinding(_ context.Context, req admission.Request) admission.Response {
+	binding, oldBinding := &toolchainv1alpha1.SpaceBinding{}, &toolchainv1alpha1.SpaceBinding{}
+	isUpdate, err := w.decode(req, binding, oldBinding)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	space := &toolchainv1alpha1.Space{}
+	var tier *toolchainv1alpha1.NSTemplateTier
+	members := &toolchainv1alpha1.SpaceBindingList{}
+	return validate(
+		func(ctx context.Context) (string, error) {
+			if found, err := w.exists(ctx, binding.Spec.Space, space); err != nil || found {
+				return "", err
+			}
+			return fmt.Sprintf("the Space '%s' does not exist", binding.Spec.Space), nil
+		},
+		func(ctx context.Context) (string, error) {
+			if space.Spec.TierName == "" {
+				return "", nil
+			}
+			t := &toolchainv1alpha1.NSTemplateTier{}
+			if found, err := w.exists(ctx, space.Spec.TierName, t); err != nil || !found {
+				return "", err
+			}
+			tier = t
+			if _, found := tier.Spec.SpaceRoles[binding.Spec.SpaceRole]; !found {
+				return fmt.Sprintf("the role '%s' is not available in the NSTemplateTier '%s'", binding.Spec.SpaceRole, tier.Name), nil
+			}
+			return "", nil
+		},
+		func(ctx context.Context) (string, error) {
+			if err := w.Client.List(ctx, members, client.InNamespace(w.Namespace),
+				client.MatchingLabels{toolchainv1alpha1.SpaceBindingSpaceLabelKey: binding.Spec.Space}); err != nil {
+				return "", err
+			}
+			for _, other := range members.Items {
+				if other.Name != binding.Name && other.Spec.MasterUserRecord == binding.Spec.MasterUserRecord {
+					return fmt.Sprintf("the SpaceBinding '%s' already binds the MasterUserRecord '%s' to the Space '%s'",
+						other.Name, binding.Spec.MasterUserRecord, binding.Spec.Space), nil
+				}
+			}
+			return "", nil
+		},
+		func(_ context.Context) (string, error) {
+			if tier == nil || (isUpdate && binding.Spec.Space == oldBinding.Spec.Space) {
+				return "", nil
+			}
+			maxMembers, err := spaceinvitation.Limit(tier, spaceinvitation.MaxMembersPerSpaceAnnotationKey)
+			if err != nil || maxMembers < 0 {
+				return "", err
+			}
+			if count := countOthers(members.Items, binding); count >= maxMembers {
+				return fmt.Sprintf("the Space '%s' has reached the maximum number of members (%d)", space.Name, maxMembers), nil
+			}
+			return "", nil
+		},
+		func(ctx context.Context) (string, error) {
+			if tier == nil || (isUpdate && binding.Spec.Space == oldBinding.Spec.Space && binding.Spec.MasterUserRecord == oldBinding.Spec.MasterUserRecord) {
+				return "", nil
+			}
+			maxSpaces, err := spaceinvitation.Limit(tier, spaceinvitation.MaxSpacesPerUserAnnotationKey)
+			if err != nil || maxSpaces < 0 {
+				return "", err
+			}
+			// the limit only applies to the users who are invited in the Space, not to its owner
+			mur := &toolchainv1alpha1.MasterUserRecord{}
+			if found, err := w.exists(ctx, binding.Spec.MasterUserRecord, mur); err != nil {
+				return "", err
+			} else if found && mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] != "" &&
+				mur.Labels[toolchainv1alpha1.MasterUserRecordOwnerLabelKey] == space.Labels[toolchainv1alpha1.SpaceCreatorLabelKey] {
+				return "", nil
+			}
+			spaces := &toolchainv1alpha1.SpaceBindingList{}
+			if err := w.Client.List(ctx, spaces, client.InNamespace(w.Namespace),
+				client.MatchingLabels{toolchainv1alpha1.SpaceBindingMasterUserRecordLabelKey: binding.Spec.MasterUserRecord}); err != nil {
+				return "", err
+			}
+			if count := countOthers(spaces.Items, binding); count >= maxSpaces {
+				return fmt.Sprintf("'%s' has reached the maximum number of Spaces (%d)", binding.Spec.MasterUserRecord, maxSpaces), nil
+			}
+			return "", nil
+		})
+}
+
+// countOthers returns the number of the given SpaceBindings, excluding the given one
+func countOthers(bindings []toolchainv1alpha1.SpaceBinding, binding *toolchainv1alpha1.SpaceBinding) int {
+	count := 0
+	for _, other := range bindings {
+		if other.Name != binding.Name {
+			count++
+		}
+	}
+	return count
+}
+
+// ValidateSocialEvent verifies that the UserTier and the NSTemplateTier of the event exist
+func (w *Webhooks) ValidateSocialEvent(_ context.Context, req admission.Request) admission.Response {
+	event, oldEvent := &toolchainv1alpha1.SocialEvent{}, &toolchainv1alpha1.SocialEvent{}
+	if _, err := w.decode(req, event, oldEvent); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(ctx context.Context) (string, error) {
+			if found, err := w.exists(ctx, event.Spec.UserTier, &toolchainv1alpha1.UserTier{}); err != nil || found {
+				return "", err
+			}
+			return fmt.Sprintf("the UserTier '%s' does not exist", event.Spec.UserTier), nil
+		},
+		func(ctx context.Context) (string, error) {
+			return w.checkTier(ctx, event.Spec.SpaceTier)
+		})
+}
+
+// ValidateNSTemplateTier verifies that the template refs are set, and that the limits are valid
+func (w *Webhooks) ValidateNSTemplateTier(_ context.Context, req admission.Request) admission.Response {
+	tier, oldTier := &toolchainv1alpha1.NSTemplateTier{}, &toolchainv1alpha1.NSTemplateTier{}
+	if _, err := w.decode(req, tier, oldTier); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(_ context.Context) (string, error) {
+			for i, ns := range tier.Spec.Namespaces {
+				if ns.TemplateRef == "" {
+					return fmt.Sprintf("the template ref of namespace #%d is missing", i), nil
+				}
+			}
+			if tier.Spec.ClusterResources != nil && tier.Spec.ClusterResources.TemplateRef == "" {
+				return "the template ref of the cluster resources is missing", nil
+			}
+			for role, spaceRole := range tier.Spec.SpaceRoles {
+				if spaceRole.TemplateRef == "" {
+					return fmt.Sprintf("the template ref of the space role '%s' is missing", role), nil
+				}
+			}
+			return "", nil
+		},
+		func(_ context.Context) (string, error) {
+			for _, key := range []string{spaceinvitation.MaxMembersPerSpaceAnnotationKey, spaceinvitation.MaxSpacesPerUserAnnotationKey} {
+				value, found := tier.Annotations[key]
+				if !found {
+					continue
+				}
+				if l, err := strconv.Atoi(value); err != nil || l < 0 {
+					return fmt.Sprintf("invalid value for annotation '%s': %s", key, value), nil
+				}
+			}
+			return "", nil
+		})
+}
+
+// ValidateUserTier verifies the deactivation timeout and the deactivation reminders of the tier
+func (w *Webhooks) ValidateUserTier(_ context.Context, req admission.Request) admission.Response {
+	tier, oldTier := &toolchainv1alpha1.UserTier{}, &toolchainv1alpha1.UserTier{}
+	if _, err := w.decode(req, tier, oldTier); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(_ context.Context) (string, error) {
+			if tier.Spec.DeactivationTimeoutDays < 0 {
+				return fmt.Sprintf("invalid deactivation timeout: %d", tier.Spec.DeactivationTimeoutDays), nil
+			}
+			return "", nil
+		},
+		func(_ context.Context) (string, error) {
+			value, found := tier.Annotations[deactivation.DeactivationReminderDaysAnnotationKey]
+			if !found {
+				return "", nil
+			}
+			if _, err := deactivation.ParseDeactivationReminders(value); err != nil {
+				return fmt.Sprintf("invalid value for annotation '%s': %s", deactivation.DeactivationReminderDaysAnnotationKey, err.Error()), nil
+			}
+			return "", nil
+		})
+}
+
+// ValidateBannedUser verifies that the email hash label matches the email address, and the settings of the ban (expiration time,
+// scope, appeal and rule). The email address of a BannedUser may also be the hash itself, once the user was purged.
+func (w *Webhooks) ValidateBannedUser(_ context.Context, req admission.Request) admission.Response {
+	bannedUser, oldBannedUser := &toolchainv1alpha1.BannedUser{}, &toolchainv1alpha1.BannedUser{}
+	if _, err := w.decode(req, bannedUser, oldBannedUser); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	return validate(
+		func(_ context.Context) (string, error) {
+			if err := banneduser.ValidateRule(bannedUser); err != nil {
+				return fmt.Sprintf("invalid ban rule: %s", err.Error()), nil
+			}
+			return "", nil
+		},
+		func(_ context.Context) (string, error) {
+			if bannedUser.Spec.Email == "" {
+				return "the email address is missing", nil
+			}
+			emailHash := bannedUser.Labels[toolchainv1alpha1.BannedUserEmailHashLabelKey]
+			if emailHash == "" {
+				if banneduser.IsRule(bannedUser) {
+					// the email address of a BannedUser which bans users by domain, phone number hash, username pattern or company is informative only
+					return "", nil
+				}
+				return fmt.Sprintf("the label '%s' is missing", toolchainv1alpha1.BannedUserEmailHashLabelKey), nil
+			}
+			if bannedUser.Spec.Email == emailHash {
+				return "", nil
+			}
+			return checkEmailHash(bannedUser.Spec.Email, emailHash), nil
+		},
+		func(_ context.Context) (string, error) {
+			if _, err := usersignup.ParseBan(bannedUser); err != nil {
+				return fmt.Sprintf("invalid ban settings: %s", err.Error()), nil
+			}
+			return "", nil
+		})
+}
+
+// checkEmailHash returns a non-empty message if both the email address and the hash are set, and if the hash does not match
+func checkEmailHash(email, emailHash string) string {
+	if email == "" || emailHash == "" || hash.EncodeString(email) == emailHash {
+		return ""
+	}
+	return fmt.Sprintf("the email hash '%s' does not match the email address", emailHash)
+}
@@ -0,0 +1,140 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// The paths on which the webhooks are served
+const (
+	ValidateUserSignupPath     = "/validate-usersignup"
+	MutateUserSignupPath       = "/mutate-usersignup"
+	ValidateSpacePath          = "/validate-space"
+	MutateSpacePath            = "/mutate-space"
+	ValidateSpaceBindingPath   = "/validate-spacebinding"
+	MutateSpaceBindingPath     = "/mutate-spacebinding"
+	ValidateSocialEventPath    = "/validate-socialevent"
+	ValidateNSTemplateTierPath = "/validate-nstemplatetier"
+	ValidateUserTierPath       = "/validate-usertier"
+	ValidateBannedUserPath     = "/validate-banneduser"
+	MutateBannedUserPath       = "/mutate-banneduser"
+)
+
+//+kubebuilder:webhook:path=/validate-usersignup,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=usersignups,verbs=create;update,versions=v1alpha1,name=vusersignup.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/mutate-usersignup,mutating=true,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=usersignups,verbs=create;update,versions=v1alpha1,name=musersignup.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-space,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=spaces,verbs=create;update,versions=v1alpha1,name=vspace.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/mutate-space,mutating=true,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=spaces,verbs=create,versions=v1alpha1,name=mspace.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-spacebinding,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=create;update,versions=v1alpha1,name=vspacebinding.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/mutate-spacebinding,mutating=true,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=spacebindings,verbs=create;update,versions=v1alpha1,name=mspacebinding.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-socialevent,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=socialevents,verbs=create;update,versions=v1alpha1,name=vsocialevent.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-nstemplatetier,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=nstemplatetiers,verbs=create;update,versions=v1alpha1,name=vnstemplatetier.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-usertier,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=usertiers,verbs=create;update,versions=v1alpha1,name=vusertier.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-banneduser,mutating=false,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=bannedusers,verbs=create;update,versions=v1alpha1,name=vbanneduser.toolchain.dev.openshift.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/mutate-banneduser,mutating=true,failurePolicy=fail,sideEffects=None,groups=toolchain.dev.openshift.com,resources=bannedusers,verbs=create;update,versions=v1alpha1,name=mbanneduser.toolchain.dev.openshift.com,admissionReviewVersions=v1
+
+// Webhooks validates and defaults the resources of the host operator when they are created or updated,
+// so that invalid input is rejected up front instead of failing during the reconcile
+type Webhooks struct {
+	Client            client.Client
+	Namespace         string
+	GetMemberClusters cluster.GetMemberClustersFunc
+	decoder           *admission.Decoder
+}
+
+// New returns the webhooks using the given client and scheme
+func New(cl client.Client, scheme *runtime.Scheme, namespace string, getMemberClusters cluster.GetMemberClustersFunc) (*Webhooks, error) {
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhooks{
+		Client:            cl,
+		Namespace:         namespace,
+		GetMemberClusters: getMemberClusters,
+		decoder:           decoder,
+	}, nil
+}
+
+// Register registers all the webhooks in the given server
+func (w *Webhooks) Register(server *webhook.Server) {
+	for path, handle := range map[string]admission.HandlerFunc{
+		ValidateUserSignupPath:     w.ValidateUserSignup,
+		MutateUserSignupPath:       w.MutateUserSignup,
+		ValidateSpacePath:          w.ValidateSpace,
+		MutateSpacePath:            w.MutateSpace,
+		ValidateSpaceBindingPath:   w.ValidateSpaceBinding,
+		MutateSpaceBindingPath:     w.MutateSpaceBinding,
+		ValidateSocialEventPath:    w.ValidateSocialEvent,
+		ValidateNSTemplateTierPath: w.ValidateNSTemplateTier,
+		ValidateUserTierPath:       w.ValidateUserTier,
+		ValidateBannedUserPath:     w.ValidateBannedUser,
+		MutateBannedUserPath:       w.MutateBannedUser,
+	} {
+		server.Register(path, &webhook.Admission{Handler: handle})
+	}
+}
+
+// decode decodes the object of the request, and the previous version of the object in case of an update (or `nil` otherwise)
+func (w *Webhooks) decode(req admission.Request, obj, oldObj runtime.Object) (bool, error) {
+	if err := w.decoder.Decode(req, obj); err != nil {
+		return false, err
+	}
+	if len(req.OldObject.Raw) == 0 {
+		return false, nil
+	}
+	if err := w.decoder.DecodeRaw(req.OldObject, oldObj); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// exists returns `true` if the resource with the given name exists in the namespace of the operator
+func (w *Webhooks) exists(ctx context.Context, name string, obj client.Object) (bool, error) {
+	if err := w.Client.Get(ctx, types.NamespacedName{Namespace: w.Namespace, Name: name}, obj); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// checkTier returns a non-empty message if the NSTemplateTier with the given name does not exist
+func (w *Webhooks) checkTier(ctx context.Context, name string) (string, error) {
+	found, err := w.exists(ctx, name, &toolchainv1alpha1.NSTemplateTier{})
+	if err != nil || found {
+		return "", err
+	}
+	return fmt.Sprintf("the NSTemplateTier '%s' does not exist", name), nil
+}
+
+// checkTargetCluster returns a non-empty message if the given target cluster is not a known member cluster
+func (w *Webhooks) checkTargetCluster(name string) string {
+	for _, member := range w.GetMemberClusters() {
+		if member.Name == name {
+			return ""
+		}
+	}
+	return fmt.Sprintf("the target cluster '%s' is not a known member cluster", name)
+}
+
+// patch returns a response which patches the object of the request into the given object
+func patch(req admission.Request, obj runtime.Object) admission.Response {
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
@@ -0,0 +1,129 @@
+package webhooks_test
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/webhooks"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	"github.com/codeready-toolchain/toolchain-common/pkg/cluster"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+// TestWebhooksOnEnvTest verifies the webhooks against a real API server. It requires the envtest binaries
+// (see `setup-envtest`) and is skipped when the `KUBEBUILDER_ASSETS` environment variable is not set.
+func TestWebhooksOnEnvTest(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS is not set")
+	}
+
+	// given
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	s := runtime.NewScheme()
+	require.NoError(t, apis.AddToScheme(s))
+	env := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		// the webhook configurations deployed with the operator, whose services are replaced with the local webhook server by envtest
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "..", "config", "webhook", "manifests.yaml")},
+		},
+	}
+	cfg, err := env.Start()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, env.Stop())
+	}()
+	cl, err := client.New(cfg, client.Options{Scheme: s})
+	require.NoError(t, err)
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:             s,
+		Host:               env.WebhookInstallOptions.LocalServingHost,
+		Port:               env.WebhookInstallOptions.LocalServingPort,
+		CertDir:            env.WebhookInstallOptions.LocalServingCertDir,
+		MetricsBindAddress: "0",
+	})
+	require.NoError(t, err)
+	noMemberClusters := func(_ ...cluster.Condition) []*cluster.CachedToolchainCluster { return nil }
+	hooks, err := webhooks.New(cl, s, test.HostOperatorNs, noMemberClusters)
+	require.NoError(t, err)
+	hooks.Register(mgr.GetWebhookServer())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = mgr.Start(ctx)
+	}()
+	waitForWebhookServer(t, env.WebhookInstallOptions)
+
+	require.NoError(t, cl.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: test.HostOperatorNs}}))
+	basicTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	basicTier.ResourceVersion = ""
+	require.NoError(t, cl.Create(ctx, basicTier))
+	baseTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates)
+	baseTier.ResourceVersion = ""
+	baseTier.Name = "base"
+	require.NoError(t, cl.Create(ctx, baseTier))
+
+	t.Run("Space with unknown tier is rejected", func(t *testing.T) {
+		err := cl.Create(ctx, spacetest.NewSpace("unknown-tier", spacetest.WithTierName("unknown")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "the NSTemplateTier 'unknown' does not exist")
+	})
+
+	t.Run("Space tier name is defaulted", func(t *testing.T) {
+		space := spacetest.NewSpace("jack", spacetest.WithTierName(""))
+		require.NoError(t, cl.Create(ctx, space))
+		assert.Equal(t, "base", space.Spec.TierName)
+	})
+
+	t.Run("SpaceBinding", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "jack")
+		binding.Labels = nil
+		require.NoError(t, cl.Create(ctx, binding))
+		assert.Equal(t, "jack", binding.Labels[toolchainv1alpha1.SpaceBindingSpaceLabelKey]) // defaulted
+
+		t.Run("duplicate is rejected", func(t *testing.T) {
+			duplicate := spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "jack")
+			duplicate.Name = "jack-jack-2"
+			err := cl.Create(ctx, duplicate)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "already binds the MasterUserRecord 'jack' to the Space 'jack'")
+		})
+
+		t.Run("unknown role is rejected", func(t *testing.T) {
+			err := cl.Create(ctx, spacebindingtest.NewSpaceBinding("jeff", "jack", "owner", "jack"))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "the role 'owner' is not available in the NSTemplateTier 'base'")
+		})
+	})
+}
+
+func waitForWebhookServer(t *testing.T, opts envtest.WebhookInstallOptions) {
+	addr := net.JoinHostPort(opts.LocalServingHost, fmt.Sprintf("%d", opts.LocalServingPort))
+	require.Eventually(t, func() bool {
+		conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", addr, &tls.Config{InsecureSkipVerify: true}) // nolint:gosec
+		if err != nil {
+			return false
+		}
+		return conn.Close() == nil
+	}, 10*time.Second, 100*time.Millisecond)
+}
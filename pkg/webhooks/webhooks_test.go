@@ -0,0 +1,418 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	"github.com/codeready-toolchain/host-operator/controllers/deactivation"
+	"github.com/codeready-toolchain/host-operator/controllers/spaceinvitation"
+	"github.com/codeready-toolchain/host-operator/controllers/usersignup"
+	"github.com/codeready-toolchain/host-operator/pkg/apis"
+	"github.com/codeready-toolchain/host-operator/pkg/banneduser"
+	"github.com/codeready-toolchain/host-operator/pkg/webhooks"
+	. "github.com/codeready-toolchain/host-operator/test"
+	tiertest "github.com/codeready-toolchain/host-operator/test/nstemplatetier"
+	spacetest "github.com/codeready-toolchain/host-operator/test/space"
+	spacebindingtest "github.com/codeready-toolchain/host-operator/test/spacebinding"
+	"github.com/codeready-toolchain/toolchain-common/pkg/hash"
+	"github.com/codeready-toolchain/toolchain-common/pkg/test"
+	murtest "github.com/codeready-toolchain/toolchain-common/pkg/test/masteruserrecord"
+	commonsignup "github.com/codeready-toolchain/toolchain-common/pkg/test/usersignup"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateUserSignup(t *testing.T) {
+	hooks := newWebhooks(t)
+
+	t.Run("valid", func(t *testing.T) {
+		userSignup := commonsignup.NewUserSignup(commonsignup.WithTargetCluster("member-1"))
+		assertAllowed(t, hooks.ValidateUserSignup(context.TODO(), newRequest(t, admissionv1.Create, userSignup, nil)))
+	})
+
+	t.Run("email hash mismatch", func(t *testing.T) {
+		userSignup := commonsignup.NewUserSignup(commonsignup.WithLabel(toolchainv1alpha1.UserSignupUserEmailHashLabelKey, "abcdef"))
+		assertDenied(t, hooks.ValidateUserSignup(context.TODO(), newRequest(t, admissionv1.Create, userSignup, nil)),
+			"the email hash 'abcdef' does not match the email address")
+	})
+
+	t.Run("unknown target cluster", func(t *testing.T) {
+		userSignup := commonsignup.NewUserSignup(commonsignup.WithTargetCluster("unknown"))
+		assertDenied(t, hooks.ValidateUserSignup(context.TODO(), newRequest(t, admissionv1.Create, userSignup, nil)),
+			"the target cluster 'unknown' is not a known member cluster")
+
+		t.Run("unchanged on update", func(t *testing.T) {
+			assertAllowed(t, hooks.ValidateUserSignup(context.TODO(), newRequest(t, admissionv1.Update, userSignup, userSignup)))
+		})
+	})
+}
+
+func TestValidateSpace(t *testing.T) {
+	hooks := newWebhooks(t)
+
+	t.Run("valid", func(t *testing.T) {
+		space := spacetest.NewSpace("jack", spacetest.WithTierName("basic"), spacetest.WithSpecTargetCluster("member-1"))
+		assertAllowed(t, hooks.ValidateSpace(context.TODO(), newRequest(t, admissionv1.Create, space, nil)))
+	})
+
+	t.Run("without tier and target cluster", func(t *testing.T) {
+		space := spacetest.NewSpace("jack", spacetest.WithTierName(""))
+		assertAllowed(t, hooks.ValidateSpace(context.TODO(), newRequest(t, admissionv1.Create, space, nil)))
+	})
+
+	t.Run("unknown tier", func(t *testing.T) {
+		space := spacetest.NewSpace("jack", spacetest.WithTierName("unknown"))
+		assertDenied(t, hooks.ValidateSpace(context.TODO(), newRequest(t, admissionv1.Create, space, nil)),
+			"the NSTemplateTier 'unknown' does not exist")
+	})
+
+	t.Run("unknown target cluster", func(t *testing.T) {
+		oldSpace := spacetest.NewSpace("jack", spacetest.WithTierName("basic"), spacetest.WithSpecTargetCluster("member-1"))
+		space := spacetest.NewSpace("jack", spacetest.WithTierName("basic"), spacetest.WithSpecTargetCluster("unknown"))
+		assertDenied(t, hooks.ValidateSpace(context.TODO(), newRequest(t, admissionv1.Update, space, oldSpace)),
+			"the target cluster 'unknown' is not a known member cluster")
+	})
+}
+
+func TestValidateSpaceBinding(t *testing.T) {
+	hooks := newWebhooks(t,
+		spacetest.NewSpace("jack", spacetest.WithTierName("basic")),
+		spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "jack"))
+
+	t.Run("valid", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jeff", "jack", "viewer", "jack")
+		assertAllowed(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)))
+	})
+
+	t.Run("update of existing binding", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jack", "jack", "viewer", "jack")
+		oldBinding := spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "jack")
+		assertAllowed(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Update, binding, oldBinding)))
+	})
+
+	t.Run("unknown Space", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jeff", "unknown", "viewer", "jack")
+		assertDenied(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)),
+			"the Space 'unknown' does not exist")
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jeff", "jack", "owner", "jack")
+		assertDenied(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)),
+			"the role 'owner' is not available in the NSTemplateTier 'basic'")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jack", "jack", "viewer", "jack")
+		binding.Name = "jack-jack-2"
+		assertDenied(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)),
+			"the SpaceBinding 'jack-jack' already binds the MasterUserRecord 'jack' to the Space 'jack'")
+	})
+
+	t.Run("limits of the tier", func(t *testing.T) {
+		limitedTier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates,
+			withTierAnnotation(spaceinvitation.MaxMembersPerSpaceAnnotationKey, "2"),
+			withTierAnnotation(spaceinvitation.MaxSpacesPerUserAnnotationKey, "2"))
+		limitedTier.Name = "limited"
+		hooks := newWebhooks(t, limitedTier,
+			murtest.NewMasterUserRecord(t, "jack", murtest.WithOwnerLabel("signupJack")),
+			murtest.NewMasterUserRecord(t, "jeff", murtest.WithOwnerLabel("signupJeff")),
+			spacetest.NewSpace("jack", spacetest.WithTierName("limited"), spacetest.WithCreatorLabel("signupJack")),
+			spacetest.NewSpace("jeff", spacetest.WithTierName("limited"), spacetest.WithCreatorLabel("signupJeff")),
+			spacetest.NewSpace("team", spacetest.WithTierName("limited"), spacetest.WithCreatorLabel("signupJohn")),
+			spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "signupJack"),
+			spacebindingtest.NewSpaceBinding("john", "jack", "viewer", "signupJack"),
+			spacebindingtest.NewSpaceBinding("jeff", "jeff", "admin", "signupJeff"),
+			spacebindingtest.NewSpaceBinding("jack", "team", "viewer", "signupJohn"))
+
+		t.Run("maximum number of members reached", func(t *testing.T) {
+			binding := spacebindingtest.NewSpaceBinding("jeff", "jack", "viewer", "signupJack")
+			assertDenied(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)),
+				"the Space 'jack' has reached the maximum number of members (2)")
+		})
+
+		t.Run("maximum number of Spaces reached", func(t *testing.T) {
+			binding := spacebindingtest.NewSpaceBinding("jack", "jeff", "viewer", "signupJeff")
+			assertDenied(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)),
+				"'jack' has reached the maximum number of Spaces (2)")
+		})
+
+		t.Run("below the limits", func(t *testing.T) {
+			binding := spacebindingtest.NewSpaceBinding("jeff", "team", "viewer", "signupJohn")
+			assertAllowed(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)))
+		})
+
+		t.Run("maximum number of Spaces not applied to the owner", func(t *testing.T) {
+			hooks := newWebhooks(t, limitedTier,
+				murtest.NewMasterUserRecord(t, "jack", murtest.WithOwnerLabel("signupJack")),
+				spacetest.NewSpace("jack", spacetest.WithTierName("limited"), spacetest.WithCreatorLabel("signupJack")),
+				spacebindingtest.NewSpaceBinding("jack", "team", "viewer", "signupJohn"),
+				spacebindingtest.NewSpaceBinding("jack", "other", "viewer", "signupJohn"))
+			binding := spacebindingtest.NewSpaceBinding("jack", "jack", "admin", "signupJack")
+			assertAllowed(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil)))
+		})
+
+		t.Run("role change of a member of a full Space", func(t *testing.T) {
+			binding := spacebindingtest.NewSpaceBinding("john", "jack", "admin", "signupJack")
+			oldBinding := spacebindingtest.NewSpaceBinding("john", "jack", "viewer", "signupJack")
+			assertAllowed(t, hooks.ValidateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Update, binding, oldBinding)))
+		})
+	})
+}
+
+func TestValidateSocialEvent(t *testing.T) {
+	hooks := newWebhooks(t, &toolchainv1alpha1.UserTier{ObjectMeta: metav1.ObjectMeta{Name: "deactivate30", Namespace: test.HostOperatorNs}})
+	newEvent := func(userTier, spaceTier string) *toolchainv1alpha1.SocialEvent {
+		return &toolchainv1alpha1.SocialEvent{
+			ObjectMeta: metav1.ObjectMeta{Name: "event", Namespace: test.HostOperatorNs},
+			Spec: toolchainv1alpha1.SocialEventSpec{
+				UserTier:  userTier,
+				SpaceTier: spaceTier,
+			},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assertAllowed(t, hooks.ValidateSocialEvent(context.TODO(), newRequest(t, admissionv1.Create, newEvent("deactivate30", "basic"), nil)))
+	})
+
+	t.Run("unknown user tier", func(t *testing.T) {
+		assertDenied(t, hooks.ValidateSocialEvent(context.TODO(), newRequest(t, admissionv1.Create, newEvent("unknown", "basic"), nil)),
+			"the UserTier 'unknown' does not exist")
+	})
+
+	t.Run("unknown space tier", func(t *testing.T) {
+		assertDenied(t, hooks.ValidateSocialEvent(context.TODO(), newRequest(t, admissionv1.Create, newEvent("deactivate30", "unknown"), nil)),
+			"the NSTemplateTier 'unknown' does not exist")
+	})
+}
+
+func TestValidateNSTemplateTier(t *testing.T) {
+	hooks := newWebhooks(t)
+
+	t.Run("valid", func(t *testing.T) {
+		tier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates,
+			withTierAnnotation(spaceinvitation.MaxMembersPerSpaceAnnotationKey, "5"))
+		assertAllowed(t, hooks.ValidateNSTemplateTier(context.TODO(), newRequest(t, admissionv1.Create, tier, nil)))
+	})
+
+	t.Run("missing template ref", func(t *testing.T) {
+		tier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates).DeepCopy() // do not alter the shared templates
+		tier.Spec.Namespaces[1].TemplateRef = ""
+		assertDenied(t, hooks.ValidateNSTemplateTier(context.TODO(), newRequest(t, admissionv1.Create, tier, nil)),
+			"the template ref of namespace #1 is missing")
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		tier := tiertest.BasicTier(t, tiertest.CurrentBasicTemplates,
+			withTierAnnotation(spaceinvitation.MaxSpacesPerUserAnnotationKey, "-1"))
+		assertDenied(t, hooks.ValidateNSTemplateTier(context.TODO(), newRequest(t, admissionv1.Create, tier, nil)),
+			"invalid value for annotation 'toolchain.dev.openshift.com/max-spaces-per-user': -1")
+	})
+}
+
+func TestValidateUserTier(t *testing.T) {
+	hooks := newWebhooks(t)
+	newUserTier := func(days int, reminders string) *toolchainv1alpha1.UserTier {
+		tier := &toolchainv1alpha1.UserTier{
+			ObjectMeta: metav1.ObjectMeta{Name: "deactivate30", Namespace: test.HostOperatorNs},
+			Spec:       toolchainv1alpha1.UserTierSpec{DeactivationTimeoutDays: days},
+		}
+		if reminders != "" {
+			tier.Annotations = map[string]string{deactivation.DeactivationReminderDaysAnnotationKey: reminders}
+		}
+		return tier
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assertAllowed(t, hooks.ValidateUserTier(context.TODO(), newRequest(t, admissionv1.Create, newUserTier(30, "7,1"), nil)))
+	})
+
+	t.Run("negative deactivation timeout", func(t *testing.T) {
+		assertDenied(t, hooks.ValidateUserTier(context.TODO(), newRequest(t, admissionv1.Create, newUserTier(-1, ""), nil)),
+			"invalid deactivation timeout: -1")
+	})
+
+	t.Run("invalid reminders", func(t *testing.T) {
+		resp := hooks.ValidateUserTier(context.TODO(), newRequest(t, admissionv1.Create, newUserTier(30, "seven"), nil))
+		assert.False(t, resp.Allowed)
+		assert.Contains(t, string(resp.Result.Reason), "invalid value for annotation 'toolchain.dev.openshift.com/deactivation-reminder-days'")
+	})
+}
+
+func TestValidateBannedUser(t *testing.T) {
+	hooks := newWebhooks(t)
+	newBannedUser := func(email, emailHash string) *toolchainv1alpha1.BannedUser {
+		return &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "banned",
+				Namespace: test.HostOperatorNs,
+				Labels:    map[string]string{toolchainv1alpha1.BannedUserEmailHashLabelKey: emailHash},
+			},
+			Spec: toolchainv1alpha1.BannedUserSpec{Email: email},
+		}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assertAllowed(t, hooks.ValidateBannedUser(context.TODO(),
+			newRequest(t, admissionv1.Create, newBannedUser("foo@redhat.com", hash.EncodeString("foo@redhat.com")), nil)))
+	})
+
+	t.Run("purged", func(t *testing.T) {
+		emailHash := hash.EncodeString("foo@redhat.com")
+		assertAllowed(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, newBannedUser(emailHash, emailHash), nil)))
+	})
+
+	t.Run("email hash mismatch", func(t *testing.T) {
+		assertDenied(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, newBannedUser("foo@redhat.com", "abcdef"), nil)),
+			"the email hash 'abcdef' does not match the email address")
+	})
+
+	t.Run("missing email hash", func(t *testing.T) {
+		assertDenied(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, newBannedUser("foo@redhat.com", ""), nil)),
+			"the label 'toolchain.dev.openshift.com/email-hash' is missing")
+	})
+
+	t.Run("ban settings", func(t *testing.T) {
+		bannedUser := newBannedUser("foo@redhat.com", hash.EncodeString("foo@redhat.com"))
+		bannedUser.Annotations = map[string]string{
+			usersignup.BanExpirationTimeAnnotationKey: "2022-12-01T10:00:00Z",
+			usersignup.BanScopeAnnotationKey:          string(usersignup.BanScopeSignup),
+		}
+		assertAllowed(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, bannedUser, nil)))
+
+		bannedUser.Annotations[usersignup.BanScopeAnnotationKey] = "forever"
+		assertDenied(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, bannedUser, nil)),
+			"invalid ban settings: unknown scope 'forever'")
+	})
+
+	t.Run("ban rule", func(t *testing.T) {
+		bannedUser := newBannedUser("throwaway.io", "")
+		bannedUser.Annotations = map[string]string{
+			banneduser.EmailDomainAnnotationKey: "throwaway.io",
+		}
+		assertAllowed(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, bannedUser, nil)))
+
+		bannedUser.Annotations[banneduser.UsernamePatternAnnotationKey] = "[a-z"
+		assertDenied(t, hooks.ValidateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, bannedUser, nil)),
+			"invalid ban rule: invalid username pattern '[a-z': error parsing regexp: missing closing ]: `[a-z`")
+	})
+}
+
+func TestMutate(t *testing.T) {
+	hooks := newWebhooks(t)
+
+	t.Run("UserSignup email hash", func(t *testing.T) {
+		userSignup := commonsignup.NewUserSignup()
+		delete(userSignup.Labels, toolchainv1alpha1.UserSignupUserEmailHashLabelKey)
+		resp := hooks.MutateUserSignup(context.TODO(), newRequest(t, admissionv1.Create, userSignup, nil))
+		assertPatched(t, resp, "/metadata/labels", map[string]interface{}{
+			toolchainv1alpha1.UserSignupUserEmailHashLabelKey: hash.EncodeString("foo@redhat.com"),
+		})
+
+		t.Run("already set", func(t *testing.T) {
+			userSignup := commonsignup.NewUserSignup()
+			resp := hooks.MutateUserSignup(context.TODO(), newRequest(t, admissionv1.Create, userSignup, nil))
+			assertAllowed(t, resp)
+			assert.Empty(t, resp.Patches)
+		})
+	})
+
+	t.Run("Space tier name", func(t *testing.T) {
+		space := spacetest.NewSpace("jack", spacetest.WithTierName(""))
+		resp := hooks.MutateSpace(context.TODO(), newRequest(t, admissionv1.Create, space, nil))
+		assertPatched(t, resp, "/spec/tierName", "base")
+
+		t.Run("not on update", func(t *testing.T) {
+			resp := hooks.MutateSpace(context.TODO(), newRequest(t, admissionv1.Update, space, space))
+			assertAllowed(t, resp)
+			assert.Empty(t, resp.Patches)
+		})
+	})
+
+	t.Run("SpaceBinding labels", func(t *testing.T) {
+		binding := spacebindingtest.NewSpaceBinding("jeff", "jack", "viewer", "jack")
+		delete(binding.Labels, toolchainv1alpha1.SpaceBindingSpaceLabelKey)
+		resp := hooks.MutateSpaceBinding(context.TODO(), newRequest(t, admissionv1.Create, binding, nil))
+		assertPatched(t, resp, "/metadata/labels/toolchain.dev.openshift.com~1space", "jack")
+	})
+
+	t.Run("BannedUser email hash", func(t *testing.T) {
+		bannedUser := &toolchainv1alpha1.BannedUser{
+			ObjectMeta: metav1.ObjectMeta{Name: "banned", Namespace: test.HostOperatorNs},
+			Spec:       toolchainv1alpha1.BannedUserSpec{Email: "foo@redhat.com"},
+		}
+		resp := hooks.MutateBannedUser(context.TODO(), newRequest(t, admissionv1.Create, bannedUser, nil))
+		assertPatched(t, resp, "/metadata/labels", map[string]interface{}{
+			toolchainv1alpha1.BannedUserEmailHashLabelKey: hash.EncodeString("foo@redhat.com"),
+		})
+	})
+}
+
+func newWebhooks(t *testing.T, initObjs ...runtime.Object) *webhooks.Webhooks {
+	os.Setenv("WATCH_NAMESPACE", test.HostOperatorNs)
+	require.NoError(t, apis.AddToScheme(scheme.Scheme))
+	cl := test.NewFakeClient(t, append(initObjs, tiertest.BasicTier(t, tiertest.CurrentBasicTemplates))...)
+	member1 := NewMemberCluster(t, "member-1", corev1.ConditionTrue)
+	hooks, err := webhooks.New(cl, scheme.Scheme, test.HostOperatorNs, NewGetMemberClusters(member1))
+	require.NoError(t, err)
+	return hooks
+}
+
+func withTierAnnotation(key, value string) tiertest.TierOption {
+	return func(tier *toolchainv1alpha1.NSTemplateTier) {
+		if tier.Annotations == nil {
+			tier.Annotations = map[string]string{}
+		}
+		tier.Annotations[key] = value
+	}
+}
+
+func newRequest(t *testing.T, op admissionv1.Operation, obj, oldObj runtime.Object) admission.Request {
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: marshal(t, obj)},
+		},
+	}
+	if oldObj != nil {
+		req.OldObject = runtime.RawExtension{Raw: marshal(t, oldObj)}
+	}
+	return req
+}
+
+func marshal(t *testing.T, obj runtime.Object) []byte {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return raw
+}
+
+func assertAllowed(t *testing.T, resp admission.Response) {
+	require.True(t, resp.Allowed, "unexpected denial: %v", resp.Result)
+}
+
+func assertDenied(t *testing.T, resp admission.Response, msg string) {
+	require.False(t, resp.Allowed)
+	assert.Equal(t, msg, string(resp.Result.Reason))
+}
+
+func assertPatched(t *testing.T, resp admission.Response, path string, value interface{}) {
+	assertAllowed(t, resp)
+	for _, p := range resp.Patches {
+		if p.Path == path {
+			assert.Equal(t, value, p.Value)
+			return
+		}
+	}
+	assert.Failf(t, "missing patch", "no patch for '%s' in %v", path, resp.Patches)
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func AssertMetricsCounterEquals(t *testing.T, expected int, c prometheus.Counter) {
@@ -15,3 +17,13 @@ func AssertMetricsCounterEquals(t *testing.T, expected int, c prometheus.Counter
 func AssertMetricsGaugeEquals(t *testing.T, expected int, g prometheus.Gauge, msgAndArgs ...interface{}) {
 	assert.Equal(t, float64(expected), promtestutil.ToFloat64(g), msgAndArgs...)
 }
+
+func AssertMetricsHistogramSampleCount(t *testing.T, expected int, h prometheus.Histogram) {
+	assert.Equal(t, expected, MetricsHistogramSampleCount(t, h))
+}
+
+func MetricsHistogramSampleCount(t *testing.T, h prometheus.Histogram) int {
+	metric := &dto.Metric{}
+	require.NoError(t, h.Write(metric))
+	return int(metric.GetHistogram().GetSampleCount())
+}
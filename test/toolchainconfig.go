@@ -0,0 +1,24 @@
+package test
+
+import (
+	toolchainv1alpha1 "github.com/codeready-toolchain/api/api/v1alpha1"
+	testconfig "github.com/codeready-toolchain/toolchain-common/pkg/test/config"
+)
+
+type configAnnotationOption struct {
+	key   string
+	value string
+}
+
+func (o configAnnotationOption) Apply(config *toolchainv1alpha1.ToolchainConfig) {
+	if config.Annotations == nil {
+		config.Annotations = map[string]string{}
+	}
+	config.Annotations[o.key] = o.value
+}
+
+// ConfigAnnotation returns an option which sets the given annotation on the ToolchainConfig
+// (for the settings which are configured with annotations, such as toolchainconfig.ReactivationPolicyAnnotationKey)
+func ConfigAnnotation(key, value string) testconfig.ToolchainConfigOption {
+	return configAnnotationOption{key: key, value: value}
+}